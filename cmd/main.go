@@ -1,64 +1,299 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
 
-	"github.com/gin-contrib/cors"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	"todo_backend/internal/domain"
 	"todo_backend/internal/infrastructure"
+	"todo_backend/internal/infrastructure/config"
+	"todo_backend/internal/infrastructure/deadline"
+	"todo_backend/internal/infrastructure/deprecation"
+	"todo_backend/internal/infrastructure/events"
+	"todo_backend/internal/infrastructure/idempotency"
+	"todo_backend/internal/infrastructure/jobs"
+	"todo_backend/internal/infrastructure/logging"
+	"todo_backend/internal/infrastructure/mail"
+	"todo_backend/internal/infrastructure/memory"
+	"todo_backend/internal/infrastructure/metrics"
 	"todo_backend/internal/infrastructure/mysql"
+	"todo_backend/internal/infrastructure/ratelimit"
+	"todo_backend/internal/infrastructure/realtime"
+	"todo_backend/internal/infrastructure/reminder"
+	"todo_backend/internal/infrastructure/retention"
+	"todo_backend/internal/infrastructure/tracing"
+	"todo_backend/internal/infrastructure/webhook"
 	"todo_backend/internal/interface/handler"
+	"todo_backend/internal/interface/repository"
 	"todo_backend/internal/usecase"
 )
 
 func main() {
 	// .envを読み込む
-	if err := godotenv.Load(".env"); err != nil {
-		log.Println("[INFO] .env not found; using system environment variables")
+	envErr := godotenv.Load(".env")
+
+	// 設定の読み込みとロガー初期化（JSON形式で標準出力へ）
+	// コマンドライン引数は環境変数より優先する
+	cfg, cfgErr := config.Load()
+	flag.StringVar(&cfg.Storage, "storage", cfg.Storage, "storage backend (sqlite or memory)")
+	flag.Parse()
+	logger := logging.New(os.Stdout, logging.ParseLevel(cfg.LogLevel))
+	slog.SetDefault(logger)
+	if cfgErr != nil {
+		fatal("invalid configuration", cfgErr)
+	}
+	routeTimeouts, err := deadline.ParseRoutes(cfg.RouteTimeouts)
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+	anonLimit, err := ratelimit.ParseLimit(cfg.AnonRateLimit)
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+	userLimit, err := ratelimit.ParseLimit(cfg.UserRateLimit)
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+
+	if envErr != nil {
+		slog.Info(".env not found; using system environment variables")
 	}
 
-	// DB初期化（今回はSQLite）
-	db, err := gorm.Open(sqlite.Open("./todo.db"), &gorm.Config{})
+	// SIGINT / SIGTERMで終了処理を開始するためのcontext
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// トレーシング（OTEL_TRACES_EXPORTER=stdout|otlp で有効化）
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Exporter:    cfg.TraceExporter,
+		ServiceName: cfg.ServiceName,
+	})
 	if err != nil {
-		panic("failed to connect database")
+		fatal("failed to set up tracing", err)
 	}
-	dbPath, _ := filepath.Abs("./todo.db")
-	log.Println("USING_SQLITE:", dbPath)
 
-	// マイグレーション
-	if err := db.AutoMigrate(&domain.User{}, &domain.Todo{}); err != nil {
-		log.Fatalf("failed to migrate: %v", err)
+	// メトリクス（Goランタイム・プロセス・DB接続プール統計を含む）
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	m := metrics.New(reg)
+
+	// Repository（--storageで保存先を選択）
+	var st storage
+	switch cfg.Storage {
+	case "sqlite":
+		st, err = newSQLiteStorage(cfg.DBPath, m)
+		if err != nil {
+			fatal("failed to set up sqlite storage", err)
+		}
+	case "memory":
+		st = newMemoryStorage()
+		slog.Warn("using in-memory storage; data is lost on restart")
+	default:
+		fatal("invalid configuration", fmt.Errorf("unknown storage %q (want sqlite or memory)", cfg.Storage))
 	}
+	// 保持期間を過ぎた冪等キーは定期的に削除する
+	go idempotency.Purge(ctx, st.idempotency, cfg.IdempotencyRetention, time.Hour)
 
-	// Repository
-	userRepo := mysql.NewUserMySQL(db)
-	todoRepo := mysql.NewTodoMysql(db)
+	// Todoの変更イベント（GET /todos/streamで配信）
+	bus := events.NewBus(cfg.EventLogSize)
+	// WebSocket接続の間で共有するプレゼンス（GET /realtime）
+	hub := realtime.NewHub()
 
 	// Usecase
-	authUC := usecase.NewAuthUsecase(userRepo)
-	todoUC := usecase.NewTodoUsecase(todoRepo)
+	authUC := usecase.NewAuthUsecase(st.repos.Users, usecase.WithAuthMetrics(m), usecase.WithAdminUserIDs(cfg.AdminUserIDs...))
+	jobUC := usecase.NewJobUsecase(st.repos.Jobs,
+		usecase.WithJobRetry(usecase.RetryPolicy{
+			MaxAttempts: cfg.Jobs.MaxAttempts,
+			Backoff:     cfg.Jobs.RetryBackoff,
+			MaxBackoff:  usecase.DefaultJobRetryPolicy.MaxBackoff,
+		}),
+		usecase.WithJobTimeout(cfg.Jobs.Timeout),
+	)
+	webhookUC := usecase.NewWebhookUsecase(st.repos.Webhooks, webhook.NewSender(cfg.Webhook.Timeout, cfg.Webhook.AllowedNetworks...),
+		usecase.WithWebhookRetry(usecase.RetryPolicy{
+			MaxAttempts: cfg.Webhook.MaxAttempts,
+			Backoff:     cfg.Webhook.RetryBackoff,
+			MaxBackoff:  usecase.DefaultWebhookRetryPolicy.MaxBackoff,
+		}),
+	)
+	// Todoの変更イベントをWebhookで通知する（イベントは変更と同じトランザクションでジョブとして保存し、
+	// ジョブのワーカーが配信待ちに加え、Dispatcherが送信する）
+	dispatcher := webhook.NewDispatcher(webhookUC, cfg.Webhook.Concurrency, cfg.Webhook.PollInterval)
+	worker := jobs.NewWorker(jobUC, cfg.Jobs.Workers, cfg.Jobs.PollInterval)
+	worker.Handle(usecase.JobTypeWebhookTodoEvent, dispatcher.HandleJob)
+	// リマインダー（Schedulerが通知し、アプリ内以外のチャネルへの送信はジョブのワーカーが行う）
+	reminderUC := usecase.NewReminderUsecase(st.repos, st.tx, usecase.WithNotificationPublisher(hub))
+	notificationUC := usecase.NewNotificationUsecase(st.repos.Notifications,
+		usecase.WithNotificationSender(domain.NotificationEmail, mail.NewChannel(st.repos.Users, mail.LogMailer{})),
+		usecase.WithNotificationSender(domain.NotificationWebhook, dispatcher),
+	)
+	scheduler := reminder.NewScheduler(reminderUC, cfg.ReminderPollInterval)
+	worker.Handle(usecase.JobTypeReminderTodoEvent, reminderUC.HandleTodoEventJob)
+	worker.Handle(usecase.JobTypeNotificationDelivery, notificationUC.HandleDeliveryJob)
+	todoUC := usecase.NewTodoUsecase(st.repos.Todos,
+		usecase.WithTodoMetrics(m),
+		usecase.WithTransactor(st.tx),
+		usecase.WithEventBus(bus),
+		usecase.WithEventJob(usecase.JobTypeWebhookTodoEvent),
+		usecase.WithEventJob(usecase.JobTypeReminderTodoEvent, usecase.ReminderTodoEvents...),
+	)
+	// 保持期間を過ぎた差分同期の墓標と完了したジョブを定期的に削除する
+	sweeper := retention.NewSweeper(cfg.Retention.SweepInterval)
+	sweeper.Add("sync tombstones", func(ctx context.Context) (int64, error) {
+		return todoUC.PruneTombstones(ctx, cfg.Retention.SyncTombstones)
+	})
+	sweeper.Add("succeeded jobs", func(ctx context.Context) (int64, error) {
+		return jobUC.PurgeSucceeded(ctx, cfg.Retention.SucceededJobs)
+	})
 
 	// Handler
 	authH := handler.NewAuthHandler(authUC)
 
 	// ルータ生成
-	router := infrastructure.NewRouter(authH, todoUC)
-
-	// CORS追加
-	router.Use(cors.Default())
+	routerOpts := []infrastructure.RouterOption{
+		infrastructure.WithMetrics(m),
+		infrastructure.WithDeadline(deadline.Config{Default: cfg.RequestTimeout, Routes: routeTimeouts}),
+		infrastructure.WithRateLimit(ratelimit.NewMemoryStore(), anonLimit, userLimit),
+		infrastructure.WithCORS(infrastructure.CORSConfig(cfg.CORS)),
+		infrastructure.WithTrustedProxies(cfg.TrustedProxies...),
+		infrastructure.WithIdempotency(st.idempotency, cfg.IdempotencyRetention),
+		infrastructure.WithRealtimeHub(hub),
+		infrastructure.WithWebhooks(webhookUC),
+		infrastructure.WithJobs(jobUC),
+		infrastructure.WithReminders(reminderUC),
+		infrastructure.WithNotifications(notificationUC),
+	}
+	if cfg.RequestValidation {
+		routerOpts = append(routerOpts, infrastructure.WithRequestValidation())
+	}
+	if cfg.Legacy.Enabled {
+		routerOpts = append(routerOpts, infrastructure.WithLegacyRoutes(deprecation.Config{
+			Deprecated:    cfg.Legacy.DeprecatedAt,
+			Sunset:        cfg.Legacy.SunsetAt,
+			Documentation: "/docs",
+		}))
+	}
+	router, err := infrastructure.NewRouter(authH, todoUC, routerOpts...)
+	if err != nil {
+		fatal("failed to build router", err)
+	}
 
 	// JWT_SECRETチェック（開発中の注意喚起）
 	if os.Getenv("JWT_SECRET") == "" {
-		log.Println("[WARN] JWT_SECRET is not set. Set a strong secret in production.")
+		slog.Warn("JWT_SECRET is not set. Set a strong secret in production.")
 	}
 
-	if err := router.Run(":8080"); err != nil {
-		log.Fatal(err)
+	// ジョブ・Webhookの配信・リマインダー・保持期間を過ぎたデータの削除のワーカーはサーバの停止後に止める（実行中のジョブは完了を待つ）
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	for _, run := range []func(context.Context){worker.Run, dispatcher.Run, scheduler.Run, sweeper.Run} {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
+
+	srv := &http.Server{Addr: cfg.Port, Handler: router}
+	// 停止時は接続中のイベントストリームを終了させる（Shutdownが長時間の接続を待ち続けないように）
+	srv.RegisterOnShutdown(bus.Close)
+	// WebSocketの接続はShutdownの対象外のため、Closeフレームを送って閉じる
+	srv.RegisterOnShutdown(hub.Close)
+	go func() {
+		slog.Info("server starting", slog.String("addr", cfg.Port))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("server stopped", err)
+		}
+	}()
+
+	// シグナル受信後、処理中のリクエストを待ってから終了する
+	<-ctx.Done()
+	slog.Info("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("server shutdown failed", slog.Any("error", err))
 	}
+	stopWorkers()
+	workers.Wait()
+	// バッファに残っているスパンを送信する
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("tracing shutdown failed", slog.Any("error", err))
+	}
+}
+
+// storageはデータの保存先ごとに用意するリポジトリ一式です。
+type storage struct {
+	repos       repository.Repositories
+	tx          repository.Transactor
+	idempotency idempotency.Store
+}
+
+// newSQLiteStorageはSQLiteに接続してマイグレーションを行い、GORM実装のリポジトリを返します。
+// DB接続プールの統計をメトリクスに登録します。
+func newSQLiteStorage(path string, m *metrics.Metrics) (storage, error) {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		return storage{}, fmt.Errorf("connect database: %w", err)
+	}
+	// GORMのクエリごとにスパンを作成する
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return storage{}, fmt.Errorf("register gorm tracing plugin: %w", err)
+	}
+	dbPath, _ := filepath.Abs(path)
+	slog.Info("using sqlite", slog.String("path", dbPath))
+
+	// マイグレーション
+	if err := db.AutoMigrate(&domain.User{}, &domain.Todo{}, &domain.TodoChange{}, &domain.TodoChangeLog{}, &domain.Webhook{}, &domain.WebhookDelivery{}, &domain.Job{}, &domain.Reminder{}, &domain.Notification{}, &idempotency.Record{}); err != nil {
+		return storage{}, fmt.Errorf("migrate: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return storage{}, fmt.Errorf("get sql.DB: %w", err)
+	}
+	if err := m.RegisterDB(sqlDB, "todo"); err != nil {
+		return storage{}, fmt.Errorf("register db metrics: %w", err)
+	}
+
+	return storage{
+		repos: repository.Repositories{
+			Todos:         mysql.NewTodoMysql(db),
+			Users:         mysql.NewUserMySQL(db),
+			Webhooks:      mysql.NewWebhookMysql(db),
+			Jobs:          mysql.NewJobMysql(db),
+			Reminders:     mysql.NewReminderMysql(db),
+			Notifications: mysql.NewNotificationMysql(db),
+		},
+		tx:          mysql.NewTransactor(db),
+		idempotency: idempotency.NewGormStore(db),
+	}, nil
+}
+
+// newMemoryStorageはメモリ上のリポジトリを返します（デモ・フロントエンド開発用）。
+func newMemoryStorage() storage {
+	repos, tx := memory.NewRepositories()
+	return storage{repos: repos, tx: tx, idempotency: idempotency.NewMemoryStore()}
+}
+
+// fatalはエラーログを出力してプロセスを終了します。
+func fatal(msg string, err error) {
+	slog.Error(msg, slog.Any("error", err))
+	os.Exit(1)
 }
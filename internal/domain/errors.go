@@ -0,0 +1,40 @@
+package domain
+
+import "errors"
+
+// ドメイン層で定義するエラーです。
+// Repository実装はDB固有のエラー（gorm.ErrRecordNotFoundなど）をこれらに変換して返し、
+// Handler層はerrors.Isで判定してHTTPステータスに対応付けます。
+var (
+	// ErrTodoNotFoundは指定されたTodoが存在しない（または他ユーザーの所有である）ことを表します。
+	ErrTodoNotFound = errors.New("todo not found")
+	// ErrVersionMismatchは更新・削除時に指定されたバージョンが現在のバージョンと一致しないことを表します。
+	// 他の端末による更新を上書きしないための楽観的排他制御に使われます。
+	ErrVersionMismatch = errors.New("todo version mismatch")
+	// ErrUserNotFoundは指定されたユーザーが存在しないことを表します。
+	ErrUserNotFound = errors.New("user not found")
+	// ErrInvalidCredentialsは、ログインでメールアドレスのユーザーが存在しない、またはパスワードが一致しないことを表します。
+	// どちらであるかは区別しません（登録済みのメールアドレスを推測されないため）。
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrEmailAlreadyExistsは同じメールアドレスのユーザーが既に登録されていることを表します。
+	ErrEmailAlreadyExists = errors.New("email already exists")
+	// ErrWebhookNotFoundは指定されたWebhookが存在しない（または他ユーザーの所有である）ことを表します。
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrWebhookDeliveryNotFoundは指定されたWebhookの配信が存在しないことを表します。
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrJobNotFoundは指定されたジョブが存在しないことを表します。
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobNotDeadは、実行を諦めた（dead）ジョブ以外を再試行しようとしたことを表します。
+	ErrJobNotDead = errors.New("job is not dead")
+	// ErrJobLeaseLostは、実行期限を過ぎて他のワーカーが取り出し直したなど、ジョブの実行結果を保存する権利を失ったことを表します。
+	ErrJobLeaseLost = errors.New("job lease lost")
+	// ErrJobLeaseExpiredは、実行期限までに完了しなかった（実行中にワーカーが異常終了したなど）ことを表します。
+	// 実行回数の上限に達したまま実行期限を過ぎたジョブを、デッドレターにする際の最後のエラーとして記録します。
+	ErrJobLeaseExpired = errors.New("job lease expired")
+	// ErrReminderNotFoundは指定されたリマインダーが存在しない（または他ユーザーの所有である）ことを表します。
+	ErrReminderNotFound = errors.New("reminder not found")
+	// ErrTodoHasNoDueDateは、期限のないTodoに期限からの相対のリマインダーを設定しようとしたことを表します。
+	ErrTodoHasNoDueDate = errors.New("todo has no due date")
+	// ErrNotificationNotFoundは指定された通知が存在しない（または他ユーザー宛てである）ことを表します。
+	ErrNotificationNotFound = errors.New("notification not found")
+)
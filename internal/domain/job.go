@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// JobStatus はバックグラウンドジョブの状態です。
+type JobStatus string
+
+const (
+	// JobPending は実行待ち（再試行待ち・実行日時待ちを含む）であることを表します。
+	JobPending JobStatus = "pending"
+	// JobRunning はワーカーが実行中であることを表します。LockedUntilを過ぎた場合は中断されたとみなし、再び実行します。
+	JobRunning JobStatus = "running"
+	// JobSucceeded は実行が完了したことを表します。
+	JobSucceeded JobStatus = "succeeded"
+	// JobDead は再試行の上限まで失敗した（または再試行しても成功しない）ため、実行を諦めたことを表します。
+	// 管理者が原因を取り除いてから再試行できます（デッドレター）。
+	JobDead JobStatus = "dead"
+)
+
+// Job はバックグラウンドで実行する処理（ジョブ）です。
+// ドメインの変更と同じトランザクションで保存する（トランザクショナルアウトボックス）ことで、
+// 変更が確定した場合にのみ、確実に1回以上実行されます。
+type Job struct {
+	// ID はジョブを一意に識別する番号です。
+	ID uint
+	// Type はジョブの種類（実行する処理）です。例: "webhook.todo_event"
+	Type string `gorm:"not null;index"`
+	// Payload は処理に渡す引数（JSON）です。
+	Payload string `gorm:"not null"`
+	// Status はジョブの状態です。
+	Status JobStatus `gorm:"not null;index:idx_jobs_due,priority:1"`
+	// Attempts は実行を開始した回数です。
+	Attempts int `gorm:"not null"`
+	// RunAt は次に実行する日時です（実行待ちの場合のみ意味を持ちます）。
+	RunAt time.Time `gorm:"index:idx_jobs_due,priority:2"`
+	// LockedUntil は実行中のジョブの実行期限です。これを過ぎても完了しない場合は他のワーカーが実行し直します。
+	LockedUntil *time.Time
+	// LastError は最後の実行が失敗した理由です。
+	LastError string
+	// CreatedAt は作成日時です。
+	CreatedAt time.Time
+	// UpdatedAt は最後に状態が変わった日時です。
+	UpdatedAt time.Time
+	// FinishedAt は完了または実行を諦めた日時です。
+	FinishedAt *time.Time
+}
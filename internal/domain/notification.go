@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// NotificationType は通知の種類です。
+// 通知を作るTodoの変更が増えた場合（共有・コメントなど）は、種類を追加してアプリ内の通知一覧に保存します。
+type NotificationType string
+
+const (
+	// NotificationReminder はTodoのリマインダーの通知です。
+	NotificationReminder NotificationType = "reminder"
+)
+
+// NotificationChannel は通知の送り先の種類（チャネル）です。
+type NotificationChannel string
+
+const (
+	// NotificationInApp はアプリ内の通知一覧（GET /notifications）です。
+	NotificationInApp NotificationChannel = "in_app"
+	// NotificationEmail はユーザーのメールアドレスへのメールです。
+	NotificationEmail NotificationChannel = "email"
+	// NotificationWebhook はユーザーが登録したWebhookです（reminder.firedを購読しているもの）。
+	NotificationWebhook NotificationChannel = "webhook"
+)
+
+// NotificationChannels は指定できるチャネルの一覧です。
+var NotificationChannels = []NotificationChannel{NotificationInApp, NotificationEmail, NotificationWebhook}
+
+// Notification はユーザーへの通知です。アプリ内の通知一覧に保存するほか、メールやWebhookで送ります。
+type Notification struct {
+	// ID は通知を一意に識別する番号です。アプリ内の通知一覧に保存した場合のみ設定されます。
+	ID uint
+	// UserID は通知を受け取るユーザーのIDです。
+	UserID uint `gorm:"not null;index:idx_notifications_unread,priority:1"`
+	// Type は通知の種類です。
+	Type NotificationType `gorm:"not null"`
+	// TodoID は通知の対象のTodoのIDです。
+	TodoID uint `gorm:"not null"`
+	// ReminderID は通知したリマインダーのIDです（リマインダーの通知の場合）。
+	ReminderID uint
+	// Title は通知の時点のTodoのタイトルです。
+	Title string `gorm:"not null"`
+	// DueAt は通知の時点のTodoの期限です。
+	DueAt *time.Time
+	// ReadAt は既読にした日時です。nil の場合は未読です。
+	// 未読の件数（GET /notifications/unread_count）をインデックスだけで数えられるよう、UserIDと複合インデックスにします。
+	ReadAt *time.Time `gorm:"index:idx_notifications_unread,priority:2"`
+	// CreatedAt は通知した日時です。
+	CreatedAt time.Time
+}
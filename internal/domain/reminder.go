@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// Reminder はTodoのリマインダーです。指定した日時（RemindAt）か、Todoの期限の指定した時間前（MinutesBefore）に1回だけ通知します。
+type Reminder struct {
+	// ID はリマインダーを一意に識別する番号です。
+	ID uint
+	// UserID はTodoを所有するユーザーのIDです。
+	UserID uint `gorm:"not null;index"`
+	// TodoID はリマインダーを設定したTodoのIDです。
+	TodoID uint `gorm:"not null;index"`
+	// RemindAt は通知する日時です（日時で指定した場合）。
+	RemindAt *time.Time
+	// MinutesBefore は期限の何分前に通知するかです（期限からの相対で指定した場合）。
+	MinutesBefore *int
+	// Channels は通知の送り先です。
+	Channels []NotificationChannel `gorm:"not null;serializer:json"`
+	// FireAt は通知する予定の日時です。期限からの相対で指定し、Todoに期限がない場合は nil です。
+	FireAt *time.Time `gorm:"index"`
+	// FiredAt は通知した日時です。nil の場合は未通知です。
+	FiredAt *time.Time
+	// CreatedAt は設定した日時です。
+	CreatedAt time.Time
+}
+
+// Relative は期限からの相対で指定したリマインダーかどうかを返します。
+func (r Reminder) Relative() bool {
+	return r.MinutesBefore != nil
+}
+
+// NextFireAt は期限がdueAtの場合に通知する日時を返します。期限からの相対で指定し、期限がない場合は nil を返します。
+func (r Reminder) NextFireAt(dueAt *time.Time) *time.Time {
+	switch {
+	case !r.Relative():
+		return r.RemindAt
+	case dueAt == nil:
+		return nil
+	default:
+		at := dueAt.Add(-time.Duration(*r.MinutesBefore) * time.Minute)
+		return &at
+	}
+}
@@ -1,5 +1,10 @@
 package domain
 
+import (
+	"strings"
+	"time"
+)
+
 // Todo はアプリケーションのドメインモデルの1つで、
 // ユーザーが管理するタスクを表します。
 // Clean ArchitectureにおけるEntityであり、
@@ -14,4 +19,50 @@ type Todo struct {
 	Title string `json:"title"`
 	// Completed はタスクが完了しているかどうかを示します。
 	Completed bool `json:"completed"`
+	// DueAt はタスクの期限です。nil の場合は期限なしです。
+	DueAt *time.Time `json:"due_at,omitempty"`
+	// Version は楽観的排他制御のためのバージョン番号です。
+	// 作成時は1で、更新されるたびに1ずつ増えます。
+	Version uint `json:"version" gorm:"not null;default:1"`
+}
+
+// TodoPatch は Todo の部分更新の内容を表します。
+// nil のフィールドは変更しません（JSON Merge Patch で省略されたフィールドに相当）。
+type TodoPatch struct {
+	// Title は新しいタイトルです。
+	Title *string
+	// Completed は新しい完了状態です。
+	Completed *bool
+	// DueAt は新しい期限です。
+	DueAt *time.Time
+	// ClearDueAt は期限を削除することを表します（DueAt より優先します）。
+	ClearDueAt bool
+}
+
+// Apply は指定された Todo に部分更新の内容を適用します。
+func (p TodoPatch) Apply(t *Todo) {
+	if p.Title != nil {
+		t.Title = *p.Title
+	}
+	if p.Completed != nil {
+		t.Completed = *p.Completed
+	}
+	if p.ClearDueAt {
+		t.DueAt = nil
+	} else if p.DueAt != nil {
+		due := *p.DueAt
+		t.DueAt = &due
+	}
+}
+
+// TodoFilter は Todo の絞り込み条件を表します。
+// ゼロ値はすべての Todo に一致します。
+type TodoFilter struct {
+	// Query を指定すると、タイトルにその文字列を含む Todo のみに絞り込みます（大文字小文字を区別しない）。
+	Query string
+}
+
+// Match は Todo が絞り込み条件に一致するかを返します。
+func (f TodoFilter) Match(t Todo) bool {
+	return f.Query == "" || strings.Contains(strings.ToLower(t.Title), strings.ToLower(f.Query))
 }
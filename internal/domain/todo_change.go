@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// TodoChange はオフライン対応のクライアントとの差分同期に使う、Todoの変更履歴です。
+// Todoの書き込み（作成・更新・削除）と同じトランザクションで記録され、Todoごとに最新の変更のみを保持します。
+// 削除されたTodoは Deleted の墓標（tombstone）として残り、同期中のクライアントに削除を伝えます。
+// 墓標は保持期間を過ぎると削除されます（TodoChangeLog.PrunedSeq）。
+type TodoChange struct {
+	// Seq は変更の通し番号です。書き込みのたびに採番し直され、同じユーザーの変更はコミットの順に大きくなります。
+	Seq uint64 `gorm:"primaryKey;autoIncrement"`
+	// TodoID は変更されたTodoのIDです。
+	TodoID uint `gorm:"not null;uniqueIndex"`
+	// UserID は変更されたTodoを所有するユーザーのIDです。
+	UserID uint `gorm:"not null;index"`
+	// Deleted はTodoが削除されたかどうかです。
+	Deleted bool `gorm:"not null"`
+	// ChangedAt は変更を記録した日時です。
+	ChangedAt time.Time
+	// Todo は変更後のTodoです（削除の場合はIDとUserIDのみ）。保存はせず、取得時にTodoから補完します。
+	Todo Todo `gorm:"-"`
+}
+
+// TodoChangeLog はユーザーごとのTodoの変更履歴の管理情報です。
+// 変更履歴を記録するトランザクションはこの行を更新してコミットまでロックするため、
+// 同じユーザーの変更はSeqの順にコミットされます（小さいSeqの変更が後からコミットされることはありません）。
+type TodoChangeLog struct {
+	// UserID はユーザーのIDです。
+	UserID uint `gorm:"primaryKey;autoIncrement:false"`
+	// Writes は変更履歴への書き込みの回数です。行をロックするため、書き込みのたびに増やします。
+	Writes uint64 `gorm:"not null;default:0"`
+	// PrunedSeq は保持期間を過ぎて削除した墓標のSeqの最大値です。
+	// これより前の位置からは削除を伝えられないため、差分ではなく全件を同期します。
+	PrunedSeq uint64 `gorm:"not null;default:0"`
+}
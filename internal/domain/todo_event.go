@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// TodoEventType はTodoの変更の種類です。
+type TodoEventType string
+
+const (
+	// TodoCreated はTodoが作成されたことを表します。
+	TodoCreated TodoEventType = "created"
+	// TodoUpdated はTodoが更新（完了を含む）されたことを表します。
+	TodoUpdated TodoEventType = "updated"
+	// TodoDeleted はTodoが削除されたことを表します。
+	TodoDeleted TodoEventType = "deleted"
+)
+
+// TodoEvent はTodoの変更を表すイベントです。
+// 変更を確定（コミット）した後に発行され、同じユーザーの他の端末・タブへの通知などに使われます。
+type TodoEvent struct {
+	// ID はイベントの通し番号です。イベントバスが発行時に採番し、後のイベントほど大きくなります。
+	ID uint64
+	// Type は変更の種類です。
+	Type TodoEventType
+	// UserID は変更されたTodoを所有するユーザーのIDです。
+	UserID uint
+	// Todo は変更後のTodoです。削除の場合はIDとUserIDのみ設定されます。
+	Todo Todo
+	// Completed は、この更新でTodoが完了になったことを表します（完了メトリクスを記録する更新と同じ条件です）。
+	Completed bool
+	// OccurredAt は変更が確定した時刻です。
+	OccurredAt time.Time
+}
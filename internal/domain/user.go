@@ -3,9 +3,11 @@ package domain
 import "time"
 
 type User struct {
-	ID        uint   `gorm:"primaryKey"`
-	Email     string `gorm:"uniqueIndex;size:255;not null"`
-	Password  string `gorm:"size:255;not null"`
+	ID       uint   `gorm:"primaryKey"`
+	Email    string `gorm:"uniqueIndex;size:255;not null"`
+	Password string `gorm:"size:255;not null"`
+	// Localeはエラーメッセージなどの表示言語の設定（"ja" / "en"）です。空の場合はAccept-Languageに従います。
+	Locale    string `gorm:"size:16;not null;default:''"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// WebhookEventType はWebhookで通知するイベントの種類です。
+type WebhookEventType string
+
+const (
+	// WebhookTodoCreated はTodoが作成されたことを表します。
+	WebhookTodoCreated WebhookEventType = "todo.created"
+	// WebhookTodoUpdated はTodoが更新（完了を含む）されたことを表します。
+	WebhookTodoUpdated WebhookEventType = "todo.updated"
+	// WebhookTodoCompleted は未完了のTodoが完了になったことを表します（todo.updatedと併せて通知されます）。
+	WebhookTodoCompleted WebhookEventType = "todo.completed"
+	// WebhookTodoDeleted はTodoが削除されたことを表します。
+	WebhookTodoDeleted WebhookEventType = "todo.deleted"
+	// WebhookReminderFired はTodoのリマインダーが通知されたことを表します（チャネルにwebhookを指定したリマインダーのみ）。
+	WebhookReminderFired WebhookEventType = "reminder.fired"
+)
+
+// WebhookEventTypes は購読できるイベントの種類の一覧です。
+var WebhookEventTypes = []WebhookEventType{WebhookTodoCreated, WebhookTodoUpdated, WebhookTodoCompleted, WebhookTodoDeleted, WebhookReminderFired}
+
+// Webhook はユーザーが登録した、Todoのイベントを通知する先（Webhookの購読）です。
+type Webhook struct {
+	// ID はWebhookを一意に識別する番号です。
+	ID uint
+	// UserID はこのWebhookを登録したユーザーのIDです。このユーザーのTodoのイベントのみを通知します。
+	UserID uint `gorm:"not null;index"`
+	// URL は通知先のURLです（http / https）。
+	URL string `gorm:"not null"`
+	// Events は通知するイベントの種類です。
+	Events []WebhookEventType `gorm:"not null;serializer:json"`
+	// Secret は通知の署名（HMAC-SHA256）の鍵です。
+	Secret string `gorm:"not null"`
+	// CreatedAt は登録日時です。
+	CreatedAt time.Time
+}
+
+// Subscribes は、Webhookがイベントの種類eventを購読しているかを返します。
+func (w Webhook) Subscribes(event WebhookEventType) bool {
+	return slices.Contains(w.Events, event)
+}
+
+// WebhookDeliveryStatus はWebhookの配信の状態です。
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending は配信待ち（再試行待ちを含む）であることを表します。
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliverySucceeded は通知先が2xxを返し、配信が完了したことを表します。
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryFailed は再試行の上限まで失敗し、配信を諦めたことを表します。
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery はWebhookへの1件のイベントの配信（配信ログ）です。
+type WebhookDelivery struct {
+	// ID は配信を一意に識別する番号です。通知先は重複の検出に使えます。
+	ID uint
+	// WebhookID は配信先のWebhookのIDです。
+	WebhookID uint `gorm:"not null;index;uniqueIndex:idx_webhook_deliveries_event,priority:1"`
+	// EventID はペイロードのイベントの識別子です。同じイベントの配信はWebhookごとに1つだけ作成します。
+	// 手動の再配信では元の配信と同じイベントを送るためnilです。
+	EventID *string `gorm:"size:64;uniqueIndex:idx_webhook_deliveries_event,priority:2"`
+	// UserID はWebhookを登録したユーザーのIDです。
+	UserID uint `gorm:"not null;index"`
+	// Event はイベントの種類です。
+	Event WebhookEventType `gorm:"not null"`
+	// Payload は送信するリクエストボディ（JSON）です。再配信でも同じ内容を送ります。
+	Payload string `gorm:"not null"`
+	// Status は配信の状態です。
+	Status WebhookDeliveryStatus `gorm:"not null;index:idx_webhook_deliveries_due,priority:1"`
+	// Attempts は送信を試みた回数です。
+	Attempts int `gorm:"not null"`
+	// NextAttemptAt は次に送信を試みる日時です（配信待ちの場合のみ意味を持ちます）。
+	NextAttemptAt time.Time `gorm:"index:idx_webhook_deliveries_due,priority:2"`
+	// LastAttemptAt は最後に送信を試みた日時です（未送信の場合はnil）。
+	LastAttemptAt *time.Time
+	// ResponseStatus は最後の送信で通知先が返したステータスコードです（接続できなかった場合は0）。
+	ResponseStatus int
+	// Error は最後の送信が失敗した理由です。
+	Error string
+	// RedeliveryOf は手動の再配信の場合の、元の配信のIDです。
+	RedeliveryOf *uint
+	// CreatedAt は配信を作成した日時です。
+	CreatedAt time.Time
+}
@@ -0,0 +1,114 @@
+package e2e_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/e2e"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jobResはバックグラウンドジョブのレスポンスです。
+type jobRes struct {
+	ID        uint           `json:"id"`
+	Type      string         `json:"type"`
+	Status    string         `json:"status"`
+	Attempts  int            `json:"attempts"`
+	LastError string         `json:"last_error"`
+	Payload   map[string]any `json:"payload"`
+}
+
+// listJobsは管理者としてジョブの一覧を取得します。
+func listJobs(t *testing.T, c *e2e.Client, query string) []jobRes {
+	t.Helper()
+	res := c.Do(http.MethodGet, "/v1/admin/jobs"+query, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode, "%s", res.Body)
+	var out []jobRes
+	res.Decode(&out)
+	return out
+}
+
+func TestAdminJobs(t *testing.T) {
+	s := e2e.NewServer(t)
+	// 最初に登録したユーザー（e2e.AdminUserID）が管理者になる
+	admin := s.SignupAndLogin("admin@example.com", "password1")
+	alice := s.SignupAndLogin("alice@example.com", "password1")
+
+	t.Run("Todoの変更イベントは同じトランザクションでジョブになり、ワーカーが実行する", func(t *testing.T) {
+		todo := alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "牛乳を買う"}).Todo()
+
+		var job jobRes
+		require.Eventually(t, func() bool {
+			for _, j := range listJobs(t, admin, "?status=succeeded") {
+				if j.Type == "webhook.todo_event" && j.Payload["Todo"].(map[string]any)["id"] == float64(todo.ID) {
+					job = j
+					return true
+				}
+			}
+			return false
+		}, 5*time.Second, 20*time.Millisecond)
+		assert.Equal(t, 1, job.Attempts)
+
+		res := admin.Do(http.MethodGet, fmt.Sprintf("/v1/admin/jobs/%d", job.ID), nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		// 完了したジョブは再実行できない
+		res = admin.Do(http.MethodPost, fmt.Sprintf("/v1/admin/jobs/%d/retry", job.ID), nil)
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+		assert.Equal(t, "job_not_dead", res.Code())
+	})
+
+	t.Run("デッドレターのジョブを確認して再実行できる", func(t *testing.T) {
+		// ハンドラのない種類のジョブは実行できないためデッドレターになる
+		job := domain.Job{Type: "e2e.unknown", Payload: `{"n":1}`, Status: domain.JobPending, RunAt: time.Now()}
+		require.NoError(t, s.DB.Create(&job).Error)
+		var dead jobRes
+		require.Eventually(t, func() bool {
+			for _, j := range listJobs(t, admin, "?status=dead") {
+				if j.ID == job.ID {
+					dead = j
+					return true
+				}
+			}
+			return false
+		}, 5*time.Second, 20*time.Millisecond)
+		assert.Contains(t, dead.LastError, "no handler")
+		assert.Equal(t, float64(1), dead.Payload["n"])
+
+		res := admin.Do(http.MethodPost, fmt.Sprintf("/v1/admin/jobs/%d/retry", job.ID), nil)
+		require.Equal(t, http.StatusAccepted, res.StatusCode, res.Error())
+		var retried jobRes
+		res.Decode(&retried)
+		assert.Equal(t, "pending", retried.Status)
+		assert.Zero(t, retried.Attempts)
+
+		// 再実行しても実行できないため、再びデッドレターになる
+		require.Eventually(t, func() bool {
+			res := admin.Do(http.MethodGet, fmt.Sprintf("/v1/admin/jobs/%d", job.ID), nil)
+			var got jobRes
+			res.Decode(&got)
+			return got.Status == "dead" && got.Attempts == 1
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("管理者以外は利用できない", func(t *testing.T) {
+		res := alice.Do(http.MethodGet, "/v1/admin/jobs", nil)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		assert.Equal(t, "forbidden", res.Code())
+		assert.Equal(t, http.StatusForbidden, alice.Do(http.MethodPost, "/v1/admin/jobs/1/retry", nil).StatusCode)
+		assert.Equal(t, http.StatusUnauthorized, s.Anonymous().Do(http.MethodGet, "/v1/admin/jobs", nil).StatusCode)
+	})
+
+	t.Run("不正な指定は400、存在しないジョブは404", func(t *testing.T) {
+		res := admin.Do(http.MethodGet, "/v1/admin/jobs?status=failed", nil)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, http.StatusBadRequest, admin.Do(http.MethodGet, "/v1/admin/jobs?limit=0", nil).StatusCode)
+		res = admin.Do(http.MethodGet, "/v1/admin/jobs/999999", nil)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, "job_not_found", res.Code())
+	})
+}
@@ -0,0 +1,364 @@
+package e2e_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/e2e"
+	"todo_backend/internal/infrastructure"
+	"todo_backend/internal/infrastructure/deprecation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth(t *testing.T) {
+	s := e2e.NewServer(t)
+	anon := s.Anonymous()
+
+	t.Run("signup", func(t *testing.T) {
+		assert.Equal(t, http.StatusCreated, anon.Signup("alice@example.com", "password1").StatusCode)
+		// 同じメールアドレスは登録できない
+		res := anon.Signup("alice@example.com", "password2")
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+		assert.Equal(t, domain.ErrEmailAlreadyExists.Error(), res.Error())
+		// 入力チェック
+		assert.Equal(t, http.StatusBadRequest, anon.Signup("not-an-email", "password1").StatusCode)
+		assert.Equal(t, http.StatusBadRequest, anon.Signup("bob@example.com", "short").StatusCode)
+	})
+
+	t.Run("login", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, anon.Login("alice@example.com", "password1").StatusCode)
+		assert.Equal(t, http.StatusUnauthorized, anon.Login("alice@example.com", "wrong-password").StatusCode)
+		assert.Equal(t, http.StatusUnauthorized, anon.Login("nobody@example.com", "password1").StatusCode)
+	})
+
+	t.Run("protected routes require a valid token", func(t *testing.T) {
+		res := anon.Do(http.MethodGet, "/v1/todos", nil)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, "missing bearer token", res.Error())
+
+		res = s.WithToken("not.a.jwt").Do(http.MethodGet, "/v1/todos", nil)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, "invalid token", res.Error())
+	})
+}
+
+func TestTodoCRUD(t *testing.T) {
+	s := e2e.NewServer(t)
+	c := s.SignupAndLogin("alice@example.com", "password1")
+
+	// 作成
+	res := c.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "牛乳を買う"})
+	require.Equal(t, http.StatusCreated, res.StatusCode, string(res.Body))
+	created := res.Todo()
+	assert.Equal(t, "牛乳を買う", created.Title)
+	assert.Equal(t, uint(1), created.Version)
+	assert.Equal(t, fmt.Sprintf("/v1/todos/%d", created.ID), res.Header.Get("Location"))
+	assert.Equal(t, `"v1"`, res.Header.Get("ETag"))
+	path := res.Header.Get("Location")
+
+	// 取得
+	res = c.Do(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, created, res.Todo())
+	assert.Equal(t, http.StatusNotModified, c.Do(http.MethodGet, path, nil, "If-None-Match", `"v1"`).StatusCode)
+
+	// 一覧
+	res = c.Do(http.MethodGet, "/v1/todos", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var todos []domain.Todo
+	res.Decode(&todos)
+	assert.Equal(t, []domain.Todo{created}, todos)
+
+	// 全体更新
+	res = c.Do(http.MethodPut, path, map[string]any{"title": "牛乳とパンを買う", "completed": false}, "If-Match", `"v1"`)
+	require.Equal(t, http.StatusOK, res.StatusCode, string(res.Body))
+	assert.Equal(t, uint(2), res.Todo().Version)
+
+	// 部分更新
+	res = c.Do(http.MethodPatch, path, `{"completed":true}`, "If-Match", `"v2"`)
+	require.Equal(t, http.StatusOK, res.StatusCode, string(res.Body))
+	patched := res.Todo()
+	assert.Equal(t, "牛乳とパンを買う", patched.Title)
+	assert.True(t, patched.Completed)
+	assert.Equal(t, `"v3"`, res.Header.Get("ETag"))
+
+	// 削除
+	res = c.Do(http.MethodDelete, path, nil, "If-Match", `"v3"`)
+	require.Equal(t, http.StatusOK, res.StatusCode, string(res.Body))
+	assert.Equal(t, http.StatusNotFound, c.Do(http.MethodGet, path, nil).StatusCode)
+}
+
+func TestCrossUserIsolation(t *testing.T) {
+	s := e2e.NewServer(t)
+	alice := s.SignupAndLogin("alice@example.com", "password1")
+	bob := s.SignupAndLogin("bob@example.com", "password1")
+
+	res := alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "alice's"})
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	path := res.Header.Get("Location")
+
+	// bobからはaliceのTodoが見えず、操作もできない（存在しない場合と区別しない）
+	res = bob.Do(http.MethodGet, "/v1/todos", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var todos []domain.Todo
+	res.Decode(&todos)
+	assert.Empty(t, todos)
+
+	assert.Equal(t, http.StatusNotFound, bob.Do(http.MethodGet, path, nil).StatusCode)
+	assert.Equal(t, http.StatusNotFound, bob.Do(http.MethodPut, path, map[string]any{"title": "hacked"}, "If-Match", `"v1"`).StatusCode)
+	assert.Equal(t, http.StatusNotFound, bob.Do(http.MethodPatch, path, `{"title":"hacked"}`, "If-Match", `"v1"`).StatusCode)
+	assert.Equal(t, http.StatusNotFound, bob.Do(http.MethodPatch, path, `{"title":"hacked"}`, "If-Match", "*").StatusCode)
+	assert.Equal(t, http.StatusNotFound, bob.Do(http.MethodDelete, path, nil, "If-Match", `"v1"`).StatusCode)
+
+	// 一括操作でも操作できない
+	res = bob.Do(http.MethodPost, "/v1/todos/batch", `{"operations":[{"op":"complete","id":1}]}`)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	res = bob.Do(http.MethodPost, "/v1/todos/complete", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `{"completed":0,"todos":[]}`, string(res.Body))
+
+	// aliceのTodoは変更されていない
+	res = alice.Do(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "alice's", res.Todo().Title)
+	assert.False(t, res.Todo().Completed)
+}
+
+func TestErrorCases(t *testing.T) {
+	s := e2e.NewServer(t)
+	c := s.SignupAndLogin("alice@example.com", "password1")
+	res := c.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "t"})
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	path := res.Header.Get("Location")
+
+	cases := []struct {
+		name    string
+		method  string
+		path    string
+		body    any
+		headers []string
+		status  int
+	}{
+		{"invalid id", http.MethodGet, "/v1/todos/abc", nil, nil, http.StatusBadRequest},
+		{"negative id on delete", http.MethodDelete, "/v1/todos/-1", nil, []string{"If-Match", "*"}, http.StatusBadRequest},
+		{"overflowing id on delete", http.MethodDelete, "/v1/todos/18446744073709551617", nil, []string{"If-Match", "*"}, http.StatusBadRequest},
+		{"unknown todo", http.MethodGet, "/v1/todos/9999", nil, nil, http.StatusNotFound},
+		{"malformed json", http.MethodPost, "/v1/todos", `{"title":`, nil, http.StatusBadRequest},
+		{"missing If-Match", http.MethodPut, path, map[string]any{"title": "x"}, nil, http.StatusPreconditionRequired},
+		{"stale If-Match", http.MethodPut, path, map[string]any{"title": "x"}, []string{"If-Match", `"v9"`}, http.StatusPreconditionFailed},
+		{"invalid If-Match", http.MethodDelete, path, nil, []string{"If-Match", "v1"}, http.StatusBadRequest},
+		{"path and body id differ", http.MethodPut, path, map[string]any{"id": 9999, "title": "x"}, []string{"If-Match", `"v1"`}, http.StatusBadRequest},
+		{"immutable field in patch", http.MethodPatch, path, `{"user_id":2}`, []string{"If-Match", `"v1"`}, http.StatusBadRequest},
+		{"blank title in patch", http.MethodPatch, path, `{"title":"   "}`, []string{"If-Match", `"v1"`}, http.StatusBadRequest},
+		{"title missing in put", http.MethodPut, path, map[string]any{"completed": true}, []string{"If-Match", `"v1"`}, http.StatusBadRequest},
+		{"oversized body", http.MethodPost, "/v1/todos", map[string]any{"title": strings.Repeat("a", 2<<20)}, nil, http.StatusRequestEntityTooLarge},
+		{"empty batch", http.MethodPost, "/v1/todos/batch", `{"operations":[]}`, nil, http.StatusBadRequest},
+		{"unknown route", http.MethodGet, "/nope", nil, nil, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := c.Do(tc.method, tc.path, tc.body, tc.headers...)
+			assert.Equal(t, tc.status, res.StatusCode, string(res.Body))
+		})
+	}
+
+	// エラーの後もTodoは変更されていない
+	res = c.Do(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, uint(1), res.Todo().Version)
+}
+
+func TestCreateValidation(t *testing.T) {
+	s := e2e.NewServer(t)
+	c := s.SignupAndLogin("alice@example.com", "password1")
+
+	// 検証エラーはフィールドごとの詳細を返す
+	res := c.Do(http.MethodPost, "/v1/todos", map[string]any{"title": " ", "user_id": 2})
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.JSONEq(t, `{
+		"error": "invalid request: user_id is not allowed",
+		"code": "invalid_request",
+		"details": [{"field": "user_id", "code": "not_allowed", "message": "is not allowed"}]
+	}`, string(res.Body))
+
+	res = c.Do(http.MethodPost, "/v1/todos", map[string]any{"title": strings.Repeat("長", 201)})
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.JSONEq(t, `{
+		"error": "invalid request: title must be at most 200 characters",
+		"code": "invalid_request",
+		"details": [{"field": "title", "code": "too_long", "message": "must be at most 200 characters", "params": {"max": 200}}]
+	}`, string(res.Body))
+
+	res = c.Do(http.MethodPost, "/v1/todos/batch", `{"operations":[{"op":"create"},{"op":"delete","id":1}]}`)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	var body struct {
+		Details []struct{ Field string } `json:"details"`
+	}
+	res.Decode(&body)
+	require.Len(t, body.Details, 2)
+	assert.Equal(t, "operations[0].title", body.Details[0].Field)
+	assert.Equal(t, "operations[1].version", body.Details[1].Field)
+
+	// タイトルの前後の空白は除去して保存する
+	res = c.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "  牛乳を買う\n"})
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "牛乳を買う", res.Todo().Title)
+
+	var todos []domain.Todo
+	c.Do(http.MethodGet, "/v1/todos", nil).Decode(&todos)
+	assert.Len(t, todos, 1)
+}
+
+func TestIdempotentCreate(t *testing.T) {
+	s := e2e.NewServer(t)
+	c := s.SignupAndLogin("alice@example.com", "password1")
+
+	first := c.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "once"}, "Idempotency-Key", "k1")
+	retry := c.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "once"}, "Idempotency-Key", "k1")
+
+	require.Equal(t, http.StatusCreated, first.StatusCode)
+	assert.Equal(t, http.StatusCreated, retry.StatusCode)
+	assert.Equal(t, "true", retry.Header.Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Todo(), retry.Todo())
+	var todos []domain.Todo
+	c.Do(http.MethodGet, "/v1/todos", nil).Decode(&todos)
+	assert.Len(t, todos, 1)
+}
+
+func TestOpenAPI(t *testing.T) {
+	s := e2e.NewServer(t, infrastructure.WithRequestValidation())
+	c := s.SignupAndLogin("alice@example.com", "password1")
+
+	t.Run("spec and docs are served without authentication", func(t *testing.T) {
+		res := s.Anonymous().Do(http.MethodGet, "/openapi.json", nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var spec struct {
+			OpenAPI string         `json:"openapi"`
+			Paths   map[string]any `json:"paths"`
+		}
+		res.Decode(&spec)
+		assert.Equal(t, "3.1.0", spec.OpenAPI)
+		assert.Contains(t, spec.Paths, "/v1/todos/{id}")
+
+		assert.Equal(t, http.StatusOK, s.Anonymous().Do(http.MethodGet, "/docs", nil).StatusCode)
+	})
+
+	t.Run("requests that do not match the spec are rejected", func(t *testing.T) {
+		res := c.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "t"})
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		path := res.Header.Get("Location")
+
+		res = c.Do(http.MethodPatch, path, `{"completed":"yes"}`, "If-Match", `"v1"`)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "request body /completed: value must be a boolean", res.Error())
+
+		res = c.Do(http.MethodPost, "/v1/todos/batch", `{"operations":[{"op":"archive","id":1}]}`)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		// 認証は検証より先に行われる
+		assert.Equal(t, http.StatusUnauthorized, s.Anonymous().Do(http.MethodPatch, path, `{"completed":"yes"}`).StatusCode)
+
+		res = c.Do(http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, uint(1), res.Todo().Version)
+	})
+}
+
+func TestLegacyRoutes(t *testing.T) {
+	sunset := time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC)
+	s := e2e.NewServer(t, infrastructure.WithLegacyRoutes(deprecation.Config{
+		Deprecated: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		Sunset:     sunset,
+	}))
+	anon := s.Anonymous()
+
+	// 旧来のパスでも登録・ログインできる
+	require.Equal(t, http.StatusCreated, anon.Do(http.MethodPost, "/signup", map[string]string{"email": "alice@example.com", "password": "password1"}).StatusCode)
+	res := anon.Do(http.MethodPost, "/login", map[string]string{"email": "alice@example.com", "password": "password1"})
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var login struct {
+		Token string `json:"token"`
+	}
+	res.Decode(&login)
+	c := s.WithToken(login.Token)
+
+	// 旧来のパスのレスポンスには廃止予定のヘッダが付く
+	res = c.Do(http.MethodPost, "/todos", map[string]any{"title": "legacy"})
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "@1792368000", res.Header.Get("Deprecation"))
+	assert.Equal(t, sunset.Format(http.TimeFormat), res.Header.Get("Sunset"))
+	assert.Equal(t, `</v1/todos>; rel="successor-version"`, res.Header.Get("Link"))
+	legacyPath := res.Header.Get("Location")
+	assert.Equal(t, fmt.Sprintf("/todos/%d", res.Todo().ID), legacyPath)
+
+	// 同じデータを/v1から操作でき、/v1のレスポンスには廃止予定のヘッダが付かない
+	res = c.Do(http.MethodGet, "/v1"+legacyPath, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "legacy", res.Todo().Title)
+	assert.Empty(t, res.Header.Get("Deprecation"))
+	assert.Empty(t, res.Header.Get("Sunset"))
+
+	res = c.Do(http.MethodPatch, legacyPath, `{"completed":true}`, "If-Match", `"v1"`)
+	require.Equal(t, http.StatusOK, res.StatusCode, string(res.Body))
+	assert.True(t, res.Todo().Completed)
+	assert.Equal(t, fmt.Sprintf(`</v1%s>; rel="successor-version"`, legacyPath), res.Header.Get("Link"))
+}
+
+func TestLocalizedErrors(t *testing.T) {
+	s := e2e.NewServer(t)
+	anon := s.Anonymous()
+
+	t.Run("Accept-Languageの言語で返し、コードは言語によらない", func(t *testing.T) {
+		res := anon.Do(http.MethodGet, "/v1/todos", nil, "Accept-Language", "ja-JP,ja;q=0.9,en;q=0.8")
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, "Bearer トークンが指定されていません", res.Error())
+		assert.Equal(t, "missing_token", res.Code())
+		assert.Equal(t, "ja", res.Header.Get("Content-Language"))
+
+		res = anon.Do(http.MethodGet, "/v1/todos", nil, "Accept-Language", "fr")
+		assert.Equal(t, "missing bearer token", res.Error())
+		assert.Equal(t, "missing_token", res.Code())
+	})
+
+	t.Run("フィールドごとの検証エラーも翻訳する", func(t *testing.T) {
+		res := anon.Do(http.MethodPost, "/v1/signup", map[string]string{"email": "not-an-email", "password": "short"},
+			"Accept-Language", "ja")
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.JSONEq(t, `{
+			"error": "入力内容に誤りがあります: email: メールアドレスの形式で入力してください; password: 8 文字以上で入力してください",
+			"code": "invalid_request",
+			"details": [
+				{"field": "email", "code": "email", "message": "メールアドレスの形式で入力してください"},
+				{"field": "password", "code": "min_length", "message": "8 文字以上で入力してください", "params": {"min": 8}}
+			]
+		}`, string(res.Body))
+
+		res = anon.Do(http.MethodPost, "/v1/signup", map[string]string{"email": "bob@example.com", "password": "password1", "locale": "fr"})
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "invalid request: locale must be one of: ja, en", res.Error())
+	})
+
+	t.Run("ユーザーの言語設定はAccept-Languageより優先される", func(t *testing.T) {
+		res := anon.Do(http.MethodPost, "/v1/signup",
+			map[string]string{"email": "carol@example.com", "password": "password1", "locale": "ja"})
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		res = anon.Login("carol@example.com", "password1")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var body struct {
+			Token string `json:"token"`
+		}
+		res.Decode(&body)
+		c := s.WithToken(body.Token)
+
+		res = c.Do(http.MethodGet, "/v1/todos/9999", nil, "Accept-Language", "en-US")
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, "Todo が見つかりません", res.Error())
+		assert.Equal(t, "todo_not_found", res.Code())
+	})
+}
@@ -0,0 +1,605 @@
+// Package e2eは、HTTP APIをエンドツーエンドで検証するためのテストハーネスを提供します。
+// NewServerはインメモリのSQLiteを使ってNewRouterを起動し、
+// Clientはサインアップ・ログイン・認証付きリクエストのヘルパーを提供します。
+// すべてのレスポンスはOpenAPIドキュメント（openapi.yaml）に照らして検証されます。
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure"
+	"todo_backend/internal/infrastructure/events"
+	"todo_backend/internal/infrastructure/idempotency"
+	"todo_backend/internal/infrastructure/jobs"
+	"todo_backend/internal/infrastructure/mail"
+	"todo_backend/internal/infrastructure/mysql"
+	"todo_backend/internal/infrastructure/openapi"
+	"todo_backend/internal/infrastructure/realtime"
+	"todo_backend/internal/infrastructure/reminder"
+	"todo_backend/internal/infrastructure/webhook"
+	"todo_backend/internal/interface/handler"
+	"todo_backend/internal/interface/repository"
+	"todo_backend/internal/usecase"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// testJWTSecretはテスト中に使うJWTの署名鍵です。
+const testJWTSecret = "e2e-test-secret"
+
+// AdminUserIDは管理者として扱うユーザーのIDです。サーバの起動後に最初に登録したユーザーが/v1/admin以下を利用できます。
+const AdminUserID = 1
+
+func init() {
+	// /docsのHTMLをレスポンス検証で文字列として扱う
+	openapi3filter.RegisterBodyDecoder("text/html", openapi3filter.PlainBodyDecoder)
+}
+
+// Serverはテスト用に起動したAPIサーバです。
+type Server struct {
+	t *testing.T
+	// URLはサーバのベースURLです（例: http://127.0.0.1:12345）。
+	URL string
+	// DBはサーバが使うDBです。テストデータの準備や検証に使えます。
+	DB *gorm.DB
+	// Mailboxはサーバが送信したメール（メールのチャネルの通知）です。
+	Mailbox *Mailbox
+	// specはレスポンスの検証に使うOpenAPIドキュメントのルーターです。
+	spec routers.Router
+}
+
+// Mailboxは送信したメールを記録するmail.Mailerです。
+type Mailbox struct {
+	mu   sync.Mutex
+	msgs []mail.Message
+}
+
+// Sendはmsgを記録します。
+func (m *Mailbox) Send(ctx context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.msgs = append(m.msgs, msg)
+	return nil
+}
+
+// Messagesはこれまでに送信したメールを送信順に返します。
+func (m *Mailbox) Messages() []mail.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]mail.Message(nil), m.msgs...)
+}
+
+// NewServerは、インメモリのSQLiteに接続した本番と同じ構成（リポジトリ・ユースケース・ルーター）で
+// APIサーバを起動します。サーバ（とジョブ・Webhookの配信・リマインダーのワーカー）はテスト終了時に停止します。
+// optsで追加のルーター設定（レート制限など）を指定できます。冪等キーは常に有効です。
+func NewServer(t *testing.T, opts ...infrastructure.RouterOption) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", testJWTSecret)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// :memory:は接続ごとに別のDBになるため1接続に固定する
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&domain.User{}, &domain.Todo{}, &domain.TodoChange{}, &domain.TodoChangeLog{}, &domain.Webhook{}, &domain.WebhookDelivery{}, &domain.Job{}, &domain.Reminder{}, &domain.Notification{}, &idempotency.Record{}))
+
+	bus := events.NewBus(events.DefaultLogSize)
+	hub := realtime.NewHub()
+	authUC := usecase.NewAuthUsecase(mysql.NewUserMySQL(db), usecase.WithAdminUserIDs(AdminUserID))
+	// ジョブとWebhookの再試行はテストで待てるよう短い間隔にする
+	jobUC := usecase.NewJobUsecase(mysql.NewJobMysql(db),
+		usecase.WithJobRetry(usecase.RetryPolicy{MaxAttempts: 3, Backoff: 50 * time.Millisecond, MaxBackoff: 200 * time.Millisecond}),
+	)
+	// 通知先はhttptestのローカルのサーバのため、ループバックへの接続を許可する
+	sender := webhook.NewSender(5*time.Second, netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128"))
+	webhookUC := usecase.NewWebhookUsecase(mysql.NewWebhookMysql(db), sender,
+		usecase.WithWebhookRetry(usecase.RetryPolicy{MaxAttempts: 3, Backoff: 50 * time.Millisecond, MaxBackoff: 200 * time.Millisecond}),
+	)
+	dispatcher := webhook.NewDispatcher(webhookUC, 2, 20*time.Millisecond)
+	worker := jobs.NewWorker(jobUC, 2, 20*time.Millisecond)
+	worker.Handle(usecase.JobTypeWebhookTodoEvent, dispatcher.HandleJob)
+	repos := repository.Repositories{
+		Todos:         mysql.NewTodoMysql(db),
+		Users:         mysql.NewUserMySQL(db),
+		Jobs:          mysql.NewJobMysql(db),
+		Reminders:     mysql.NewReminderMysql(db),
+		Notifications: mysql.NewNotificationMysql(db),
+	}
+	mailbox := &Mailbox{}
+	reminderUC := usecase.NewReminderUsecase(repos, mysql.NewTransactor(db), usecase.WithNotificationPublisher(hub))
+	notificationUC := usecase.NewNotificationUsecase(repos.Notifications,
+		usecase.WithNotificationSender(domain.NotificationEmail, mail.NewChannel(repos.Users, mailbox)),
+		usecase.WithNotificationSender(domain.NotificationWebhook, dispatcher),
+	)
+	scheduler := reminder.NewScheduler(reminderUC, 20*time.Millisecond)
+	worker.Handle(usecase.JobTypeReminderTodoEvent, reminderUC.HandleTodoEventJob)
+	worker.Handle(usecase.JobTypeNotificationDelivery, notificationUC.HandleDeliveryJob)
+	todoUC := usecase.NewTodoUsecase(repos.Todos,
+		usecase.WithTransactor(mysql.NewTransactor(db)),
+		usecase.WithEventBus(bus),
+		usecase.WithEventJob(usecase.JobTypeWebhookTodoEvent),
+		usecase.WithEventJob(usecase.JobTypeReminderTodoEvent, usecase.ReminderTodoEvents...),
+	)
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	for _, run := range []func(context.Context){worker.Run, dispatcher.Run, scheduler.Run} {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
+	t.Cleanup(func() {
+		stopWorkers()
+		workers.Wait()
+	})
+
+	// アクセスログはテスト出力に含めない
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	opts = append([]infrastructure.RouterOption{
+		infrastructure.WithIdempotency(idempotency.NewGormStore(db), 0),
+		infrastructure.WithRealtimeHub(hub),
+		infrastructure.WithWebhooks(webhookUC),
+		infrastructure.WithJobs(jobUC),
+		infrastructure.WithReminders(reminderUC),
+		infrastructure.WithNotifications(notificationUC),
+	}, opts...)
+	router, err := infrastructure.NewRouter(handler.NewAuthHandler(authUC), todoUC, opts...)
+	require.NoError(t, err)
+
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+	spec, err := legacy.NewRouter(doc)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	// 接続中のイベントストリームを先に終了させる（srv.Closeは処理中のリクエストの完了を待つ）
+	t.Cleanup(bus.Close)
+	// WebSocketの接続はsrv.Closeの対象外のため、ハブから閉じる
+	t.Cleanup(hub.Close)
+	return &Server{t: t, URL: srv.URL, DB: db, Mailbox: mailbox, spec: spec}
+}
+
+// Anonymousは認証ヘッダを付けないクライアントを返します。
+func (s *Server) Anonymous() *Client {
+	return &Client{s: s}
+}
+
+// WithTokenは指定したトークンを付けて送信するクライアントを返します（不正なトークンの検証などに使います）。
+func (s *Server) WithToken(token string) *Client {
+	return &Client{s: s, Token: token}
+}
+
+// SignupAndLoginはユーザーを登録してログインし、そのユーザーとして認証済みのクライアントを返します。
+func (s *Server) SignupAndLogin(email, password string) *Client {
+	s.t.Helper()
+	c := s.Anonymous()
+	res := c.Signup(email, password)
+	require.Equal(s.t, http.StatusCreated, res.StatusCode, "signup: %s", res.Body)
+
+	res = c.Login(email, password)
+	require.Equal(s.t, http.StatusOK, res.StatusCode, "login: %s", res.Body)
+	var body struct {
+		Token string `json:"token"`
+	}
+	res.Decode(&body)
+	require.NotEmpty(s.t, body.Token)
+	return s.WithToken(body.Token)
+}
+
+// ClientはAPIサーバへリクエストを送るクライアントです。
+// Tokenが設定されている場合はAuthorization: Bearerヘッダを付与します。
+type Client struct {
+	s     *Server
+	Token string
+}
+
+// Signupは/v1/signupを呼び出します。
+func (c *Client) Signup(email, password string) *Response {
+	return c.Do(http.MethodPost, "/v1/signup", map[string]string{"email": email, "password": password})
+}
+
+// Loginは/v1/loginを呼び出します。
+func (c *Client) Login(email, password string) *Response {
+	return c.Do(http.MethodPost, "/v1/login", map[string]string{"email": email, "password": password})
+}
+
+// Doはリクエストを送信してレスポンスを返します。
+// bodyがnil以外の場合はJSONとして送信します（stringと[]byteはそのまま送信します）。
+// headersは"名前", "値"の組で指定します。
+func (c *Client) Do(method, path string, body any, headers ...string) *Response {
+	t := c.s.t
+	t.Helper()
+	require.Zero(t, len(headers)%2, "headers must be name/value pairs")
+
+	var r io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		r = strings.NewReader(b)
+	case []byte:
+		r = bytes.NewReader(b)
+	default:
+		encoded, err := json.Marshal(b)
+		require.NoError(t, err)
+		r = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, c.s.URL+path, r)
+	require.NoError(t, err)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	for i := 0; i < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	c.s.checkResponse(req, res, data)
+	return &Response{t: t, StatusCode: res.StatusCode, Header: res.Header, Body: data}
+}
+
+// checkResponseは、レスポンスのステータス・ヘッダ・ボディがOpenAPIドキュメントの記述と一致することを確認します。
+// ドキュメントに記述のないパス（存在しないルートなど）は検証しません。
+func (s *Server) checkResponse(req *http.Request, res *http.Response, body []byte) {
+	s.t.Helper()
+	route, pathParams, err := s.spec.FindRoute(req)
+	if err != nil {
+		return
+	}
+	err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{Request: req, PathParams: pathParams, Route: route},
+		Status:                 res.StatusCode,
+		Header:                 res.Header,
+		Body:                   io.NopCloser(bytes.NewReader(body)),
+		// ドキュメントに記述のないステータスコードも不一致とする
+		Options: &openapi3filter.Options{IncludeResponseStatus: true},
+	})
+	require.NoError(s.t, err, "%s %s: response does not match the OpenAPI spec: %s", req.Method, req.URL.Path, body)
+}
+
+// Responseはレスポンスのステータス・ヘッダ・ボディです。
+type Response struct {
+	t          *testing.T
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// DecodeはボディをJSONとしてvにデコードします。
+func (r *Response) Decode(v any) {
+	r.t.Helper()
+	require.NoError(r.t, json.Unmarshal(r.Body, v), "body: %s", r.Body)
+}
+
+// Todoはボディをdomain.Todoとしてデコードして返します。
+func (r *Response) Todo() domain.Todo {
+	r.t.Helper()
+	var todo domain.Todo
+	r.Decode(&todo)
+	return todo
+}
+
+// Errorはエラーレスポンスの"error"フィールドを返します。
+func (r *Response) Error() string {
+	r.t.Helper()
+	var body struct {
+		Error string `json:"error"`
+	}
+	r.Decode(&body)
+	return body.Error
+}
+
+// Codeはエラーレスポンスの"code"フィールド（機械可読なエラーコード）を返します。
+func (r *Response) Code() string {
+	r.t.Helper()
+	var body struct {
+		Code string `json:"code"`
+	}
+	r.Decode(&body)
+	return body.Code
+}
+
+// Eventは受信したServer-Sent Eventsのイベントです。
+type Event struct {
+	ID   string
+	Type string
+	Data string
+}
+
+// EventStreamはServer-Sent Eventsのストリームの受信側です。
+type EventStream struct {
+	t      *testing.T
+	cancel context.CancelFunc
+	events chan Event
+	// Headerはストリームのレスポンスヘッダです。
+	Header http.Header
+}
+
+// Streamはpathのイベントストリーム（text/event-stream）に接続します。
+// 200以外が返った場合はテストを失敗させます。ストリームはClose（またはテスト終了時）に切断します。
+// headersは"名前", "値"の組で指定します（Last-Event-IDなど）。
+func (c *Client) Stream(path string, headers ...string) *EventStream {
+	t := c.s.t
+	t.Helper()
+	require.Zero(t, len(headers)%2, "headers must be name/value pairs")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.s.URL+path, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	for i := 0; i < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		cancel()
+		require.Failf(t, "stream failed", "GET %s: %d %s", path, res.StatusCode, body)
+	}
+
+	s := &EventStream{t: t, cancel: cancel, events: make(chan Event, 100), Header: res.Header}
+	go func() {
+		defer res.Body.Close()
+		defer close(s.events)
+		var ev Event
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "id":
+				ev.ID = value
+			case "event":
+				ev.Type = value
+			case "data":
+				ev.Data = value
+			case "":
+				// 空行でイベントが確定する（コメント行・retryのみのブロックは無視する）
+				if line == "" && ev.Data != "" {
+					s.events <- ev
+				}
+				if line == "" {
+					ev = Event{}
+				}
+			}
+		}
+	}()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Nextは次のイベントを返します。一定時間内に受信できない場合はテストを失敗させます。
+func (s *EventStream) Next() Event {
+	s.t.Helper()
+	select {
+	case ev, ok := <-s.events:
+		require.True(s.t, ok, "stream closed")
+		return ev
+	case <-time.After(5 * time.Second):
+		require.FailNow(s.t, "no event received")
+		return Event{}
+	}
+}
+
+// NoEventは、waitの間イベントを受信しないことを確認します。
+func (s *EventStream) NoEvent(wait time.Duration) {
+	s.t.Helper()
+	select {
+	case ev, ok := <-s.events:
+		if ok {
+			require.Failf(s.t, "unexpected event", "%+v", ev)
+		}
+	case <-time.After(wait):
+	}
+}
+
+// Closeはストリームを切断します。
+func (s *EventStream) Close() {
+	s.cancel()
+}
+
+// Messageはリアルタイム接続（WebSocket）で受信したJSONのメッセージです。
+type Message map[string]any
+
+// Typeはメッセージの"type"を返します。
+func (m Message) Type() string {
+	typ, _ := m["type"].(string)
+	return typ
+}
+
+// Socketはリアルタイム接続（WebSocket）のクライアント側です。
+type Socket struct {
+	t        *testing.T
+	conn     *websocket.Conn
+	messages chan Message
+	// pendingはNextOfで読み飛ばした、まだ取り出していないメッセージです。
+	pending []Message
+	// errは受信を終了した原因（Closeフレームなど）です。messagesが閉じた後に読み出せます。
+	err error
+}
+
+// Dialはpathにリアルタイム接続（WebSocket）で接続します。JWTはAuthorizationヘッダで送ります。
+// 接続に失敗した場合はテストを失敗させます。接続はClose（またはテスト終了時）に切断します。
+func (c *Client) Dial(path string) *Socket {
+	t := c.s.t
+	t.Helper()
+	conn, res, err := websocket.DefaultDialer.Dial(c.wsURL(path), c.authHeader())
+	if err != nil {
+		status := 0
+		if res != nil {
+			status = res.StatusCode
+		}
+		require.FailNowf(t, "dial failed", "GET %s: %d %v", path, status, err)
+	}
+
+	s := &Socket{t: t, conn: conn, messages: make(chan Message, 100)}
+	go func() {
+		defer close(s.messages)
+		for {
+			var msg Message
+			if err := conn.ReadJSON(&msg); err != nil {
+				s.err = err
+				return
+			}
+			s.messages <- msg
+		}
+	}()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// DialErrorは、pathへのリアルタイム接続が拒否されることを確認し、そのときのレスポンスを返します。
+// レスポンスはOpenAPIドキュメントに照らして検証します。
+func (c *Client) DialError(path string) *Response {
+	t := c.s.t
+	t.Helper()
+	conn, res, err := websocket.DefaultDialer.Dial(c.wsURL(path), c.authHeader())
+	if err == nil {
+		conn.Close()
+		require.FailNowf(t, "dial succeeded", "GET %s", path)
+	}
+	require.NotNil(t, res, "GET %s: %v", path, err)
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, c.s.URL+path, nil)
+	require.NoError(t, err)
+	c.s.checkResponse(req, res, data)
+	return &Response{t: t, StatusCode: res.StatusCode, Header: res.Header, Body: data}
+}
+
+func (c *Client) wsURL(path string) string {
+	return "ws" + strings.TrimPrefix(c.s.URL, "http") + path
+}
+
+func (c *Client) authHeader() http.Header {
+	header := http.Header{}
+	if c.Token != "" {
+		header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return header
+}
+
+// SendはメッセージをJSONで送信します。
+func (s *Socket) Send(msg any) {
+	s.t.Helper()
+	require.NoError(s.t, s.conn.WriteJSON(msg))
+}
+
+// Nextは次のメッセージを返します。一定時間内に受信できない場合はテストを失敗させます。
+func (s *Socket) Next() Message {
+	s.t.Helper()
+	if len(s.pending) > 0 {
+		msg := s.pending[0]
+		s.pending = s.pending[1:]
+		return msg
+	}
+	return s.recv()
+}
+
+// NextOfは次のtypeのメッセージを返します。途中の他のメッセージは後のNextで取り出せます。
+func (s *Socket) NextOf(typ string) Message {
+	s.t.Helper()
+	for i, msg := range s.pending {
+		if msg.Type() == typ {
+			s.pending = append(s.pending[:i:i], s.pending[i+1:]...)
+			return msg
+		}
+	}
+	for {
+		msg := s.recv()
+		if msg.Type() == typ {
+			return msg
+		}
+		s.pending = append(s.pending, msg)
+	}
+}
+
+// NoMessageは、waitの間メッセージを受信しないことを確認します。
+func (s *Socket) NoMessage(wait time.Duration) {
+	s.t.Helper()
+	require.Empty(s.t, s.pending)
+	select {
+	case msg, ok := <-s.messages:
+		if ok {
+			require.Failf(s.t, "unexpected message", "%v", msg)
+		}
+	case <-time.After(wait):
+	}
+}
+
+// Closedは、サーバが接続を閉じるまで待ち、Closeフレームのコードを返します。
+func (s *Socket) Closed() int {
+	s.t.Helper()
+	for {
+		select {
+		case _, ok := <-s.messages:
+			if !ok {
+				var closeErr *websocket.CloseError
+				require.ErrorAs(s.t, s.err, &closeErr)
+				return closeErr.Code
+			}
+		case <-time.After(5 * time.Second):
+			require.FailNow(s.t, "connection not closed")
+			return 0
+		}
+	}
+}
+
+// Closeは接続を切断します。
+func (s *Socket) Close() {
+	s.conn.Close()
+}
+
+func (s *Socket) recv() Message {
+	s.t.Helper()
+	select {
+	case msg, ok := <-s.messages:
+		require.True(s.t, ok, "connection closed: %v", s.err)
+		return msg
+	case <-time.After(5 * time.Second):
+		require.FailNow(s.t, "no message received")
+		return nil
+	}
+}
@@ -0,0 +1,91 @@
+package e2e_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"todo_backend/internal/e2e"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unreadCountは未読の通知の件数とETagを返します。
+func unreadCount(t *testing.T, c *e2e.Client) (int, string) {
+	t.Helper()
+	res := c.Do(http.MethodGet, "/v1/notifications/unread_count", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode, "%s", res.Body)
+	var out struct {
+		Unread int `json:"unread"`
+	}
+	res.Decode(&out)
+	return out.Unread, res.Header.Get("ETag")
+}
+
+func TestNotificationCenter(t *testing.T) {
+	s := e2e.NewServer(t)
+	alice := s.SignupAndLogin("alice@example.com", "password1")
+	bob := s.SignupAndLogin("bob@example.com", "password1")
+
+	// リマインダーの通知がリアルタイム接続で届く
+	ws := alice.Dial("/v1/realtime")
+	bobWS := bob.Dial("/v1/realtime")
+	assert.Equal(t, "welcome", ws.Next().Type())
+	assert.Equal(t, "welcome", bobWS.Next().Type())
+	subscribe(t, ws, "notifications")
+	subscribe(t, bobWS, "notifications")
+	todo := alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "牛乳を買う"}).Todo()
+	now := time.Now().UTC().Format(time.RFC3339)
+	createReminder(t, alice, todo.ID, map[string]any{"remind_at": now})
+	createReminder(t, alice, todo.ID, map[string]any{"remind_at": now})
+
+	for range 2 {
+		msg := ws.NextOf("notification")
+		assert.Equal(t, "notifications", msg["channel"])
+		data := msg["data"].(map[string]any)
+		assert.Equal(t, "reminder", data["type"])
+		assert.Equal(t, "牛乳を買う", data["title"])
+		assert.Equal(t, false, data["read"])
+	}
+	bobWS.NoMessage(200 * time.Millisecond)
+	notifications := listNotifications(t, alice, "")
+	require.Len(t, notifications, 2)
+
+	// 未読の件数は件数が変わらない間は304を返す
+	unread, etag := unreadCount(t, alice)
+	assert.Equal(t, 2, unread)
+	assert.Equal(t, `"u2"`, etag)
+	res := alice.Do(http.MethodGet, "/v1/notifications/unread_count", nil, "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+	unread, _ = unreadCount(t, bob)
+	assert.Zero(t, unread)
+
+	// すべて既読にする
+	res = alice.Do(http.MethodPost, "/v1/notifications/read_all", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode, "%s", res.Body)
+	var marked struct {
+		Read int `json:"read"`
+	}
+	res.Decode(&marked)
+	assert.Equal(t, 2, marked.Read)
+	res = alice.Do(http.MethodGet, "/v1/notifications/unread_count", nil, "If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	unread, _ = unreadCount(t, alice)
+	assert.Zero(t, unread)
+	assert.Empty(t, listNotifications(t, alice, "?unread=true"))
+
+	// 削除は宛先のユーザーのみ
+	path := fmt.Sprintf("/v1/notifications/%d", notifications[0].ID)
+	res = bob.Do(http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Equal(t, "notification_not_found", res.Code())
+	res = alice.Do(http.MethodDelete, path, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode, "%s", res.Body)
+	res = alice.Do(http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	remaining := listNotifications(t, alice, "")
+	require.Len(t, remaining, 1)
+	assert.Equal(t, notifications[1].ID, remaining[0].ID)
+}
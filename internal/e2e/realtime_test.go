@@ -0,0 +1,186 @@
+package e2e_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"todo_backend/internal/e2e"
+	"todo_backend/internal/infrastructure"
+	"todo_backend/internal/infrastructure/deadline"
+	"todo_backend/internal/infrastructure/realtime"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// subscribeはチャネルを購読し、ackを返します。
+func subscribe(t *testing.T, s *e2e.Socket, channel string) e2e.Message {
+	t.Helper()
+	s.Send(map[string]any{"type": "subscribe", "id": "sub-" + channel, "channel": channel})
+	ack := s.NextOf("ack")
+	require.Equal(t, "sub-"+channel, ack["id"])
+	return ack
+}
+
+func TestRealtime(t *testing.T) {
+	// 処理期限はWebSocketの接続には適用されない（パスワードのハッシュ化に時間がかかる登録・ログインは除く）
+	const timeout = 500 * time.Millisecond
+	s := e2e.NewServer(t, infrastructure.WithDeadline(deadline.Config{
+		Default: timeout,
+		Routes:  map[string]time.Duration{"POST /v1/signup": 0, "POST /v1/login": 0},
+	}))
+	alice := s.SignupAndLogin("alice@example.com", "password1")
+	bob := s.SignupAndLogin("bob@example.com", "password1")
+
+	t.Run("購読したチャネルの変更が通知される", func(t *testing.T) {
+		ws := alice.Dial("/v1/realtime")
+		bobWS := bob.Dial("/v1/realtime")
+		assert.Equal(t, "welcome", ws.Next().Type())
+		assert.Equal(t, "welcome", bobWS.Next().Type())
+		subscribe(t, ws, "todos")
+		subscribe(t, bobWS, "todos")
+		time.Sleep(timeout + 200*time.Millisecond)
+
+		// 期限を過ぎた接続でも変更操作を行える
+		ws.Send(map[string]any{"type": "mutate", "id": "m1", "operation": map[string]any{"op": "create", "title": "牛乳を買う"}})
+		ack := ws.NextOf("ack")
+		require.Contains(t, ack, "result", "%v", ack)
+		created := ack["result"].(map[string]any)
+
+		ev := ws.NextOf("event")
+		assert.Equal(t, "todos", ev["channel"])
+		assert.Equal(t, "created", ev["event"])
+		assert.NotZero(t, ev["event_id"])
+		assert.Equal(t, "牛乳を買う", ev["data"].(map[string]any)["todo"].(map[string]any)["title"])
+		// 他のユーザーのTodoの変更は届かない
+		bobWS.NoMessage(200 * time.Millisecond)
+
+		ws.Send(map[string]any{"type": "unsubscribe", "channel": "todos"})
+		assert.Equal(t, "ack", ws.Next().Type())
+		res := alice.Do(http.MethodDelete, fmt.Sprintf("/v1/todos/%v", created["id"]), nil, "If-Match", `"v1"`)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		ws.NoMessage(200 * time.Millisecond)
+	})
+
+	t.Run("変更操作の結果がackとeventで届く", func(t *testing.T) {
+		ws := alice.Dial("/v1/realtime")
+		subscribe(t, ws, "todos")
+
+		ws.Send(map[string]any{"type": "mutate", "id": "m1", "operation": map[string]any{"op": "create", "title": "手紙を出す"}})
+		ack := ws.NextOf("ack")
+		ev := ws.NextOf("event")
+
+		assert.Equal(t, "m1", ack["id"])
+		result := ack["result"].(map[string]any)
+		assert.EqualValues(t, http.StatusCreated, result["status"])
+		todo := result["todo"].(map[string]any)
+		assert.Equal(t, "手紙を出す", todo["title"])
+		assert.Equal(t, "created", ev["event"])
+
+		// 1件のTodoのチャネルでも受信できる
+		todoChannel := fmt.Sprintf("todo:%v", todo["id"])
+		ws2 := alice.Dial("/v1/realtime")
+		subscribe(t, ws2, todoChannel)
+		ws.Send(map[string]any{"type": "mutate", "id": "m2", "operation": map[string]any{"op": "complete", "id": todo["id"]}})
+		assert.Equal(t, "m2", ws.NextOf("ack")["id"])
+		ev = ws2.NextOf("event")
+		assert.Equal(t, todoChannel, ev["channel"])
+		assert.Equal(t, "updated", ev["event"])
+	})
+
+	t.Run("変更操作のエラーはHTTPと同じコードで返る", func(t *testing.T) {
+		ws := alice.Dial("/v1/realtime")
+
+		ws.Send(map[string]any{"type": "mutate", "id": "m1", "operation": map[string]any{"op": "create"}})
+		invalid := ws.NextOf("error")
+		ws.Send(map[string]any{"type": "mutate", "id": "m2", "operation": map[string]any{"op": "delete", "id": 9999, "version": 1}})
+		notFound := ws.NextOf("error")
+		ws.Send(map[string]any{"type": "archive", "id": "m3"})
+		unknown := ws.NextOf("error")
+
+		assert.Equal(t, "m1", invalid["id"])
+		assert.Equal(t, "invalid_request", invalid["code"])
+		assert.Equal(t, "operation.title", invalid["details"].([]any)[0].(map[string]any)["field"])
+		assert.Equal(t, "todo_not_found", notFound["code"])
+		assert.Equal(t, "unknown_message_type", unknown["code"])
+	})
+
+	t.Run("プレゼンスが同じユーザーの他の接続に共有される", func(t *testing.T) {
+		res := alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "共有"})
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		channel := fmt.Sprintf("todo:%d", res.Todo().ID)
+		editor := alice.Dial("/v1/realtime")
+		viewer := alice.Dial("/v1/realtime")
+		editorID := editor.Next()["connection"]
+		subscribe(t, editor, channel)
+		subscribe(t, viewer, channel)
+
+		editor.Send(map[string]any{"type": "presence", "id": "p1", "channel": channel, "state": "editing"})
+		assert.Equal(t, "p1", editor.NextOf("ack")["id"])
+		p := viewer.NextOf("presence")
+		assert.Equal(t, channel, p["channel"])
+		assert.Equal(t, editorID, p["connection"])
+		assert.Equal(t, "editing", p["state"])
+
+		// 後から購読した接続はackで現在の状態を受け取る
+		late := alice.Dial("/v1/realtime")
+		ack := subscribe(t, late, channel)
+		require.Len(t, ack["presence"], 1)
+		assert.Equal(t, "editing", ack["presence"].([]any)[0].(map[string]any)["state"])
+
+		// 切断するとleftが通知される
+		editor.Close()
+		p = viewer.NextOf("presence")
+		assert.Equal(t, editorID, p["connection"])
+		assert.Equal(t, "left", p["state"])
+	})
+
+	t.Run("他のユーザーのTodoのチャネルは購読できない", func(t *testing.T) {
+		res := alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "秘密"})
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		ws := bob.Dial("/v1/realtime")
+
+		ws.Send(map[string]any{"type": "subscribe", "channel": fmt.Sprintf("todo:%d", res.Todo().ID)})
+		notFound := ws.NextOf("error")
+		ws.Send(map[string]any{"type": "subscribe", "channel": "project:1"})
+		unknown := ws.NextOf("error")
+		ws.Send(map[string]any{"type": "presence", "channel": "todos", "state": "viewing"})
+		notSubscribed := ws.NextOf("error")
+
+		assert.Equal(t, "todo_not_found", notFound["code"])
+		assert.Equal(t, "unknown_channel", unknown["code"])
+		assert.Equal(t, "not_subscribed", notSubscribed["code"])
+	})
+
+	t.Run("クエリパラメータのJWTで接続できる", func(t *testing.T) {
+		ws := s.Anonymous().Dial("/v1/realtime?access_token=" + alice.Token)
+		ws.Send(map[string]any{"type": "ping", "id": "p"})
+
+		assert.Equal(t, "welcome", ws.Next().Type())
+		assert.Equal(t, "pong", ws.Next().Type())
+	})
+
+	t.Run("JWTがない場合は401", func(t *testing.T) {
+		res := s.Anonymous().DialError("/v1/realtime")
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, "missing_token", res.Code())
+	})
+}
+
+func TestRealtime_ClosedOnShutdown(t *testing.T) {
+	// given
+	hub := realtime.NewHub()
+	s := e2e.NewServer(t, infrastructure.WithRealtimeHub(hub))
+	ws := s.SignupAndLogin("alice@example.com", "password1").Dial("/v1/realtime")
+	ws.Next()
+
+	// when
+	hub.Close()
+
+	// then
+	assert.Equal(t, websocket.CloseGoingAway, ws.Closed())
+}
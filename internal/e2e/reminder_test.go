@@ -0,0 +1,193 @@
+package e2e_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/e2e"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reminderResはリマインダーのレスポンスです。
+type reminderRes struct {
+	ID            uint       `json:"id"`
+	TodoID        uint       `json:"todo_id"`
+	MinutesBefore *int       `json:"minutes_before"`
+	Channels      []string   `json:"channels"`
+	FireAt        *time.Time `json:"fire_at"`
+	FiredAt       *time.Time `json:"fired_at"`
+}
+
+// notificationResは通知のレスポンスです。
+type notificationRes struct {
+	ID         uint       `json:"id"`
+	Type       string     `json:"type"`
+	TodoID     uint       `json:"todo_id"`
+	ReminderID uint       `json:"reminder_id"`
+	Title      string     `json:"title"`
+	Read       bool       `json:"read"`
+	ReadAt     *time.Time `json:"read_at"`
+}
+
+// listNotificationsはログインユーザー宛ての通知を返します。
+func listNotifications(t *testing.T, c *e2e.Client, query string) []notificationRes {
+	t.Helper()
+	res := c.Do(http.MethodGet, "/v1/notifications"+query, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode, "%s", res.Body)
+	var out []notificationRes
+	res.Decode(&out)
+	return out
+}
+
+// createReminderはTodoにリマインダーを設定して返します。
+func createReminder(t *testing.T, c *e2e.Client, todoID uint, body map[string]any) reminderRes {
+	t.Helper()
+	res := c.Do(http.MethodPost, fmt.Sprintf("/v1/todos/%d/reminders", todoID), body)
+	require.Equal(t, http.StatusCreated, res.StatusCode, "%s", res.Body)
+	var r reminderRes
+	res.Decode(&r)
+	return r
+}
+
+func TestReminders(t *testing.T) {
+	s := e2e.NewServer(t)
+	alice := s.SignupAndLogin("alice@example.com", "password1")
+	bob := s.SignupAndLogin("bob@example.com", "password1")
+
+	t.Run("期限の前に1回だけアプリ内・メール・Webhookに通知し、既読にできる", func(t *testing.T) {
+		recv := newReceiver(t, http.StatusOK)
+		register(t, alice, recv, "reminder.fired")
+		dueAt := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+		todo := alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "牛乳を買う", "due_at": dueAt}).Todo()
+		r := createReminder(t, alice, todo.ID, map[string]any{"minutes_before": 5, "channels": []string{"webhook", "email", "in_app"}})
+		assert.Equal(t, []string{"in_app", "email", "webhook"}, r.Channels)
+
+		var notifications []notificationRes
+		require.Eventually(t, func() bool {
+			notifications = listNotifications(t, alice, "?unread=true")
+			return len(notifications) == 1
+		}, 5*time.Second, 20*time.Millisecond)
+		n := notifications[0]
+		assert.Equal(t, "reminder", n.Type)
+		assert.Equal(t, todo.ID, n.TodoID)
+		assert.Equal(t, r.ID, n.ReminderID)
+		assert.Equal(t, "牛乳を買う", n.Title)
+		assert.False(t, n.Read)
+
+		got := recv.waitFor(t, 1)
+		assert.Equal(t, "reminder.fired", got[0].Event)
+		assert.True(t, got[0].Verified)
+		data := got[0].Body["data"].(map[string]any)
+		assert.Equal(t, float64(todo.ID), data["id"])
+		assert.Equal(t, "牛乳を買う", data["reminder"].(map[string]any)["title"])
+		require.Eventually(t, func() bool { return len(s.Mailbox.Messages()) == 1 }, 5*time.Second, 20*time.Millisecond)
+		mail := s.Mailbox.Messages()[0]
+		assert.Equal(t, "alice@example.com", mail.To)
+		assert.Equal(t, "Reminder: 牛乳を買う", mail.Subject)
+
+		res := alice.Do(http.MethodPost, fmt.Sprintf("/v1/notifications/%d/read", n.ID), nil)
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", res.Body)
+		var read notificationRes
+		res.Decode(&read)
+		assert.True(t, read.Read)
+		assert.NotNil(t, read.ReadAt)
+		assert.Empty(t, listNotifications(t, alice, "?unread=true"))
+		assert.Len(t, listNotifications(t, alice, ""), 1)
+
+		// 通知済みのリマインダーは再び通知しない
+		time.Sleep(100 * time.Millisecond)
+		assert.Len(t, listNotifications(t, alice, ""), 1)
+		assert.Len(t, recv.waitFor(t, 1), 1)
+		res = alice.Do(http.MethodGet, fmt.Sprintf("/v1/todos/%d/reminders", todo.ID), nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var reminders []reminderRes
+		res.Decode(&reminders)
+		require.Len(t, reminders, 1)
+		assert.NotNil(t, reminders[0].FiredAt)
+
+		// 他のユーザーの通知は既読にできない
+		res = bob.Do(http.MethodPost, fmt.Sprintf("/v1/notifications/%d/read", n.ID), nil)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, "notification_not_found", res.Code())
+	})
+
+	t.Run("期限を変更すると期限からの相対のリマインダーの通知する日時も変わる", func(t *testing.T) {
+		dueAt := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+		todo := alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "書類を出す", "due_at": dueAt.Format(time.RFC3339)}).Todo()
+		r := createReminder(t, alice, todo.ID, map[string]any{"minutes_before": 60})
+		require.NotNil(t, r.FireAt)
+		assert.True(t, dueAt.Add(-time.Hour).Equal(*r.FireAt))
+
+		later := dueAt.Add(24 * time.Hour)
+		res := alice.Do(http.MethodPatch, fmt.Sprintf("/v1/todos/%d", todo.ID),
+			fmt.Sprintf(`{"due_at":%q}`, later.Format(time.RFC3339)), "If-Match", `"v1"`)
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", res.Body)
+
+		require.Eventually(t, func() bool {
+			res := alice.Do(http.MethodGet, fmt.Sprintf("/v1/todos/%d/reminders", todo.ID), nil)
+			var reminders []reminderRes
+			res.Decode(&reminders)
+			return len(reminders) == 1 && reminders[0].FireAt != nil && later.Add(-time.Hour).Equal(*reminders[0].FireAt)
+		}, 5*time.Second, 20*time.Millisecond)
+
+		// 削除したリマインダーは一覧に含まれない
+		res = alice.Do(http.MethodDelete, fmt.Sprintf("/v1/todos/%d/reminders/%d", todo.ID, r.ID), nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		res = alice.Do(http.MethodDelete, fmt.Sprintf("/v1/todos/%d/reminders/%d", todo.ID, r.ID), nil)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, "reminder_not_found", res.Code())
+	})
+
+	t.Run("一括操作と差分同期でも期限を設定・削除できる", func(t *testing.T) {
+		dueAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+		res := alice.Do(http.MethodPost, "/v1/todos/batch", map[string]any{"operations": []map[string]any{
+			{"op": "create", "title": "請求書を払う", "due_at": dueAt.Format(time.RFC3339)},
+		}})
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", res.Body)
+		var batch struct {
+			Results []struct {
+				Todo domain.Todo `json:"todo"`
+			} `json:"results"`
+		}
+		res.Decode(&batch)
+		todo := batch.Results[0].Todo
+		require.NotNil(t, todo.DueAt)
+		assert.True(t, dueAt.Equal(*todo.DueAt))
+
+		res = alice.Do(http.MethodPost, "/v1/sync", map[string]any{"changes": []map[string]any{
+			{"id": todo.ID, "version": todo.Version, "due_at": nil},
+		}})
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", res.Body)
+		todo = alice.Do(http.MethodGet, fmt.Sprintf("/v1/todos/%d", todo.ID), nil).Todo()
+		assert.Nil(t, todo.DueAt)
+		assert.Equal(t, "請求書を払う", todo.Title)
+	})
+
+	t.Run("不正な指定は400、期限のないTodoへの相対の指定は409", func(t *testing.T) {
+		todo := alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "期限なし"}).Todo()
+		path := fmt.Sprintf("/v1/todos/%d/reminders", todo.ID)
+
+		res := alice.Do(http.MethodPost, path, map[string]any{"minutes_before": 10})
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+		assert.Equal(t, "todo_has_no_due_date", res.Code())
+		for _, body := range []map[string]any{
+			{},
+			{"minutes_before": 10, "remind_at": time.Now().Format(time.RFC3339)},
+			{"minutes_before": -1},
+			{"remind_at": time.Now().Format(time.RFC3339), "channels": []string{"sms"}},
+		} {
+			res := alice.Do(http.MethodPost, path, body)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, "%v", body)
+			assert.Equal(t, "invalid_request", res.Code(), "%v", body)
+		}
+		// 他のユーザーのTodoには設定できない
+		res = bob.Do(http.MethodPost, path, map[string]any{"remind_at": time.Now().Format(time.RFC3339)})
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, http.StatusBadRequest, alice.Do(http.MethodGet, "/v1/notifications?unread=maybe", nil).StatusCode)
+	})
+}
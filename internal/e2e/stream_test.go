@@ -0,0 +1,111 @@
+package e2e_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/e2e"
+	"todo_backend/internal/infrastructure"
+	"todo_backend/internal/infrastructure/deadline"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventTodoはイベントのdataのTodoを返します。
+func eventTodo(t *testing.T, ev e2e.Event) domain.Todo {
+	t.Helper()
+	var data struct {
+		ID   uint         `json:"id"`
+		Todo *domain.Todo `json:"todo"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(ev.Data), &data), ev.Data)
+	if data.Todo == nil {
+		return domain.Todo{ID: data.ID}
+	}
+	return *data.Todo
+}
+
+func TestTodoStream(t *testing.T) {
+	// 処理期限はストリームには適用されない。bcryptで時間のかかる登録・ログインには、
+	// 短い期限が（-raceなどで）間に合わなくならないよう、ルート別に十分長い期限を設定する
+	const timeout = time.Second
+	s := e2e.NewServer(t, infrastructure.WithDeadline(deadline.Config{
+		Default: timeout,
+		Routes:  map[string]time.Duration{"POST /v1/signup": time.Minute, "POST /v1/login": time.Minute},
+	}))
+	alice := s.SignupAndLogin("alice@example.com", "password1")
+	bob := s.SignupAndLogin("bob@example.com", "password1")
+
+	t.Run("自分のTodoの作成・更新・削除が通知される", func(t *testing.T) {
+		stream := alice.Stream("/v1/todos/stream")
+		bobStream := bob.Stream("/v1/todos/stream")
+		assert.Equal(t, "text/event-stream", stream.Header.Get("Content-Type"))
+		time.Sleep(timeout + 200*time.Millisecond)
+
+		res := alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "牛乳を買う"})
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		created := res.Todo()
+		path := fmt.Sprintf("/v1/todos/%d", created.ID)
+		res = alice.Do(http.MethodPatch, path, map[string]any{"completed": true}, "If-Match", `"v1"`)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		res = alice.Do(http.MethodDelete, path, nil, "If-Match", `"v2"`)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		ev := stream.Next()
+		assert.Equal(t, "created", ev.Type)
+		assert.Equal(t, created, eventTodo(t, ev))
+		ev = stream.Next()
+		assert.Equal(t, "updated", ev.Type)
+		assert.True(t, eventTodo(t, ev).Completed)
+		assert.Equal(t, uint(2), eventTodo(t, ev).Version)
+		ev = stream.Next()
+		assert.Equal(t, "deleted", ev.Type)
+		assert.JSONEq(t, fmt.Sprintf(`{"id":%d}`, created.ID), ev.Data)
+
+		// 他のユーザーの変更は通知されない
+		bobStream.NoEvent(100 * time.Millisecond)
+	})
+
+	t.Run("Last-Event-IDで切断中の変更から再開する", func(t *testing.T) {
+		stream := alice.Stream("/v1/todos/stream")
+		res := alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "1"})
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		last := stream.Next()
+		stream.Close()
+
+		// 切断中の変更（一括操作を含む）
+		res = alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "2"})
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		res = alice.Do(http.MethodPost, "/v1/todos/complete", nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		resumed := alice.Stream("/v1/todos/stream", "Last-Event-ID", last.ID)
+		ev := resumed.Next()
+		assert.Equal(t, "created", ev.Type)
+		assert.Equal(t, "2", eventTodo(t, ev).Title)
+		for range 2 {
+			ev = resumed.Next()
+			assert.Equal(t, "updated", ev.Type)
+			assert.True(t, eventTodo(t, ev).Completed)
+		}
+		resumed.NoEvent(100 * time.Millisecond)
+
+		// 保持していない（再起動前などの）IDからの再開はresetを通知する
+		reset := alice.Stream("/v1/todos/stream?last_event_id=1")
+		assert.Equal(t, "reset", reset.Next().Type)
+	})
+
+	t.Run("不正なリクエスト", func(t *testing.T) {
+		res := s.Anonymous().Do(http.MethodGet, "/v1/todos/stream", nil)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		res = alice.Do(http.MethodGet, "/v1/todos/stream", nil, "Last-Event-ID", "abc")
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "invalid_last_event_id", res.Code())
+	})
+}
@@ -0,0 +1,139 @@
+package e2e_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/e2e"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncPullはGET /v1/syncのレスポンスです。
+type syncPull struct {
+	Changes []struct {
+		ID      uint         `json:"id"`
+		Deleted bool         `json:"deleted"`
+		Todo    *domain.Todo `json:"todo"`
+	} `json:"changes"`
+	Token   string `json:"token"`
+	Full    bool   `json:"full"`
+	HasMore bool   `json:"has_more"`
+}
+
+// syncPushはPOST /v1/syncのレスポンスです。
+type syncPush struct {
+	Results []struct {
+		Index    int          `json:"index"`
+		ClientID string       `json:"client_id"`
+		ID       uint         `json:"id"`
+		Status   string       `json:"status"`
+		Todo     *domain.Todo `json:"todo"`
+		Server   *domain.Todo `json:"server"`
+		Code     string       `json:"code"`
+	} `json:"results"`
+}
+
+// pullはsinceより後の変更を取得します。
+func pull(t *testing.T, c *e2e.Client, query string) syncPull {
+	t.Helper()
+	res := c.Do(http.MethodGet, "/v1/sync"+query, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode, res.Error())
+	var page syncPull
+	res.Decode(&page)
+	return page
+}
+
+func TestSync(t *testing.T) {
+	s := e2e.NewServer(t)
+	alice := s.SignupAndLogin("alice@example.com", "password1")
+	bob := s.SignupAndLogin("bob@example.com", "password1")
+
+	t.Run("初回は全件、以降は削除を含む差分が返る", func(t *testing.T) {
+		keep := alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "残す"}).Todo()
+		remove := alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "消す"}).Todo()
+		require.Equal(t, http.StatusCreated, bob.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "bob"}).StatusCode)
+
+		first := pull(t, alice, "")
+		require.True(t, first.Full)
+		require.Len(t, first.Changes, 2)
+
+		res := alice.Do(http.MethodPatch, fmt.Sprintf("/v1/todos/%d", keep.ID), map[string]any{"completed": true}, "If-Match", `"v1"`)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		res = alice.Do(http.MethodDelete, fmt.Sprintf("/v1/todos/%d", remove.ID), nil, "If-Match", `"v1"`)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		delta := pull(t, alice, "?since="+first.Token)
+		assert.False(t, delta.Full)
+		assert.False(t, delta.HasMore)
+		require.Len(t, delta.Changes, 2)
+		assert.Equal(t, keep.ID, delta.Changes[0].ID)
+		assert.True(t, delta.Changes[0].Todo.Completed)
+		assert.Equal(t, remove.ID, delta.Changes[1].ID)
+		assert.True(t, delta.Changes[1].Deleted)
+		assert.Nil(t, delta.Changes[1].Todo)
+
+		// 変更がなければ空で、位置は変わらない
+		empty := pull(t, alice, "?since="+delta.Token)
+		assert.Empty(t, empty.Changes)
+		assert.Equal(t, delta.Token, empty.Token)
+
+		// limitを超える分はhas_moreで続けて取得する
+		page := pull(t, alice, "?limit=1&since="+first.Token)
+		assert.True(t, page.HasMore)
+		assert.Len(t, page.Changes, 1)
+		page = pull(t, alice, "?limit=1&since="+page.Token)
+		assert.False(t, page.HasMore)
+		assert.Equal(t, delta.Token, page.Token)
+	})
+
+	t.Run("オフライン中の変更を送信すると競合が変更ごとに返る", func(t *testing.T) {
+		stale := alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "元"}).Todo()
+		gone := alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "消される"}).Todo()
+		// オフライン中に別の端末で更新・削除される
+		res := alice.Do(http.MethodPatch, fmt.Sprintf("/v1/todos/%d", stale.ID), map[string]any{"title": "別の端末"}, "If-Match", `"v1"`)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		res = alice.Do(http.MethodDelete, fmt.Sprintf("/v1/todos/%d", gone.ID), nil, "If-Match", `"v1"`)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		res = alice.Do(http.MethodPost, "/v1/sync", map[string]any{"changes": []map[string]any{
+			{"client_id": "local-1", "title": "オフラインで作成"},
+			{"id": stale.ID, "version": 1, "completed": true},
+			{"id": gone.ID, "version": 1, "title": "編集"},
+			{"id": gone.ID, "version": 1, "deleted": true},
+		}})
+		require.Equal(t, http.StatusOK, res.StatusCode, res.Error())
+		var push syncPush
+		res.Decode(&push)
+
+		require.Len(t, push.Results, 4)
+		assert.Equal(t, "applied", push.Results[0].Status)
+		assert.Equal(t, "local-1", push.Results[0].ClientID)
+		assert.Equal(t, "オフラインで作成", push.Results[0].Todo.Title)
+		assert.Equal(t, "conflict", push.Results[1].Status)
+		assert.Equal(t, "version_mismatch", push.Results[1].Code)
+		assert.Equal(t, "別の端末", push.Results[1].Server.Title)
+		assert.Equal(t, "conflict", push.Results[2].Status)
+		assert.Equal(t, "todo_not_found", push.Results[2].Code)
+		assert.Nil(t, push.Results[2].Server)
+		assert.Equal(t, "applied", push.Results[3].Status)
+
+		// 競合した変更は適用されていない
+		assert.False(t, alice.Do(http.MethodGet, fmt.Sprintf("/v1/todos/%d", stale.ID), nil).Todo().Completed)
+	})
+
+	t.Run("不正な入力は400", func(t *testing.T) {
+		invalidToken := alice.Do(http.MethodGet, "/v1/sync?since=abc", nil)
+		missingVersion := alice.Do(http.MethodPost, "/v1/sync", map[string]any{"changes": []map[string]any{{"id": 1, "title": "x"}}})
+		empty := alice.Do(http.MethodPost, "/v1/sync", map[string]any{"changes": []map[string]any{}})
+
+		assert.Equal(t, http.StatusBadRequest, invalidToken.StatusCode)
+		assert.Equal(t, "invalid_sync_token", invalidToken.Code())
+		assert.Equal(t, http.StatusBadRequest, missingVersion.StatusCode)
+		assert.Equal(t, "invalid_request", missingVersion.Code())
+		assert.Equal(t, http.StatusBadRequest, empty.StatusCode)
+	})
+}
@@ -0,0 +1,235 @@
+package e2e_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"todo_backend/internal/e2e"
+	"todo_backend/internal/infrastructure/webhook"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receivedはWebhookの通知先が受信したリクエストです。
+type received struct {
+	Event    string
+	Verified bool
+	Body     map[string]any
+}
+
+// receiverはWebhookの通知先のテスト用のHTTPサーバです。statusを返し、受信したリクエストを記録します。
+type receiver struct {
+	URL    string
+	secret string
+	status int
+
+	mu       sync.Mutex
+	requests []received
+}
+
+func newReceiver(t *testing.T, status int) *receiver {
+	t.Helper()
+	r := &receiver{status: status}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		var decoded map[string]any
+		_ = json.Unmarshal(body, &decoded)
+		r.mu.Lock()
+		r.requests = append(r.requests, received{
+			Event:    req.Header.Get(webhook.HeaderEvent),
+			Verified: webhook.Verify(r.secret, req.Header.Get(webhook.HeaderTimestamp), body, req.Header.Get(webhook.HeaderSignature)),
+			Body:     decoded,
+		})
+		status := r.status
+		r.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	r.URL = srv.URL
+	return r
+}
+
+// waitForは、受信したリクエストがn件になるまで待って返します。
+func (r *receiver) waitFor(t *testing.T, n int) []received {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return len(r.requests) >= n
+	}, 5*time.Second, 10*time.Millisecond)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]received(nil), r.requests...)
+}
+
+// webhookResはWebhookのレスポンスです。
+type webhookRes struct {
+	ID     uint     `json:"id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+// deliveryResはWebhookの配信のレスポンスです。
+type deliveryRes struct {
+	ID             uint           `json:"id"`
+	Event          string         `json:"event"`
+	Status         string         `json:"status"`
+	Attempts       int            `json:"attempts"`
+	ResponseStatus int            `json:"response_status"`
+	RedeliveryOf   *uint          `json:"redelivery_of"`
+	Payload        map[string]any `json:"payload"`
+}
+
+// registerはrecvに通知するWebhookをeventsの購読で登録します。
+func register(t *testing.T, c *e2e.Client, recv *receiver, events ...string) webhookRes {
+	t.Helper()
+	res := c.Do(http.MethodPost, "/v1/webhooks", map[string]any{"url": recv.URL, "events": events})
+	require.Equal(t, http.StatusCreated, res.StatusCode, res.Error())
+	var w webhookRes
+	res.Decode(&w)
+	require.NotEmpty(t, w.Secret)
+	recv.secret = w.Secret
+	return w
+}
+
+// deliveriesはWebhookの配信ログを返します。
+func deliveries(t *testing.T, c *e2e.Client, id uint) []deliveryRes {
+	t.Helper()
+	res := c.Do(http.MethodGet, fmt.Sprintf("/v1/webhooks/%d/deliveries", id), nil)
+	require.Equal(t, http.StatusOK, res.StatusCode, "%s", res.Body)
+	var out []deliveryRes
+	res.Decode(&out)
+	return out
+}
+
+func TestWebhooks(t *testing.T) {
+	s := e2e.NewServer(t)
+	alice := s.SignupAndLogin("alice@example.com", "password1")
+	bob := s.SignupAndLogin("bob@example.com", "password1")
+
+	t.Run("Todoの作成・完了・削除が署名付きで通知される", func(t *testing.T) {
+		recv := newReceiver(t, http.StatusOK)
+		w := register(t, alice, recv, "todo.created", "todo.completed", "todo.deleted")
+
+		todo := alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "牛乳を買う"}).Todo()
+		path := fmt.Sprintf("/v1/todos/%d", todo.ID)
+		require.Equal(t, http.StatusOK, alice.Do(http.MethodPatch, path, `{"completed":true}`, "If-Match", `"v1"`).StatusCode)
+		require.Equal(t, http.StatusOK, alice.Do(http.MethodDelete, path, nil, "If-Match", `"v2"`).StatusCode)
+		// 購読していないユーザーのTodoは通知しない
+		require.Equal(t, http.StatusCreated, bob.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "bob"}).StatusCode)
+
+		got := recv.waitFor(t, 3)
+		require.Len(t, got, 3)
+		// 配信の順序は保証しないため、イベントの発生時刻で並べて確認する
+		slices.SortStableFunc(got, func(a, b received) int {
+			return strings.Compare(a.Body["occurred_at"].(string), b.Body["occurred_at"].(string))
+		})
+		for i, want := range []string{"todo.created", "todo.completed", "todo.deleted"} {
+			assert.Equal(t, want, got[i].Event)
+			assert.Equal(t, want, got[i].Body["type"])
+			assert.True(t, got[i].Verified, "signature of %s", want)
+		}
+		completed := got[1].Body["data"].(map[string]any)["todo"].(map[string]any)
+		assert.Equal(t, true, completed["completed"])
+
+		log := deliveries(t, alice, w.ID)
+		require.Len(t, log, 3)
+		var events []string
+		for _, d := range log {
+			assert.Equal(t, "succeeded", d.Status)
+			assert.Equal(t, 1, d.Attempts)
+			assert.Equal(t, http.StatusOK, d.ResponseStatus)
+			events = append(events, d.Event)
+		}
+		assert.ElementsMatch(t, []string{"todo.created", "todo.completed", "todo.deleted"}, events)
+		assert.Greater(t, log[0].ID, log[2].ID, "newest first")
+	})
+
+	t.Run("失敗した配信は再試行され、上限で失敗になる。手動で再配信できる", func(t *testing.T) {
+		recv := newReceiver(t, http.StatusInternalServerError)
+		w := register(t, alice, recv, "todo.created")
+
+		require.Equal(t, http.StatusCreated, alice.Do(http.MethodPost, "/v1/todos", map[string]any{"title": "a"}).StatusCode)
+
+		// テストのサーバは3回まで試行する
+		got := recv.waitFor(t, 3)
+		assert.Equal(t, got[0].Body["id"], got[2].Body["id"], "retries resend the same event")
+		var failed deliveryRes
+		require.Eventually(t, func() bool {
+			log := deliveries(t, alice, w.ID)
+			failed = log[0]
+			return failed.Status == "failed"
+		}, 5*time.Second, 20*time.Millisecond)
+		assert.Equal(t, 3, failed.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, failed.ResponseStatus)
+
+		recv.mu.Lock()
+		recv.status = http.StatusNoContent
+		recv.mu.Unlock()
+		res := alice.Do(http.MethodPost, fmt.Sprintf("/v1/webhooks/%d/deliveries/%d/redeliver", w.ID, failed.ID), nil)
+		require.Equal(t, http.StatusAccepted, res.StatusCode, res.Error())
+		var redelivery deliveryRes
+		res.Decode(&redelivery)
+		require.NotNil(t, redelivery.RedeliveryOf)
+		assert.Equal(t, failed.ID, *redelivery.RedeliveryOf)
+		assert.Equal(t, failed.Payload, redelivery.Payload)
+
+		got = recv.waitFor(t, 4)
+		assert.Equal(t, got[0].Body["id"], got[3].Body["id"])
+		require.Eventually(t, func() bool {
+			return deliveries(t, alice, w.ID)[0].Status == "succeeded"
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("一覧と取得では署名の鍵を返さず、他のユーザーのWebhookは見えない", func(t *testing.T) {
+		recv := newReceiver(t, http.StatusOK)
+		w := register(t, alice, recv, "todo.updated")
+		path := fmt.Sprintf("/v1/webhooks/%d", w.ID)
+
+		res := alice.Do(http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var got webhookRes
+		res.Decode(&got)
+		assert.Equal(t, recv.URL, got.URL)
+		assert.Empty(t, got.Secret)
+
+		res = bob.Do(http.MethodGet, path, nil)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, "webhook_not_found", res.Code())
+		assert.Equal(t, http.StatusNotFound, bob.Do(http.MethodDelete, path, nil).StatusCode)
+		assert.Equal(t, http.StatusNotFound, bob.Do(http.MethodGet, path+"/deliveries", nil).StatusCode)
+
+		require.Equal(t, http.StatusOK, alice.Do(http.MethodDelete, path, nil).StatusCode)
+		assert.Equal(t, http.StatusNotFound, alice.Do(http.MethodGet, path, nil).StatusCode)
+		res = alice.Do(http.MethodPost, path+"/deliveries/1/redeliver", nil)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("不正な登録は400", func(t *testing.T) {
+		for name, body := range map[string]map[string]any{
+			"URLなし":    {"events": []string{"todo.created"}},
+			"相対URL":    {"url": "/hook", "events": []string{"todo.created"}},
+			"http以外":   {"url": "ftp://example.com/hook", "events": []string{"todo.created"}},
+			"イベントなし":   {"url": "https://example.com/hook", "events": []string{}},
+			"不明なイベント":  {"url": "https://example.com/hook", "events": []string{"todo.archived"}},
+			"短すぎる鍵":    {"url": "https://example.com/hook", "events": []string{"todo.created"}, "secret": "short"},
+			"未知のフィールド": {"url": "https://example.com/hook", "events": []string{"todo.created"}, "active": true},
+		} {
+			res := alice.Do(http.MethodPost, "/v1/webhooks", body)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, name)
+		}
+		res := alice.Do(http.MethodGet, "/v1/webhooks/1/deliveries?limit=0", nil)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, http.StatusUnauthorized, s.Anonymous().Do(http.MethodGet, "/v1/webhooks", nil).StatusCode)
+	})
+}
@@ -0,0 +1,319 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Configはアプリケーション全体の設定値を保持する構造体です。
+// 値は環境変数（.envを含む）から読み込まれ、Composition Root（main.go）で各層へ注入されます。
+type Config struct {
+	// Portはサーバが待ち受けるアドレスです（例: ":8080"）。
+	Port string
+	// Storageはデータの保存先です（sqlite / memory）。memoryは再起動でデータが消えるデモ・開発用です。
+	Storage string
+	// DBPathはSQLiteのデータベースファイルのパスです。
+	DBPath string
+	// LogLevelはログの出力レベルです（debug / info / warn / error）。
+	LogLevel string
+	// TraceExporterはトレースの出力先です（none / stdout / otlp）。
+	TraceExporter string
+	// ServiceNameはトレースに記録されるサービス名です。
+	ServiceName string
+	// RequestTimeoutはリクエストごとの処理期限（DBクエリを含む）のデフォルト値です。
+	RequestTimeout time.Duration
+	// RouteTimeoutsはルートごとの処理期限です（"GET /todos=2s,POST /login=5s"形式）。
+	RouteTimeouts string
+	// AnonRateLimitは未認証ルートのクライアントIPごとの制限です（"10/1m"形式、"off"で無効）。
+	AnonRateLimit string
+	// UserRateLimitは認証済みルートのユーザーごとの制限です（"300/1m"形式、"off"で無効）。
+	UserRateLimit string
+	// CORSはCORSポリシーの設定です。
+	CORS CORSConfig
+	// IdempotencyRetentionは冪等キーとそのレスポンスを保持する期間です。
+	IdempotencyRetention time.Duration
+	// RequestValidationはリクエストをOpenAPIドキュメントに照らして検証するかどうかです。
+	RequestValidation bool
+	// Legacyはプレフィックスなしの旧来のルート（/todosなど）の設定です。
+	Legacy LegacyConfig
+	// EventLogSizeは変更の通知（GET /todos/stream）の再開用に保持するイベント数です。
+	EventLogSize int
+	// WebhookはWebhookの配信に関する設定です。
+	Webhook WebhookConfig
+	// Jobsはバックグラウンドジョブの実行に関する設定です。
+	Jobs JobConfig
+	// ReminderPollIntervalは通知する日時を迎えたリマインダーを確認する間隔です。
+	ReminderPollInterval time.Duration
+	// TrustedProxiesは、X-Forwarded-ForなどのヘッダのクライアントのIPを信頼するプロキシ（IPアドレスまたはCIDR）です。
+	// 空の場合はヘッダを信頼せず、接続元のアドレスをクライアントのIPとします。
+	TrustedProxies []string
+	// AdminUserIDsは管理者として扱うユーザーのIDです（/admin以下のAPIを利用できます）。
+	AdminUserIDs []uint
+	// Retentionは保持期間を過ぎたデータの削除に関する設定です。
+	Retention RetentionConfig
+}
+
+// RetentionConfigは保持期間を過ぎたデータの削除に関する設定値です。
+type RetentionConfig struct {
+	// SweepIntervalは保持期間を過ぎたデータを削除する間隔です。
+	SweepInterval time.Duration
+	// SyncTombstonesは差分同期で削除を伝える墓標を保持する期間です。
+	// これより長くオフラインだったクライアントは、差分ではなく全件を同期します。
+	SyncTombstones time.Duration
+	// SucceededJobsは完了したバックグラウンドジョブを保持する期間です。
+	SucceededJobs time.Duration
+}
+
+// JobConfigはバックグラウンドジョブの実行に関する設定値です。
+type JobConfig struct {
+	// Workersは同時に実行するジョブの数です。
+	Workers int
+	// PollIntervalは実行日時を迎えたジョブを確認する間隔です。
+	PollInterval time.Duration
+	// Timeoutは1回の実行期限です。期限を過ぎても完了しないジョブは別のワーカーが実行し直します。
+	Timeout time.Duration
+	// MaxAttemptsは1件のジョブの実行を試みる最大回数（初回を含む）です。
+	MaxAttempts int
+	// RetryBackoffは1回目の失敗から再試行までの待ち時間です（失敗するたびに2倍、最大1時間）。
+	RetryBackoff time.Duration
+}
+
+// WebhookConfigはWebhookの配信に関する設定値です。
+type WebhookConfig struct {
+	// Timeoutは1回の送信の期限です。
+	Timeout time.Duration
+	// MaxAttemptsは1件の配信で送信を試みる最大回数（初回を含む）です。
+	MaxAttempts int
+	// RetryBackoffは1回目の失敗から再試行までの待ち時間です（失敗するたびに2倍、最大1時間）。
+	RetryBackoff time.Duration
+	// PollIntervalは再試行待ちの配信を確認する間隔です。
+	PollInterval time.Duration
+	// Concurrencyは同時に送信する通知先（Webhook）の数です。1つのWebhookへの配信は1件ずつ順に送信します。
+	Concurrency int
+	// AllowedNetworksは、公開されていないアドレスのうち通知先として接続を許可する範囲です（ローカルの開発用）。
+	AllowedNetworks []netip.Prefix
+}
+
+// LegacyConfigは/v1の別名として残している旧来のルートに関する設定値です。
+type LegacyConfig struct {
+	// Enabledは旧来のルートを公開するかどうかです。
+	Enabled bool
+	// DeprecatedAtは廃止予定とした日時です（Deprecationヘッダ）。
+	DeprecatedAt time.Time
+	// SunsetAtは旧来のルートを削除する予定の日時です（Sunsetヘッダ）。
+	SunsetAt time.Time
+}
+
+// CORSConfigはCORSポリシーに関する設定値です。
+type CORSConfig struct {
+	// AllowedOriginsは許可するオリジンです（"https://*.example.com"のワイルドカード可）。
+	AllowedOrigins []string
+	// AllowedHeadersはリクエストで許可するヘッダです。
+	AllowedHeaders []string
+	// ExposedHeadersはブラウザに公開するレスポンスヘッダです。
+	ExposedHeaders []string
+	// AllowCredentialsは資格情報付きリクエストを許可するかどうかです。
+	AllowCredentials bool
+	// MaxAgeはプリフライト結果のキャッシュ時間です。
+	MaxAge time.Duration
+}
+
+// Loadは環境変数から設定を読み込みます。
+// 未設定の項目には開発用のデフォルト値が使われます。
+// 値の形式が不正な場合は、不正な項目をすべてまとめたエラーを返します。
+func Load() (Config, error) {
+	var l loader
+	cfg := Config{
+		Port:     normalizePort(l.string("PORT", ":8080")),
+		Storage:  l.string("STORAGE", "sqlite"),
+		DBPath:   l.string("DB_PATH", "./todo.db"),
+		LogLevel: l.string("LOG_LEVEL", "info"),
+		// OpenTelemetryの標準環境変数名に合わせる
+		TraceExporter:  l.string("OTEL_TRACES_EXPORTER", "none"),
+		ServiceName:    l.string("OTEL_SERVICE_NAME", "todo_backend"),
+		RequestTimeout: l.duration("REQUEST_TIMEOUT", 5*time.Second),
+		RouteTimeouts:  l.string("ROUTE_TIMEOUTS", ""),
+		AnonRateLimit:  l.string("RATE_LIMIT_ANON", "10/1m"),
+		UserRateLimit:  l.string("RATE_LIMIT_USER", "300/1m"),
+		CORS: CORSConfig{
+			AllowedOrigins: l.list("CORS_ALLOWED_ORIGINS", []string{"http://localhost:3000", "http://localhost:5173"}),
+			AllowedHeaders: l.list("CORS_ALLOWED_HEADERS", []string{
+				"Origin", "Content-Type", "Accept", "Authorization",
+				"If-Match", "If-None-Match", "Idempotency-Key", "X-Request-ID", "Last-Event-ID",
+			}),
+			ExposedHeaders: l.list("CORS_EXPOSED_HEADERS", []string{
+				"ETag", "Location", "Link", "X-Request-ID",
+				"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After",
+				"Idempotent-Replayed", "Deprecation", "Sunset",
+			}),
+			AllowCredentials: l.bool("CORS_ALLOW_CREDENTIALS", true),
+			MaxAge:           l.duration("CORS_MAX_AGE", 12*time.Hour),
+		},
+		IdempotencyRetention: l.duration("IDEMPOTENCY_RETENTION", 24*time.Hour),
+		RequestValidation:    l.bool("REQUEST_VALIDATION", false),
+		Legacy: LegacyConfig{
+			Enabled:      l.bool("LEGACY_ROUTES", true),
+			DeprecatedAt: l.date("LEGACY_DEPRECATED_AT", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)),
+			SunsetAt:     l.date("LEGACY_SUNSET_AT", time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC)),
+		},
+		EventLogSize: l.int("EVENT_LOG_SIZE", 1000),
+		Webhook: WebhookConfig{
+			Timeout:         l.duration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxAttempts:     l.int("WEBHOOK_MAX_ATTEMPTS", 8),
+			RetryBackoff:    l.duration("WEBHOOK_RETRY_BACKOFF", 30*time.Second),
+			PollInterval:    l.duration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+			Concurrency:     l.int("WEBHOOK_CONCURRENCY", 8),
+			AllowedNetworks: l.prefixes("WEBHOOK_ALLOWED_NETWORKS"),
+		},
+		Jobs: JobConfig{
+			Workers:      l.int("JOB_WORKERS", 4),
+			PollInterval: l.duration("JOB_POLL_INTERVAL", time.Second),
+			Timeout:      l.duration("JOB_TIMEOUT", 5*time.Minute),
+			MaxAttempts:  l.int("JOB_MAX_ATTEMPTS", 10),
+			RetryBackoff: l.duration("JOB_RETRY_BACKOFF", 10*time.Second),
+		},
+		ReminderPollInterval: l.duration("REMINDER_POLL_INTERVAL", 15*time.Second),
+		TrustedProxies:       l.list("TRUSTED_PROXIES", nil),
+		AdminUserIDs:         l.ids("ADMIN_USER_IDS"),
+		Retention: RetentionConfig{
+			SweepInterval:  l.duration("RETENTION_SWEEP_INTERVAL", time.Hour),
+			SyncTombstones: l.duration("SYNC_TOMBSTONE_RETENTION", 30*24*time.Hour),
+			SucceededJobs:  l.duration("JOB_RETENTION", 7*24*time.Hour),
+		},
+	}
+	return cfg, errors.Join(l.errs...)
+}
+
+// loaderは環境変数を型変換しながら読み込み、変換エラーを蓄積します。
+type loader struct {
+	errs []error
+}
+
+// stringは環境変数keyの値を返します。未設定または空の場合はdefを返します。
+func (l *loader) string(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// listは環境変数keyをカンマ区切りのリストとして読み込みます（前後の空白と空要素は除去）。
+func (l *loader) list(key string, def []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// prefixesは環境変数keyをカンマ区切りのCIDR（"127.0.0.0/8"など）のリストとして読み込みます。未設定の場合はnilです。
+func (l *loader) prefixes(key string) []netip.Prefix {
+	var out []netip.Prefix
+	for _, item := range l.list(key, nil) {
+		p, err := netip.ParsePrefix(item)
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		out = append(out, p.Masked())
+	}
+	return out
+}
+
+// idsは環境変数keyをカンマ区切りのID（正の整数）のリストとして読み込みます。未設定の場合はnilです。
+func (l *loader) ids(key string) []uint {
+	var out []uint
+	for _, item := range l.list(key, nil) {
+		n, err := strconv.ParseUint(item, 10, 0)
+		if err == nil && n == 0 {
+			err = errors.New("must be a positive integer")
+		}
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		out = append(out, uint(n))
+	}
+	return out
+}
+
+// boolは環境変数keyを真偽値（true / false / 1 / 0など）として読み込みます。
+func (l *loader) bool(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %w", key, err))
+		return def
+	}
+	return b
+}
+
+// intは環境変数keyを正の整数として読み込みます。
+func (l *loader) int(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err == nil && n <= 0 {
+		err = errors.New("must be a positive integer")
+	}
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %w", key, err))
+		return def
+	}
+	return n
+}
+
+// durationは環境変数keyを"5s"のような期間として読み込みます。
+func (l *loader) duration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %w", key, err))
+		return def
+	}
+	return d
+}
+
+// dateは環境変数keyを"2006-01-02"形式の日付（UTC）またはRFC 3339形式の日時として読み込みます。
+func (l *loader) date(key string, def time.Time) time.Time {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: expected YYYY-MM-DD or RFC 3339: %w", key, err))
+		return def
+	}
+	return t
+}
+
+// normalizePortは"8080"のようなポート番号のみの指定を":8080"形式に揃えます。
+func normalizePort(p string) string {
+	if strings.Contains(p, ":") {
+		return p
+	}
+	return ":" + p
+}
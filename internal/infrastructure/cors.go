@@ -0,0 +1,74 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfigはCORSポリシーの設定です。
+type CORSConfig struct {
+	// AllowedOriginsは許可するオリジンの一覧です。
+	// "https://*.example.com"のように1箇所だけ"*"を含むワイルドカード指定ができます。
+	// "*"単独（全オリジン許可）はAllowCredentialsと同時には指定できません。
+	AllowedOrigins []string
+	// AllowedHeadersはリクエストで許可するヘッダです（Authorizationなど）。
+	AllowedHeaders []string
+	// ExposedHeadersはブラウザのJavaScriptから参照できるレスポンスヘッダです（ETagなど）。
+	ExposedHeaders []string
+	// AllowCredentialsはCookieやAuthorizationヘッダ付きのリクエストを許可するかどうかです。
+	AllowCredentials bool
+	// MaxAgeはプリフライト結果をブラウザがキャッシュしてよい時間です。
+	MaxAge time.Duration
+}
+
+// corsMethodsはCORSで許可するHTTPメソッドです。
+var corsMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodHead, http.MethodOptions,
+}
+
+// newCORSは設定からCORSミドルウェアを生成します。
+// 設定が不正な場合（オリジン未指定、資格情報付きで"*"など）はエラーを返します。
+func newCORS(cfg CORSConfig) (gin.HandlerFunc, error) {
+	if len(cfg.AllowedOrigins) == 0 {
+		return nil, errors.New("cors: at least one allowed origin is required")
+	}
+
+	c := cors.Config{
+		AllowMethods:     corsMethods,
+		AllowHeaders:     cfg.AllowedHeaders,
+		ExposeHeaders:    cfg.ExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}
+	if len(cfg.AllowedOrigins) == 1 && cfg.AllowedOrigins[0] == "*" {
+		if cfg.AllowCredentials {
+			// ブラウザは資格情報付きリクエストに対する"*"を拒否するため、設定ミスとして扱う
+			return nil, errors.New(`cors: allowed origin "*" cannot be combined with credentials`)
+		}
+		c.AllowAllOrigins = true
+	} else {
+		for _, o := range cfg.AllowedOrigins {
+			if o == "*" {
+				return nil, errors.New(`cors: "*" must be the only allowed origin`)
+			}
+			if strings.Count(o, "*") > 1 {
+				return nil, fmt.Errorf("cors: origin %q may contain at most one wildcard", o)
+			}
+			if strings.Contains(o, "*") {
+				c.AllowWildcard = true
+			}
+		}
+		c.AllowOrigins = cfg.AllowedOrigins
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("cors: %w", err)
+	}
+	return cors.New(c), nil
+}
@@ -0,0 +1,104 @@
+package infrastructure_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"todo_backend/internal/infrastructure"
+	"todo_backend/internal/interface/handler"
+	"todo_backend/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCORS = infrastructure.CORSConfig{
+	AllowedOrigins:   []string{"https://app.example.com", "https://*.preview.example.com"},
+	AllowedHeaders:   []string{"Content-Type", "Authorization", "If-Match"},
+	ExposedHeaders:   []string{"ETag", "Location"},
+	AllowCredentials: true,
+	MaxAge:           10 * time.Minute,
+}
+
+func newCORSRouter(t *testing.T, cfg infrastructure.CORSConfig) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r, err := infrastructure.NewRouter(handler.NewAuthHandler(nil), usecase.NewTodoUsecase(nil), infrastructure.WithCORS(cfg))
+	require.NoError(t, err)
+	return r
+}
+
+func preflight(r http.Handler, origin, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	req.Header.Set("Access-Control-Request-Headers", "Authorization, If-Match")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORS_PreflightFromAllowedOrigin(t *testing.T) {
+	r := newCORSRouter(t, testCORS)
+
+	w := preflight(r, "https://app.example.com", "/v1/todos/1")
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), http.MethodPut)
+}
+
+func TestCORS_WildcardOriginPattern(t *testing.T) {
+	r := newCORSRouter(t, testCORS)
+
+	w := preflight(r, "https://pr-42.preview.example.com", "/v1/login")
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://pr-42.preview.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORS_RejectsUnknownOrigin(t *testing.T) {
+	r := newCORSRouter(t, testCORS)
+
+	w := preflight(r, "https://evil.example.net", "/v1/todos")
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORS_ExposesHeadersOnActualRequest(t *testing.T) {
+	r := newCORSRouter(t, testCORS)
+	req := httptest.NewRequest(http.MethodGet, "/v1/todos", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// 認証エラーのレスポンスでもCORSヘッダは付与される（ブラウザがエラー内容を読めるように）
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "Etag")
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "Location")
+}
+
+func TestCORS_InvalidConfig(t *testing.T) {
+	cases := map[string]infrastructure.CORSConfig{
+		"no origins":            {},
+		"star with credentials": {AllowedOrigins: []string{"*"}, AllowCredentials: true},
+		"star mixed":            {AllowedOrigins: []string{"*", "https://a.example.com"}},
+		"two wildcards":         {AllowedOrigins: []string{"https://*.*.example.com"}},
+		"bad scheme":            {AllowedOrigins: []string{"ftp://example.com"}},
+	}
+	for name, cfg := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := infrastructure.NewRouter(handler.NewAuthHandler(nil), usecase.NewTodoUsecase(nil), infrastructure.WithCORS(cfg))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package deadline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Configはリクエストごとの処理期限（DBクエリを含む）の設定です。
+type Config struct {
+	// Defaultはルート個別の指定がない場合の期限です。0の場合は期限を設けません。
+	Default time.Duration
+	// Routesは「メソッド + 半角スペース + ルートテンプレート」（例: "GET /todos"）ごとの期限です。
+	// 0を指定したルートには期限を設けません（ストリーミングなど長時間の接続向け）。
+	Routes map[string]time.Duration
+}
+
+// Middlewareはリクエストのcontextに期限を設定するGinミドルウェアを返します。
+// contextはusecase・repositoryへ引き継がれ、GORMのWithContextによって
+// 期限切れやクライアント切断時には実行中のクエリもキャンセルされます。
+func Middleware(cfg Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d := cfg.Default
+		if rd, ok := cfg.Routes[c.Request.Method+" "+c.FullPath()]; ok {
+			d = rd
+		}
+		if d <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// ParseRoutesは"GET /todos=2s,POST /login=5s"形式の文字列をルートごとの期限に変換します。
+// 空文字の場合は空のmapを返します。
+func ParseRoutes(s string) (map[string]time.Duration, error) {
+	routes := map[string]time.Duration{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, dur, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid route timeout %q: expected \"METHOD /path=duration\"", entry)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(dur))
+		if err != nil {
+			return nil, fmt.Errorf("invalid route timeout %q: %w", entry, err)
+		}
+		routes[strings.Join(strings.Fields(route), " ")] = d
+	}
+	return routes, nil
+}
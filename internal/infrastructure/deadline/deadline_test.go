@@ -0,0 +1,61 @@
+package deadline_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"todo_backend/internal/infrastructure/deadline"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := deadline.ParseRoutes(" GET  /todos=2s, POST /login=500ms ,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{
+		"GET /todos":  2 * time.Second,
+		"POST /login": 500 * time.Millisecond,
+	}, routes)
+
+	_, err = deadline.ParseRoutes("GET /todos")
+	assert.Error(t, err)
+	_, err = deadline.ParseRoutes("GET /todos=soon")
+	assert.Error(t, err)
+}
+
+func TestMiddleware_AppliesRouteSpecificDeadline(t *testing.T) {
+	// given
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(deadline.Middleware(deadline.Config{
+		Default: time.Second,
+		Routes: map[string]time.Duration{
+			"GET /fast":   10 * time.Millisecond,
+			"GET /stream": 0,
+		},
+	}))
+	remaining := map[string]time.Duration{}
+	handler := func(c *gin.Context) {
+		if dl, ok := c.Request.Context().Deadline(); ok {
+			remaining[c.FullPath()] = time.Until(dl)
+		}
+		c.Status(http.StatusOK)
+	}
+	r.GET("/fast", handler)
+	r.GET("/default", handler)
+	r.GET("/stream", handler)
+
+	// when
+	for _, p := range []string{"/fast", "/default", "/stream"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, p, nil))
+	}
+
+	// then
+	assert.LessOrEqual(t, remaining["/fast"], 10*time.Millisecond)
+	assert.Greater(t, remaining["/default"], 500*time.Millisecond)
+	assert.NotContains(t, remaining, "/stream")
+}
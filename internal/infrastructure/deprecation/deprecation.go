@@ -0,0 +1,48 @@
+// Package deprecationは、廃止予定のルートのレスポンスに
+// Deprecation（RFC 9745）・Sunset（RFC 8594）・Linkヘッダを付与するミドルウェアを提供します。
+package deprecation
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Configは廃止予定のルートの設定です。
+type Config struct {
+	// Deprecatedは廃止予定とした日時です（Deprecationヘッダ）。
+	Deprecated time.Time
+	// Sunsetはルートを削除する予定の日時です（Sunsetヘッダ）。ゼロ値の場合は付与しません。
+	Sunset time.Time
+	// Successorはリクエストパスから後継のパスを返します（Link rel="successor-version"）。
+	// nilの場合は付与しません。
+	Successor func(path string) string
+	// Documentationは移行方法の説明のURLです（Link rel="deprecation"）。空の場合は付与しません。
+	Documentation string
+}
+
+// Middlewareは、レスポンスにDeprecation・Sunset・Linkヘッダを付与するミドルウェアを返します。
+// ヘッダはハンドラの実行前に設定するため、エラーレスポンスにも付与されます。
+func Middleware(cfg Config) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", cfg.Deprecated.Unix())
+	var sunset string
+	if !cfg.Sunset.IsZero() {
+		sunset = cfg.Sunset.UTC().Format(http.TimeFormat)
+	}
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		h.Set("Deprecation", deprecation)
+		if sunset != "" {
+			h.Set("Sunset", sunset)
+		}
+		if cfg.Successor != nil {
+			h.Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, cfg.Successor(c.Request.URL.Path)))
+		}
+		if cfg.Documentation != "" {
+			h.Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"; type="text/html"`, cfg.Documentation))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,57 @@
+package deprecation_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"todo_backend/internal/infrastructure/deprecation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRouter(cfg deprecation.Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(deprecation.Middleware(cfg))
+	r.GET("/todos", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestMiddleware_SetsHeaders(t *testing.T) {
+	// given
+	r := newRouter(deprecation.Config{
+		Deprecated:    time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		Sunset:        time.Date(2027, 4, 30, 9, 0, 0, 0, time.FixedZone("JST", 9*60*60)),
+		Successor:     func(path string) string { return "/v1" + path },
+		Documentation: "/docs",
+	})
+
+	// when
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos", nil))
+
+	// then
+	assert.Equal(t, "@1792368000", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Fri, 30 Apr 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, []string{
+		`</v1/todos>; rel="successor-version"`,
+		`</docs>; rel="deprecation"; type="text/html"`,
+	}, w.Header().Values("Link"))
+}
+
+func TestMiddleware_OmitsOptionalHeaders(t *testing.T) {
+	// given
+	r := newRouter(deprecation.Config{Deprecated: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)})
+
+	// when
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos", nil))
+
+	// then
+	assert.NotEmpty(t, w.Header().Get("Deprecation"))
+	assert.Empty(t, w.Header().Get("Sunset"))
+	assert.Empty(t, w.Header().Values("Link"))
+}
@@ -0,0 +1,142 @@
+// Package eventsは、Todoの変更イベントをプロセス内で配信するイベントバスを提供します。
+package events
+
+import (
+	"sync"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/usecase"
+)
+
+// subscriberBufferは購読者ごとの未受信イベントの上限です。
+// 超えた購読者は受信が追いつかないとみなして購読を打ち切ります。
+const subscriberBuffer = 64
+
+// DefaultLogSizeは再開用に保持するイベント数のデフォルト値です。
+const DefaultLogSize = 1000
+
+// Busはプロセス内のイベントバスです（usecase.TodoEventBusの実装）。
+// 直近のイベントを一定数保持し、Last-Event-IDによる再開に使います。
+// 複数のgoroutineから安全に利用できます。
+//
+// イベントのIDはプロセスの起動時刻（マイクロ秒）から始まる連番です。
+// 再起動前のIDで再開しようとした場合も、保持していない範囲として取りこぼしを検出できます。
+type Bus struct {
+	mu     sync.Mutex
+	lastID uint64
+	// logは直近のイベントのリングバッファです。
+	log    []domain.TodoEvent
+	start  int
+	subs   map[*subscriber]struct{}
+	closed bool
+}
+
+type subscriber struct {
+	userID uint
+	ch     chan domain.TodoEvent
+}
+
+// コンパイル時に Bus が usecase.TodoEventBus を実装しているか確認します。
+var _ usecase.TodoEventBus = (*Bus)(nil)
+
+// NewBusは直近logSize件のイベントを保持するBusを返します。logSizeが0以下の場合はDefaultLogSizeを使います。
+func NewBus(logSize int) *Bus {
+	if logSize <= 0 {
+		logSize = DefaultLogSize
+	}
+	return &Bus{
+		lastID: uint64(time.Now().UnixMicro()),
+		log:    make([]domain.TodoEvent, 0, logSize),
+		subs:   make(map[*subscriber]struct{}),
+	}
+}
+
+// Publishはイベントを採番してログに追加し、同じユーザーの購読者に配信します。
+// 受信が追いついていない購読者は待たずに購読を打ち切ります。
+func (b *Bus) Publish(event domain.TodoEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	b.lastID++
+	event.ID = b.lastID
+	if len(b.log) < cap(b.log) {
+		b.log = append(b.log, event)
+	} else {
+		b.log[b.start] = event
+		b.start = (b.start + 1) % len(b.log)
+	}
+
+	for s := range b.subs {
+		if s.userID != event.UserID {
+			continue
+		}
+		select {
+		case s.ch <- event:
+		default:
+			b.remove(s)
+		}
+	}
+}
+
+// SubscribeはuserIDのイベントの購読を開始します。
+// lastEventIDより後の保持しているイベントをReplayに、以降のイベントをEventsに返すため、
+// 再開時にイベントが重複したり抜けたりすることはありません。
+// lastEventIDの直後のイベントを既に保持していない場合（または未知のIDの場合）はResetを設定します。
+func (b *Bus) Subscribe(userID uint, lastEventID uint64) usecase.TodoEventStream {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := &subscriber{userID: userID, ch: make(chan domain.TodoEvent, subscriberBuffer)}
+	stream := usecase.TodoEventStream{Events: s.ch, Close: func() { b.unsubscribe(s) }}
+	if b.closed {
+		close(s.ch)
+		return stream
+	}
+	b.subs[s] = struct{}{}
+
+	if lastEventID == 0 {
+		return stream
+	}
+	oldest := b.lastID + 1
+	if len(b.log) > 0 {
+		oldest = b.log[b.start].ID
+	}
+	stream.Reset = lastEventID+1 < oldest || lastEventID > b.lastID
+	for i := range b.log {
+		event := b.log[(b.start+i)%len(b.log)]
+		if event.ID > lastEventID && event.UserID == userID {
+			stream.Replay = append(stream.Replay, event)
+		}
+	}
+	return stream
+}
+
+// Closeはすべての購読を終了し、以降の発行・購読を受け付けません。
+// サーバの停止時に、接続中のストリームを終わらせるために呼び出します。
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subs {
+		b.remove(s)
+	}
+}
+
+// unsubscribeは購読を終了します。既に終了している場合は何もしません。
+func (b *Bus) unsubscribe(s *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[s]; ok {
+		b.remove(s)
+	}
+}
+
+// removeは購読者を削除してチャネルを閉じます。b.muを保持した状態で呼び出してください。
+func (b *Bus) remove(s *subscriber) {
+	delete(b.subs, s)
+	close(s.ch)
+}
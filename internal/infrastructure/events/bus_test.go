@@ -0,0 +1,149 @@
+package events_test
+
+import (
+	"testing"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publish(bus *events.Bus, userID, todoID uint) {
+	bus.Publish(domain.TodoEvent{Type: domain.TodoCreated, UserID: userID, Todo: domain.Todo{ID: todoID, UserID: userID}})
+}
+
+// drainは購読のチャネルから受信済みのイベントをすべて取り出します。
+func drain(ch <-chan domain.TodoEvent) (got []domain.TodoEvent, closed bool) {
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return got, true
+			}
+			got = append(got, ev)
+		default:
+			return got, false
+		}
+	}
+}
+
+func todoIDs(events []domain.TodoEvent) []uint {
+	ids := make([]uint, len(events))
+	for i, ev := range events {
+		ids[i] = ev.Todo.ID
+	}
+	return ids
+}
+
+// 購読者には自分のTodoのイベントのみ、採番された順に配信される
+func TestBus_DeliversOwnEventsInOrder(t *testing.T) {
+	// given
+	bus := events.NewBus(10)
+	alice := bus.Subscribe(1, 0)
+	defer alice.Close()
+
+	// when
+	publish(bus, 1, 10)
+	publish(bus, 2, 20)
+	publish(bus, 1, 11)
+
+	// then
+	got, closed := drain(alice.Events)
+	assert.False(t, closed)
+	assert.Equal(t, []uint{10, 11}, todoIDs(got))
+	assert.Less(t, got[0].ID, got[1].ID)
+	assert.False(t, alice.Reset)
+	assert.Empty(t, alice.Replay)
+}
+
+// Last-Event-IDより後のイベントを保持している範囲から再送し、以降は購読で受け取る
+func TestBus_ResumesFromLastEventID(t *testing.T) {
+	// given
+	bus := events.NewBus(10)
+	first := bus.Subscribe(1, 0)
+	publish(bus, 1, 10)
+	publish(bus, 1, 11)
+	publish(bus, 2, 20)
+	got, _ := drain(first.Events)
+	first.Close()
+	publish(bus, 1, 12)
+
+	// when
+	resumed := bus.Subscribe(1, got[0].ID)
+	defer resumed.Close()
+	publish(bus, 1, 13)
+
+	// then
+	assert.False(t, resumed.Reset)
+	assert.Equal(t, []uint{11, 12}, todoIDs(resumed.Replay))
+	live, _ := drain(resumed.Events)
+	assert.Equal(t, []uint{13}, todoIDs(live))
+}
+
+// 保持数を超えて古いイベントが消えている場合や未知のIDの場合は、取りこぼしを通知する
+func TestBus_ResetsWhenLastEventIDIsNotRetained(t *testing.T) {
+	// given
+	bus := events.NewBus(2)
+	sub := bus.Subscribe(1, 0)
+	for id := uint(1); id <= 4; id++ {
+		publish(bus, 1, id)
+	}
+	got, _ := drain(sub.Events)
+	sub.Close()
+
+	// when
+	evicted := bus.Subscribe(1, got[0].ID)
+	latest := bus.Subscribe(1, got[1].ID)
+	unknown := bus.Subscribe(1, got[3].ID+100)
+
+	// then
+	assert.True(t, evicted.Reset)
+	assert.Equal(t, []uint{3, 4}, todoIDs(evicted.Replay))
+	assert.False(t, latest.Reset)
+	assert.Equal(t, []uint{3, 4}, todoIDs(latest.Replay))
+	assert.True(t, unknown.Reset)
+	assert.Empty(t, unknown.Replay)
+}
+
+// 受信が追いつかない購読者は打ち切られ、他の購読者への配信は止まらない
+func TestBus_DropsSlowSubscriber(t *testing.T) {
+	// given
+	bus := events.NewBus(1000)
+	slow := bus.Subscribe(1, 0)
+	fast := bus.Subscribe(1, 0)
+
+	// when
+	var received int
+	for id := uint(1); id <= 200; id++ {
+		publish(bus, 1, id)
+		got, _ := drain(fast.Events)
+		received += len(got)
+	}
+
+	// then
+	got, closed := drain(slow.Events)
+	assert.True(t, closed)
+	assert.NotEmpty(t, got)
+	assert.Equal(t, 200, received)
+	slow.Close() // 打ち切り後のCloseは何もしない
+}
+
+// Closeで購読中のストリームは終了し、以降の購読はすぐに閉じられる
+func TestBus_CloseEndsStreams(t *testing.T) {
+	// given
+	bus := events.NewBus(10)
+	sub := bus.Subscribe(1, 0)
+
+	// when
+	bus.Close()
+	publish(bus, 1, 10)
+	after := bus.Subscribe(1, 0)
+
+	// then
+	_, closed := drain(sub.Events)
+	assert.True(t, closed)
+	_, ok := <-after.Events
+	require.False(t, ok)
+}
@@ -0,0 +1,74 @@
+package i18n
+
+// エラーレスポンスの"code"として返す機械可読なコードです。
+// クライアントは文言ではなくコードで分岐してください。コードは言語によらず変わりません。
+const (
+	CodeBadRequest              = "bad_request"
+	CodeBatchFailed             = "batch_failed"
+	CodeConflict                = "conflict"
+	CodeEmailAlreadyExists      = "email_already_exists"
+	CodeIdempotencyInProgress   = "idempotency_in_progress"
+	CodeIdempotencyKeyReused    = "idempotency_key_reused"
+	CodeIdempotencyKeyTooLong   = "idempotency_key_too_long"
+	CodeForbidden               = "forbidden"
+	CodeInternalError           = "internal_error"
+	CodeInvalidCredentials      = "invalid_credentials"
+	CodeInvalidID               = "invalid_id"
+	CodeInvalidIfMatch          = "invalid_if_match"
+	CodeInvalidJSON             = "invalid_json"
+	CodeInvalidLastEventID      = "invalid_last_event_id"
+	CodeInvalidRequest          = "invalid_request"
+	CodeInvalidSyncToken        = "invalid_sync_token"
+	CodeInvalidToken            = "invalid_token"
+	CodeInvalidVersion          = "invalid_version"
+	CodeJobNotDead              = "job_not_dead"
+	CodeJobNotFound             = "job_not_found"
+	CodeMissingToken            = "missing_token"
+	CodeNotSubscribed           = "not_subscribed"
+	CodeNotExecuted             = "not_executed"
+	CodeNotificationNotFound    = "notification_not_found"
+	CodePayloadTooLarge         = "payload_too_large"
+	CodePreconditionRequired    = "precondition_required"
+	CodeRateLimited             = "rate_limited"
+	CodeReminderNotFound        = "reminder_not_found"
+	CodeRequestCanceled         = "request_canceled"
+	CodeRequestSpecMismatch     = "request_spec_mismatch"
+	CodeRequestTimeout          = "request_timeout"
+	CodeRolledBack              = "rolled_back"
+	CodeServerMisconfigured     = "server_misconfigured"
+	CodeTodoHasNoDueDate        = "todo_has_no_due_date"
+	CodeTodoNotFound            = "todo_not_found"
+	CodeUnauthorized            = "unauthorized"
+	CodeUnknownChannel          = "unknown_channel"
+	CodeUnknownMessageType      = "unknown_message_type"
+	CodeVersionMismatch         = "version_mismatch"
+	CodeWebhookDeliveryNotFound = "webhook_delivery_not_found"
+	CodeWebhookNotFound         = "webhook_not_found"
+)
+
+// フィールドごとの検証エラー（"details"の"code"）のコードです。
+// カタログでは"field."を前に付けたキーで文言を定義します。
+const (
+	FieldCannotBeNull    = "cannot_be_null"
+	FieldEmail           = "email"
+	FieldEmpty           = "empty"
+	FieldIDMismatch      = "id_mismatch"
+	FieldImmutable       = "immutable"
+	FieldInvalid         = "invalid"
+	FieldInvalidType     = "invalid_type"
+	FieldItemCount       = "item_count"
+	FieldMinLength       = "min_length"
+	FieldNotAllowed      = "not_allowed"
+	FieldNotAllowedForOp = "not_allowed_for_op"
+	FieldOneOf           = "one_of"
+	FieldPositiveInteger = "positive_integer"
+	FieldRequired        = "required"
+	FieldTooLong         = "too_long"
+	FieldUnknownOp       = "unknown_op"
+	FieldURL             = "url"
+)
+
+// FieldMessageはフィールドの検証エラーのコードに対応するlの言語の文言を返します。
+func FieldMessage(l Locale, code string, params Params) string {
+	return Message(l, "field."+code, params)
+}
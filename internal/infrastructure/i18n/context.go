@@ -0,0 +1,58 @@
+package i18n
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// contextLocaleはユーザーの言語設定を保存するgin.Contextのキーです。
+const contextLocale = "i18n.locale"
+
+// SetUserLocaleは認証済みユーザーの言語設定をgin.Contextに保存します。
+// 対応していない言語や空文字の場合は何もしません。
+func SetUserLocale(c *gin.Context, tag string) {
+	if l, ok := Parse(tag); ok {
+		c.Set(contextLocale, l)
+	}
+}
+
+// FromContextはレスポンスの言語を決めます。
+// ユーザーの言語設定（SetUserLocale）、Accept-Languageヘッダ、Defaultの順に優先します。
+func FromContext(c *gin.Context) Locale {
+	if v, ok := c.Get(contextLocale); ok {
+		if l, ok := v.(Locale); ok {
+			return l
+		}
+	}
+	return Negotiate(c.GetHeader("Accept-Language"))
+}
+
+// Errorはcodeのエラーレスポンスのボディ（{"error": 文言, "code": コード}）を返し、
+// Content-Languageヘッダに選んだ言語を設定します。
+func Error(c *gin.Context, code string, params Params) gin.H {
+	l := FromContext(c)
+	c.Header("Content-Language", string(l))
+	return gin.H{"error": Message(l, code, params), "code": code}
+}
+
+// Abortはcodeのエラーレスポンスをstatusで返し、以降のハンドラを実行しません。
+func Abort(c *gin.Context, status int, code string, params Params) {
+	c.AbortWithStatusJSON(status, Error(c, code, params))
+}
+
+// StatusCodeは、ステータスコードだけが分かるエラーに使うコードを返します。
+func StatusCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusConflict:
+		return CodeConflict
+	default:
+		return CodeInternalError
+	}
+}
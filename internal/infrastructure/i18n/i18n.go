@@ -0,0 +1,116 @@
+// Package i18nは、エラーメッセージなどクライアント向けの文言の多言語化（日本語・英語）を提供します。
+// 文言はlocales/*.jsonのカタログに、機械可読なコードをキーとして定義します。
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Localeはレスポンスの言語です。値はBCP 47の言語タグです。
+type Locale string
+
+const (
+	// Englishは英語です。Accept-Languageやユーザー設定で言語が決まらない場合に使います。
+	English Locale = "en"
+	// Japaneseは日本語です。
+	Japanese Locale = "ja"
+)
+
+// Defaultは言語が決まらない場合に使う言語です。
+const Default = English
+
+// Supportedは対応している言語の一覧です。
+var Supported = []Locale{English, Japanese}
+
+// Paramsはメッセージの{name}を置き換える値です。
+type Params map[string]any
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// catalogsは言語ごとのコード→メッセージの対応です。
+var catalogs = mustLoadCatalogs()
+
+func mustLoadCatalogs() map[Locale]map[string]string {
+	out := make(map[Locale]map[string]string, len(Supported))
+	for _, l := range Supported {
+		data, err := localeFS.ReadFile(path.Join("locales", string(l)+".json"))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: load catalog %s: %v", l, err))
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("i18n: parse catalog %s: %v", l, err))
+		}
+		out[l] = catalog
+	}
+	return out
+}
+
+// Parseは言語タグ（"ja"、"ja-JP"、"en-US"など）を対応する言語に変換します。
+// 対応していない言語の場合はfalseを返します。
+func Parse(tag string) (Locale, bool) {
+	primary, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+	primary = strings.ToLower(primary)
+	for _, l := range Supported {
+		if primary == string(l) {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// NegotiateはAccept-Languageヘッダの値から、q値が最も大きい対応言語を選びます。
+// q値が同じ場合は先に書かれた言語を優先し、対応言語がない場合はDefaultを返します。
+func Negotiate(acceptLanguage string) Locale {
+	type candidate struct {
+		locale Locale
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if l, ok := Parse(tag); ok && q > 0 {
+			candidates = append(candidates, candidate{l, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return Default
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].locale
+}
+
+// Messageはコードに対応するlの言語のメッセージを返します。
+// メッセージ中の{name}はparamsの値で置き換えます。
+// lの言語にメッセージがない場合は英語を、英語にもない場合はコードをそのまま返します。
+func Message(l Locale, code string, params Params) string {
+	msg, ok := catalogs[l][code]
+	if !ok {
+		if msg, ok = catalogs[Default][code]; !ok {
+			return code
+		}
+	}
+	if len(params) == 0 {
+		return msg
+	}
+	pairs := make([]string, 0, len(params)*2)
+	for name, v := range params {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
@@ -0,0 +1,104 @@
+package i18n_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"slices"
+	"testing"
+
+	"todo_backend/internal/infrastructure/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// すべての言語のカタログに同じコードと同じ置換パラメータが定義されている
+func TestCatalogs_HaveSameKeysAndParams(t *testing.T) {
+	// given
+	placeholder := regexp.MustCompile(`\{[a-z_]+\}`)
+	load := func(l i18n.Locale) map[string]string {
+		data, err := os.ReadFile("locales/" + string(l) + ".json")
+		require.NoError(t, err)
+		var catalog map[string]string
+		require.NoError(t, json.Unmarshal(data, &catalog))
+		return catalog
+	}
+	en := load(i18n.English)
+
+	// when / then
+	for _, l := range i18n.Supported {
+		catalog := load(l)
+		assert.ElementsMatch(t, keys(en), keys(catalog), "locale %s", l)
+		for code, msg := range en {
+			want := placeholder.FindAllString(msg, -1)
+			got := placeholder.FindAllString(catalog[code], -1)
+			slices.Sort(want)
+			slices.Sort(got)
+			assert.Equal(t, want, got, "locale %s code %s", l, code)
+		}
+	}
+}
+
+func keys(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+func TestMessage(t *testing.T) {
+	assert.Equal(t, "must be at most 200 characters", i18n.FieldMessage(i18n.English, i18n.FieldTooLong, i18n.Params{"max": 200}))
+	assert.Equal(t, "200 文字以内で入力してください", i18n.FieldMessage(i18n.Japanese, i18n.FieldTooLong, i18n.Params{"max": 200}))
+	// 未定義のコードはコードをそのまま返す
+	assert.Equal(t, "no_such_code", i18n.Message(i18n.Japanese, "no_such_code", nil))
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]i18n.Locale{
+		"":                          i18n.English,
+		"ja":                        i18n.Japanese,
+		"ja-JP,ja;q=0.9,en;q=0.8":   i18n.Japanese,
+		"en-US,en;q=0.9,ja;q=0.8":   i18n.English,
+		"fr-FR, ja;q=0.5":           i18n.Japanese,
+		"en;q=0.3, ja;q=0.7":        i18n.Japanese,
+		"ja;q=0, fr":                i18n.English,
+		"de, fr;q=0.5":              i18n.English,
+		"JA-jp;q=0.8, en;q=invalid": i18n.Japanese,
+	}
+	for header, want := range cases {
+		assert.Equal(t, want, i18n.Negotiate(header), "Accept-Language: %q", header)
+	}
+}
+
+// ユーザーの言語設定はAccept-Languageより優先される
+func TestError_PrefersUserLocale(t *testing.T) {
+	// given
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		i18n.SetUserLocale(c, c.Query("locale"))
+		i18n.Abort(c, http.StatusUnauthorized, i18n.CodeInvalidToken, nil)
+	})
+	request := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/"+query, nil)
+		req.Header.Set("Accept-Language", "en-US")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// when
+	header := request("")
+	user := request("?locale=ja")
+
+	// then
+	assert.JSONEq(t, `{"error":"invalid token","code":"invalid_token"}`, header.Body.String())
+	assert.Equal(t, "en", header.Header().Get("Content-Language"))
+	assert.JSONEq(t, `{"error":"トークンが無効です","code":"invalid_token"}`, user.Body.String())
+	assert.Equal(t, "ja", user.Header().Get("Content-Language"))
+}
@@ -0,0 +1,239 @@
+package idempotency_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"todo_backend/internal/infrastructure/idempotency"
+	jwtmw "todo_backend/internal/infrastructure/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestStore(t *testing.T) *idempotency.GormStore {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// :memory:は接続ごとに別のDBになるため1接続に固定する
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&idempotency.Record{}))
+	return idempotency.NewGormStore(db)
+}
+
+// newRouterは呼び出し回数を数えるPOST /todosを持つ、未認証のルーターを返します。
+func newRouter(store idempotency.Store, retention time.Duration, status int) (*gin.Engine, *int) {
+	return newUserRouter(store, retention, status, 0)
+}
+
+// newUserRouterは、userIDのユーザーとして認証済みのリクエストを処理するnewRouterです（0の場合は未認証）。
+func newUserRouter(store idempotency.Store, retention time.Duration, status int, userID uint) (*gin.Engine, *int) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	r := gin.New()
+	if userID != 0 {
+		r.Use(func(c *gin.Context) { c.Set(jwtmw.ContextUserID, userID) })
+	}
+	r.Use(idempotency.Middleware(store, retention))
+	r.POST("/todos", func(c *gin.Context) {
+		calls++
+		c.Header("Location", "/todos/1")
+		c.JSON(status, gin.H{"id": 1, "call": calls})
+	})
+	return r, &calls
+}
+
+func post(r http.Handler, key, body string) *httptest.ResponseRecorder {
+	return postFrom(r, "192.0.2.1:1234", key, body)
+}
+
+// postFromは接続元のアドレスをremoteAddrとしてPOST /todosを送ります。
+func postFrom(r http.Handler, remoteAddr, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(idempotency.HeaderKey, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_ReplaysStoredResponse(t *testing.T) {
+	stores := map[string]idempotency.Store{
+		"gorm":   newTestStore(t),
+		"memory": idempotency.NewMemoryStore(),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			// given
+			r, calls := newRouter(store, time.Hour, http.StatusCreated)
+			first := post(r, "key-1", `{"title":"a"}`)
+
+			// when
+			second := post(r, "key-1", `{"title":"a"}`)
+			other := post(r, "key-1", `{"title":"b"}`)
+
+			// then: ハンドラは1回しか実行されず、同じレスポンスが返る
+			assert.Equal(t, 1, *calls)
+			assert.Equal(t, http.StatusCreated, second.Code)
+			assert.JSONEq(t, first.Body.String(), second.Body.String())
+			assert.Equal(t, "/todos/1", second.Header().Get("Location"))
+			assert.Equal(t, "true", second.Header().Get(idempotency.HeaderReplayed))
+			assert.Empty(t, first.Header().Get(idempotency.HeaderReplayed))
+			assert.Equal(t, http.StatusUnprocessableEntity, other.Code)
+		})
+	}
+}
+
+func TestMiddleware_RejectsDifferentPayload(t *testing.T) {
+	// given
+	r, calls := newRouter(newTestStore(t), time.Hour, http.StatusCreated)
+	post(r, "key-1", `{"title":"a"}`)
+
+	// when
+	w := post(r, "key-1", `{"title":"b"}`)
+
+	// then
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestMiddleware_ConflictWhileInProgress(t *testing.T) {
+	// given: 処理中に同じキーで再送する
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(idempotency.Middleware(newTestStore(t), time.Hour))
+	var inner *httptest.ResponseRecorder
+	r.POST("/todos", func(c *gin.Context) {
+		inner = post(r, "key-1", `{}`)
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	// when
+	outer := post(r, "key-1", `{}`)
+
+	// then
+	assert.Equal(t, http.StatusCreated, outer.Code)
+	require.NotNil(t, inner)
+	assert.Equal(t, http.StatusConflict, inner.Code)
+}
+
+func TestMiddleware_ServerErrorReleasesKey(t *testing.T) {
+	// given
+	r, calls := newRouter(newTestStore(t), time.Hour, http.StatusInternalServerError)
+	post(r, "key-1", `{}`)
+
+	// when
+	w := post(r, "key-1", `{}`)
+
+	// then: 5xxは記録されないので再実行される
+	assert.Equal(t, 2, *calls)
+	assert.Empty(t, w.Header().Get(idempotency.HeaderReplayed))
+}
+
+func TestMiddleware_WithoutKeyOrAfterRetention(t *testing.T) {
+	// given: 保持期間が極端に短い
+	r, calls := newRouter(newTestStore(t), time.Nanosecond, http.StatusCreated)
+
+	// when
+	post(r, "", `{}`)
+	post(r, "", `{}`)
+	post(r, "key-1", `{}`)
+	time.Sleep(time.Millisecond)
+	post(r, "key-1", `{}`)
+
+	// then: キーなし・期限切れのキーはどちらも毎回実行される
+	assert.Equal(t, 4, *calls)
+}
+
+// ハッシュを計算する前にボディの大きさを制限し、上限を超えたリクエストはハンドラを実行せずに413を返す
+func TestMiddleware_RejectsTooLargeBody(t *testing.T) {
+	// given
+	r, calls := newRouter(newTestStore(t), time.Hour, http.StatusCreated)
+	body := `{"title":"` + strings.Repeat("a", idempotency.MaxBodyBytes) + `"}`
+
+	// when
+	w := post(r, "key-1", body)
+	retry := post(r, "key-1", `{"title":"a"}`)
+
+	// then: 記録は作られないので、同じキーで改めて送れる
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, 1, *calls)
+}
+
+// 処理中のままInProgressLeaseを過ぎた記録（異常終了したリクエスト）は、同じキーの再試行が引き継ぐ
+func TestMiddleware_TakesOverAbandonedInProgressKey(t *testing.T) {
+	// given: POST /todosのボディ{}と同じハッシュの処理中の記録
+	store := newTestStore(t)
+	sum := sha256.Sum256([]byte("POST /todos\n{}"))
+	hash := hex.EncodeToString(sum[:])
+	stale := &idempotency.Record{UserID: 1, Key: "key-1", RequestHash: hash, CreatedAt: time.Now().Add(-2 * idempotency.InProgressLease)}
+	_, created, err := store.Begin(context.Background(), stale)
+	require.NoError(t, err)
+	require.True(t, created)
+	fresh := &idempotency.Record{UserID: 1, Key: "key-2", RequestHash: hash}
+	_, created, err = store.Begin(context.Background(), fresh)
+	require.NoError(t, err)
+	require.True(t, created)
+	r, calls := newUserRouter(store, time.Hour, http.StatusCreated, 1)
+
+	// when
+	takenOver := post(r, "key-1", `{}`)
+	inProgress := post(r, "key-2", `{}`)
+
+	// then
+	assert.Equal(t, http.StatusCreated, takenOver.Code)
+	assert.Equal(t, http.StatusConflict, inProgress.Code)
+	assert.Equal(t, 1, *calls)
+}
+
+// 未認証のリクエストのキーはクライアントのIPごとに管理し、他のクライアントには保存済みのレスポンスを返さない
+func TestMiddleware_ScopesAnonymousKeysByClientIP(t *testing.T) {
+	// given
+	r, calls := newRouter(newTestStore(t), time.Hour, http.StatusCreated)
+	first := postFrom(r, "192.0.2.1:1234", "key-1", `{"title":"a"}`)
+
+	// when
+	retried := postFrom(r, "192.0.2.1:5678", "key-1", `{"title":"a"}`)
+	other := postFrom(r, "198.51.100.7:1234", "key-1", `{"title":"a"}`)
+
+	// then
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, "true", retried.Header().Get(idempotency.HeaderReplayed))
+	assert.Equal(t, http.StatusCreated, other.Code)
+	assert.Empty(t, other.Header().Get(idempotency.HeaderReplayed))
+	assert.Equal(t, 2, *calls)
+}
+
+func TestGormStore_DeleteOlderThan(t *testing.T) {
+	// given
+	store := newTestStore(t)
+	ctx := context.Background()
+	_, created, err := store.Begin(ctx, &idempotency.Record{UserID: 1, Key: "k", RequestHash: "h"})
+	require.NoError(t, err)
+	require.True(t, created)
+
+	// when
+	n, err := store.DeleteOlderThan(ctx, time.Now().Add(time.Minute))
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	_, created, err = store.Begin(ctx, &idempotency.Record{UserID: 1, Key: "k", RequestHash: "h"})
+	require.NoError(t, err)
+	assert.True(t, created)
+}
@@ -0,0 +1,92 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStoreはプロセス内のメモリに記録を保持するStoreの実装です。
+// 単一インスタンスでの実行（デモ・開発用）を想定しています。
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[memoryKey]*Record
+	nextID  uint
+}
+
+// memoryKeyは記録を一意に識別するキーです。
+type memoryKey struct {
+	userID uint
+	key    string
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStoreは空のMemoryStoreを返します。
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[memoryKey]*Record), nextID: 1}
+}
+
+// Beginは記録がなければ作成し、あれば既存の記録のコピーを返します。
+func (s *MemoryStore) Begin(_ context.Context, rec *Record) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := memoryKey{userID: rec.UserID, key: rec.Key}
+	if existing, ok := s.records[k]; ok {
+		cp := *existing
+		return &cp, false, nil
+	}
+	rec.ID, rec.CreatedAt = s.nextID, time.Now()
+	s.nextID++
+	cp := *rec
+	s.records[k] = &cp
+	return nil, true, nil
+}
+
+// Completeは記録にレスポンスを保存します。
+func (s *MemoryStore) Complete(_ context.Context, id uint, status int, header string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec := s.find(id); rec != nil {
+		rec.Status, rec.Header, rec.Body = status, header, body
+	}
+	return nil
+}
+
+// Releaseは記録を削除します。
+func (s *MemoryStore) Release(_ context.Context, id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec := s.find(id); rec != nil {
+		delete(s.records, memoryKey{userID: rec.UserID, key: rec.Key})
+	}
+	return nil
+}
+
+// DeleteOlderThanはbeforeより前に作成された記録を削除します。
+func (s *MemoryStore) DeleteOlderThan(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for k, rec := range s.records {
+		if rec.CreatedAt.Before(before) {
+			delete(s.records, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// findはIDで記録を探します。呼び出し側でロックを取得してください。
+func (s *MemoryStore) find(id uint) *Record {
+	for _, rec := range s.records {
+		if rec.ID == id {
+			return rec
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"todo_backend/internal/infrastructure/i18n"
+	jwtmw "todo_backend/internal/infrastructure/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HeaderKeyはクライアントが冪等キーを指定するリクエストヘッダです。
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayedは保存済みのレスポンスを再送したことを示すレスポンスヘッダです。
+	HeaderReplayed = "Idempotent-Replayed"
+
+	// maxKeyLengthは冪等キーの最大長です。
+	maxKeyLength = 255
+	// MaxBodyBytesはハッシュのために読み込むリクエストボディの上限です（ハンドラのJSONのボディの上限と同じ1MiB）。
+	// 超えた場合はハンドラを実行せず413を返します。
+	MaxBodyBytes = 1 << 20
+	// InProgressLeaseは処理中の記録を有効とみなす期間です。
+	// 処理中にプロセスが異常終了して記録が残っても、この期間を過ぎれば同じキーの再試行が処理を引き継ぎます。
+	// リクエストの処理期限より十分長くしてください。
+	InProgressLease = time.Minute
+)
+
+// replayHeadersは記録して再送時に復元するレスポンスヘッダです。
+var replayHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// Middlewareは、Idempotency-Keyヘッダ付きのPOSTリクエストを1回だけ処理するGinミドルウェアを返します。
+// キーはユーザーごと（未認証のルートではクライアントのIPごと）に管理され、retentionの間保持されます。
+//   - 初回: ハンドラを実行し、ステータス・ヘッダ・ボディを保存する
+//   - 再試行: ハンドラを実行せず保存済みのレスポンスを返す（Idempotent-Replayed: true）
+//   - 初回の処理中: 409（InProgressLeaseを過ぎた処理中の記録は、異常終了したとみなして引き継ぐ）
+//   - 同じキーで異なるリクエスト（メソッド・パス・ボディ）: 422
+//
+// 5xxで終わったリクエストは記録を削除し、同じキーで再試行できるようにします。
+// キーのないリクエストやPOST以外のリクエストはそのまま通します。
+func Middleware(store Store, retention time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderKey)
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			i18n.Abort(c, http.StatusBadRequest, i18n.CodeIdempotencyKeyTooLong, nil)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				i18n.Abort(c, http.StatusRequestEntityTooLarge, i18n.CodePayloadTooLarge, nil)
+				return
+			}
+			i18n.Abort(c, http.StatusBadRequest, i18n.CodeBadRequest, nil)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		uid := userID(c)
+		rec := &Record{
+			UserID:      uid,
+			Key:         key,
+			RequestHash: requestHash(c.Request.Method, c.Request.URL.Path, body),
+		}
+		if uid == 0 {
+			rec.Key = anonymousKey(c.ClientIP(), key)
+		}
+		existing, err := begin(ctx, store, rec, retention)
+		if err != nil {
+			slog.ErrorContext(ctx, "idempotency store failed", slog.Any("error", err))
+			i18n.Abort(c, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+			return
+		}
+		if existing != nil {
+			replay(c, existing, rec.RequestHash)
+			return
+		}
+
+		w := &recorder{ResponseWriter: c.Writer}
+		c.Writer = w
+		completed := false
+		defer func() {
+			// パニックや5xxの場合は記録を残さない（クライアントが同じキーで再試行できる）
+			if completed {
+				return
+			}
+			if err := store.Release(context.WithoutCancel(ctx), rec.ID); err != nil {
+				slog.ErrorContext(ctx, "failed to release idempotency key", slog.Any("error", err))
+			}
+		}()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		header := make(map[string]string, len(replayHeaders))
+		for _, name := range replayHeaders {
+			if v := c.Writer.Header().Get(name); v != "" {
+				header[name] = v
+			}
+		}
+		encoded, _ := json.Marshal(header)
+		// リクエストのcontextが期限切れでも、処理済みの結果は保存する
+		if err := store.Complete(context.WithoutCancel(ctx), rec.ID, status, string(encoded), w.body.Bytes()); err != nil {
+			slog.ErrorContext(ctx, "failed to save idempotent response", slog.Any("error", err))
+			return
+		}
+		completed = true
+	}
+}
+
+// beginは記録を作成します。保持期間を過ぎた既存の記録と、InProgressLeaseを過ぎても処理中の記録は
+// 削除して作り直します。有効な既存の記録がある場合はそれを返します。
+func begin(ctx context.Context, store Store, rec *Record, retention time.Duration) (*Record, error) {
+	existing, created, err := store.Begin(ctx, rec)
+	if err != nil || created {
+		return nil, err
+	}
+	age := time.Since(existing.CreatedAt)
+	expired := retention > 0 && age >= retention
+	abandoned := existing.Status == 0 && age >= InProgressLease
+	if !expired && !abandoned {
+		return existing, nil
+	}
+	if err := store.Release(ctx, existing.ID); err != nil {
+		return nil, err
+	}
+	existing, _, err = store.Begin(ctx, rec)
+	return existing, err
+}
+
+// replayは既存の記録に応じたレスポンスを返します。
+func replay(c *gin.Context, rec *Record, hash string) {
+	switch {
+	case rec.RequestHash != hash:
+		i18n.Abort(c, http.StatusUnprocessableEntity, i18n.CodeIdempotencyKeyReused, nil)
+	case rec.Status == 0:
+		i18n.Abort(c, http.StatusConflict, i18n.CodeIdempotencyInProgress, nil)
+	default:
+		var header map[string]string
+		_ = json.Unmarshal([]byte(rec.Header), &header)
+		for name, v := range header {
+			c.Header(name, v)
+		}
+		c.Header(HeaderReplayed, "true")
+		c.Status(rec.Status)
+		_, _ = c.Writer.Write(rec.Body)
+		c.Abort()
+	}
+}
+
+// userIDは認証済みユーザーのIDを返します。未認証の場合は0を返します。
+func userID(c *gin.Context) uint {
+	if v, ok := c.Get(jwtmw.ContextUserID); ok {
+		if uid, ok := v.(uint); ok {
+			return uid
+		}
+	}
+	return 0
+}
+
+// anonymousKeyは未認証のリクエストのキーを、クライアントのIPごとの記録のキーにします。
+// 未認証のリクエスト（/v1/signupなど）はユーザーを区別できないため、他のクライアントが同じキーを送っても
+// 保存済みのレスポンスを返さないようにします。キーの長さの上限に収まるようハッシュにします。
+func anonymousKey(ip, key string) string {
+	sum := sha256.Sum256([]byte(ip + "\n" + key))
+	return "anon:" + hex.EncodeToString(sum[:])
+}
+
+// requestHashはメソッド・パス・ボディからリクエストの同一性を判定するハッシュを計算します。
+func requestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorderはレスポンスボディを記録しながらクライアントへ書き込むResponseWriterです。
+type recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Purgeはintervalごとに保持期間を過ぎた記録を削除します。ctxが終了するまでブロックします。
+func Purge(ctx context.Context, store Store, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := store.DeleteOlderThan(ctx, time.Now().Add(-retention))
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to purge idempotency keys", slog.Any("error", err))
+				continue
+			}
+			if n > 0 {
+				slog.DebugContext(ctx, "purged idempotency keys", slog.Int64("count", n))
+			}
+		}
+	}
+}
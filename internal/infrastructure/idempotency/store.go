@@ -0,0 +1,97 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Recordは冪等キーごとに保存されるリクエストとレスポンスの記録です。
+// Statusが0の間は最初のリクエストが処理中であることを表します。
+type Record struct {
+	ID          uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"uniqueIndex:idx_idempotency_user_key;not null"`
+	Key         string `gorm:"column:idempotency_key;uniqueIndex:idx_idempotency_user_key;size:255;not null"`
+	RequestHash string `gorm:"size:64;not null"`
+	Status      int    `gorm:"not null;default:0"`
+	// Headerは再送時に返すレスポンスヘッダ（Content-Type / Location / ETag）のJSONです。
+	Header    string `gorm:"type:text"`
+	Body      []byte
+	CreatedAt time.Time `gorm:"index"`
+}
+
+// TableNameはRecordのテーブル名を返します。
+func (Record) TableName() string { return "idempotency_keys" }
+
+// Storeは冪等キーの記録を保持するストアです。
+type Store interface {
+	// Beginは処理中の記録を新規作成します。
+	// 同じユーザー・キーの記録が既に存在する場合は作成せず、既存の記録を返します（createdはfalse）。
+	Begin(ctx context.Context, rec *Record) (existing *Record, created bool, err error)
+	// Completeは処理中の記録にレスポンスを保存します。
+	Complete(ctx context.Context, id uint, status int, header string, body []byte) error
+	// Releaseは処理中の記録を削除し、同じキーで再試行できるようにします。
+	Release(ctx context.Context, id uint) error
+	// DeleteOlderThanはbeforeより前に作成された記録を削除し、削除件数を返します。
+	DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
+}
+
+// GormStoreはGORMを使ってDBに記録を保存するStoreの実装です。
+// (user_id, key)の一意制約により、同時に届いた同じキーのリクエストのうち1つだけが処理されます。
+type GormStore struct {
+	db *gorm.DB
+}
+
+var _ Store = (*GormStore)(nil)
+
+// NewGormStoreは指定されたgorm.DBを使うGormStoreを返します。
+// テーブルはRecordをAutoMigrateして作成してください。
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// Beginは一意制約の衝突時に何もしないINSERTで記録を作成し、衝突した場合は既存の記録を読み込みます。
+func (s *GormStore) Begin(ctx context.Context, rec *Record) (*Record, bool, error) {
+	res := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(rec)
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return nil, true, nil
+	}
+
+	var existing Record
+	err := s.db.WithContext(ctx).
+		Where("user_id = ? AND idempotency_key = ?", rec.UserID, rec.Key).
+		First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 読み込みまでの間に削除された場合は作り直す
+		return s.Begin(ctx, rec)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+// Completeは記録にレスポンスのステータス・ヘッダ・ボディを保存します。
+func (s *GormStore) Complete(ctx context.Context, id uint, status int, header string, body []byte) error {
+	return s.db.WithContext(ctx).Model(&Record{}).Where("id = ?", id).
+		Updates(map[string]any{"status": status, "header": header, "body": body}).Error
+}
+
+// Releaseは記録を削除します。
+func (s *GormStore) Release(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Delete(&Record{}, id).Error
+}
+
+// DeleteOlderThanは保持期間を過ぎた記録を削除します。
+func (s *GormStore) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Where("created_at < ?", before).Delete(&Record{})
+	return res.RowsAffected, res.Error
+}
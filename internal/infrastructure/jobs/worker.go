@@ -0,0 +1,149 @@
+// Package jobsは、バックグラウンドジョブ（トランザクショナルアウトボックス）を実行するワーカーを提供します。
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/usecase"
+)
+
+const (
+	// DefaultConcurrencyは同時に実行するジョブの数のデフォルト値です。
+	DefaultConcurrency = 4
+	// DefaultPollIntervalは実行日時を迎えたジョブを確認する間隔のデフォルト値です。
+	DefaultPollInterval = time.Second
+	// recordTimeoutは停止時に実行結果を記録する期限です。
+	recordTimeout = 5 * time.Second
+)
+
+// Workerは、実行日時を迎えたジョブをJobUsecaseから取り出し、種類ごとのハンドラで実行するワーカーです。
+// Handleでハンドラを登録してから、Runを別のgoroutineで実行します。
+//
+// ジョブは取り出す時にJobUsecase.Timeoutの間だけ実行中として確保されます。
+// 実行中にプロセスが異常終了した場合は、期限が過ぎた後に別のワーカーが実行し直します。
+type Worker struct {
+	uc          *usecase.JobUsecase
+	concurrency int
+	interval    time.Duration
+	handlers    map[string]usecase.JobHandler
+}
+
+// NewWorkerは、ucのジョブをconcurrency件まで同時に実行するWorkerを返します。
+// pollIntervalは実行日時を迎えたジョブを確認する間隔です。0以下の場合はそれぞれデフォルト値を使います。
+func NewWorker(uc *usecase.JobUsecase, concurrency int, pollInterval time.Duration) *Worker {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	return &Worker{uc: uc, concurrency: concurrency, interval: pollInterval, handlers: map[string]usecase.JobHandler{}}
+}
+
+// Handleはtypの種類のジョブを実行するハンドラを登録します。Runの前に呼び出してください。
+// ハンドラが登録されていない種類のジョブは、実行できないためデッドレターにします。
+func (w *Worker) Handle(typ string, h usecase.JobHandler) {
+	w.handlers[typ] = h
+}
+
+// Runはctxがキャンセルされるまでジョブを実行します。
+// ctxのキャンセル後は新しいジョブを取り出さず、実行中のジョブが終わるのを待ってから戻ります。
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range w.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// loopは、ジョブがなくなるまで1件ずつ取り出して実行し、なくなったらPollIntervalだけ待つことを繰り返します。
+func (w *Worker) loop(ctx context.Context) {
+	for {
+		for w.runNext(ctx) {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+// runNextはジョブを1件取り出して実行し、実行したかどうかを返します。
+func (w *Worker) runNext(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	claimed, err := w.uc.Claim(ctx, 1)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "failed to claim jobs", slog.Any("error", err))
+		}
+		return false
+	}
+	if len(claimed) == 0 {
+		return false
+	}
+	w.execute(ctx, claimed[0])
+	return true
+}
+
+// executeはジョブを実行し、結果を記録します。
+// 停止時に実行中のジョブを中断しないよう、ハンドラにはctxのキャンセルを伝えず、実行期限のみを設定します。
+func (w *Worker) execute(ctx context.Context, job domain.Job) {
+	attrs := []any{slog.Uint64("job_id", uint64(job.ID)), slog.String("type", job.Type), slog.Int("attempt", job.Attempts)}
+	runCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), w.uc.Timeout)
+	err := w.call(runCtx, job)
+	cancel()
+
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+	defer cancel()
+	if err == nil {
+		err := w.uc.Complete(recordCtx, job)
+		switch {
+		case errors.Is(err, domain.ErrJobLeaseLost):
+			slog.WarnContext(ctx, "job lease lost; discarding result", attrs...)
+		case err != nil:
+			slog.ErrorContext(ctx, "failed to record job completion", append(attrs, slog.Any("error", err))...)
+		}
+		return
+	}
+	failed, recordErr := w.uc.Fail(recordCtx, job, err)
+	if errors.Is(recordErr, domain.ErrJobLeaseLost) {
+		slog.WarnContext(ctx, "job lease lost; discarding result", append(attrs, slog.Any("error", err))...)
+		return
+	}
+	if recordErr != nil {
+		slog.ErrorContext(ctx, "failed to record job failure", append(attrs, slog.Any("error", recordErr))...)
+		return
+	}
+	if failed.Status == domain.JobDead {
+		slog.ErrorContext(ctx, "job is dead", append(attrs, slog.Any("error", err))...)
+		return
+	}
+	slog.WarnContext(ctx, "job failed; will retry", append(attrs, slog.Any("error", err), slog.Time("run_at", failed.RunAt))...)
+}
+
+// callはジョブの種類のハンドラを呼び出します。ハンドラのpanicはエラーとして返します。
+func (w *Worker) call(ctx context.Context, job domain.Job) (err error) {
+	h, ok := w.handlers[job.Type]
+	if !ok {
+		return usecase.PermanentJobError(fmt.Errorf("no handler for job type %q", job.Type))
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job handler panicked: %v", r)
+		}
+	}()
+	return h(ctx, job)
+}
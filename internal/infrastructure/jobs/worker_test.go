@@ -0,0 +1,164 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/jobs"
+	"todo_backend/internal/infrastructure/memory"
+	"todo_backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newJobUsecaseは、テストで待てるよう再試行の間隔を短くしたJobUsecaseを返します。
+func newJobUsecase() *usecase.JobUsecase {
+	return usecase.NewJobUsecase(memory.NewJobRepo(),
+		usecase.WithJobRetry(usecase.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}))
+}
+
+// runは、ワーカーをテストの終了まで実行します。
+func run(t *testing.T, w *jobs.Worker) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// waitForStatusは、IDのジョブが状態statusになるまで待って返します。
+func waitForStatus(t *testing.T, uc *usecase.JobUsecase, id uint, status domain.JobStatus) domain.Job {
+	t.Helper()
+	var job domain.Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = uc.GetJob(context.Background(), id)
+		return err == nil && job.Status == status
+	}, 5*time.Second, 5*time.Millisecond)
+	return job
+}
+
+// 失敗したジョブ（panicを含む）は再試行され、成功すると完了になる
+func TestWorker_RetriesFailedJobsUntilSuccess(t *testing.T) {
+	// given
+	uc := newJobUsecase()
+	w := jobs.NewWorker(uc, 2, 5*time.Millisecond)
+	var calls atomic.Int32
+	w.Handle("flaky", func(ctx context.Context, job domain.Job) error {
+		switch calls.Add(1) {
+		case 1:
+			return errors.New("temporary")
+		case 2:
+			panic("boom")
+		}
+		return nil
+	})
+	job, err := uc.Enqueue(context.Background(), "flaky", map[string]string{"k": "v"}, time.Time{})
+	require.NoError(t, err)
+
+	// when
+	run(t, w)
+
+	// then
+	done := waitForStatus(t, uc, job.ID, domain.JobSucceeded)
+	assert.Equal(t, 3, done.Attempts)
+	assert.Empty(t, done.LastError)
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+// ハンドラのない種類や再試行の上限に達したジョブはデッドレターになる
+func TestWorker_DeadLettersUnknownAndExhaustedJobs(t *testing.T) {
+	// given
+	uc := newJobUsecase()
+	w := jobs.NewWorker(uc, 1, 5*time.Millisecond)
+	w.Handle("failing", func(ctx context.Context, job domain.Job) error { return errors.New("always") })
+	unknown, err := uc.Enqueue(context.Background(), "unknown", nil, time.Time{})
+	require.NoError(t, err)
+	failing, err := uc.Enqueue(context.Background(), "failing", nil, time.Time{})
+	require.NoError(t, err)
+
+	// when
+	run(t, w)
+
+	// then
+	dead := waitForStatus(t, uc, unknown.ID, domain.JobDead)
+	assert.Equal(t, 1, dead.Attempts)
+	assert.Contains(t, dead.LastError, "no handler")
+	dead = waitForStatus(t, uc, failing.ID, domain.JobDead)
+	assert.Equal(t, 3, dead.Attempts)
+	assert.Equal(t, "always", dead.LastError)
+}
+
+// 実行日時を指定したジョブは、その日時まで実行しない
+func TestWorker_RunsScheduledJobsAtRunAt(t *testing.T) {
+	// given
+	uc := newJobUsecase()
+	w := jobs.NewWorker(uc, 1, 5*time.Millisecond)
+	ran := make(chan time.Time, 1)
+	w.Handle("scheduled", func(ctx context.Context, job domain.Job) error {
+		ran <- time.Now()
+		return nil
+	})
+	runAt := time.Now().Add(100 * time.Millisecond)
+	_, err := uc.Enqueue(context.Background(), "scheduled", nil, runAt)
+	require.NoError(t, err)
+
+	// when
+	run(t, w)
+
+	// then
+	select {
+	case at := <-ran:
+		assert.False(t, at.Before(runAt), "ran at %v before %v", at, runAt)
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduled job did not run")
+	}
+}
+
+// 停止時は新しいジョブを取り出さず、実行中のジョブの完了を待つ
+func TestWorker_RunWaitsForInFlightJobsOnShutdown(t *testing.T) {
+	// given
+	uc := newJobUsecase()
+	w := jobs.NewWorker(uc, 1, 5*time.Millisecond)
+	started, release := make(chan struct{}), make(chan struct{})
+	w.Handle("slow", func(ctx context.Context, job domain.Job) error {
+		close(started)
+		<-release
+		return ctx.Err()
+	})
+	job, err := uc.Enqueue(context.Background(), "slow", nil, time.Time{})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Run(ctx)
+	}()
+	<-started
+
+	// when
+	cancel()
+
+	// then
+	select {
+	case <-done:
+		t.Fatal("Run returned before the in-flight job finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-done
+	got, err := uc.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobSucceeded, got.Status, "handler context is not canceled on shutdown")
+}
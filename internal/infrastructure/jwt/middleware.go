@@ -5,53 +5,108 @@ import (
 	"os"
 	"strings"
 
+	"todo_backend/internal/infrastructure/i18n"
+	"todo_backend/internal/infrastructure/logging"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 const ContextUserID = "userID"
 
+// ContextAdminは、認証されたユーザーが管理者（"admin"クレームがtrue）かどうかを保持するキーです。
+const ContextAdmin = "admin"
+
 // AuthRequiredはJWTを検証し、認証されたユーザーのみがアクセスできるようにする
 // Ginのミドルウェア関数を返します
 func AuthRequired() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 1. Authorization ヘッダーの取得
-		auth := c.GetHeader("Authorization")
-		if !strings.HasPrefix(auth, "Bearer ") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
-			return
-		}
-		tokenStr := strings.TrimPrefix(auth, "Bearer ")
+	return authRequired("")
+}
 
-		// 2. 秘密鍵の読み込み（環境変数から）
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			// サーバー側の設定ミス（JWT_SECRET未設定）
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "server misconfigured"})
-			return
+// AuthRequiredOrQueryTokenはAuthRequiredと同じ検証を行うミドルウェアを返します。
+// Authorizationヘッダがない場合は、クエリパラメータparamのJWTも受け付けます
+// （ブラウザのWebSocketなど、ヘッダを指定できないクライアント向け）。
+func AuthRequiredOrQueryToken(param string) gin.HandlerFunc {
+	return authRequired(param)
+}
+
+// Authenticatedは、リクエストがAuthRequired（queryParamを指定した場合はAuthRequiredOrQueryToken）の
+// 検証を通るかを返します。認証より前に未認証のリクエストだけを扱うミドルウェアで使います。
+func Authenticated(c *gin.Context, queryParam string) bool {
+	_, status, _ := verify(c, queryParam)
+	return status == 0
+}
+
+// verifyはリクエストのJWTを検証してクレームを返します。
+// 検証できない場合は、応答するステータスとエラーコードを返します。
+func verify(c *gin.Context, queryParam string) (jwt.MapClaims, int, string) {
+	// 1. Authorization ヘッダー（許可されていればクエリパラメータ）の取得
+	tokenStr, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok && queryParam != "" {
+		tokenStr = c.Query(queryParam)
+		ok = tokenStr != ""
+	}
+	if !ok {
+		return nil, http.StatusUnauthorized, i18n.CodeMissingToken
+	}
+
+	// 2. 秘密鍵の読み込み（環境変数から）
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		// サーバー側の設定ミス（JWT_SECRET未設定）
+		return nil, http.StatusInternalServerError, i18n.CodeServerMisconfigured
+	}
+
+	// 3. JWT のパースと署名検証
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		// 署名アルゴリズムのチェック（HMACのみ許可）
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
 		}
+		return []byte(secret), nil
+	})
+	if err != nil || !token.Valid {
+		// 検証エラーまたは不正なトークン
+		return nil, http.StatusUnauthorized, i18n.CodeInvalidToken
+	}
+	return claims, 0, ""
+}
 
-		// 3. JWT のパースと署名検証
-		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-			// 署名アルゴリズムのチェック（HMACのみ許可）
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(secret), nil
-		})
-		if err != nil || !token.Valid {
-			// 検証エラーまたは不正なトークン
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+func authRequired(queryParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, status, code := verify(c, queryParam)
+		if status != 0 {
+			i18n.Abort(c, status, code, nil)
 			return
 		}
 
 		// 4. Claims（ペイロード部分）の取り出し
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			if sub, ok := claims["sub"].(float64); ok { // JWTはjsonでfloatになる
-				c.Set(ContextUserID, uint(sub))
-			}
+		if sub, ok := claims["sub"].(float64); ok { // JWTはjsonでfloatになる
+			c.Set(ContextUserID, uint(sub))
+			// 以降のログにuser_idが出力されるようcontextへ追加
+			c.Request = c.Request.WithContext(logging.WithUserID(c.Request.Context(), uint(sub)))
+		}
+		if admin, ok := claims["admin"].(bool); ok {
+			c.Set(ContextAdmin, admin)
+		}
+		// 表示言語の設定があればAccept-Languageより優先する
+		if locale, ok := claims["locale"].(string); ok {
+			i18n.SetUserLocale(c, locale)
 		}
 		// 5. 次のハンドラへ処理を渡す
 		c.Next()
 	}
 }
+
+// AdminRequiredは管理者のみがアクセスできるようにするミドルウェアを返します。
+// AuthRequiredの後に登録してください。管理者でない場合は403を返します。
+func AdminRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool(ContextAdmin) {
+			i18n.Abort(c, http.StatusForbidden, i18n.CodeForbidden, nil)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+type ctxKey int
+
+const (
+	attrsKey ctxKey = iota
+	requestIDKey
+)
+
+// WithAttrsはcontextにログ属性を追加します。
+// ここで追加した属性は、このcontextを渡したslog.*Context呼び出しすべてに出力されます。
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	prev := attrsFromContext(ctx)
+	merged := make([]slog.Attr, 0, len(prev)+len(attrs))
+	merged = append(merged, prev...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, attrsKey, merged)
+}
+
+// attrsFromContextはcontextに積まれたログ属性を返します。
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(attrsKey).([]slog.Attr)
+	return attrs
+}
+
+// WithRequestIDはリクエストIDをcontextに保存し、ログ属性request_idとしても追加します。
+func WithRequestID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey, id)
+	return WithAttrs(ctx, slog.String("request_id", id))
+}
+
+// RequestIDFromContextはcontextに保存されたリクエストIDを返します。
+// 保存されていない場合は空文字を返します。
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithUserIDは認証済みユーザーのIDをログ属性user_idとしてcontextへ追加します。
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	return WithAttrs(ctx, slog.Uint64("user_id", uint64(userID)))
+}
@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// redactedはマスク済みの値として出力される文字列です。
+const redacted = "[REDACTED]"
+
+// sensitiveKeysは値を必ずマスクする属性キー（小文字）です。
+var sensitiveKeys = map[string]struct{}{
+	"authorization": {},
+	"cookie":        {},
+	"set-cookie":    {},
+}
+
+// sensitiveFragmentsはキーに含まれていればマスク対象とみなす部分文字列です。
+// 例: "password", "new_password", "jwt_secret", "token"
+var sensitiveFragments = []string{"password", "secret", "token"}
+
+// Newは指定されたレベル以上のログをJSON形式でwへ出力するロガーを返します。
+// 機密情報（Authorizationヘッダやパスワードなど）の値は自動的にマスクされ、
+// WithAttrsでcontextに積まれた属性（request_id, user_idなど）が各ログへ付与されます。
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	})
+	return slog.New(&contextHandler{Handler: h})
+}
+
+// ParseLevelは"debug" / "info" / "warn" / "error"をslog.Levelに変換します。
+// 不明な値の場合はInfoを返します。
+func ParseLevel(s string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// IsSensitiveはキーが機密情報を表すかどうかを判定します。
+func IsSensitive(key string) bool {
+	k := strings.ToLower(key)
+	if _, ok := sensitiveKeys[k]; ok {
+		return true
+	}
+	for _, f := range sensitiveFragments {
+		if strings.Contains(k, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactはslog.HandlerOptions.ReplaceAttrとして使われ、機密属性の値を置き換えます。
+// グループ内の属性（例: headers.Authorization）にも適用されます。
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindGroup && IsSensitive(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
+
+// contextHandlerはcontextに保存された属性をログレコードへ追加するslog.Handlerです。
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handleはcontextの属性を付与してから元のハンドラへ処理を委譲します。
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs := attrsFromContext(ctx); len(attrs) > 0 {
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrsは属性を追加した新しいハンドラを返します。
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroupはグループを追加した新しいハンドラを返します。
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,112 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todo_backend/internal/infrastructure/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &m))
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestNew_RedactsSensitiveAttributes(t *testing.T) {
+	// given
+	var buf bytes.Buffer
+	logger := logging.New(&buf, slog.LevelInfo)
+
+	// when
+	logger.Info("signup",
+		slog.String("email", "a@example.com"),
+		slog.String("password", "hunter22"),
+		slog.Group("headers", slog.String("Authorization", "Bearer abc")),
+	)
+
+	// then
+	lines := decodeLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "a@example.com", lines[0]["email"])
+	assert.Equal(t, "[REDACTED]", lines[0]["password"])
+	assert.Equal(t, "[REDACTED]", lines[0]["headers"].(map[string]any)["Authorization"])
+	assert.NotContains(t, buf.String(), "hunter22")
+	assert.NotContains(t, buf.String(), "Bearer abc")
+}
+
+func TestParseLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelDebug, logging.ParseLevel("debug"))
+	assert.Equal(t, slog.LevelWarn, logging.ParseLevel("WARN"))
+	assert.Equal(t, slog.LevelInfo, logging.ParseLevel("unknown"))
+}
+
+func newTestRouter(buf *bytes.Buffer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := logging.New(buf, slog.LevelDebug)
+	r := gin.New()
+	r.Use(logging.Middleware(logger))
+	r.GET("/hello", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(logging.WithUserID(c.Request.Context(), 42))
+		logger.InfoContext(c.Request.Context(), "in handler")
+		c.Status(http.StatusNoContent)
+	})
+	return r
+}
+
+func TestMiddleware_PropagatesIncomingRequestID(t *testing.T) {
+	// given
+	var buf bytes.Buffer
+	r := newTestRouter(&buf)
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.Header.Set(logging.HeaderRequestID, "req-123")
+	req.Header.Set("Authorization", "Bearer secret-token")
+
+	// when
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// then
+	assert.Equal(t, "req-123", w.Header().Get(logging.HeaderRequestID))
+	lines := decodeLines(t, &buf)
+	require.Len(t, lines, 3) // request started / in handler / request completed
+	for _, l := range lines {
+		assert.Equal(t, "req-123", l["request_id"])
+	}
+	assert.EqualValues(t, 42, lines[1]["user_id"])
+	assert.EqualValues(t, 42, lines[2]["user_id"])
+	assert.EqualValues(t, http.StatusNoContent, lines[2]["status"])
+	assert.Equal(t, "/hello", lines[2]["route"])
+	assert.NotContains(t, buf.String(), "secret-token")
+}
+
+func TestMiddleware_GeneratesRequestIDWhenMissingOrInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	r := newTestRouter(&buf)
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.Header.Set(logging.HeaderRequestID, "has space")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	id := w.Header().Get(logging.HeaderRequestID)
+	assert.Len(t, id, 32)
+	assert.NotEqual(t, "has space", id)
+}
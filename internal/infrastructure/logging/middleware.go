@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderRequestIDはリクエストIDを受け渡すHTTPヘッダ名です。
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLenはクライアントから受け取るリクエストIDの最大長です。
+const maxRequestIDLen = 128
+
+// Middlewareはリクエストごとのログ出力を行うGinミドルウェアを返します。
+// - X-Request-IDヘッダを引き継ぐ（無い・不正な場合は新規発行）
+// - レスポンスヘッダにX-Request-IDを付与
+// - request_idをcontextのログ属性に追加（以降の層のログにも出力される）
+// - 処理完了後にアクセスログを1行出力（5xxはERROR、4xxはWARN）
+func Middleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		id := c.GetHeader(HeaderRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Header(HeaderRequestID, id)
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), id))
+
+		if logger.Enabled(c.Request.Context(), slog.LevelDebug) {
+			logger.DebugContext(c.Request.Context(), "request started",
+				slog.String("method", c.Request.Method),
+				slog.String("path", c.Request.URL.Path),
+				headersAttr(c.Request.Header),
+			)
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		// c.Request.Contextには認証ミドルウェアが追加したuser_idも含まれる
+		logger.LogAttrs(c.Request.Context(), level, "request completed", attrs...)
+	}
+}
+
+// validRequestIDはクライアントから受け取ったリクエストIDを採用してよいか判定します。
+// 空文字、長すぎる値、表示可能なASCII以外を含む値は拒否します。
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestIDはランダムな16バイトを16進数文字列にしたリクエストIDを生成します。
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// headersAttrはHTTPヘッダをログ用のグループ属性に変換します。
+// Authorizationなどの機密ヘッダの値はハンドラ側でマスクされます。
+func headersAttr(h http.Header) slog.Attr {
+	attrs := make([]any, 0, len(h))
+	for k, v := range h {
+		if len(v) == 1 {
+			attrs = append(attrs, slog.String(k, v[0]))
+		} else {
+			attrs = append(attrs, slog.Any(k, v))
+		}
+	}
+	return slog.Group("headers", attrs...)
+}
@@ -0,0 +1,24 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"todo_backend/internal/infrastructure/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recoveryはpanicを回復して500を返すGinミドルウェアです。
+// gin.Recovery()と異なり、スタックトレースはテキストではなく構造化ログとして出力します。
+func Recovery(logger *slog.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		logger.ErrorContext(c.Request.Context(), "panic recovered",
+			slog.Any("panic", err),
+			slog.String("stack", string(debug.Stack())),
+		)
+		i18n.Abort(c, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	})
+}
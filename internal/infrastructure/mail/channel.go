@@ -0,0 +1,60 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
+	"todo_backend/internal/interface/repository"
+	"todo_backend/internal/usecase"
+)
+
+// Channelは、通知を受け取るユーザーのメールアドレスにメールで送るusecase.NotificationSenderの実装です。
+// 件名と本文はユーザーの言語設定（未設定の場合は英語）で作成します。
+type Channel struct {
+	users  repository.UserRepository
+	mailer Mailer
+}
+
+// コンパイル時に Channel が usecase.NotificationSender を実装しているか確認します。
+var _ usecase.NotificationSender = (*Channel)(nil)
+
+// NewChannelは、usersからメールアドレスを取得してmailerで送信するChannelを返します。
+func NewChannel(users repository.UserRepository, mailer Mailer) *Channel {
+	return &Channel{users: users, mailer: mailer}
+}
+
+// SendNotificationは通知nをメールで送信します。
+// ユーザーが削除されていた場合は再試行しても成功しないため、usecase.PermanentJobErrorを返します。
+func (c *Channel) SendNotification(ctx context.Context, n domain.Notification) error {
+	user, err := c.users.FindByID(ctx, n.UserID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return usecase.PermanentJobError(fmt.Errorf("send notification mail: %w", err))
+	}
+	if err != nil {
+		return err
+	}
+	return c.mailer.Send(ctx, newMessage(user, n))
+}
+
+// newMessageはユーザーの言語で通知nのメールを作成します。
+func newMessage(user *domain.User, n domain.Notification) Message {
+	l, ok := i18n.Parse(user.Locale)
+	if !ok {
+		l = i18n.Default
+	}
+	params := i18n.Params{"title": n.Title}
+	body := "mail.reminder.body_no_due"
+	if n.DueAt != nil {
+		params["due_at"] = n.DueAt.UTC().Format(time.RFC3339)
+		body = "mail.reminder.body"
+	}
+	return Message{
+		To:      user.Email,
+		Subject: i18n.Message(l, "mail.reminder.subject", params),
+		Body:    i18n.Message(l, body, params),
+	}
+}
@@ -0,0 +1,64 @@
+package mail_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/mail"
+	"todo_backend/internal/infrastructure/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMailerは送信したメールを記録するmail.Mailerです。
+type fakeMailer struct {
+	sent []mail.Message
+}
+
+func (f *fakeMailer) Send(ctx context.Context, msg mail.Message) error {
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+// 通知はユーザーのメールアドレスに、ユーザーの言語で送る
+func TestChannel_SendsLocalizedMailToUser(t *testing.T) {
+	// given
+	users := memory.NewUserRepo()
+	ja := &domain.User{Email: "alice@example.com", Password: "x", Locale: "ja"}
+	en := &domain.User{Email: "bob@example.com", Password: "x"}
+	require.NoError(t, users.Create(context.Background(), ja))
+	require.NoError(t, users.Create(context.Background(), en))
+	mailer := &fakeMailer{}
+	ch := mail.NewChannel(users, mailer)
+	dueAt := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+
+	// when
+	require.NoError(t, ch.SendNotification(context.Background(), domain.Notification{UserID: ja.ID, Title: "牛乳を買う", DueAt: &dueAt}))
+	require.NoError(t, ch.SendNotification(context.Background(), domain.Notification{UserID: en.ID, Title: "Buy milk"}))
+
+	// then
+	require.Len(t, mailer.sent, 2)
+	assert.Equal(t, "alice@example.com", mailer.sent[0].To)
+	assert.Equal(t, "リマインダー: 牛乳を買う", mailer.sent[0].Subject)
+	assert.Contains(t, mailer.sent[0].Body, "2026-10-20T09:00:00Z")
+	assert.Equal(t, "bob@example.com", mailer.sent[1].To)
+	assert.Equal(t, "Reminder: Buy milk", mailer.sent[1].Subject)
+	assert.NotContains(t, mailer.sent[1].Body, "due at")
+}
+
+// 削除されたユーザーへの通知は送らない
+func TestChannel_FailsForUnknownUser(t *testing.T) {
+	// given
+	mailer := &fakeMailer{}
+	ch := mail.NewChannel(memory.NewUserRepo(), mailer)
+
+	// when
+	err := ch.SendNotification(context.Background(), domain.Notification{UserID: 99, Title: "t"})
+
+	// then
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	assert.Empty(t, mailer.sent)
+}
@@ -0,0 +1,40 @@
+// Package mailは、メールの送信の抽象（Mailer）と、通知をメールで送るチャネルを提供します。
+package mail
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Messageは送信するメールです。本文はプレーンテキストです。
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailerはメールの送信を抽象化したインターフェースです。
+// SMTPや外部のメール配信サービスなど、送信の方法ごとに実装します。
+type Mailer interface {
+	// Sendはmsgを送信します。失敗した場合は呼び出し側で再試行します。
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailerは、メールを送信せずにログに出力するMailerです。ローカル実行や送信先を用意していない環境で使います。
+type LogMailer struct {
+	Logger *slog.Logger
+}
+
+// コンパイル時に LogMailer が Mailer を実装しているか確認します。
+var _ Mailer = LogMailer{}
+
+// Sendはmsgをログに出力します。
+func (m LogMailer) Send(ctx context.Context, msg Message) error {
+	logger := m.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.InfoContext(ctx, "mail",
+		slog.String("to", msg.To), slog.String("subject", msg.Subject), slog.String("body", msg.Body))
+	return nil
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"todo_backend/internal/infrastructure/memory"
+	"todo_backend/internal/interface/repository"
+	"todo_backend/internal/interface/repository/repositorytest"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) (repository.Repositories, repository.Transactor) {
+		return memory.NewRepositories()
+	})
+}
@@ -0,0 +1,187 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+)
+
+// JobRepoはバックグラウンドジョブをメモリ上に保持するrepository.JobRepositoryの実装です。
+// 複数のgoroutineから安全に利用できます。
+type JobRepo struct {
+	mu     sync.RWMutex
+	jobs   map[uint]domain.Job
+	nextID uint
+}
+
+// コンパイル時に JobRepo が repository.JobRepository を実装しているか確認します。
+var _ repository.JobRepository = (*JobRepo)(nil)
+
+// NewJobRepoは空のJobRepoを返します。
+func NewJobRepo() *JobRepo {
+	return &JobRepo{jobs: make(map[uint]domain.Job), nextID: 1}
+}
+
+// EnqueueはIDを採番してジョブを保存します。
+func (r *JobRepo) Enqueue(ctx context.Context, job *domain.Job) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	job.ID, job.CreatedAt, job.UpdatedAt = r.nextID, now, now
+	r.nextID++
+	saveForRollback(ctx, &r.mu, r.jobs, job.ID)
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+// Claimは実行するジョブを実行日時の古い順に取り出して実行中にします。
+// 実行回数の上限に達したまま実行期限を過ぎたジョブは、取り出さずにデッドレターにします。
+func (r *JobRepo) Claim(ctx context.Context, now time.Time, lease time.Duration, maxAttempts, limit int) ([]domain.Job, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []domain.Job
+	for _, job := range r.jobs {
+		pending := job.Status == domain.JobPending && !job.RunAt.After(now)
+		expired := job.Status == domain.JobRunning && job.LockedUntil != nil && !job.LockedUntil.After(now)
+		if expired && job.Attempts >= maxAttempts {
+			job.Status, job.LockedUntil, job.LastError = domain.JobDead, nil, domain.ErrJobLeaseExpired.Error()
+			job.FinishedAt, job.UpdatedAt = &now, now
+			saveForRollback(ctx, &r.mu, r.jobs, job.ID)
+			r.jobs[job.ID] = job
+			continue
+		}
+		if pending || expired {
+			due = append(due, job)
+		}
+	}
+	slices.SortFunc(due, func(a, b domain.Job) int {
+		return cmp.Or(a.RunAt.Compare(b.RunAt), cmp.Compare(a.ID, b.ID))
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	lockedUntil := now.Add(lease)
+	for i := range due {
+		due[i].Status = domain.JobRunning
+		due[i].Attempts++
+		due[i].LockedUntil = &lockedUntil
+		due[i].UpdatedAt = now
+		saveForRollback(ctx, &r.mu, r.jobs, due[i].ID)
+		r.jobs[due[i].ID] = due[i]
+	}
+	return due, nil
+}
+
+// Updateはジョブの実行結果を保存します。
+func (r *JobRepo) Update(ctx context.Context, job domain.Job) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.jobs[job.ID]
+	if !ok {
+		return domain.ErrJobNotFound
+	}
+	if stored.Status != domain.JobRunning || stored.Attempts != job.Attempts {
+		// 実行期限を過ぎて他のワーカーが取り出し直した
+		return domain.ErrJobLeaseLost
+	}
+	stored.Status, stored.Attempts, stored.RunAt = job.Status, job.Attempts, job.RunAt
+	stored.LockedUntil, stored.LastError, stored.FinishedAt = job.LockedUntil, job.LastError, job.FinishedAt
+	stored.UpdatedAt = time.Now()
+	saveForRollback(ctx, &r.mu, r.jobs, job.ID)
+	r.jobs[job.ID] = stored
+	return nil
+}
+
+// Requeueは実行を諦めたジョブを実行待ちに戻します。
+func (r *JobRepo) Requeue(ctx context.Context, id uint, runAt time.Time) (domain.Job, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Job{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return domain.Job{}, domain.ErrJobNotFound
+	}
+	if job.Status != domain.JobDead {
+		return domain.Job{}, domain.ErrJobNotDead
+	}
+	job.Status, job.Attempts, job.RunAt, job.FinishedAt = domain.JobPending, 0, runAt, nil
+	job.UpdatedAt = time.Now()
+	saveForRollback(ctx, &r.mu, r.jobs, id)
+	r.jobs[id] = job
+	return job, nil
+}
+
+// FindByIDはIDのジョブを返します。
+func (r *JobRepo) FindByID(ctx context.Context, id uint) (domain.Job, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Job{}, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return domain.Job{}, domain.ErrJobNotFound
+	}
+	return job, nil
+}
+
+// Findは状態がstatusのジョブ（空の場合はすべて）を新しい順に返します。
+func (r *JobRepo) Find(ctx context.Context, status domain.JobStatus, limit int) ([]domain.Job, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var jobs []domain.Job
+	for _, id := range slices.Backward(slices.Sorted(maps.Keys(r.jobs))) {
+		if len(jobs) == limit {
+			break
+		}
+		if job := r.jobs[id]; status == "" || job.Status == status {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+// DeleteSucceededは完了日時がbeforeより前の完了したジョブを削除します。
+func (r *JobRepo) DeleteSucceeded(ctx context.Context, before time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for id, job := range r.jobs {
+		if job.Status == domain.JobSucceeded && job.FinishedAt != nil && job.FinishedAt.Before(before) {
+			saveForRollback(ctx, &r.mu, r.jobs, id)
+			delete(r.jobs, id)
+			n++
+		}
+	}
+	return n, nil
+}
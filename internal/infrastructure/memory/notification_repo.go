@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+)
+
+// NotificationRepoは通知をメモリ上に保持するrepository.NotificationRepositoryの実装です。
+// 複数のgoroutineから安全に利用できます。
+type NotificationRepo struct {
+	mu            sync.RWMutex
+	notifications map[uint]domain.Notification
+	nextID        uint
+}
+
+// コンパイル時に NotificationRepo が repository.NotificationRepository を実装しているか確認します。
+var _ repository.NotificationRepository = (*NotificationRepo)(nil)
+
+// NewNotificationRepoは空のNotificationRepoを返します。
+func NewNotificationRepo() *NotificationRepo {
+	return &NotificationRepo{notifications: make(map[uint]domain.Notification), nextID: 1}
+}
+
+// CreateはIDを採番して通知を保存します。
+func (r *NotificationRepo) Create(ctx context.Context, notification *domain.Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	notification.ID, notification.CreatedAt = r.nextID, time.Now()
+	r.nextID++
+	saveForRollback(ctx, &r.mu, r.notifications, notification.ID)
+	r.notifications[notification.ID] = *notification
+	return nil
+}
+
+// FindByUserはユーザー宛ての通知を新しい順に返します。
+func (r *NotificationRepo) FindByUser(ctx context.Context, userID uint, unreadOnly bool, limit int) ([]domain.Notification, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var notifications []domain.Notification
+	for _, id := range slices.Backward(slices.Sorted(maps.Keys(r.notifications))) {
+		if len(notifications) == limit {
+			break
+		}
+		n := r.notifications[id]
+		if n.UserID == userID && (!unreadOnly || n.ReadAt == nil) {
+			notifications = append(notifications, n)
+		}
+	}
+	return notifications, nil
+}
+
+// MarkReadは通知を既読にして、更新後の通知を返します。
+func (r *NotificationRepo) MarkRead(ctx context.Context, userID, id uint, at time.Time) (domain.Notification, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Notification{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n, ok := r.notifications[id]
+	if !ok || n.UserID != userID {
+		return domain.Notification{}, domain.ErrNotificationNotFound
+	}
+	if n.ReadAt == nil {
+		n.ReadAt = &at
+		saveForRollback(ctx, &r.mu, r.notifications, id)
+		r.notifications[id] = n
+	}
+	return n, nil
+}
+
+// MarkAllReadはユーザー宛ての未読の通知をすべて既読にして、既読にした件数を返します。
+func (r *NotificationRepo) MarkAllRead(ctx context.Context, userID uint, at time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var marked int
+	for id, n := range r.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			n.ReadAt = &at
+			saveForRollback(ctx, &r.mu, r.notifications, id)
+			r.notifications[id] = n
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+// Deleteは通知を削除します。
+func (r *NotificationRepo) Delete(ctx context.Context, userID, id uint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n, ok := r.notifications[id]
+	if !ok || n.UserID != userID {
+		return domain.ErrNotificationNotFound
+	}
+	saveForRollback(ctx, &r.mu, r.notifications, id)
+	delete(r.notifications, id)
+	return nil
+}
+
+// CountUnreadはユーザー宛ての未読の通知の件数を返します。
+func (r *NotificationRepo) CountUnread(ctx context.Context, userID uint) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int
+	for _, n := range r.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
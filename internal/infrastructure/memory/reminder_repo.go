@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+)
+
+// ReminderRepoはリマインダーをメモリ上に保持するrepository.ReminderRepositoryの実装です。
+// 複数のgoroutineから安全に利用できます。
+type ReminderRepo struct {
+	mu        sync.RWMutex
+	reminders map[uint]domain.Reminder
+	nextID    uint
+}
+
+// コンパイル時に ReminderRepo が repository.ReminderRepository を実装しているか確認します。
+var _ repository.ReminderRepository = (*ReminderRepo)(nil)
+
+// NewReminderRepoは空のReminderRepoを返します。
+func NewReminderRepo() *ReminderRepo {
+	return &ReminderRepo{reminders: make(map[uint]domain.Reminder), nextID: 1}
+}
+
+// CreateはIDを採番してリマインダーを保存します。
+func (r *ReminderRepo) Create(ctx context.Context, reminder *domain.Reminder) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reminder.ID, reminder.CreatedAt = r.nextID, time.Now()
+	r.nextID++
+	saveForRollback(ctx, &r.mu, r.reminders, reminder.ID)
+	r.reminders[reminder.ID] = *reminder
+	return nil
+}
+
+// FindByTodoはTodoのリマインダーを作成順に返します。
+func (r *ReminderRepo) FindByTodo(ctx context.Context, userID, todoID uint) ([]domain.Reminder, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var reminders []domain.Reminder
+	for _, id := range slices.Sorted(maps.Keys(r.reminders)) {
+		if rem := r.reminders[id]; rem.UserID == userID && rem.TodoID == todoID {
+			reminders = append(reminders, rem)
+		}
+	}
+	return reminders, nil
+}
+
+// Deleteはリマインダーを削除します。
+func (r *ReminderRepo) Delete(ctx context.Context, userID, todoID, id uint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rem, ok := r.reminders[id]
+	if !ok || rem.UserID != userID || rem.TodoID != todoID {
+		return domain.ErrReminderNotFound
+	}
+	saveForRollback(ctx, &r.mu, r.reminders, id)
+	delete(r.reminders, id)
+	return nil
+}
+
+// DeleteByTodoはTodoのリマインダーをすべて削除します。
+func (r *ReminderRepo) DeleteByTodo(ctx context.Context, userID, todoID uint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, rem := range r.reminders {
+		if rem.UserID == userID && rem.TodoID == todoID {
+			saveForRollback(ctx, &r.mu, r.reminders, id)
+			delete(r.reminders, id)
+		}
+	}
+	return nil
+}
+
+// Rescheduleはリマインダーの通知する日時を変更します。
+func (r *ReminderRepo) Reschedule(ctx context.Context, id uint, fireAt *time.Time, rearm bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rem, ok := r.reminders[id]
+	if !ok {
+		return domain.ErrReminderNotFound
+	}
+	if rem.FiredAt != nil && !rearm {
+		return nil
+	}
+	rem.FireAt, rem.FiredAt = fireAt, nil
+	saveForRollback(ctx, &r.mu, r.reminders, id)
+	r.reminders[id] = rem
+	return nil
+}
+
+// Dueは通知する日時を過ぎた未通知のリマインダーを返します。
+func (r *ReminderRepo) Due(ctx context.Context, now time.Time, limit int) ([]domain.Reminder, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var due []domain.Reminder
+	for _, rem := range r.reminders {
+		if isDue(rem, now) {
+			due = append(due, rem)
+		}
+	}
+	slices.SortFunc(due, func(a, b domain.Reminder) int {
+		return cmp.Or(a.FireAt.Compare(*b.FireAt), cmp.Compare(a.ID, b.ID))
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// MarkFiredはリマインダーを通知済みにします。
+func (r *ReminderRepo) MarkFired(ctx context.Context, id uint, now time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rem, ok := r.reminders[id]
+	if !ok || !isDue(rem, now) {
+		return false, nil
+	}
+	rem.FiredAt = &now
+	saveForRollback(ctx, &r.mu, r.reminders, id)
+	r.reminders[id] = rem
+	return true, nil
+}
+
+// isDueはリマインダーが未通知で、通知する日時がnow以前かどうかを返します。
+func isDue(rem domain.Reminder, now time.Time) bool {
+	return rem.FiredAt == nil && rem.FireAt != nil && !rem.FireAt.After(now)
+}
@@ -0,0 +1,232 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+)
+
+// TodoRepoはTodoをメモリ上に保持するrepository.TodoRepositoryの実装です。
+// 複数のgoroutineから安全に利用でき、GORMの実装と同じ振る舞い（ID採番・バージョン管理・所有者の確認）をします。
+type TodoRepo struct {
+	mu     sync.RWMutex
+	todos  map[uint]domain.Todo
+	nextID uint
+	// changesはTodoのIDごとの最新の変更履歴です。
+	changes map[uint]domain.TodoChange
+	lastSeq uint64
+	// prunedはユーザーごとの削除した墓標のseqの最大値です。
+	pruned map[uint]uint64
+}
+
+// コンパイル時に TodoRepo が repository.TodoRepository を実装しているか確認します。
+var _ repository.TodoRepository = (*TodoRepo)(nil)
+
+// NewTodoRepoは空のTodoRepoを返します。
+func NewTodoRepo() *TodoRepo {
+	return &TodoRepo{todos: make(map[uint]domain.Todo), nextID: 1, changes: make(map[uint]domain.TodoChange), pruned: make(map[uint]uint64)}
+}
+
+// FindByUserは指定ユーザーのTodoをID順に返します。
+func (r *TodoRepo) FindByUser(ctx context.Context, userID uint) ([]domain.Todo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var todos []domain.Todo
+	for _, id := range slices.Sorted(maps.Keys(r.todos)) {
+		if t := r.todos[id]; t.UserID == userID {
+			todos = append(todos, t)
+		}
+	}
+	return todos, nil
+}
+
+// FindByIDは指定ユーザーが所有するIDのTodoを返します。
+func (r *TodoRepo) FindByID(ctx context.Context, userID uint, id uint) (domain.Todo, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Todo{}, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.todos[id]
+	if !ok || t.UserID != userID {
+		return domain.Todo{}, domain.ErrTodoNotFound
+	}
+	return t, nil
+}
+
+// CreateはIDを採番し、バージョン1でTodoを保存します。
+func (r *TodoRepo) Create(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Todo{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo.ID = r.nextID
+	todo.Version = 1
+	r.nextID++
+	saveForRollback(ctx, &r.mu, r.todos, todo.ID)
+	r.todos[todo.ID] = todo
+	r.recordChange(ctx, todo.UserID, todo.ID, false)
+	return todo, nil
+}
+
+// Updateはバージョンが一致する場合のみタイトルと完了状態を更新し、バージョンを進めます。
+func (r *TodoRepo) Update(ctx context.Context, todo domain.Todo) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := r.lookup(todo.UserID, todo.ID, todo.Version)
+	if err != nil {
+		return err
+	}
+	current.Title = todo.Title
+	current.Completed = todo.Completed
+	current.DueAt = todo.DueAt
+	current.Version++
+	saveForRollback(ctx, &r.mu, r.todos, current.ID)
+	r.todos[current.ID] = current
+	r.recordChange(ctx, current.UserID, current.ID, false)
+	return nil
+}
+
+// Deleteはバージョンが一致する場合のみTodoを削除します。
+func (r *TodoRepo) Delete(ctx context.Context, userID, id, version uint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.lookup(userID, id, version); err != nil {
+		return err
+	}
+	saveForRollback(ctx, &r.mu, r.todos, id)
+	delete(r.todos, id)
+	r.recordChange(ctx, userID, id, true)
+	return nil
+}
+
+// Changesは指定ユーザーの変更履歴のうちseqがsinceより大きいものを、seqの昇順に最大limit件返します。
+func (r *TodoRepo) Changes(ctx context.Context, userID uint, since uint64, limit int) ([]domain.TodoChange, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var changes []domain.TodoChange
+	for _, c := range r.changes {
+		if c.UserID != userID || c.Seq <= since {
+			continue
+		}
+		c.Todo = domain.Todo{ID: c.TodoID, UserID: c.UserID}
+		if t, ok := r.todos[c.TodoID]; ok && !c.Deleted {
+			c.Todo = t
+		}
+		changes = append(changes, c)
+	}
+	slices.SortFunc(changes, func(a, b domain.TodoChange) int { return cmp.Compare(a.Seq, b.Seq) })
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+	return changes, nil
+}
+
+// LastChangeSeqは指定ユーザーの最新の変更のseqを返します。
+func (r *TodoRepo) LastChangeSeq(ctx context.Context, userID uint) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var seq uint64
+	for _, c := range r.changes {
+		if c.UserID == userID {
+			seq = max(seq, c.Seq)
+		}
+	}
+	return seq, nil
+}
+
+// PrunedChangeSeqは指定ユーザーの削除した墓標のseqの最大値を返します。
+func (r *TodoRepo) PrunedChangeSeq(ctx context.Context, userID uint) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.pruned[userID], nil
+}
+
+// PruneTombstonesはbeforeより前に記録された削除の墓標を削除し、削除した件数を返します。
+func (r *TodoRepo) PruneTombstones(ctx context.Context, before time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for todoID, c := range r.changes {
+		if !c.Deleted || !c.ChangedAt.Before(before) {
+			continue
+		}
+		saveForRollback(ctx, &r.mu, r.changes, todoID)
+		delete(r.changes, todoID)
+		if c.Seq > r.pruned[c.UserID] {
+			saveForRollback(ctx, &r.mu, r.pruned, c.UserID)
+			r.pruned[c.UserID] = c.Seq
+		}
+		n++
+	}
+	return n, nil
+}
+
+// recordChangeはTodoの変更履歴を新しいseqで記録し直します。呼び出し側でロックを取得してください。
+// メモリ上の変更はコミット前でも読めるため、ロールバックでは元の変更履歴を新しいseqで記録し直し
+// （作成の取り消しは墓標として）、途中の状態を同期したクライアントにも取り消しを伝えます。
+func (r *TodoRepo) recordChange(ctx context.Context, userID, todoID uint, deleted bool) {
+	previous, existed := r.changes[todoID]
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		restored := previous
+		if !existed {
+			restored = domain.TodoChange{TodoID: todoID, UserID: userID, Deleted: true}
+		}
+		r.lastSeq++
+		restored.Seq, restored.ChangedAt = r.lastSeq, time.Now()
+		r.changes[todoID] = restored
+	})
+	r.lastSeq++
+	r.changes[todoID] = domain.TodoChange{Seq: r.lastSeq, TodoID: todoID, UserID: userID, Deleted: deleted, ChangedAt: time.Now()}
+}
+
+// lookupは所有者とバージョンを確認してTodoを返します。呼び出し側でロックを取得してください。
+func (r *TodoRepo) lookup(userID, id, version uint) (domain.Todo, error) {
+	t, ok := r.todos[id]
+	if !ok || t.UserID != userID {
+		return domain.Todo{}, domain.ErrTodoNotFound
+	}
+	if t.Version != version {
+		return domain.Todo{}, domain.ErrVersionMismatch
+	}
+	return t, nil
+}
@@ -0,0 +1,47 @@
+package memory_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoRepo_ConcurrentAccess(t *testing.T) {
+	// given
+	repo := memory.NewTodoRepo()
+	ctx := context.Background()
+	const n = 50
+
+	// when: 作成と一覧取得を並行に行う
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := repo.Create(ctx, domain.Todo{UserID: 1, Title: "t"})
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := repo.FindByUser(ctx, 1)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// then: IDは重複せずに採番される
+	todos, err := repo.FindByUser(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, todos, n)
+	seen := make(map[uint]bool)
+	for _, todo := range todos {
+		assert.False(t, seen[todo.ID])
+		seen[todo.ID] = true
+	}
+}
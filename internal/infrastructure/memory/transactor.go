@@ -0,0 +1,120 @@
+// Package memoryは、リポジトリをメモリ上に実装します。
+// DBを使わないテストやローカル実行で利用します。
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"todo_backend/internal/interface/repository"
+)
+
+// txKeyはcontextに実行中のトランザクション（*tx）を保持するためのキーです。
+type txKey struct{}
+
+// txは実行中のトランザクションです。
+// リポジトリへの書き込みを元に戻す関数（undoログ）を書き込んだ順に保持します。
+type tx struct {
+	t    *Transactor
+	mu   sync.Mutex
+	undo []func()
+}
+
+// Transactorはメモリ上のリポジトリに対するrepository.Transactorの実装です。
+// トランザクションは1つずつ直列に実行され、fnがエラーを返した場合は
+// そのトランザクション内で行った書き込みだけを元に戻します。
+// トランザクションの外で行われた書き込みは戻しません。
+type Transactor struct {
+	mu    sync.Mutex
+	repos repository.Repositories
+}
+
+// コンパイル時に Transactor が repository.Transactor を実装しているか確認します。
+var _ repository.Transactor = (*Transactor)(nil)
+
+// NewTransactorは、reposをトランザクション内で使うTransactorを返します。
+func NewTransactor(repos repository.Repositories) *Transactor {
+	return &Transactor{repos: repos}
+}
+
+// NewRepositoriesは、空のメモリ上のリポジトリ一式と、それらに対するTransactorを返します。
+func NewRepositories() (repository.Repositories, *Transactor) {
+	repos := repository.Repositories{
+		Todos:         NewTodoRepo(),
+		Users:         NewUserRepo(),
+		Webhooks:      NewWebhookRepo(),
+		Jobs:          NewJobRepo(),
+		Reminders:     NewReminderRepo(),
+		Notifications: NewNotificationRepo(),
+	}
+	return repos, NewTransactor(repos)
+}
+
+// WithinTransactionは、fnを実行し、失敗した場合はfnの中で行った書き込みを元に戻します。
+// 同じTransactorのトランザクション中に呼び出された場合は入れ子のトランザクションとして扱い、
+// 内側の失敗では内側の変更だけを戻します。内側が成功した場合、その変更は外側と一緒に戻されます。
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context, repos repository.Repositories) error) error {
+	outer, nested := ctx.Value(txKey{}).(*tx)
+	if !nested || outer.t != t {
+		outer = nil
+		t.mu.Lock()
+		defer t.mu.Unlock()
+	}
+
+	current := &tx{t: t}
+	if err := fn(context.WithValue(ctx, txKey{}, current), t.repos); err != nil {
+		current.rollback()
+		return err
+	}
+	if outer != nil {
+		current.mu.Lock()
+		defer current.mu.Unlock()
+		for _, undo := range current.undo {
+			outer.add(undo)
+		}
+	}
+	return nil
+}
+
+// addは、トランザクションが失敗したときに実行するundoを登録します。
+func (x *tx) add(undo func()) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.undo = append(x.undo, undo)
+}
+
+// rollbackは、登録されたundoを書き込みと逆の順に実行します。
+func (x *tx) rollback() {
+	x.mu.Lock()
+	undo := x.undo
+	x.undo = nil
+	x.mu.Unlock()
+
+	for _, f := range slices.Backward(undo) {
+		f()
+	}
+}
+
+// onRollbackは、ctxのトランザクションが失敗したときに実行するundoを登録します。
+// トランザクションの外では何もしません。
+func onRollback(ctx context.Context, undo func()) {
+	if x, ok := ctx.Value(txKey{}).(*tx); ok {
+		x.add(undo)
+	}
+}
+
+// saveForRollbackは、ctxのトランザクションが失敗したときにm[key]を現在の値（存在しなければ削除）へ戻すよう登録します。
+// トランザクションの外では何もしません。書き込みの前に、muのロックを取得した状態で呼び出してください。
+func saveForRollback[K comparable, V any](ctx context.Context, mu sync.Locker, m map[K]V, key K) {
+	old, existed := m[key]
+	onRollback(ctx, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if existed {
+			m[key] = old
+		} else {
+			delete(m, key)
+		}
+	})
+}
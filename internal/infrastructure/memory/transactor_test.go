@@ -0,0 +1,98 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/memory"
+	"todo_backend/internal/interface/repository"
+	"todo_backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactor_RollsBackOnlyOwnWrites(t *testing.T) {
+	// given
+	repos, tx := memory.NewRepositories()
+	ctx := context.Background()
+	kept, err := repos.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "keep"})
+	require.NoError(t, err)
+
+	// when
+	err = tx.WithinTransaction(ctx, func(txCtx context.Context, r repository.Repositories) error {
+		kept.Title = "outer"
+		require.NoError(t, r.Todos.Update(txCtx, kept))
+		// トランザクションの外の書き込みはロールバックの対象にならない
+		require.NoError(t, repos.Users.Create(ctx, &domain.User{Email: "a@example.com", Password: "x"}))
+		// 入れ子の失敗は内側の変更だけを戻す
+		inner := tx.WithinTransaction(txCtx, func(txCtx context.Context, r repository.Repositories) error {
+			_, err := r.Todos.Create(txCtx, domain.Todo{UserID: 1, Title: "inner"})
+			require.NoError(t, err)
+			return errors.New("inner failed")
+		})
+		require.Error(t, inner)
+		todos, err := r.Todos.FindByUser(txCtx, 1)
+		require.NoError(t, err)
+		require.Len(t, todos, 1)
+		assert.Equal(t, "outer", todos[0].Title)
+		return errors.New("outer failed")
+	})
+
+	// then
+	assert.Error(t, err)
+	todos, err := repos.Todos.FindByUser(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, todos, 1)
+	assert.Equal(t, "keep", todos[0].Title)
+	assert.Equal(t, uint(1), todos[0].Version)
+	_, err = repos.Users.FindByEmail(ctx, "a@example.com")
+	assert.NoError(t, err)
+}
+
+// hookedTodoRepoはUpdateの前にhookを呼び出すTodoRepoです。
+type hookedTodoRepo struct {
+	*memory.TodoRepo
+	hook func()
+}
+
+func (r hookedTodoRepo) Update(ctx context.Context, todo domain.Todo) error {
+	r.hook()
+	return r.TodoRepo.Update(ctx, todo)
+}
+
+func TestTransactor_VersionConflictKeepsConcurrentWrites(t *testing.T) {
+	// given: --storage=memoryと同じく、ユースケースがリポジトリとTransactorを共有する
+	repos, _ := memory.NewRepositories()
+	ctx := context.Background()
+	auth := usecase.NewAuthUsecase(repos.Users)
+	jobs := usecase.NewJobUsecase(repos.Jobs)
+	_, err := jobs.Enqueue(ctx, "test", nil, time.Time{})
+	require.NoError(t, err)
+	todo, err := repos.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "a"})
+	require.NoError(t, err)
+	// トランザクション中に、他のリクエストのサインアップとワーカーのジョブの取り出しが行われる
+	todos := hookedTodoRepo{TodoRepo: repos.Todos.(*memory.TodoRepo), hook: func() {
+		require.NoError(t, auth.Signup(ctx, "other@example.com", "password", "en"))
+		claimed, err := jobs.Claim(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+	}}
+	repos.Todos = todos
+	todoUC := usecase.NewTodoUsecase(todos, usecase.WithTransactor(memory.NewTransactor(repos)))
+
+	// when: 古いバージョンでの更新（412）でトランザクションがロールバックされる
+	todo.Version = 99
+	_, err = todoUC.UpdateTodo(ctx, todo)
+
+	// then
+	require.ErrorIs(t, err, domain.ErrVersionMismatch)
+	_, err = repos.Users.FindByEmail(ctx, "other@example.com")
+	assert.NoError(t, err)
+	running, err := jobs.GetJobs(ctx, domain.JobRunning, 10)
+	require.NoError(t, err)
+	assert.Len(t, running, 1)
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+)
+
+// UserRepoはユーザーをメモリ上に保持するrepository.UserRepositoryの実装です。
+// 複数のgoroutineから安全に利用できます。
+type UserRepo struct {
+	mu     sync.RWMutex
+	users  map[uint]domain.User
+	nextID uint
+}
+
+// コンパイル時に UserRepo が repository.UserRepository を実装しているか確認します。
+var _ repository.UserRepository = (*UserRepo)(nil)
+
+// NewUserRepoは空のUserRepoを返します。
+func NewUserRepo() *UserRepo {
+	return &UserRepo{users: make(map[uint]domain.User), nextID: 1}
+}
+
+// CreateはIDを採番してユーザーを保存します。同じEmailが存在する場合はdomain.ErrEmailAlreadyExistsを返します。
+func (r *UserRepo) Create(ctx context.Context, u *domain.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == u.Email {
+			return domain.ErrEmailAlreadyExists
+		}
+	}
+	now := time.Now()
+	u.ID, u.CreatedAt, u.UpdatedAt = r.nextID, now, now
+	r.nextID++
+	saveForRollback(ctx, &r.mu, r.users, u.ID)
+	r.users[u.ID] = *u
+	return nil
+}
+
+// FindByEmailはEmailが一致するユーザーを返します。
+func (r *UserRepo) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+// FindByIDはIDが一致するユーザーを返します。
+func (r *UserRepo) FindByID(ctx context.Context, id uint) (*domain.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return &u, nil
+}
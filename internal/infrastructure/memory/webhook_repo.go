@@ -0,0 +1,209 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+)
+
+// WebhookRepoはWebhookと配信ログをメモリ上に保持するrepository.WebhookRepositoryの実装です。
+// 複数のgoroutineから安全に利用できます。
+type WebhookRepo struct {
+	mu             sync.RWMutex
+	webhooks       map[uint]domain.Webhook
+	deliveries     map[uint]domain.WebhookDelivery
+	nextID         uint
+	nextDeliveryID uint
+}
+
+// コンパイル時に WebhookRepo が repository.WebhookRepository を実装しているか確認します。
+var _ repository.WebhookRepository = (*WebhookRepo)(nil)
+
+// NewWebhookRepoは空のWebhookRepoを返します。
+func NewWebhookRepo() *WebhookRepo {
+	return &WebhookRepo{
+		webhooks:       make(map[uint]domain.Webhook),
+		deliveries:     make(map[uint]domain.WebhookDelivery),
+		nextID:         1,
+		nextDeliveryID: 1,
+	}
+}
+
+// CreateはIDを採番してWebhookを保存します。
+func (r *WebhookRepo) Create(ctx context.Context, w *domain.Webhook) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w.ID, w.CreatedAt = r.nextID, time.Now()
+	r.nextID++
+	stored := *w
+	stored.Events = slices.Clone(w.Events)
+	saveForRollback(ctx, &r.mu, r.webhooks, w.ID)
+	r.webhooks[w.ID] = stored
+	return nil
+}
+
+// FindByUserは指定ユーザーのWebhookをID順に返します。
+func (r *WebhookRepo) FindByUser(ctx context.Context, userID uint) ([]domain.Webhook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var webhooks []domain.Webhook
+	for _, id := range slices.Sorted(maps.Keys(r.webhooks)) {
+		if w := r.webhooks[id]; w.UserID == userID {
+			w.Events = slices.Clone(w.Events)
+			webhooks = append(webhooks, w)
+		}
+	}
+	return webhooks, nil
+}
+
+// FindByIDは指定ユーザーが所有するIDのWebhookを返します。
+func (r *WebhookRepo) FindByID(ctx context.Context, userID, id uint) (domain.Webhook, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Webhook{}, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	w, ok := r.webhooks[id]
+	if !ok || w.UserID != userID {
+		return domain.Webhook{}, domain.ErrWebhookNotFound
+	}
+	w.Events = slices.Clone(w.Events)
+	return w, nil
+}
+
+// Deleteは指定ユーザーが所有するIDのWebhookと、その配信ログを削除します。
+func (r *WebhookRepo) Delete(ctx context.Context, userID, id uint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if w, ok := r.webhooks[id]; !ok || w.UserID != userID {
+		return domain.ErrWebhookNotFound
+	}
+	saveForRollback(ctx, &r.mu, r.webhooks, id)
+	delete(r.webhooks, id)
+	for deliveryID, d := range r.deliveries {
+		if d.WebhookID == id {
+			saveForRollback(ctx, &r.mu, r.deliveries, deliveryID)
+			delete(r.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+// CreateDeliveryはIDを採番して配信を保存します。同じイベントの配信が既にある場合は保存しません。
+func (r *WebhookRepo) CreateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if d.EventID != nil {
+		for _, existing := range r.deliveries {
+			if existing.WebhookID == d.WebhookID && existing.EventID != nil && *existing.EventID == *d.EventID {
+				return nil
+			}
+		}
+	}
+	d.ID, d.CreatedAt = r.nextDeliveryID, time.Now()
+	r.nextDeliveryID++
+	saveForRollback(ctx, &r.mu, r.deliveries, d.ID)
+	r.deliveries[d.ID] = *d
+	return nil
+}
+
+// FindDeliveriesは指定ユーザーのWebhookの配信を新しい順に最大limit件返します。
+func (r *WebhookRepo) FindDeliveries(ctx context.Context, userID, webhookID uint, limit int) ([]domain.WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var deliveries []domain.WebhookDelivery
+	for _, d := range r.deliveries {
+		if d.WebhookID == webhookID && d.UserID == userID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	slices.SortFunc(deliveries, func(a, b domain.WebhookDelivery) int { return cmp.Compare(b.ID, a.ID) })
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// FindDeliveryは指定ユーザーのWebhookのIDの配信を返します。
+func (r *WebhookRepo) FindDelivery(ctx context.Context, userID, webhookID, id uint) (domain.WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, ok := r.deliveries[id]
+	if !ok || d.WebhookID != webhookID || d.UserID != userID {
+		return domain.WebhookDelivery{}, domain.ErrWebhookDeliveryNotFound
+	}
+	return d, nil
+}
+
+// DueDeliveriesは送信時刻を迎えた配信待ちの配信を、excludeのWebhookのものを除いてNextAttemptAtの古い順に最大limit件返します。
+func (r *WebhookRepo) DueDeliveries(ctx context.Context, now time.Time, limit int, exclude []uint) ([]domain.WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var deliveries []domain.WebhookDelivery
+	for _, d := range r.deliveries {
+		if d.Status == domain.WebhookDeliveryPending && !d.NextAttemptAt.After(now) && !slices.Contains(exclude, d.WebhookID) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	slices.SortFunc(deliveries, func(a, b domain.WebhookDelivery) int {
+		return cmp.Or(a.NextAttemptAt.Compare(b.NextAttemptAt), cmp.Compare(a.ID, b.ID))
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// UpdateDeliveryは配信の送信結果を保存します。
+func (r *WebhookRepo) UpdateDelivery(ctx context.Context, d domain.WebhookDelivery) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.deliveries[d.ID]
+	if !ok {
+		return domain.ErrWebhookDeliveryNotFound
+	}
+	current.Status, current.Attempts, current.NextAttemptAt = d.Status, d.Attempts, d.NextAttemptAt
+	current.LastAttemptAt, current.ResponseStatus, current.Error = d.LastAttemptAt, d.ResponseStatus, d.Error
+	saveForRollback(ctx, &r.mu, r.deliveries, d.ID)
+	r.deliveries[d.ID] = current
+	return nil
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespaceは全メトリクス名の接頭辞です（例: todo_http_requests_total）。
+const namespace = "todo"
+
+// unmatchedRouteはどのルートにも一致しなかったリクエストのrouteラベル値です。
+// 生のパスをラベルにするとカーディナリティが爆発するため、まとめて扱います。
+const unmatchedRoute = "unmatched"
+
+// MetricsはアプリケーションのPrometheusメトリクスを保持する構造体です。
+// レジストリは外部から注入されるため、テストでは独立したレジストリを使えます。
+// usecase.AuthMetrics / usecase.TodoMetrics インターフェースを実装します。
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	logins       *prometheus.CounterVec
+	todoCreated  prometheus.Counter
+	todoComplete prometheus.Counter
+}
+
+// Newは指定されたレジストリにメトリクスを登録したMetricsを返します。
+// 同じレジストリに2回登録するとpanicするため、レジストリごとに1回だけ呼び出してください。
+func New(reg *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		registry: reg,
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Total number of HTTP requests by method, route template and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route template and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "auth_logins_total",
+			Help:      "Login attempts by result (success / failure).",
+		}, []string{"result"}),
+		todoCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "todos_created_total",
+			Help:      "Number of todos created.",
+		}),
+		todoComplete: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "todos_completed_total",
+			Help:      "Number of todos marked as completed.",
+		}),
+	}
+	reg.MustRegister(m.httpRequests, m.httpDuration, m.logins, m.todoCreated, m.todoComplete)
+	return m
+}
+
+// RegisterDBはデータベース接続プールの統計（open/in-use/idle接続数、待ち時間など）を登録します。
+// gorm.DBの場合はdb.DB()で取得した*sql.DBを渡してください。
+func (m *Metrics) RegisterDB(db *sql.DB, dbName string) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// HandlerはPrometheusのスクレイプ用エンドポイント（/metrics）のハンドラを返します。
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Middlewareはリクエスト数とレイテンシを計測するGinミドルウェアを返します。
+// routeラベルには実際のパスではなくルートテンプレート（例: /todos/:id）を使います。
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		m.httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		m.httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// LoginSucceededはログイン成功を記録します。
+func (m *Metrics) LoginSucceeded() { m.logins.WithLabelValues("success").Inc() }
+
+// LoginFailedはログイン失敗を記録します。
+func (m *Metrics) LoginFailed() { m.logins.WithLabelValues("failure").Inc() }
+
+// TodoCreatedはTodoの作成を記録します。
+func (m *Metrics) TodoCreated() { m.todoCreated.Inc() }
+
+// TodoCompletedはTodoの完了を記録します。
+func (m *Metrics) TodoCompleted() { m.todoComplete.Inc() }
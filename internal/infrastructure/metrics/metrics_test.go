@@ -0,0 +1,70 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todo_backend/internal/infrastructure/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware_LabelsByRouteTemplateAndStatus(t *testing.T) {
+	// given
+	gin.SetMode(gin.TestMode)
+	reg := prometheus.NewRegistry()
+	m := metrics.New(reg)
+	r := gin.New()
+	r.Use(m.Middleware())
+	r.GET("/todos/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/metrics", gin.WrapH(m.Handler()))
+
+	// when
+	for _, path := range []string{"/todos/1", "/todos/2", "/nope"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// then
+	require.Equal(t, http.StatusOK, w.Code)
+	body, _ := io.ReadAll(w.Body)
+	assert.Contains(t, string(body), `todo_http_requests_total{method="GET",route="/todos/:id",status="200"} 2`)
+	assert.Contains(t, string(body), `todo_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, string(body), `todo_http_request_duration_seconds_count{method="GET",route="/todos/:id",status="200"} 2`)
+	assert.NotContains(t, string(body), `route="/todos/1"`)
+}
+
+func TestUsecaseCounters(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := metrics.New(reg)
+
+	m.LoginSucceeded()
+	m.LoginFailed()
+	m.LoginFailed()
+	m.TodoCreated()
+	m.TodoCompleted()
+
+	const expected = `
+# HELP todo_auth_logins_total Login attempts by result (success / failure).
+# TYPE todo_auth_logins_total counter
+todo_auth_logins_total{result="failure"} 2
+todo_auth_logins_total{result="success"} 1
+# HELP todo_todos_completed_total Number of todos marked as completed.
+# TYPE todo_todos_completed_total counter
+todo_todos_completed_total 1
+# HELP todo_todos_created_total Number of todos created.
+# TYPE todo_todos_created_total counter
+todo_todos_created_total 1
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"todo_auth_logins_total", "todo_todos_created_total", "todo_todos_completed_total")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,24 @@
+package mysql_test
+
+import (
+	"testing"
+
+	"todo_backend/internal/infrastructure/mysql"
+	"todo_backend/internal/interface/repository"
+	"todo_backend/internal/interface/repository/repositorytest"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) (repository.Repositories, repository.Transactor) {
+		db := newTestDB(t)
+		repos := repository.Repositories{
+			Todos:         mysql.NewTodoMysql(db),
+			Users:         mysql.NewUserMySQL(db),
+			Webhooks:      mysql.NewWebhookMysql(db),
+			Jobs:          mysql.NewJobMysql(db),
+			Reminders:     mysql.NewReminderMysql(db),
+			Notifications: mysql.NewNotificationMysql(db),
+		}
+		return repos, mysql.NewTransactor(db)
+	})
+}
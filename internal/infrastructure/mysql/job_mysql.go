@@ -0,0 +1,180 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+
+	"gorm.io/gorm"
+)
+
+// JobMysqlはJobRepositoryインターフェースのGORMの実装です。
+type JobMysql struct {
+	DB *gorm.DB
+}
+
+// コンパイル時に JobMysql が JobRepository を実装しているか確認します。
+var _ repository.JobRepository = (*JobMysql)(nil)
+
+// NewJobMysqlは、指定されたgorm.DB接続を使用するJobMysqlを返します。
+func NewJobMysql(db *gorm.DB) *JobMysql {
+	return &JobMysql{DB: db}
+}
+
+// Enqueueはジョブをデータベースに追加します。
+func (r *JobMysql) Enqueue(ctx context.Context, job *domain.Job) (err error) {
+	ctx, span := startSpan(ctx, "JobMysql.Enqueue")
+	defer func() { endSpan(span, err) }()
+
+	return r.DB.WithContext(ctx).Create(job).Error
+}
+
+// Claimは実行するジョブを取り出して実行中にします。
+// 候補を読み取った後、状態と実行回数が読み取り時から変わっていない場合のみ更新することで、
+// 複数のワーカー（複数のプロセスを含む）が同じジョブを取り出さないようにします。
+// 実行回数の上限に達したまま実行期限を過ぎたジョブは、先にデッドレターにして候補から外します。
+func (r *JobMysql) Claim(ctx context.Context, now time.Time, lease time.Duration, maxAttempts, limit int) (claimed []domain.Job, err error) {
+	ctx, span := startSpan(ctx, "JobMysql.Claim")
+	defer func() { endSpan(span, err) }()
+
+	err = r.DB.WithContext(ctx).Model(&domain.Job{}).
+		Where("status = ? AND locked_until <= ? AND attempts >= ?", domain.JobRunning, now, maxAttempts).
+		Updates(map[string]any{
+			"status":       domain.JobDead,
+			"locked_until": nil,
+			"last_error":   domain.ErrJobLeaseExpired.Error(),
+			"finished_at":  now,
+			"updated_at":   now,
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+	var candidates []domain.Job
+	err = r.DB.WithContext(ctx).
+		Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until <= ?)", domain.JobPending, now, domain.JobRunning, now).
+		Order("run_at, id").Limit(limit).Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+	lockedUntil := now.Add(lease)
+	for _, job := range candidates {
+		res := r.DB.WithContext(ctx).Model(&domain.Job{}).
+			Where("id = ? AND status = ? AND attempts = ?", job.ID, job.Status, job.Attempts).
+			Updates(map[string]any{
+				"status":       domain.JobRunning,
+				"attempts":     job.Attempts + 1,
+				"locked_until": lockedUntil,
+				"updated_at":   now,
+			})
+		if res.Error != nil {
+			return claimed, res.Error
+		}
+		if res.RowsAffected == 0 {
+			// 他のワーカーが先に取り出した
+			continue
+		}
+		job.Status = domain.JobRunning
+		job.Attempts++
+		job.LockedUntil = &lockedUntil
+		job.UpdatedAt = now
+		claimed = append(claimed, job)
+	}
+	return claimed, nil
+}
+
+// Updateはジョブの実行結果を保存します。
+// 取り出した後に他のワーカーが取り出し直していないことを、状態と実行回数を条件にして確認します。
+func (r *JobMysql) Update(ctx context.Context, job domain.Job) (err error) {
+	ctx, span := startSpan(ctx, "JobMysql.Update")
+	defer func() { endSpan(span, err) }()
+
+	res := r.DB.WithContext(ctx).Model(&domain.Job{}).
+		Where("id = ? AND status = ? AND attempts = ?", job.ID, domain.JobRunning, job.Attempts).
+		Updates(map[string]any{
+			"status":       job.Status,
+			"attempts":     job.Attempts,
+			"run_at":       job.RunAt,
+			"locked_until": job.LockedUntil,
+			"last_error":   job.LastError,
+			"finished_at":  job.FinishedAt,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return r.missing(ctx, job.ID, domain.ErrJobLeaseLost)
+	}
+	return nil
+}
+
+// Requeueは実行を諦めたジョブを実行待ちに戻します。
+func (r *JobMysql) Requeue(ctx context.Context, id uint, runAt time.Time) (_ domain.Job, err error) {
+	ctx, span := startSpan(ctx, "JobMysql.Requeue")
+	defer func() { endSpan(span, err) }()
+
+	res := r.DB.WithContext(ctx).Model(&domain.Job{}).
+		Where("id = ? AND status = ?", id, domain.JobDead).
+		Updates(map[string]any{
+			"status":      domain.JobPending,
+			"attempts":    0,
+			"run_at":      runAt,
+			"finished_at": nil,
+			"updated_at":  time.Now(),
+		})
+	if res.Error != nil {
+		return domain.Job{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.Job{}, r.missing(ctx, id, domain.ErrJobNotDead)
+	}
+	return r.FindByID(ctx, id)
+}
+
+// missingは条件付きの更新で対象がなかった理由を返します。
+// ジョブが存在しない場合はdomain.ErrJobNotFound、存在する場合は状態が条件と異なるためstaleを返します。
+func (r *JobMysql) missing(ctx context.Context, id uint, stale error) error {
+	var n int64
+	if err := r.DB.WithContext(ctx).Model(&domain.Job{}).Where("id = ?", id).Count(&n).Error; err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrJobNotFound
+	}
+	return stale
+}
+
+// FindByIDはIDのジョブを返します。
+func (r *JobMysql) FindByID(ctx context.Context, id uint) (_ domain.Job, err error) {
+	ctx, span := startSpan(ctx, "JobMysql.FindByID")
+	defer func() { endSpan(span, err) }()
+
+	var job domain.Job
+	if err = r.DB.WithContext(ctx).First(&job, id).Error; err != nil {
+		return domain.Job{}, notFoundAs(err, domain.ErrJobNotFound)
+	}
+	return job, nil
+}
+
+// Findは状態がstatusのジョブを新しい順に返します。
+func (r *JobMysql) Find(ctx context.Context, status domain.JobStatus, limit int) (jobs []domain.Job, err error) {
+	ctx, span := startSpan(ctx, "JobMysql.Find")
+	defer func() { endSpan(span, err) }()
+
+	q := r.DB.WithContext(ctx).Order("id DESC").Limit(limit)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	err = q.Find(&jobs).Error
+	return jobs, err
+}
+
+// DeleteSucceededは完了日時がbeforeより前の完了したジョブを削除します。
+func (r *JobMysql) DeleteSucceeded(ctx context.Context, before time.Time) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "JobMysql.DeleteSucceeded")
+	defer func() { endSpan(span, err) }()
+
+	res := r.DB.WithContext(ctx).Where("status = ? AND finished_at < ?", domain.JobSucceeded, before).Delete(&domain.Job{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,105 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+
+	"gorm.io/gorm"
+)
+
+// NotificationMysqlはNotificationRepositoryインターフェースのGORMの実装です。
+type NotificationMysql struct {
+	DB *gorm.DB
+}
+
+// コンパイル時に NotificationMysql が NotificationRepository を実装しているか確認します。
+var _ repository.NotificationRepository = (*NotificationMysql)(nil)
+
+// NewNotificationMysqlは、指定されたgorm.DB接続を使用するNotificationMysqlを返します。
+func NewNotificationMysql(db *gorm.DB) *NotificationMysql {
+	return &NotificationMysql{DB: db}
+}
+
+// Createは通知をデータベースに追加します。
+func (r *NotificationMysql) Create(ctx context.Context, notification *domain.Notification) (err error) {
+	ctx, span := startSpan(ctx, "NotificationMysql.Create")
+	defer func() { endSpan(span, err) }()
+
+	return r.DB.WithContext(ctx).Create(notification).Error
+}
+
+// FindByUserはユーザー宛ての通知を新しい順に返します。
+func (r *NotificationMysql) FindByUser(ctx context.Context, userID uint, unreadOnly bool, limit int) (notifications []domain.Notification, err error) {
+	ctx, span := startSpan(ctx, "NotificationMysql.FindByUser")
+	defer func() { endSpan(span, err) }()
+
+	q := r.DB.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Limit(limit)
+	if unreadOnly {
+		q = q.Where("read_at IS NULL")
+	}
+	err = q.Find(&notifications).Error
+	return notifications, err
+}
+
+// MarkReadは通知を既読にして、更新後の通知を返します。
+func (r *NotificationMysql) MarkRead(ctx context.Context, userID, id uint, at time.Time) (_ domain.Notification, err error) {
+	ctx, span := startSpan(ctx, "NotificationMysql.MarkRead")
+	defer func() { endSpan(span, err) }()
+
+	var notification domain.Notification
+	err = r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+			return notFoundAs(err, domain.ErrNotificationNotFound)
+		}
+		if notification.ReadAt != nil {
+			return nil
+		}
+		notification.ReadAt = &at
+		return tx.Model(&domain.Notification{}).Where("id = ?", id).Update("read_at", at).Error
+	})
+	if err != nil {
+		return domain.Notification{}, err
+	}
+	return notification, nil
+}
+
+// MarkAllReadはユーザー宛ての未読の通知をすべて既読にして、既読にした件数を返します。
+func (r *NotificationMysql) MarkAllRead(ctx context.Context, userID uint, at time.Time) (_ int, err error) {
+	ctx, span := startSpan(ctx, "NotificationMysql.MarkAllRead")
+	defer func() { endSpan(span, err) }()
+
+	res := r.DB.WithContext(ctx).Model(&domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", at)
+	return int(res.RowsAffected), res.Error
+}
+
+// Deleteは通知を削除します。
+func (r *NotificationMysql) Delete(ctx context.Context, userID, id uint) (err error) {
+	ctx, span := startSpan(ctx, "NotificationMysql.Delete")
+	defer func() { endSpan(span, err) }()
+
+	res := r.DB.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&domain.Notification{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrNotificationNotFound
+	}
+	return nil
+}
+
+// CountUnreadはユーザー宛ての未読の通知の件数を返します。
+func (r *NotificationMysql) CountUnread(ctx context.Context, userID uint) (_ int, err error) {
+	ctx, span := startSpan(ctx, "NotificationMysql.CountUnread")
+	defer func() { endSpan(span, err) }()
+
+	var count int64
+	err = r.DB.WithContext(ctx).Model(&domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return int(count), err
+}
@@ -0,0 +1,116 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+
+	"gorm.io/gorm"
+)
+
+// ReminderMysqlはReminderRepositoryインターフェースのGORMの実装です。
+type ReminderMysql struct {
+	DB *gorm.DB
+}
+
+// コンパイル時に ReminderMysql が ReminderRepository を実装しているか確認します。
+var _ repository.ReminderRepository = (*ReminderMysql)(nil)
+
+// NewReminderMysqlは、指定されたgorm.DB接続を使用するReminderMysqlを返します。
+func NewReminderMysql(db *gorm.DB) *ReminderMysql {
+	return &ReminderMysql{DB: db}
+}
+
+// Createはリマインダーをデータベースに追加します。
+func (r *ReminderMysql) Create(ctx context.Context, reminder *domain.Reminder) (err error) {
+	ctx, span := startSpan(ctx, "ReminderMysql.Create")
+	defer func() { endSpan(span, err) }()
+
+	return r.DB.WithContext(ctx).Create(reminder).Error
+}
+
+// FindByTodoはTodoのリマインダーを作成順に返します。
+func (r *ReminderMysql) FindByTodo(ctx context.Context, userID, todoID uint) (reminders []domain.Reminder, err error) {
+	ctx, span := startSpan(ctx, "ReminderMysql.FindByTodo")
+	defer func() { endSpan(span, err) }()
+
+	err = r.DB.WithContext(ctx).Where("user_id = ? AND todo_id = ?", userID, todoID).Order("id").Find(&reminders).Error
+	return reminders, err
+}
+
+// Deleteはリマインダーを削除します。
+func (r *ReminderMysql) Delete(ctx context.Context, userID, todoID, id uint) (err error) {
+	ctx, span := startSpan(ctx, "ReminderMysql.Delete")
+	defer func() { endSpan(span, err) }()
+
+	res := r.DB.WithContext(ctx).Where("id = ? AND user_id = ? AND todo_id = ?", id, userID, todoID).Delete(&domain.Reminder{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrReminderNotFound
+	}
+	return nil
+}
+
+// DeleteByTodoはTodoのリマインダーをすべて削除します。
+func (r *ReminderMysql) DeleteByTodo(ctx context.Context, userID, todoID uint) (err error) {
+	ctx, span := startSpan(ctx, "ReminderMysql.DeleteByTodo")
+	defer func() { endSpan(span, err) }()
+
+	return r.DB.WithContext(ctx).Where("user_id = ? AND todo_id = ?", userID, todoID).Delete(&domain.Reminder{}).Error
+}
+
+// Rescheduleはリマインダーの通知する日時を変更します。
+func (r *ReminderMysql) Reschedule(ctx context.Context, id uint, fireAt *time.Time, rearm bool) (err error) {
+	ctx, span := startSpan(ctx, "ReminderMysql.Reschedule")
+	defer func() { endSpan(span, err) }()
+
+	q := r.DB.WithContext(ctx).Model(&domain.Reminder{}).Where("id = ?", id)
+	values := map[string]any{"fire_at": fireAt}
+	if rearm {
+		values["fired_at"] = nil
+	} else {
+		q = q.Where("fired_at IS NULL")
+	}
+	if err = q.Updates(values).Error; err != nil {
+		return err
+	}
+	// 通知済みのため更新しなかった場合と存在しない場合を区別する
+	var count int64
+	if err = r.DB.WithContext(ctx).Model(&domain.Reminder{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return domain.ErrReminderNotFound
+	}
+	return nil
+}
+
+// Dueは通知する日時を過ぎた未通知のリマインダーを返します。
+func (r *ReminderMysql) Due(ctx context.Context, now time.Time, limit int) (reminders []domain.Reminder, err error) {
+	ctx, span := startSpan(ctx, "ReminderMysql.Due")
+	defer func() { endSpan(span, err) }()
+
+	err = r.DB.WithContext(ctx).
+		Where("fired_at IS NULL AND fire_at IS NOT NULL AND fire_at <= ?", now).
+		Order("fire_at, id").Limit(limit).Find(&reminders).Error
+	return reminders, err
+}
+
+// MarkFiredはリマインダーを通知済みにします。
+// 条件付きのUPDATEにより、複数のスケジューラが同時に処理しても通知済みにできるのは1つだけです。
+func (r *ReminderMysql) MarkFired(ctx context.Context, id uint, now time.Time) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "ReminderMysql.MarkFired")
+	defer func() { endSpan(span, err) }()
+
+	res := r.DB.WithContext(ctx).Model(&domain.Reminder{}).
+		Where("id = ? AND fired_at IS NULL AND fire_at IS NOT NULL AND fire_at <= ?", id, now).
+		Update("fired_at", now)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
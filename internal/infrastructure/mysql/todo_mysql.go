@@ -1,10 +1,15 @@
 package mysql
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"todo_backend/internal/domain"
 	"todo_backend/internal/interface/repository"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // TodoMysqlはGORMを利用してMySQLデータベース上で
@@ -25,29 +30,217 @@ func NewTodoMysql(db *gorm.DB) *TodoMysql {
 }
 
 // FindByUser は、データベースから特定の user ID の Todo を取得します。
-func (r *TodoMysql) FindByUser(userID uint) ([]domain.Todo, error) {
-	var todos []domain.Todo
-	err := r.DB.Where("user_id = ?", userID).Find(&todos).Error
+func (r *TodoMysql) FindByUser(ctx context.Context, userID uint) (todos []domain.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoMysql.FindByUser")
+	defer func() { endSpan(span, err) }()
+
+	err = r.DB.WithContext(ctx).Where("user_id = ?", userID).Find(&todos).Error
 	return todos, err
 }
 
-// Create は、指定されたTodoをデータベースに新規登録します。
-func (r *TodoMysql) Create(todo domain.Todo) error {
-	return r.DB.Create(&todo).Error
+// FindByID は、指定ユーザーが所有するIDのTodoを取得します。
+func (r *TodoMysql) FindByID(ctx context.Context, userID uint, id uint) (todo domain.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoMysql.FindByID")
+	defer func() { endSpan(span, err) }()
+
+	err = r.DB.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&todo).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Todo{}, domain.ErrTodoNotFound
+	}
+	return todo, err
+}
+
+// Create は、指定されたTodoをデータベースに新規登録し、採番されたIDを含むTodoを返します。
+// バージョンは1から始まります。
+func (r *TodoMysql) Create(ctx context.Context, todo domain.Todo) (_ domain.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoMysql.Create")
+	defer func() { endSpan(span, err) }()
+
+	todo.ID = 0
+	todo.Version = 1
+	err = r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&todo).Error; err != nil {
+			return err
+		}
+		return recordChange(tx, todo.UserID, todo.ID, false)
+	})
+	if err != nil {
+		return domain.Todo{}, err
+	}
+	return todo, nil
 }
 
 // Update は、指定されたTodoの情報をデータベース上で更新します。
-func (r *TodoMysql) Update(todo domain.Todo) error {
-	return r.DB.Model(&domain.Todo{}).
-		Where("id = ? AND user_id = ?", todo.ID, todo.UserID).
-		Updates(map[string]any{
-			"title":     todo.Title,
-			"completed": todo.Completed,
-		}).Error
+// バージョンが一致する行のみを更新し、同時にバージョンを1つ進めます（楽観的排他制御）。
+func (r *TodoMysql) Update(ctx context.Context, todo domain.Todo) (err error) {
+	ctx, span := startSpan(ctx, "TodoMysql.Update")
+	defer func() { endSpan(span, err) }()
+
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&domain.Todo{}).
+			Where("id = ? AND user_id = ? AND version = ?", todo.ID, todo.UserID, todo.Version).
+			Updates(map[string]any{
+				"title":     todo.Title,
+				"completed": todo.Completed,
+				"due_at":    todo.DueAt,
+				"version":   gorm.Expr("version + 1"),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return notFoundOrMismatch(tx, todo.UserID, todo.ID)
+		}
+		return recordChange(tx, todo.UserID, todo.ID, false)
+	})
 }
 
 // Delete は、指定されたIDのTodoをデータベースから削除します。
-func (r *TodoMysql) Delete(userID uint, id int) error {
-	return r.DB.Where("id = ? AND user_id = ?", id, userID).
-		Delete(&domain.Todo{}).Error
+// バージョンが一致しない場合は削除しません。
+func (r *TodoMysql) Delete(ctx context.Context, userID, id, version uint) (err error) {
+	ctx, span := startSpan(ctx, "TodoMysql.Delete")
+	defer func() { endSpan(span, err) }()
+
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND user_id = ? AND version = ?", id, userID, version).Delete(&domain.Todo{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return notFoundOrMismatch(tx, userID, id)
+		}
+		return recordChange(tx, userID, id, true)
+	})
+}
+
+// Changes は、指定ユーザーのTodoの変更履歴のうちseqがsinceより大きいものを、変更後のTodoとともに返します。
+func (r *TodoMysql) Changes(ctx context.Context, userID uint, since uint64, limit int) (changes []domain.TodoChange, err error) {
+	ctx, span := startSpan(ctx, "TodoMysql.Changes")
+	defer func() { endSpan(span, err) }()
+
+	db := r.DB.WithContext(ctx)
+	err = db.Where("user_id = ? AND seq > ?", userID, since).Order("seq").Limit(limit).Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint
+	for _, c := range changes {
+		if !c.Deleted {
+			ids = append(ids, c.TodoID)
+		}
+	}
+	var todos []domain.Todo
+	if len(ids) > 0 {
+		if err = db.Where("id IN ? AND user_id = ?", ids, userID).Find(&todos).Error; err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[uint]domain.Todo, len(todos))
+	for _, t := range todos {
+		byID[t.ID] = t
+	}
+	for i, c := range changes {
+		changes[i].Todo = domain.Todo{ID: c.TodoID, UserID: c.UserID}
+		if t, ok := byID[c.TodoID]; ok {
+			changes[i].Todo = t
+		}
+	}
+	return changes, nil
+}
+
+// LastChangeSeq は、指定ユーザーのTodoの最新の変更のseqを返します。
+func (r *TodoMysql) LastChangeSeq(ctx context.Context, userID uint) (seq uint64, err error) {
+	ctx, span := startSpan(ctx, "TodoMysql.LastChangeSeq")
+	defer func() { endSpan(span, err) }()
+
+	err = r.DB.WithContext(ctx).Model(&domain.TodoChange{}).
+		Where("user_id = ?", userID).Select("COALESCE(MAX(seq), 0)").Scan(&seq).Error
+	return seq, err
+}
+
+// PrunedChangeSeq は、指定ユーザーの削除済みの墓標のseqの最大値を返します。
+func (r *TodoMysql) PrunedChangeSeq(ctx context.Context, userID uint) (seq uint64, err error) {
+	ctx, span := startSpan(ctx, "TodoMysql.PrunedChangeSeq")
+	defer func() { endSpan(span, err) }()
+
+	err = r.DB.WithContext(ctx).Model(&domain.TodoChangeLog{}).
+		Where("user_id = ?", userID).Select("COALESCE(MAX(pruned_seq), 0)").Scan(&seq).Error
+	return seq, err
+}
+
+// PruneTombstones は、beforeより前に記録された削除の墓標をユーザーごとのトランザクションで削除し、
+// 削除した墓標のseqの最大値をPrunedSeqとして記録します。
+// ChangedAtのない（記録日時を保存する前の）墓標も削除します。
+func (r *TodoMysql) PruneTombstones(ctx context.Context, before time.Time) (n int64, err error) {
+	ctx, span := startSpan(ctx, "TodoMysql.PruneTombstones")
+	defer func() { endSpan(span, err) }()
+
+	db := r.DB.WithContext(ctx)
+	expired := "deleted = ? AND (changed_at < ? OR changed_at IS NULL)"
+	var users []struct {
+		UserID uint
+		Seq    uint64
+	}
+	err = db.Model(&domain.TodoChange{}).Select("user_id, MAX(seq) AS seq").
+		Where(expired, true, before).Group("user_id").Scan(&users).Error
+	if err != nil {
+		return 0, err
+	}
+	for _, u := range users {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := lockChangeLog(tx, u.UserID); err != nil {
+				return err
+			}
+			err := tx.Model(&domain.TodoChangeLog{}).
+				Where("user_id = ? AND pruned_seq < ?", u.UserID, u.Seq).Update("pruned_seq", u.Seq).Error
+			if err != nil {
+				return err
+			}
+			res := tx.Where("user_id = ? AND seq <= ? AND "+expired, u.UserID, u.Seq, true, before).Delete(&domain.TodoChange{})
+			n += res.RowsAffected
+			return res.Error
+		})
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// recordChange は、Todoの変更履歴を新しいseqで記録し直します（Todoごとに最新の変更のみを残す）。
+// Todoの書き込みと同じトランザクションtxで呼び出してください。
+// seqは行の挿入時に採番されコミット時ではないため、先にユーザーの変更履歴の管理行をロックして
+// 同じユーザーの書き込みをコミットまで直列化します（後からコミットされた小さいseqの変更を、
+// 差分同期のクライアントが取りこぼさないように）。
+func recordChange(tx *gorm.DB, userID, todoID uint, deleted bool) error {
+	if err := lockChangeLog(tx, userID); err != nil {
+		return err
+	}
+	if err := tx.Where("todo_id = ?", todoID).Delete(&domain.TodoChange{}).Error; err != nil {
+		return err
+	}
+	return tx.Create(&domain.TodoChange{TodoID: todoID, UserID: userID, Deleted: deleted, ChangedAt: time.Now()}).Error
+}
+
+// lockChangeLog は、ユーザーの変更履歴の管理行を作成または更新し、トランザクションの終了まで行ロックを保持します。
+func lockChangeLog(tx *gorm.DB, userID uint) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]any{"writes": gorm.Expr("writes + 1")}),
+	}).Create(&domain.TodoChangeLog{UserID: userID, Writes: 1}).Error
+}
+
+// notFoundOrMismatch は、条件付き更新・削除が0件だった理由を判定します。
+// Todoが存在すればバージョン不一致、存在しなければ未検出です。
+func notFoundOrMismatch(db *gorm.DB, userID uint, id uint) error {
+	var n int64
+	if err := db.Model(&domain.Todo{}).
+		Where("id = ? AND user_id = ?", id, userID).Count(&n).Error; err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrTodoNotFound
+	}
+	return domain.ErrVersionMismatch
 }
@@ -0,0 +1,121 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/mysql"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// :memory:は接続ごとに別のDBになるため1接続に固定する
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&domain.User{}, &domain.Todo{}, &domain.TodoChange{}, &domain.TodoChangeLog{}, &domain.Webhook{}, &domain.WebhookDelivery{}, &domain.Job{}, &domain.Reminder{}, &domain.Notification{}))
+	return db
+}
+
+func TestTodoMysql_HonorsCanceledContext(t *testing.T) {
+	// given
+	repo := mysql.NewTodoMysql(newTestDB(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	_, err := repo.FindByUser(ctx, 1)
+
+	// then
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestTodoMysql_HonorsDeadline(t *testing.T) {
+	repo := mysql.NewTodoMysql(newTestDB(t))
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := repo.Create(ctx, domain.Todo{UserID: 1, Title: "late"})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestTodoMysql_OptimisticConcurrency(t *testing.T) {
+	// given
+	ctx := context.Background()
+	repo := mysql.NewTodoMysql(newTestDB(t))
+	todo, err := repo.Create(ctx, domain.Todo{UserID: 1, Title: "a"})
+	require.NoError(t, err)
+	assert.NotZero(t, todo.ID)
+	assert.Equal(t, uint(1), todo.Version)
+
+	// when: 最新バージョンでの更新は成功し、バージョンが進む
+	todo.Title = "b"
+	require.NoError(t, repo.Update(ctx, todo))
+	got, err := repo.FindByID(ctx, 1, todo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "b", got.Title)
+	assert.Equal(t, uint(2), got.Version)
+
+	// then: 古いバージョンでの更新・削除は拒否される
+	todo.Title = "stale"
+	assert.ErrorIs(t, repo.Update(ctx, todo), domain.ErrVersionMismatch)
+	assert.ErrorIs(t, repo.Delete(ctx, 1, todo.ID, 1), domain.ErrVersionMismatch)
+
+	// 他ユーザーのTodoや存在しないTodoは未検出
+	_, err = repo.FindByID(ctx, 2, todo.ID)
+	assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+	assert.ErrorIs(t, repo.Update(ctx, domain.Todo{ID: 99, UserID: 1, Version: 1}), domain.ErrTodoNotFound)
+
+	require.NoError(t, repo.Delete(ctx, 1, todo.ID, 2))
+	assert.ErrorIs(t, repo.Delete(ctx, 1, todo.ID, 2), domain.ErrTodoNotFound)
+}
+
+func TestTodoMysql_LocksChangeLogPerWrite(t *testing.T) {
+	// given
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := mysql.NewTodoMysql(db)
+	todo, err := repo.Create(ctx, domain.Todo{UserID: 1, Title: "a"})
+	require.NoError(t, err)
+
+	// when
+	require.NoError(t, repo.Update(ctx, todo))
+	require.NoError(t, repo.Delete(ctx, 1, todo.ID, 2))
+
+	// then: 変更を記録するたびにユーザーの管理行を更新（ロック）する
+	var log domain.TodoChangeLog
+	require.NoError(t, db.First(&log, "user_id = ?", 1).Error)
+	assert.Equal(t, uint64(3), log.Writes)
+}
+
+func TestTodoMysql_PrunesTombstonesWithoutChangedAt(t *testing.T) {
+	// given: 記録日時を保存する前に記録された墓標
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := mysql.NewTodoMysql(db)
+	require.NoError(t, db.Exec("INSERT INTO todo_changes (seq, todo_id, user_id, deleted) VALUES (4, 10, 1, true)").Error)
+
+	// when
+	changes, err := repo.Changes(ctx, 1, 0, 10)
+	require.NoError(t, err)
+	n, err := repo.PruneTombstones(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+
+	// then
+	require.Len(t, changes, 1)
+	assert.True(t, changes[0].ChangedAt.IsZero())
+	assert.Equal(t, int64(1), n)
+	pruned, err := repo.PrunedChangeSeq(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), pruned)
+}
@@ -0,0 +1,43 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+
+	"todo_backend/internal/domain"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracerはリポジトリ層のスパンを作成するトレーサです。
+var tracer = otel.Tracer("todo_backend/internal/infrastructure/mysql")
+
+// startSpanはリポジトリメソッド用のスパンを開始します。
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name)
+}
+
+// endSpanはエラーを記録してスパンを終了します。
+// レコード未検出は正常系として扱い、エラーにはしません。
+func endSpan(span trace.Span, err error) {
+	if err != nil && !isNotFound(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// isNotFoundはレコード未検出を表すエラーかを判定します。
+func isNotFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound) ||
+		errors.Is(err, domain.ErrTodoNotFound) ||
+		errors.Is(err, domain.ErrUserNotFound) ||
+		errors.Is(err, domain.ErrWebhookNotFound) ||
+		errors.Is(err, domain.ErrWebhookDeliveryNotFound) ||
+		errors.Is(err, domain.ErrJobNotFound) ||
+		errors.Is(err, domain.ErrReminderNotFound) ||
+		errors.Is(err, domain.ErrNotificationNotFound)
+}
@@ -0,0 +1,47 @@
+package mysql
+
+import (
+	"context"
+
+	"todo_backend/internal/interface/repository"
+
+	"gorm.io/gorm"
+)
+
+// txKeyはcontextにトランザクション中の*gorm.DBを保持するためのキーです。
+type txKey struct{}
+
+// TransactorはGORMのトランザクションを使ったrepository.Transactorの実装です。
+type Transactor struct {
+	DB *gorm.DB
+}
+
+// コンパイル時に Transactor が repository.Transactor を実装しているか確認します。
+var _ repository.Transactor = (*Transactor)(nil)
+
+// NewTransactorは、指定されたgorm.DB接続を使用するTransactorを返します。
+func NewTransactor(db *gorm.DB) *Transactor {
+	return &Transactor{DB: db}
+}
+
+// WithinTransactionは、fnをトランザクション内で実行し、トランザクションに束縛したリポジトリを渡します。
+// ctxが既にトランザクション中の場合は、GORMの入れ子トランザクション（SAVEPOINT）を使います。
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context, repos repository.Repositories) error) (err error) {
+	ctx, span := startSpan(ctx, "Transactor.WithinTransaction")
+	defer func() { endSpan(span, err) }()
+
+	db := t.DB
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		db = tx
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx), repository.Repositories{
+			Todos:         NewTodoMysql(tx),
+			Users:         NewUserMySQL(tx),
+			Webhooks:      NewWebhookMysql(tx),
+			Jobs:          NewJobMysql(tx),
+			Reminders:     NewReminderMysql(tx),
+			Notifications: NewNotificationMysql(tx),
+		})
+	})
+}
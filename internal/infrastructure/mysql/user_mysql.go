@@ -1,6 +1,9 @@
 package mysql
 
 import (
+	"context"
+	"errors"
+
 	"todo_backend/internal/domain"
 	"todo_backend/internal/interface/repository"
 
@@ -23,26 +26,52 @@ func NewUserMySQL(db *gorm.DB) *userMySQL {
 }
 
 // CreateはユーザをDBに追加します。
-func (r *userMySQL) Create(u *domain.User) error {
-	return r.db.Create(u).Error
+// 同じEmailのユーザが既に存在する場合はdomain.ErrEmailAlreadyExistsを返します。
+func (r *userMySQL) Create(ctx context.Context, u *domain.User) (err error) {
+	ctx, span := startSpan(ctx, "userMySQL.Create")
+	defer func() { endSpan(span, err) }()
+
+	if err = r.db.WithContext(ctx).Create(u).Error; err != nil {
+		// 一意制約違反のエラーはドライバごとに異なるため、既存ユーザの有無で判定する
+		var n int64
+		if r.db.WithContext(ctx).Model(&domain.User{}).Where("email = ?", u.Email).Count(&n).Error == nil && n > 0 {
+			return domain.ErrEmailAlreadyExists
+		}
+		return err
+	}
+	return nil
 }
 
 // FindByEmailはEmailをキーにユーザを検索します。
-// 該当するユーザが存在しない場合はエラーを返します。
-func (r *userMySQL) FindByEmail(email string) (*domain.User, error) {
+// 該当するユーザが存在しない場合はdomain.ErrUserNotFoundを返します。
+func (r *userMySQL) FindByEmail(ctx context.Context, email string) (_ *domain.User, err error) {
+	ctx, span := startSpan(ctx, "userMySQL.FindByEmail")
+	defer func() { endSpan(span, err) }()
+
 	var u domain.User
-	if err := r.db.Where("email = ?", email).First(&u).Error; err != nil {
-		return nil, err
+	if err = r.db.WithContext(ctx).Where("email = ?", email).First(&u).Error; err != nil {
+		return nil, notFound(err)
 	}
 	return &u, nil
 }
 
 // FindByIDはIDをキーにユーザを検索します。
-// 該当するユーザが存在しない場合、エラーを返します。
-func (r *userMySQL) FindByID(id uint) (*domain.User, error) {
+// 該当するユーザが存在しない場合、domain.ErrUserNotFoundを返します。
+func (r *userMySQL) FindByID(ctx context.Context, id uint) (_ *domain.User, err error) {
+	ctx, span := startSpan(ctx, "userMySQL.FindByID")
+	defer func() { endSpan(span, err) }()
+
 	var u domain.User
-	if err := r.db.First(&u, id).Error; err != nil {
-		return nil, err
+	if err = r.db.WithContext(ctx).First(&u, id).Error; err != nil {
+		return nil, notFound(err)
 	}
 	return &u, nil
 }
+
+// notFoundはgorm.ErrRecordNotFoundをdomain.ErrUserNotFoundに変換します。
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrUserNotFound
+	}
+	return err
+}
@@ -0,0 +1,148 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookMysqlはWebhookRepositoryインターフェースのGORMの実装です。
+type WebhookMysql struct {
+	DB *gorm.DB
+}
+
+// コンパイル時に WebhookMysql が WebhookRepository を実装しているか確認します。
+var _ repository.WebhookRepository = (*WebhookMysql)(nil)
+
+// NewWebhookMysqlは、指定されたgorm.DB接続を使用するWebhookMysqlを返します。
+func NewWebhookMysql(db *gorm.DB) *WebhookMysql {
+	return &WebhookMysql{DB: db}
+}
+
+// CreateはWebhookをデータベースに追加します。
+func (r *WebhookMysql) Create(ctx context.Context, webhook *domain.Webhook) (err error) {
+	ctx, span := startSpan(ctx, "WebhookMysql.Create")
+	defer func() { endSpan(span, err) }()
+
+	return r.DB.WithContext(ctx).Create(webhook).Error
+}
+
+// FindByUserは指定ユーザーのWebhookをID順に返します。
+func (r *WebhookMysql) FindByUser(ctx context.Context, userID uint) (webhooks []domain.Webhook, err error) {
+	ctx, span := startSpan(ctx, "WebhookMysql.FindByUser")
+	defer func() { endSpan(span, err) }()
+
+	err = r.DB.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// FindByIDは指定ユーザーが所有するIDのWebhookを返します。
+func (r *WebhookMysql) FindByID(ctx context.Context, userID, id uint) (_ domain.Webhook, err error) {
+	ctx, span := startSpan(ctx, "WebhookMysql.FindByID")
+	defer func() { endSpan(span, err) }()
+
+	var w domain.Webhook
+	if err = r.DB.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&w).Error; err != nil {
+		return domain.Webhook{}, notFoundAs(err, domain.ErrWebhookNotFound)
+	}
+	return w, nil
+}
+
+// Deleteは指定ユーザーが所有するIDのWebhookと、その配信ログを削除します。
+func (r *WebhookMysql) Delete(ctx context.Context, userID, id uint) (err error) {
+	ctx, span := startSpan(ctx, "WebhookMysql.Delete")
+	defer func() { endSpan(span, err) }()
+
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&domain.Webhook{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return domain.ErrWebhookNotFound
+		}
+		return tx.Where("webhook_id = ?", id).Delete(&domain.WebhookDelivery{}).Error
+	})
+}
+
+// CreateDeliveryは配信をデータベースに追加します。
+// 同じイベントの配信が既にある場合は、一意制約の衝突時に何もしないINSERTで追加しません。
+func (r *WebhookMysql) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (err error) {
+	ctx, span := startSpan(ctx, "WebhookMysql.CreateDelivery")
+	defer func() { endSpan(span, err) }()
+
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(delivery).Error
+}
+
+// FindDeliveriesは指定ユーザーのWebhookの配信を新しい順に返します。
+func (r *WebhookMysql) FindDeliveries(ctx context.Context, userID, webhookID uint, limit int) (deliveries []domain.WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "WebhookMysql.FindDeliveries")
+	defer func() { endSpan(span, err) }()
+
+	err = r.DB.WithContext(ctx).Where("webhook_id = ? AND user_id = ?", webhookID, userID).
+		Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// FindDeliveryは指定ユーザーのWebhookのIDの配信を返します。
+func (r *WebhookMysql) FindDelivery(ctx context.Context, userID, webhookID, id uint) (_ domain.WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "WebhookMysql.FindDelivery")
+	defer func() { endSpan(span, err) }()
+
+	var d domain.WebhookDelivery
+	err = r.DB.WithContext(ctx).Where("id = ? AND webhook_id = ? AND user_id = ?", id, webhookID, userID).First(&d).Error
+	if err != nil {
+		return domain.WebhookDelivery{}, notFoundAs(err, domain.ErrWebhookDeliveryNotFound)
+	}
+	return d, nil
+}
+
+// DueDeliveriesは送信時刻を迎えた配信待ちの配信を、excludeのWebhookのものを除いて返します。
+func (r *WebhookMysql) DueDeliveries(ctx context.Context, now time.Time, limit int, exclude []uint) (deliveries []domain.WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "WebhookMysql.DueDeliveries")
+	defer func() { endSpan(span, err) }()
+
+	db := r.DB.WithContext(ctx).Where("status = ? AND next_attempt_at <= ?", domain.WebhookDeliveryPending, now)
+	if len(exclude) > 0 {
+		db = db.Where("webhook_id NOT IN ?", exclude)
+	}
+	err = db.Order("next_attempt_at, id").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// UpdateDeliveryは配信の送信結果を保存します。
+func (r *WebhookMysql) UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) (err error) {
+	ctx, span := startSpan(ctx, "WebhookMysql.UpdateDelivery")
+	defer func() { endSpan(span, err) }()
+
+	res := r.DB.WithContext(ctx).Model(&domain.WebhookDelivery{}).Where("id = ?", delivery.ID).
+		Updates(map[string]any{
+			"status":          delivery.Status,
+			"attempts":        delivery.Attempts,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"last_attempt_at": delivery.LastAttemptAt,
+			"response_status": delivery.ResponseStatus,
+			"error":           delivery.Error,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
+
+// notFoundAsはgorm.ErrRecordNotFoundをnotFound（ドメインのエラー）に変換します。
+func notFoundAs(err, notFound error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package openapi
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+// SpecHandlerはドキュメントをJSONで返すハンドラを返します。
+// JSONは起動時に一度だけ生成します。
+func SpecHandler(doc *openapi3.T) (gin.HandlerFunc, error) {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	}, nil
+}
+
+// docsPageはRedocでドキュメントを表示するHTMLです。
+var docsPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>{{.Title}}</title>
+</head>
+<body>
+  <redoc spec-url="{{.SpecURL}}"></redoc>
+  <script src="https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`))
+
+// DocsHandlerはspecURLのドキュメントをRedocで表示するHTMLページのハンドラを返します。
+func DocsHandler(doc *openapi3.T, specURL string) gin.HandlerFunc {
+	data := struct{ Title, SpecURL string }{Title: doc.Info.Title, SpecURL: specURL}
+	return func(c *gin.Context) {
+		c.Status(http.StatusOK)
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		_ = docsPage.Execute(c.Writer, data)
+	}
+}
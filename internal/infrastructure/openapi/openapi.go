@@ -0,0 +1,53 @@
+// Package openapiは、APIのOpenAPIドキュメント（openapi.yaml）の読み込みと配信、
+// およびドキュメントに基づくリクエストの検証を提供します。
+// ドキュメントはバイナリに埋め込まれ、/openapi.jsonと/docs（Redoc）で公開されます。
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+// Loadは埋め込みのOpenAPIドキュメントを読み込み、構文と参照を検証して返します。
+// スキーマはOpenAPI 3.0と共通の範囲（型の配列やnullを使わない）で記述しています。
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(specYAML)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: load spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("openapi: invalid spec: %w", err)
+	}
+	return doc, nil
+}
+
+// Operationsは、ドキュメントに記述されたすべての操作を"METHOD /path"形式（パスパラメータは{id}形式）で返します。
+// ルーターの登録内容との突き合わせに使います。
+func Operations(doc *openapi3.T) []string {
+	var ops []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			ops = append(ops, method+" "+path)
+		}
+	}
+	return ops
+}
+
+// SpecPathはGinのルートパス（/todos/:id）をOpenAPIのパス（/todos/{id}）に変換します。
+func SpecPath(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
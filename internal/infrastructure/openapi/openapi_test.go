@@ -0,0 +1,138 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todo_backend/internal/infrastructure/openapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	doc, err := openapi.Load()
+
+	require.NoError(t, err)
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.Contains(t, openapi.Operations(doc), "PATCH /v1/todos/{id}")
+}
+
+func TestSpecPath(t *testing.T) {
+	assert.Equal(t, "/todos", openapi.SpecPath("/todos"))
+	assert.Equal(t, "/todos/{id}", openapi.SpecPath("/todos/:id"))
+	assert.Equal(t, "/files/{path}", openapi.SpecPath("/files/*path"))
+}
+
+// newValidatingRouterはValidateRequestsを適用し、受け取ったボディをそのまま返すルーターを生成します。
+// /v1以下に加えて、/v1の別名としてプレフィックスなしのルートも登録します。
+func newValidatingRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	r := gin.New()
+	echo := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, "application/json", body)
+	}
+	v1 := r.Group("/v1", openapi.ValidateRequests(doc, ""))
+	v1.PATCH("/todos/:id", echo)
+	v1.POST("/todos/batch", echo)
+	v1.GET("/undocumented", echo)
+	legacy := r.Group("/", openapi.ValidateRequests(doc, "/v1"))
+	legacy.PATCH("/todos/:id", echo)
+	return r
+}
+
+func send(r http.Handler, method, path, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestValidateRequests_PassesValidRequestWithBodyIntact(t *testing.T) {
+	r := newValidatingRouter(t)
+
+	w := send(r, http.MethodPatch, "/v1/todos/1", "application/merge-patch+json", `{"completed":true}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"completed":true}`, w.Body.String())
+}
+
+func TestValidateRequests_ValidatesAliasWithSpecPrefix(t *testing.T) {
+	r := newValidatingRouter(t)
+
+	assert.Equal(t, http.StatusOK, send(r, http.MethodPatch, "/todos/1", "application/json", `{"completed":true}`).Code)
+	assert.Equal(t, http.StatusBadRequest, send(r, http.MethodPatch, "/todos/1", "application/json", `{"completed":1}`).Code)
+}
+
+func TestValidateRequests_RejectsInvalidRequests(t *testing.T) {
+	r := newValidatingRouter(t)
+	cases := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		contains    string
+	}{
+		{"non-numeric path parameter", http.MethodPatch, "/v1/todos/abc", "application/json", `{}`, `path parameter "id"`},
+		{"wrong field type", http.MethodPatch, "/v1/todos/1", "application/json", `{"title":1}`, "request body /title"},
+		{"unknown field", http.MethodPatch, "/v1/todos/1", "application/json", `{"user_id":2}`, "request body"},
+		{"unsupported content type", http.MethodPatch, "/v1/todos/1", "text/plain", `{}`, "request body"},
+		{"unknown batch op", http.MethodPost, "/v1/todos/batch", "application/json", `{"operations":[{"op":"archive"}]}`, "request body /operations/0/op"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := send(r, tc.method, tc.path, tc.contentType, tc.body)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var body struct {
+				Error string `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Contains(t, body.Error, tc.contains)
+		})
+	}
+}
+
+func TestValidateRequests_SkipsUndocumentedRoutes(t *testing.T) {
+	r := newValidatingRouter(t)
+
+	w := send(r, http.MethodGet, "/v1/undocumented", "", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestSpecAndDocsHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+	specHandler, err := openapi.SpecHandler(doc)
+	require.NoError(t, err)
+	r := gin.New()
+	r.GET("/openapi.json", specHandler)
+	r.GET("/docs", openapi.DocsHandler(doc, "/openapi.json"))
+
+	w := send(r, http.MethodGet, "/openapi.json", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var served map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &served))
+	assert.Equal(t, "3.1.0", served["openapi"])
+
+	w = send(r, http.MethodGet, "/docs", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), `spec-url="/openapi.json"`)
+}
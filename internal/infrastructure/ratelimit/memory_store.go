@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepIntervalは不要になったバケットを掃除する間隔です。
+const sweepInterval = time.Minute
+
+// bucketはキーごとのトークンバケットの状態です。
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// fullAtはトークンが満タンに戻る時刻です（掃除の判定に使います）。
+	fullAt time.Time
+}
+
+// MemoryStoreはプロセス内のmapでバケットを管理するStoreの実装です。
+// 複数のgoroutineから安全に利用できます。
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStoreは空のMemoryStoreを返します。
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// Allowはkeyのバケットを経過時間に応じて補充してから、トークンを1つ消費します。
+func (s *MemoryStore) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	capacity := float64(limit.Requests)
+	rate := limit.ratePerSecond()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	res := Result{}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - b.tokens) / rate)
+	}
+	res.Remaining = int(math.Floor(b.tokens))
+	res.ResetAfter = secondsToDuration((capacity - b.tokens) / rate)
+	b.fullAt = now.Add(res.ResetAfter)
+	return res, nil
+}
+
+// sweepは満タンに戻ったバケットを削除し、mapが際限なく大きくなるのを防ぎます。
+// 満タンのバケットは新規作成したものと同じ状態なので、削除しても判定は変わりません。
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for k, b := range s.buckets {
+		if !now.Before(b.fullAt) {
+			delete(s.buckets, k)
+		}
+	}
+}
+
+// secondsToDurationは秒数（小数）をtime.Durationに変換します。
+func secondsToDuration(sec float64) time.Duration {
+	return time.Duration(sec * float64(time.Second))
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limitはトークンバケットの設定です。
+// Period あたり Requests 回までのリクエストを許可し、バケット容量（バースト）も Requests とします。
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Enabledは制限が有効かどうかを返します。
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// ratePerSecondは1秒あたりに補充されるトークン数です。
+func (l Limit) ratePerSecond() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Stringは"10/1m0s"形式の文字列を返します。
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// ParseLimitは"10/1m"（1分あたり10回）形式の文字列をLimitに変換します。
+// "off"または空文字の場合は無効な（制限しない）Limitを返します。
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" {
+		return Limit{}, nil
+	}
+	n, p, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected \"requests/period\"", s)
+	}
+	requests, err := strconv.Atoi(strings.TrimSpace(n))
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive integer", s)
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(p))
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", s)
+	}
+	return Limit{Requests: requests, Period: period}, nil
+}
+
+// Resultは1回のリクエストに対する判定結果です。
+type Result struct {
+	// Allowedはリクエストを許可するかどうかです。
+	Allowed bool
+	// Remainingは残りのリクエスト可能回数です。
+	Remaining int
+	// ResetAfterはバケットが満タンに戻るまでの時間です。
+	ResetAfter time.Duration
+	// RetryAfterは拒否された場合に次のリクエストが許可されるまでの時間です。
+	RetryAfter time.Duration
+}
+
+// Storeはキーごとのトークンバケットの状態を保持するストアです。
+// 現在はプロセス内のMemoryStoreのみですが、複数インスタンス構成では
+// Redisなどの共有ストアの実装に差し替えられます。
+type Store interface {
+	// Allowはkeyのバケットからトークンを1つ消費し、判定結果を返します。
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jwtmw "todo_backend/internal/infrastructure/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClockはテストで時間を進めるための時計です。
+type fakeClock struct{ t time.Time }
+
+func (f *fakeClock) now() time.Time          { return f.t }
+func (f *fakeClock) advance(d time.Duration) { f.t = f.t.Add(d) }
+
+func newStore(clock *fakeClock) *MemoryStore {
+	s := NewMemoryStore()
+	s.now = clock.now
+	return s
+}
+
+func TestParseLimit(t *testing.T) {
+	l, err := ParseLimit("10/1m")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 10, Period: time.Minute}, l)
+
+	l, err = ParseLimit("off")
+	require.NoError(t, err)
+	assert.False(t, l.Enabled())
+
+	for _, bad := range []string{"10", "x/1m", "0/1m", "10/soon", "10/-1s"} {
+		_, err := ParseLimit(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	// given: 1秒あたり2回（容量2）
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	s := newStore(clock)
+	limit := Limit{Requests: 2, Period: time.Second}
+	ctx := context.Background()
+
+	// when / then: 容量分は通り、3回目は拒否される
+	r1, _ := s.Allow(ctx, "k", limit)
+	r2, _ := s.Allow(ctx, "k", limit)
+	r3, _ := s.Allow(ctx, "k", limit)
+	assert.True(t, r1.Allowed)
+	assert.Equal(t, 1, r1.Remaining)
+	assert.True(t, r2.Allowed)
+	assert.Equal(t, 0, r2.Remaining)
+	assert.False(t, r3.Allowed)
+	assert.Equal(t, 500*time.Millisecond, r3.RetryAfter)
+	assert.Equal(t, time.Second, r3.ResetAfter)
+
+	// 別のキーは独立している
+	other, _ := s.Allow(ctx, "other", limit)
+	assert.True(t, other.Allowed)
+
+	// 0.5秒でトークンが1つ補充される
+	clock.advance(500 * time.Millisecond)
+	r4, _ := s.Allow(ctx, "k", limit)
+	assert.True(t, r4.Allowed)
+}
+
+func TestMemoryStore_SweepsFullBuckets(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	s := newStore(clock)
+	limit := Limit{Requests: 1, Period: time.Second}
+
+	_, _ = s.Allow(context.Background(), "a", limit)
+	clock.advance(2 * sweepInterval)
+	_, _ = s.Allow(context.Background(), "b", limit)
+
+	assert.NotContains(t, s.buckets, "a")
+	assert.Contains(t, s.buckets, "b")
+}
+
+func TestMiddleware_SetsHeadersAndRejectsWith429(t *testing.T) {
+	// given
+	gin.SetMode(gin.TestMode)
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	store := newStore(clock)
+	limit := Limit{Requests: 1, Period: time.Minute}
+
+	r := gin.New()
+	r.GET("/anon", Middleware(store, "anon", limit, ByIP), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/me",
+		func(c *gin.Context) { c.Set(jwtmw.ContextUserID, uint(c.GetHeader("X-User")[0]-'0')) },
+		Middleware(store, "user", limit, ByUser),
+		func(c *gin.Context) { c.Status(http.StatusOK) },
+	)
+	do := func(path, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// when / then
+	w := do("/anon", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "1;w=60", w.Header().Get("RateLimit-Policy"))
+
+	w = do("/anon", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// 認証済みルートはユーザーごとのバケット（匿名ルートの消費とは独立）
+	assert.Equal(t, http.StatusOK, do("/me", "1").Code)
+	assert.Equal(t, http.StatusOK, do("/me", "2").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do("/me", "1").Code)
+}
@@ -0,0 +1,215 @@
+// Package realtimeは、WebSocket接続のチャネル購読とプレゼンス（誰がどのTodoを閲覧・編集中か）を
+// 接続間で共有し、アプリ内の通知を接続に届けるハブを提供します。
+package realtime
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/usecase"
+)
+
+// NotificationsChannelは、ユーザー宛てのアプリ内の通知が届くチャネルです。
+const NotificationsChannel = "notifications"
+
+// プレゼンスの状態です。
+const (
+	// Viewingは閲覧中を表します。
+	Viewing = "viewing"
+	// Editingは編集中を表します。
+	Editing = "editing"
+	// Leftはチャネルから離れた（状態を解除した・購読を終了した・切断した）ことを表します。
+	Left = "left"
+)
+
+// Presenceはチャネル上の1つの接続の状態です。
+type Presence struct {
+	Connection string `json:"connection"`
+	UserID     uint   `json:"user_id"`
+	State      string `json:"state"`
+}
+
+// Peerはハブに参加する接続です。
+// メソッドはハブのロックを保持した状態で呼び出されるため、ブロックせず、ハブを呼び出さないでください。
+type Peer interface {
+	// IDは接続を一意に識別する文字列です。
+	ID() string
+	// UserIDは接続しているユーザーのIDです。
+	UserID() uint
+	// PresenceChangedは購読中のチャネルで他の接続の状態が変わったことを通知します。
+	PresenceChanged(channel string, p Presence)
+	// NotificationCreatedはNotificationsChannelを購読中の接続にユーザー宛ての通知を届けます。
+	NotificationCreated(n domain.Notification)
+	// Closeは接続を閉じます。
+	Close()
+}
+
+// Hubは接続ごとのチャネル購読とプレゼンスを管理します。
+// プレゼンスは同じユーザーの接続の間で共有されます（Todoはユーザーごとに所有されるため）。
+// 複数のgoroutineから安全に利用できます。
+type Hub struct {
+	mu     sync.Mutex
+	peers  map[string]*member
+	closed bool
+}
+
+// コンパイル時に Hub が usecase.NotificationPublisher を実装しているか確認します。
+var _ usecase.NotificationPublisher = (*Hub)(nil)
+
+// memberは参加中の接続と、購読中のチャネルごとの状態（空文字は状態なし）です。
+type member struct {
+	peer     Peer
+	channels map[string]string
+}
+
+// NewHubは空のHubを返します。
+func NewHub() *Hub {
+	return &Hub{peers: make(map[string]*member)}
+}
+
+// Joinは接続をハブに参加させます。ハブが閉じられている場合は接続を閉じてfalseを返します。
+func (h *Hub) Join(p Peer) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		p.Close()
+		return false
+	}
+	h.peers[p.ID()] = &member{peer: p, channels: make(map[string]string)}
+	return true
+}
+
+// Leaveは接続をハブから外し、状態を設定していたチャネルの他の接続にLeftを通知します。
+func (h *Hub) Leave(p Peer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	m, ok := h.peers[p.ID()]
+	if !ok {
+		return
+	}
+	delete(h.peers, p.ID())
+	for channel, state := range m.channels {
+		if state != "" {
+			h.broadcast(m, channel, Left)
+		}
+	}
+}
+
+// Subscribeはチャネルを購読し、同じチャネルで状態を設定している他の接続の一覧を返します。
+func (h *Hub) Subscribe(p Peer, channel string) []Presence {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	m, ok := h.peers[p.ID()]
+	if !ok {
+		return nil
+	}
+	if _, subscribed := m.channels[channel]; !subscribed {
+		m.channels[channel] = ""
+	}
+
+	var out []Presence
+	for _, other := range h.peers {
+		if other == m || other.peer.UserID() != p.UserID() {
+			continue
+		}
+		if state := other.channels[channel]; state != "" {
+			out = append(out, Presence{Connection: other.peer.ID(), UserID: other.peer.UserID(), State: state})
+		}
+	}
+	slices.SortFunc(out, func(a, b Presence) int { return strings.Compare(a.Connection, b.Connection) })
+	return out
+}
+
+// Unsubscribeはチャネルの購読を終了します。状態を設定していた場合は他の接続にLeftを通知します。
+func (h *Hub) Unsubscribe(p Peer, channel string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	m, ok := h.peers[p.ID()]
+	if !ok {
+		return
+	}
+	state, subscribed := m.channels[channel]
+	if !subscribed {
+		return
+	}
+	delete(m.channels, channel)
+	if state != "" {
+		h.broadcast(m, channel, Left)
+	}
+}
+
+// Subscribedは接続がチャネルを購読しているかを返します。
+func (h *Hub) Subscribed(p Peer, channel string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	m, ok := h.peers[p.ID()]
+	if !ok {
+		return false
+	}
+	_, subscribed := m.channels[channel]
+	return subscribed
+}
+
+// SetPresenceは購読中のチャネルでの接続の状態を設定し、同じチャネルを購読している他の接続に通知します。
+// stateにLeftを指定すると状態を解除します。チャネルを購読していない場合はfalseを返します。
+func (h *Hub) SetPresence(p Peer, channel, state string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	m, ok := h.peers[p.ID()]
+	if !ok {
+		return false
+	}
+	current, subscribed := m.channels[channel]
+	if !subscribed {
+		return false
+	}
+	next := state
+	if state == Left {
+		next = ""
+	}
+	if current == next {
+		return true
+	}
+	m.channels[channel] = next
+	h.broadcast(m, channel, state)
+	return true
+}
+
+// PublishNotificationは、通知の宛先のユーザーの接続のうちNotificationsChannelを購読しているものに通知を届けます。
+func (h *Hub) PublishNotification(n domain.Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, m := range h.peers {
+		if m.peer.UserID() != n.UserID {
+			continue
+		}
+		if _, subscribed := m.channels[NotificationsChannel]; subscribed {
+			m.peer.NotificationCreated(n)
+		}
+	}
+}
+
+// Closeはすべての接続を閉じ、以降の参加を受け付けません。サーバの停止時に呼び出します。
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, m := range h.peers {
+		m.peer.Close()
+	}
+}
+
+// broadcastは、fromのチャネルでの状態を同じユーザーの他の購読者に通知します。h.muを保持した状態で呼び出してください。
+func (h *Hub) broadcast(from *member, channel, state string) {
+	p := Presence{Connection: from.peer.ID(), UserID: from.peer.UserID(), State: state}
+	for _, other := range h.peers {
+		if other == from || other.peer.UserID() != p.UserID {
+			continue
+		}
+		if _, subscribed := other.channels[channel]; subscribed {
+			other.peer.PresenceChanged(channel, p)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package realtime_test
+
+import (
+	"testing"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/realtime"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePeerは受け取った通知を記録するPeerです。
+type fakePeer struct {
+	id       string
+	userID   uint
+	received []string
+	closed   bool
+}
+
+func (p *fakePeer) ID() string   { return p.id }
+func (p *fakePeer) UserID() uint { return p.userID }
+func (p *fakePeer) Close()       { p.closed = true }
+
+func (p *fakePeer) PresenceChanged(channel string, presence realtime.Presence) {
+	p.received = append(p.received, channel+" "+presence.Connection+" "+presence.State)
+}
+
+func (p *fakePeer) NotificationCreated(n domain.Notification) {
+	p.received = append(p.received, realtime.NotificationsChannel+" "+n.Title)
+}
+
+func TestHub_SharesPresenceWithSameUserSubscribers(t *testing.T) {
+	// given
+	hub := realtime.NewHub()
+	editor := &fakePeer{id: "a", userID: 1}
+	viewer := &fakePeer{id: "b", userID: 1}
+	unsubscribed := &fakePeer{id: "c", userID: 1}
+	otherUser := &fakePeer{id: "d", userID: 2}
+	for _, p := range []*fakePeer{editor, viewer, unsubscribed, otherUser} {
+		hub.Join(p)
+	}
+	hub.Subscribe(editor, "todo:1")
+	hub.Subscribe(viewer, "todo:1")
+	hub.Subscribe(otherUser, "todo:1")
+
+	// when
+	ok := hub.SetPresence(editor, "todo:1", realtime.Editing)
+
+	// then: 同じユーザーでチャネルを購読している接続にのみ届く
+	assert.True(t, ok)
+	assert.Equal(t, []string{"todo:1 a editing"}, viewer.received)
+	assert.Empty(t, editor.received)
+	assert.Empty(t, unsubscribed.received)
+	assert.Empty(t, otherUser.received)
+}
+
+func TestHub_SubscribeReturnsCurrentPresence(t *testing.T) {
+	// given
+	hub := realtime.NewHub()
+	editor := &fakePeer{id: "a", userID: 1}
+	late := &fakePeer{id: "b", userID: 1}
+	hub.Join(editor)
+	hub.Join(late)
+	hub.Subscribe(editor, "todo:1")
+	hub.SetPresence(editor, "todo:1", realtime.Editing)
+
+	// when
+	got := hub.Subscribe(late, "todo:1")
+
+	// then
+	assert.Equal(t, []realtime.Presence{{Connection: "a", UserID: 1, State: realtime.Editing}}, got)
+}
+
+func TestHub_NotifiesLeftOnUnsubscribeAndLeave(t *testing.T) {
+	// given
+	hub := realtime.NewHub()
+	a := &fakePeer{id: "a", userID: 1}
+	b := &fakePeer{id: "b", userID: 1}
+	viewer := &fakePeer{id: "c", userID: 1}
+	for _, p := range []*fakePeer{a, b, viewer} {
+		hub.Join(p)
+		hub.Subscribe(p, "todos")
+	}
+	hub.SetPresence(a, "todos", realtime.Viewing)
+	hub.SetPresence(b, "todos", realtime.Editing)
+	viewer.received = nil
+
+	// when
+	hub.Unsubscribe(a, "todos")
+	hub.Leave(b)
+
+	// then
+	assert.Equal(t, []string{"todos a left", "todos b left"}, viewer.received)
+	assert.False(t, hub.Subscribed(a, "todos"))
+	assert.False(t, hub.Subscribed(b, "todos"))
+}
+
+func TestHub_SetPresenceRequiresSubscription(t *testing.T) {
+	// given
+	hub := realtime.NewHub()
+	p := &fakePeer{id: "a", userID: 1}
+	hub.Join(p)
+
+	// when
+	ok := hub.SetPresence(p, "todos", realtime.Viewing)
+
+	// then
+	assert.False(t, ok)
+}
+
+func TestHub_CloseClosesPeersAndRejectsNewOnes(t *testing.T) {
+	// given
+	hub := realtime.NewHub()
+	joined := &fakePeer{id: "a", userID: 1}
+	hub.Join(joined)
+
+	// when
+	hub.Close()
+	late := &fakePeer{id: "b", userID: 1}
+	ok := hub.Join(late)
+
+	// then
+	assert.True(t, joined.closed)
+	assert.False(t, ok)
+	assert.True(t, late.closed)
+}
+
+func TestHub_PublishNotificationReachesRecipientSubscribers(t *testing.T) {
+	// given
+	hub := realtime.NewHub()
+	subscribed := &fakePeer{id: "a", userID: 1}
+	unsubscribed := &fakePeer{id: "b", userID: 1}
+	otherUser := &fakePeer{id: "c", userID: 2}
+	for _, p := range []*fakePeer{subscribed, unsubscribed, otherUser} {
+		hub.Join(p)
+	}
+	hub.Subscribe(subscribed, realtime.NotificationsChannel)
+	hub.Subscribe(unsubscribed, "todos")
+	hub.Subscribe(otherUser, realtime.NotificationsChannel)
+
+	// when
+	hub.PublishNotification(domain.Notification{ID: 1, UserID: 1, Title: "牛乳を買う"})
+
+	// then: 宛先のユーザーでチャネルを購読している接続にのみ届く
+	assert.Equal(t, []string{"notifications 牛乳を買う"}, subscribed.received)
+	assert.Empty(t, unsubscribed.received)
+	assert.Empty(t, otherUser.received)
+}
@@ -0,0 +1,65 @@
+// Package reminderは、通知する日時を迎えたTodoのリマインダーを定期的に通知するスケジューラを提供します。
+package reminder
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"todo_backend/internal/usecase"
+)
+
+const (
+	// DefaultPollIntervalは通知する日時を迎えたリマインダーを確認する間隔のデフォルト値です。
+	DefaultPollInterval = 15 * time.Second
+	// batchSizeは1回に取り出して通知するリマインダーの数です。
+	batchSize = 100
+)
+
+// Schedulerは、通知する日時を迎えたリマインダーをPollIntervalごとに通知するワーカーです。Runを別のgoroutineで実行します。
+//
+// 通知済みかどうかはデータベースに保存するため、サーバを再起動しても通知し忘れたり2回通知したりしません。
+// 停止中に通知する日時を迎えたリマインダーは、起動後の最初の確認で通知します。
+type Scheduler struct {
+	uc       *usecase.ReminderUsecase
+	interval time.Duration
+}
+
+// NewSchedulerは、ucで通知するSchedulerを返します。
+// pollIntervalは通知する日時を迎えたリマインダーを確認する間隔です（0以下の場合はDefaultPollInterval）。
+func NewScheduler(uc *usecase.ReminderUsecase, pollInterval time.Duration) *Scheduler {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	return &Scheduler{uc: uc, interval: pollInterval}
+}
+
+// Runはctxがキャンセルされるまで、通知する日時を迎えたリマインダーを通知します。
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.fire(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fireは通知する日時を迎えたリマインダーがなくなるまで通知します。
+func (s *Scheduler) fire(ctx context.Context) {
+	for {
+		n, err := s.uc.FireDue(ctx, batchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "failed to fire reminders", slog.Any("error", err))
+			}
+			return
+		}
+		if n < batchSize {
+			return
+		}
+	}
+}
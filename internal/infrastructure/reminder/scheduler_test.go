@@ -0,0 +1,111 @@
+package reminder_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/memory"
+	"todo_backend/internal/infrastructure/reminder"
+	"todo_backend/internal/interface/repository"
+	"todo_backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runは、スケジューラをテストの終了まで実行します。
+func run(t *testing.T, s *reminder.Scheduler) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// addRemindersは、ユーザー7のTodoに通知する日時がatのリマインダーをn件設定します。
+func addReminders(t *testing.T, repos repository.Repositories, uc *usecase.ReminderUsecase, n int, at time.Time) {
+	t.Helper()
+	todo, err := repos.Todos.Create(context.Background(), domain.Todo{UserID: 7, Title: "牛乳を買う"})
+	require.NoError(t, err)
+	for range n {
+		_, err := uc.CreateReminder(context.Background(), 7, todo.ID, domain.Reminder{RemindAt: &at})
+		require.NoError(t, err)
+	}
+}
+
+// countNotificationsはユーザー7宛ての通知の件数を返します。
+func countNotifications(t *testing.T, repos repository.Repositories) int {
+	t.Helper()
+	notifications, err := repos.Notifications.FindByUser(context.Background(), 7, false, 1000)
+	require.NoError(t, err)
+	return len(notifications)
+}
+
+// 起動時に、停止中に通知する日時を迎えたリマインダーを1回の確認の件数を超えてもすべて通知する
+func TestScheduler_FiresOverdueRemindersOnStart(t *testing.T) {
+	// given
+	repos, tx := memory.NewRepositories()
+	uc := usecase.NewReminderUsecase(repos, tx)
+	addReminders(t, repos, uc, 150, time.Now().Add(-time.Minute))
+
+	// when: 間隔を長くして、起動時の確認だけで通知されることを確かめる
+	run(t, reminder.NewScheduler(uc, time.Hour))
+
+	// then
+	require.Eventually(t, func() bool {
+		return countNotifications(t, repos) == 150
+	}, 5*time.Second, 5*time.Millisecond)
+	fired, err := uc.FireDue(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Zero(t, fired)
+}
+
+// 通知する日時を後から迎えたリマインダーは、次の確認で1回だけ通知する
+func TestScheduler_FiresRemindersWhenTheyBecomeDue(t *testing.T) {
+	// given
+	repos, tx := memory.NewRepositories()
+	uc := usecase.NewReminderUsecase(repos, tx)
+	addReminders(t, repos, uc, 1, time.Now().Add(300*time.Millisecond))
+
+	// when
+	run(t, reminder.NewScheduler(uc, 5*time.Millisecond))
+
+	// then
+	assert.Zero(t, countNotifications(t, repos))
+	require.Eventually(t, func() bool {
+		return countNotifications(t, repos) == 1
+	}, 5*time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, countNotifications(t, repos))
+}
+
+// Runはctxがキャンセルされると戻る
+func TestScheduler_StopsWhenContextIsCanceled(t *testing.T) {
+	// given
+	repos, tx := memory.NewRepositories()
+	s := reminder.NewScheduler(usecase.NewReminderUsecase(repos, tx), time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+
+	// when
+	cancel()
+
+	// then
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the context was canceled")
+	}
+}
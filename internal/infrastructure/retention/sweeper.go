@@ -0,0 +1,71 @@
+// Package retentionは、保持期間を過ぎたデータ（差分同期の墓標など）を定期的に削除するワーカーを提供します。
+package retention
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DefaultIntervalは保持期間を過ぎたデータを削除する間隔のデフォルト値です。
+const DefaultInterval = time.Hour
+
+// Purgeは保持期間を過ぎたデータを削除し、削除した件数を返す関数です。
+type Purge func(ctx context.Context) (int64, error)
+
+// Sweeperは、登録した削除処理をintervalごとに実行するワーカーです。Runを別のgoroutineで実行します。
+// 1つの削除処理が失敗しても、他の削除処理は実行します。
+type Sweeper struct {
+	interval time.Duration
+	purges   []namedPurge
+}
+
+// namedPurgeはログに出力する名前を付けた削除処理です。
+type namedPurge struct {
+	name  string
+	purge Purge
+}
+
+// NewSweeperは、intervalごとに削除するSweeperを返します（0以下の場合はDefaultInterval）。
+func NewSweeper(interval time.Duration) *Sweeper {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Sweeper{interval: interval}
+}
+
+// Addはnameの削除処理purgeを登録します。Runの前に呼び出してください。
+func (s *Sweeper) Add(name string, purge Purge) {
+	s.purges = append(s.purges, namedPurge{name: name, purge: purge})
+}
+
+// Runはctxがキャンセルされるまで、起動時とintervalごとに登録した削除処理を実行します。
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.sweep(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweepは登録した削除処理を順に実行します。
+func (s *Sweeper) sweep(ctx context.Context) {
+	for _, p := range s.purges {
+		n, err := p.purge(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.ErrorContext(ctx, "failed to purge expired data", slog.String("target", p.name), slog.Any("error", err))
+			continue
+		}
+		if n > 0 {
+			slog.DebugContext(ctx, "purged expired data", slog.String("target", p.name), slog.Int64("count", n))
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package retention_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"todo_backend/internal/infrastructure/retention"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSweeper_RunsEveryPurgeEvenIfOneFails(t *testing.T) {
+	// given
+	var failed, purged atomic.Int32
+	s := retention.NewSweeper(10 * time.Millisecond)
+	s.Add("broken", func(ctx context.Context) (int64, error) {
+		failed.Add(1)
+		return 0, errors.New("boom")
+	})
+	s.Add("tombstones", func(ctx context.Context) (int64, error) {
+		purged.Add(1)
+		return 3, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	// when
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+
+	// then: 起動時と間隔ごとに、失敗した削除処理の後も続けて実行する
+	assert.Eventually(t, func() bool { return purged.Load() >= 2 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+	assert.GreaterOrEqual(t, failed.Load(), int32(2))
+}
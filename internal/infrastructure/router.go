@@ -1,34 +1,193 @@
 package infrastructure
 
 import (
+	"log/slog"
+	"maps"
+	"strings"
+	"time"
+
+	"todo_backend/internal/infrastructure/deadline"
+	"todo_backend/internal/infrastructure/deprecation"
+	"todo_backend/internal/infrastructure/idempotency"
 	jwtmw "todo_backend/internal/infrastructure/jwt"
+	"todo_backend/internal/infrastructure/logging"
+	"todo_backend/internal/infrastructure/openapi"
+	"todo_backend/internal/infrastructure/ratelimit"
+	"todo_backend/internal/infrastructure/realtime"
+	"todo_backend/internal/infrastructure/tracing"
 	"todo_backend/internal/interface/handler"
 	"todo_backend/internal/usecase"
 
-	"github.com/gin-contrib/cors"
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
 )
 
-func NewRouter(authHandler *handler.AuthHandler, todoUC *usecase.TodoUsecase) *gin.Engine {
-	r := gin.Default()
-	// CORS のデフォルト設定を有効
-	r.Use(cors.Default())
+// NewRouterはGinのエンジンを生成し、ミドルウェアと全エンドポイントを登録します。
+// optsでメトリクスなどの任意機能を有効にできます。
+// CORS設定や埋め込みのOpenAPIドキュメントが不正な場合はエラーを返します。
+func NewRouter(authHandler *handler.AuthHandler, todoUC *usecase.TodoUsecase, opts ...RouterOption) (*gin.Engine, error) {
+	var o routerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	spec, err := openapi.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	// gin.Default()のテキストロガーの代わりに構造化ログ（slog）を使う
+	r := gin.New()
+	// 信頼するプロキシ以外からのX-Forwarded-Forは無視する（クライアントがIPを偽ってレート制限を回避できないように）
+	if err := r.SetTrustedProxies(o.trustedProxies); err != nil {
+		return nil, err
+	}
+	r.Use(logging.Middleware(slog.Default()), logging.Recovery(slog.Default()))
+
+	// CORS（プリフライトはここで応答し、以降のミドルウェアには到達しない）
+	if o.cors != nil {
+		corsMW, err := newCORS(*o.cors)
+		if err != nil {
+			return nil, err
+		}
+		r.Use(corsMW)
+	}
+
+	// OpenTelemetryのサーバスパン（トレーサ未設定時は何もしない）
+	r.Use(tracing.Middleware())
+
+	// Prometheusメトリクス（リクエスト数・レイテンシ）と/metricsエンドポイント
+	if o.metrics != nil {
+		r.Use(o.metrics.Middleware())
+		r.GET("/metrics", gin.WrapH(o.metrics.Handler()))
+	}
 
+	// リクエストの処理期限（期限切れ・切断時は実行中のDBクエリもキャンセルされる）
+	if o.deadline != nil {
+		r.Use(deadline.Middleware(o.deadlineConfig()))
+	}
+
+	// APIドキュメント（OpenAPI）とその閲覧ページ
+	specHandler, err := openapi.SpecHandler(spec)
+	if err != nil {
+		return nil, err
+	}
+	r.GET("/openapi.json", specHandler)
+	r.GET("/docs", openapi.DocsHandler(spec, "/openapi.json"))
+
+	// APIは/v1などのバージョンごとのプレフィックスにマウントする
+	hub := o.hub
+	if hub == nil {
+		hub = realtime.NewHub()
+	}
+	v1 := handler.NewV1(authHandler, todoUC, hub)
+	v1.Webhooks = o.webhooks
+	v1.Jobs = o.jobs
+	v1.Reminders = o.reminders
+	v1.Notifications = o.notifications
+	o.mountAPI(r.Group("/v1"), v1, spec, "")
+	for _, v := range o.versions {
+		o.mountAPI(r.Group(v.prefix), v.api, spec, "")
+	}
+
+	// 旧来のルート（/todosなど）はv1の別名として残し、廃止予定であることをヘッダで通知する
+	if o.legacy != nil {
+		legacy := *o.legacy
+		legacy.Successor = func(path string) string { return "/v1" + path }
+		o.mountAPI(r.Group("/", deprecation.Middleware(legacy)), v1, spec, "/v1")
+	}
+
+	return r, nil
+}
+
+// streamingRoutesは長時間接続を保つルート（APIのプレフィックスを除く）です。
+var streamingRoutes = []string{"GET /todos/stream", "GET /realtime"}
+
+// deadlineConfigは、処理期限の設定にストリーミングのルートの期限なし（0）を加えたものを返します。
+// ルートごとの期限で明示的に指定されている場合はその指定に従います。
+func (o *routerOptions) deadlineConfig() deadline.Config {
+	cfg := *o.deadline
+	cfg.Routes = maps.Clone(cfg.Routes)
+	if cfg.Routes == nil {
+		cfg.Routes = map[string]time.Duration{}
+	}
+	prefixes := []string{"/v1"}
+	for _, v := range o.versions {
+		prefixes = append(prefixes, v.prefix)
+	}
+	if o.legacy != nil {
+		prefixes = append(prefixes, "")
+	}
+	for _, route := range streamingRoutes {
+		method, path, _ := strings.Cut(route, " ")
+		for _, prefix := range prefixes {
+			key := method + " " + prefix + path
+			if _, ok := cfg.Routes[key]; !ok {
+				cfg.Routes[key] = 0
+			}
+		}
+	}
+	return cfg
+}
+
+// mountAPIは、gの下に認証不要・認証必須・管理者用のグループを作成してapiのエンドポイントを登録します。
+// specPrefixは、OpenAPIドキュメント上でこのグループのルートに対応するパスのプレフィックスです。
+func (o *routerOptions) mountAPI(g *gin.RouterGroup, api handler.API, spec *openapi3.T, specPrefix string) {
 	// 認証不要
-	// 新規ユーザー登録
-	r.POST("/signup", authHandler.Signup)
-	// ログイン（JWT 発行）
-	r.POST("/login", authHandler.Login)
+	public := g.Group("")
+	if o.rateLimit != nil {
+		// 未認証のルートはクライアントIPごとに制限（総当たり対策）
+		public.Use(ratelimit.Middleware(o.rateLimit.store, "anon", o.rateLimit.anon, ratelimit.ByIP))
+	}
+	public.Use(o.validationHandlers(spec, specPrefix)...)
 
 	// 認証必須のルート
-	// r.Group("/") でルートグループを作成
-	auth := r.Group("/")
+	auth := g.Group("")
+	if o.rateLimit != nil {
+		// 有効なJWTを持たないリクエストは、認証の前にクライアントIPごとに制限（総当たり対策）
+		auth.Use(ratelimit.Middleware(o.rateLimit.store, "anon", o.rateLimit.anon, ratelimit.ByAnonymousIP("")))
+	}
 	// jwtmw.AuthRequired() ミドルウェアを適用
 	// → リクエストヘッダーに JWT が必要になる
 	auth.Use(jwtmw.AuthRequired())
-	{
-		handler.NewTodoHandler(auth, todoUC)
+	if o.rateLimit != nil {
+		// 認証済みのルートはユーザーIDごとに制限
+		auth.Use(ratelimit.Middleware(o.rateLimit.store, "user", o.rateLimit.user, ratelimit.ByUser))
 	}
+	auth.Use(o.validationHandlers(spec, specPrefix)...)
+	// POST /todosの再試行による重複作成を防ぐ（キーはユーザーごと）
+	auth.Use(o.idempotencyHandlers()...)
 
-	return r
+	// WebSocket（ブラウザはヘッダを指定できないため、クエリパラメータaccess_tokenのJWTも受け付ける）
+	ws := g.Group("")
+	if o.rateLimit != nil {
+		ws.Use(ratelimit.Middleware(o.rateLimit.store, "anon", o.rateLimit.anon, ratelimit.ByAnonymousIP("access_token")))
+	}
+	ws.Use(jwtmw.AuthRequiredOrQueryToken("access_token"))
+	if o.rateLimit != nil {
+		ws.Use(ratelimit.Middleware(o.rateLimit.store, "user", o.rateLimit.user, ratelimit.ByUser))
+	}
+	ws.Use(o.validationHandlers(spec, specPrefix)...)
+
+	// 管理者用のルート（認証必須のルートのうち、"admin"クレームを持つユーザーのみ）
+	admin := auth.Group("/admin", jwtmw.AdminRequired())
+
+	api.Register(handler.Mount{Public: public, Protected: auth, Idempotent: o.idempotencyHandlers(), Realtime: ws, Admin: admin})
+}
+
+// validationHandlersはリクエスト検証が有効な場合にそのミドルウェアを返します。
+func (o *routerOptions) validationHandlers(spec *openapi3.T, specPrefix string) []gin.HandlerFunc {
+	if !o.requestValidation {
+		return nil
+	}
+	return []gin.HandlerFunc{openapi.ValidateRequests(spec, specPrefix)}
+}
+
+// idempotencyHandlersは冪等キーが有効な場合にそのミドルウェアを返します。
+// ミドルウェアはIdempotency-Key付きのPOSTリクエストのみを対象とします。
+func (o *routerOptions) idempotencyHandlers() []gin.HandlerFunc {
+	if o.idempotency == nil {
+		return nil
+	}
+	return []gin.HandlerFunc{idempotency.Middleware(o.idempotency.store, o.idempotency.retention)}
 }
@@ -0,0 +1,152 @@
+package infrastructure
+
+import (
+	"time"
+
+	"todo_backend/internal/infrastructure/deadline"
+	"todo_backend/internal/infrastructure/deprecation"
+	"todo_backend/internal/infrastructure/idempotency"
+	"todo_backend/internal/infrastructure/metrics"
+	"todo_backend/internal/infrastructure/ratelimit"
+	"todo_backend/internal/infrastructure/realtime"
+	"todo_backend/internal/interface/handler"
+	"todo_backend/internal/usecase"
+)
+
+// routerOptionsはNewRouterの任意設定をまとめた構造体です。
+type routerOptions struct {
+	metrics   *metrics.Metrics
+	deadline  *deadline.Config
+	rateLimit *rateLimitOptions
+	cors      *CORSConfig
+	// trustedProxiesは、X-Forwarded-ForなどのヘッダのクライアントのIPを信頼するプロキシです。
+	// nilの場合はどのヘッダも信頼せず、接続元のアドレスをクライアントのIPとします。
+	trustedProxies []string
+	// idempotencyは冪等キーの設定です。
+	idempotency *idempotencyOptions
+	// requestValidationはOpenAPIドキュメントによるリクエスト検証を行うかどうかです。
+	requestValidation bool
+	// legacyは旧来のルート（プレフィックスなし）の廃止予定の設定です。nilの場合は旧来のルートを登録しません。
+	legacy *deprecation.Config
+	// versionsはv1以外に追加でマウントするAPIのバージョンです。
+	versions []apiVersion
+	// hubはWebSocket接続のハブです。nilの場合はNewRouterで生成します。
+	hub *realtime.Hub
+	// webhooksはWebhookのユースケースです。nilの場合は/webhooksを登録しません。
+	webhooks *usecase.WebhookUsecase
+	// jobsはバックグラウンドジョブのユースケースです。nilの場合は/admin/jobsを登録しません。
+	jobs *usecase.JobUsecase
+	// remindersはリマインダーのユースケースです。nilの場合は/todos/:id/remindersを登録しません。
+	reminders *usecase.ReminderUsecase
+	// notificationsはアプリ内の通知のユースケースです。nilの場合は/notificationsを登録しません。
+	notifications *usecase.NotificationUsecase
+}
+
+// apiVersionはプレフィックスにマウントするAPIのバージョンです。
+type apiVersion struct {
+	prefix string
+	api    handler.API
+}
+
+// idempotencyOptionsは冪等キーの設定です。
+type idempotencyOptions struct {
+	store     idempotency.Store
+	retention time.Duration
+}
+
+// rateLimitOptionsはレート制限の設定です。
+type rateLimitOptions struct {
+	store ratelimit.Store
+	anon  ratelimit.Limit
+	user  ratelimit.Limit
+}
+
+// RouterOptionはNewRouterの任意設定です。
+type RouterOption func(*routerOptions)
+
+// WithMetricsはHTTPメトリクスの計測ミドルウェアと/metricsエンドポイントを有効にします。
+func WithMetrics(m *metrics.Metrics) RouterOption {
+	return func(o *routerOptions) { o.metrics = m }
+}
+
+// WithDeadlineはリクエストごとの処理期限（ルート別に設定可能）を有効にします。
+func WithDeadline(cfg deadline.Config) RouterOption {
+	return func(o *routerOptions) { o.deadline = &cfg }
+}
+
+// WithRateLimitはレート制限を有効にします。
+// 未認証のルート（/signup, /login）はクライアントIPごとにanon、
+// 認証必須のルートはユーザーIDごとにuserの予算で制限します。
+func WithRateLimit(store ratelimit.Store, anon, user ratelimit.Limit) RouterOption {
+	return func(o *routerOptions) {
+		o.rateLimit = &rateLimitOptions{store: store, anon: anon, user: user}
+	}
+}
+
+// WithTrustedProxiesは、proxies（IPアドレスまたはCIDR）からの接続に限り、X-Forwarded-Forなどのヘッダの
+// クライアントのIPを信頼します（レート制限・アクセスログに使われます）。
+// 指定しない場合はヘッダを信頼しないため、ロードバランサなどの後ろで動かす場合はそのアドレスを指定してください。
+func WithTrustedProxies(proxies ...string) RouterOption {
+	return func(o *routerOptions) { o.trustedProxies = proxies }
+}
+
+// WithCORSは設定に基づくCORSポリシーを有効にします。
+// 指定しない場合、CORSヘッダは付与されません（同一オリジンからの利用のみ）。
+func WithCORS(cfg CORSConfig) RouterOption {
+	return func(o *routerOptions) { o.cors = &cfg }
+}
+
+// WithIdempotencyは作成系のPOSTエンドポイント（/v1/signup, /v1/todos など）でIdempotency-Keyヘッダを有効にします。
+// 同じキーでの再試行には保存済みのレスポンスを返し、記録はretentionの間保持されます。
+func WithIdempotency(store idempotency.Store, retention time.Duration) RouterOption {
+	return func(o *routerOptions) {
+		o.idempotency = &idempotencyOptions{store: store, retention: retention}
+	}
+}
+
+// WithRequestValidationは、APIの各エンドポイントへのリクエストを
+// OpenAPIドキュメント（/openapi.json）に照らして検証し、不一致の場合は400を返します。
+func WithRequestValidation() RouterOption {
+	return func(o *routerOptions) { o.requestValidation = true }
+}
+
+// WithLegacyRoutesは、/v1のエンドポイントをプレフィックスなし（/todosなど）でも公開します。
+// 旧来のクライアント向けの別名で、レスポンスにはcfgに基づくDeprecation・Sunsetヘッダと
+// 後継の/v1のパスを示すLinkヘッダが付与されます（cfg.Successorは無視されます）。
+func WithLegacyRoutes(cfg deprecation.Config) RouterOption {
+	return func(o *routerOptions) { o.legacy = &cfg }
+}
+
+// WithAPIVersionは、v1に加えてapiをprefix（例: "/v2"）にマウントします。
+// 同じユースケースに対して、リクエスト・レスポンスの形式が異なるバージョンを並行して提供できます。
+func WithAPIVersion(prefix string, api handler.API) RouterOption {
+	return func(o *routerOptions) {
+		o.versions = append(o.versions, apiVersion{prefix: prefix, api: api})
+	}
+}
+
+// WithRealtimeHubは、WebSocket接続（/v1/realtime）のハブにhubを使います。
+// サーバの停止時にhub.Closeで接続を閉じられるよう、呼び出し側でハブを持つ場合に指定します。
+func WithRealtimeHub(hub *realtime.Hub) RouterOption {
+	return func(o *routerOptions) { o.hub = hub }
+}
+
+// WithWebhooksは、Webhookの購読と配信ログのエンドポイント（/v1/webhooks）を有効にします。
+func WithWebhooks(uc *usecase.WebhookUsecase) RouterOption {
+	return func(o *routerOptions) { o.webhooks = uc }
+}
+
+// WithJobsは、管理者向けのバックグラウンドジョブの確認・再実行のエンドポイント（/v1/admin/jobs）を有効にします。
+func WithJobs(uc *usecase.JobUsecase) RouterOption {
+	return func(o *routerOptions) { o.jobs = uc }
+}
+
+// WithRemindersは、Todoのリマインダーのエンドポイント（/v1/todos/:id/reminders）を有効にします。
+func WithReminders(uc *usecase.ReminderUsecase) RouterOption {
+	return func(o *routerOptions) { o.reminders = uc }
+}
+
+// WithNotificationsは、アプリ内の通知一覧のエンドポイント（/v1/notifications）を有効にします。
+func WithNotifications(uc *usecase.NotificationUsecase) RouterOption {
+	return func(o *routerOptions) { o.notifications = uc }
+}
@@ -0,0 +1,167 @@
+package infrastructure_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"todo_backend/internal/infrastructure"
+	"todo_backend/internal/infrastructure/deprecation"
+	"todo_backend/internal/infrastructure/memory"
+	"todo_backend/internal/infrastructure/metrics"
+	"todo_backend/internal/infrastructure/openapi"
+	"todo_backend/internal/infrastructure/ratelimit"
+	"todo_backend/internal/interface/handler"
+	"todo_backend/internal/interface/repository"
+	"todo_backend/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// OpenAPIドキュメントにはNewRouterが登録するすべてのルートが過不足なく記述されている
+func TestRouter_RoutesMatchOpenAPISpec(t *testing.T) {
+	// given
+	gin.SetMode(gin.TestMode)
+	r, err := infrastructure.NewRouter(handler.NewAuthHandler(nil), usecase.NewTodoUsecase(nil),
+		infrastructure.WithMetrics(metrics.New(prometheus.NewRegistry())),
+		infrastructure.WithWebhooks(usecase.NewWebhookUsecase(nil, nil)),
+		infrastructure.WithJobs(usecase.NewJobUsecase(nil)),
+		infrastructure.WithReminders(usecase.NewReminderUsecase(repository.Repositories{}, nil)),
+		infrastructure.WithNotifications(usecase.NewNotificationUsecase(nil)))
+	require.NoError(t, err)
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	// when
+	var routes []string
+	for _, route := range r.Routes() {
+		routes = append(routes, route.Method+" "+openapi.SpecPath(route.Path))
+	}
+
+	// then
+	assert.ElementsMatch(t, openapi.Operations(doc), routes)
+}
+
+// 旧来のルートは/v1のすべてのエンドポイントの別名で、廃止予定のヘッダを返す
+func TestRouter_LegacyRoutesAliasV1(t *testing.T) {
+	// given
+	gin.SetMode(gin.TestMode)
+	sunset := time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC)
+	r, err := infrastructure.NewRouter(handler.NewAuthHandler(nil), usecase.NewTodoUsecase(nil),
+		infrastructure.WithLegacyRoutes(deprecation.Config{Deprecated: sunset.AddDate(0, -6, 0), Sunset: sunset}))
+	require.NoError(t, err)
+
+	// when
+	v1 := map[string]bool{}
+	var legacy []string
+	for _, route := range r.Routes() {
+		if strings.HasPrefix(route.Path, "/v1/") {
+			v1[route.Method+" "+strings.TrimPrefix(route.Path, "/v1")] = true
+		} else if route.Path != "/openapi.json" && route.Path != "/docs" {
+			legacy = append(legacy, route.Method+" "+route.Path)
+		}
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos/1", nil))
+
+	// then
+	assert.Len(t, legacy, len(v1))
+	for _, route := range legacy {
+		assert.True(t, v1[route], "%s has no /v1 counterpart", route)
+	}
+	// 認証エラーでも廃止予定のヘッダは付与される
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Fri, 30 Apr 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</v1/todos/1>; rel="successor-version"`, w.Header().Get("Link"))
+}
+
+// 信頼するプロキシ以外からのX-Forwarded-Forは無視するため、値を変えても未認証のレート制限は回避できない
+func TestRouter_RateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	login := func(r http.Handler, remoteAddr, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/login", strings.NewReader("{"))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	limit := ratelimit.Limit{Requests: 2, Period: time.Minute}
+
+	t.Run("プロキシを信頼しない場合は接続元のアドレスで制限する", func(t *testing.T) {
+		// given
+		r, err := infrastructure.NewRouter(handler.NewAuthHandler(nil), usecase.NewTodoUsecase(nil),
+			infrastructure.WithRateLimit(ratelimit.NewMemoryStore(), limit, limit))
+		require.NoError(t, err)
+
+		// when
+		var codes []int
+		for i := range 3 {
+			codes = append(codes, login(r, "203.0.113.7:1234", fmt.Sprintf("198.51.100.%d", i+1)))
+		}
+
+		// then
+		assert.Equal(t, []int{http.StatusBadRequest, http.StatusBadRequest, http.StatusTooManyRequests}, codes)
+	})
+
+	t.Run("信頼するプロキシからの接続はX-Forwarded-ForのIPで制限する", func(t *testing.T) {
+		// given
+		r, err := infrastructure.NewRouter(handler.NewAuthHandler(nil), usecase.NewTodoUsecase(nil),
+			infrastructure.WithRateLimit(ratelimit.NewMemoryStore(), limit, limit),
+			infrastructure.WithTrustedProxies("10.0.0.0/8"))
+		require.NoError(t, err)
+
+		// when
+		var codes []int
+		for i := range 3 {
+			codes = append(codes, login(r, "10.0.0.1:1234", fmt.Sprintf("198.51.100.%d", i+1)))
+		}
+
+		// then
+		assert.Equal(t, []int{http.StatusBadRequest, http.StatusBadRequest, http.StatusBadRequest}, codes)
+	})
+}
+
+// 認証必須のルートでも、有効なJWTを持たないリクエストは認証の前にクライアントIPごとに制限する
+func TestRouter_RateLimitsUnauthenticatedRequestsToProtectedRoutes(t *testing.T) {
+	// given: 未認証の上限は2回、ユーザーごとの上限は十分に大きい
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-secret")
+	auth := usecase.NewAuthUsecase(memory.NewUserRepo())
+	require.NoError(t, auth.Signup(context.Background(), "a@example.com", "password", ""))
+	token, err := auth.Login(context.Background(), "a@example.com", "password")
+	require.NoError(t, err)
+	r, err := infrastructure.NewRouter(handler.NewAuthHandler(nil), usecase.NewTodoUsecase(memory.NewTodoRepo()),
+		infrastructure.WithRateLimit(ratelimit.NewMemoryStore(),
+			ratelimit.Limit{Requests: 2, Period: time.Minute}, ratelimit.Limit{Requests: 100, Period: time.Minute}))
+	require.NoError(t, err)
+	get := func(path, authorization string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", authorization)
+		req.RemoteAddr = "203.0.113.7:1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// when
+	var codes []int
+	for _, authorization := range []string{"", "Bearer forged", ""} {
+		codes = append(codes, get("/v1/todos", authorization))
+	}
+	realtime := get("/v1/realtime", "")
+	authorized := get("/v1/todos", "Bearer "+token)
+
+	// then: 不正なトークンも未認証として数え、WebSocketのルートとも上限を共有する。認証済みのリクエストは制限しない
+	assert.Equal(t, []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}, codes)
+	assert.Equal(t, http.StatusTooManyRequests, realtime)
+	assert.Equal(t, http.StatusOK, authorized)
+}
@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// gormSpanKeyはgorm.DBのインスタンス変数にスパンを保存する際のキーです。
+const gormSpanKey = "tracing:span"
+
+// GormPluginはGORMの各クエリに対してクライアントスパンを作成するプラグインです。
+// db.Use(tracing.GormPlugin{})で登録します。
+// スパンはStatement.Context（WithContextで渡したcontext）の子として作成されるため、
+// リポジトリではdb.WithContext(ctx)を使ってcontextを渡す必要があります。
+type GormPlugin struct{}
+
+var _ gorm.Plugin = GormPlugin{}
+
+// Nameはプラグイン名を返します。
+func (GormPlugin) Name() string { return "tracing" }
+
+// InitializeはGORMのコールバックにスパンの開始・終了処理を登録します。
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	tracer := otel.Tracer(instrumentationName)
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tracing:before_create", before(tracer, "create")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", after),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", before(tracer, "query")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", after),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", before(tracer, "update")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", after),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", before(tracer, "delete")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", after),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", before(tracer, "row")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", after),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", before(tracer, "raw")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", after),
+	)
+}
+
+// beforeはクエリ実行前にスパンを開始するコールバックを返します。
+func before(tracer trace.Tracer, op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+		ctx, span := tracer.Start(db.Statement.Context, "gorm."+op,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", db.Dialector.Name()),
+				attribute.String("db.operation.name", op),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+// afterはクエリ実行後にSQL・影響行数・エラーをスパンへ記録して終了するコールバックです。
+func after(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	if db.Statement != nil {
+		span.SetAttributes(
+			attribute.String("db.collection.name", db.Statement.Table),
+			attribute.String("db.query.text", db.Statement.SQL.String()),
+		)
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", db.RowsAffected))
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"todo_backend/internal/infrastructure/logging"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationNameはこのパッケージが作成するスパンの計装スコープ名です。
+const instrumentationName = "todo_backend/internal/infrastructure/tracing"
+
+// Middlewareはリクエストごとにサーバスパンを作成するGinミドルウェアを返します。
+// - 受信ヘッダ（traceparent）から親スパンを引き継ぐ
+// - スパン名は「メソッド + ルートテンプレート」（例: "PUT /todos/:id"）
+// - trace_id / span_idをログ属性に追加し、ログとトレースを突き合わせられるようにする
+// - 5xxの場合はスパンのステータスをErrorにする
+func Middleware() gin.HandlerFunc {
+	tracer := otel.Tracer(instrumentationName)
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+			),
+		)
+		defer span.End()
+
+		if sc := span.SpanContext(); sc.IsValid() {
+			ctx = logging.WithAttrs(ctx,
+				slog.String("trace_id", sc.TraceID().String()),
+				slog.String("span_id", sc.SpanID().String()),
+			)
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+		if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last())
+		}
+	}
+}
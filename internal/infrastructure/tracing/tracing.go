@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Exporterの種類です。OTEL_TRACES_EXPORTER環境変数の値と対応します。
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Configはトレーシングの設定です。
+type Config struct {
+	// Exporterはスパンの出力先です（none / stdout / otlp）。
+	// otlpの場合、送信先はOTEL_EXPORTER_OTLP_ENDPOINTなど標準の環境変数で指定します
+	// （未指定時はローカルのコレクタ http://localhost:4318）。
+	Exporter string
+	// ServiceNameはリソース属性service.nameに設定されるサービス名です。
+	ServiceName string
+}
+
+// ShutdownFuncはバッファ済みのスパンを送信してトレーサプロバイダを停止する関数です。
+type ShutdownFunc func(context.Context) error
+
+// Setupはグローバルなトレーサプロバイダとプロパゲータ（W3C Trace Context / Baggage）を設定します。
+// Exporterがnoneの場合はプロバイダを設定せず、otelのデフォルト（何もしない実装）のままにします。
+// 戻り値のShutdownFuncはサーバ終了時に必ず呼び出してください。
+func Setup(ctx context.Context, cfg Config) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("create resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
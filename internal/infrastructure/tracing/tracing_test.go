@@ -0,0 +1,69 @@
+package tracing_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/mysql"
+	"todo_backend/internal/infrastructure/tracing"
+	"todo_backend/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSpansAcrossHandlerUsecaseRepositoryAndGorm(t *testing.T) {
+	// given
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(tracing.GormPlugin{}))
+	require.NoError(t, db.AutoMigrate(&domain.Todo{}, &domain.TodoChange{}, &domain.TodoChangeLog{}))
+	uc := usecase.NewTodoUsecase(mysql.NewTodoMysql(db))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(tracing.Middleware())
+	r.GET("/todos", func(c *gin.Context) {
+		todos, err := uc.GetTodos(c.Request.Context(), 1)
+		require.NoError(t, err)
+		c.JSON(http.StatusOK, todos)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	// when
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// then
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range rec.Ended() {
+		byName[s.Name()] = s
+	}
+	server := byName["GET /todos"]
+	ucSpan := byName["TodoUsecase.GetTodos"]
+	repoSpan := byName["TodoMysql.FindByUser"]
+	query := byName["gorm.query"]
+	require.NotNil(t, server)
+	require.NotNil(t, ucSpan)
+	require.NotNil(t, repoSpan)
+	require.NotNil(t, query)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, server.SpanContext().SpanID(), ucSpan.Parent().SpanID())
+	assert.Equal(t, ucSpan.SpanContext().SpanID(), repoSpan.Parent().SpanID())
+	assert.Equal(t, repoSpan.SpanContext().SpanID(), query.Parent().SpanID())
+}
@@ -0,0 +1,176 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/usecase"
+)
+
+const (
+	// DefaultConcurrencyは同時に送信する通知先（Webhook）の数のデフォルト値です。
+	DefaultConcurrency = 8
+	// DefaultPollIntervalは再試行待ちの配信を確認する間隔のデフォルト値です。
+	DefaultPollInterval = 5 * time.Second
+	// batchSizeは1回に取り出して送信する配信の数です。
+	batchSize = 100
+)
+
+// Dispatcherは、Todoの変更イベントのジョブ（usecase.JobTypeWebhookTodoEvent）をWebhookの配信待ちに加え、
+// 配信待ちの配信を送信するワーカーです。HandleJobをジョブのワーカーに登録し、Runを別のgoroutineで実行します。
+//
+// イベントはTodoの変更と同じトランザクションでジョブとして保存されるため、サーバが停止してもイベントは失われません。
+// 送信はWebhookごとに1件ずつ順に行い、Concurrency個までのWebhookに並行して送信します。応答の遅い通知先があっても、
+// 他の通知先への送信は待たせません。失敗した配信はWebhookUsecaseの再試行の方針に従ってPollIntervalごとに送信し直します。
+type Dispatcher struct {
+	uc          *usecase.WebhookUsecase
+	concurrency int
+	interval    time.Duration
+	wake        chan struct{}
+
+	mu   sync.Mutex
+	busy map[uint]bool // 送信中のWebhookのID
+	wg   sync.WaitGroup
+}
+
+// コンパイル時に HandleJob が usecase.JobHandler であり、Dispatcher が usecase.NotificationSender を実装しているか確認します。
+var (
+	_ usecase.JobHandler         = (*Dispatcher)(nil).HandleJob
+	_ usecase.NotificationSender = (*Dispatcher)(nil)
+)
+
+// NewDispatcherは、ucの配信をconcurrency個までのWebhookに並行して送信するDispatcherを返します。
+// pollIntervalは再試行待ちの配信を確認する間隔です。0以下の場合はそれぞれデフォルト値を使います。
+func NewDispatcher(uc *usecase.WebhookUsecase, concurrency int, pollInterval time.Duration) *Dispatcher {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	return &Dispatcher{uc: uc, concurrency: concurrency, interval: pollInterval, wake: make(chan struct{}, 1), busy: map[uint]bool{}}
+}
+
+// HandleJobは、ジョブのペイロードのTodoの変更イベントを配信待ちに加え、すぐに送信するようRunに知らせます。
+// ペイロードを読めない場合は再試行しても成功しないため、usecase.PermanentJobErrorを返します。
+func (d *Dispatcher) HandleJob(ctx context.Context, job domain.Job) error {
+	var event domain.TodoEvent
+	if err := json.Unmarshal([]byte(job.Payload), &event); err != nil {
+		return usecase.PermanentJobError(fmt.Errorf("decode todo event: %w", err))
+	}
+	// 再試行で同じイベントを重複して配信待ちに加えないよう、ジョブのIDをイベントのキーにする
+	if err := d.uc.Enqueue(ctx, fmt.Sprintf("job:%d", job.ID), event); err != nil {
+		return err
+	}
+	d.Notify()
+	return nil
+}
+
+// SendNotificationは、リマインダーの通知をreminder.firedとして配信待ちに加え、すぐに送信するようRunに知らせます。
+// チャネルにwebhookを指定したリマインダーの通知の送信に使います。
+func (d *Dispatcher) SendNotification(ctx context.Context, n domain.Notification) error {
+	if err := d.uc.EnqueueNotification(ctx, n); err != nil {
+		return err
+	}
+	d.Notify()
+	return nil
+}
+
+// Notifyは、PollIntervalを待たずに配信待ちの配信を送信するようRunに知らせます。
+func (d *Dispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+		// すでに知らせてある
+	}
+}
+
+// Runはctxがキャンセルされるまで、配信待ちの配信を送信します。
+// 送信中の配信はctxのキャンセルで中断し、次回の起動時に送信し直します。中断した送信が終わるのを待ってから戻ります。
+func (d *Dispatcher) Run(ctx context.Context) {
+	defer d.wg.Wait()
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+			d.deliver(ctx)
+		case <-ticker.C:
+			d.deliver(ctx)
+		}
+	}
+}
+
+// deliverは、送信時刻を迎えた配信をWebhookごとにまとめ、送信中でないWebhookへの送信を同時に送信できる数まで開始します。
+// 送信を終えたWebhookは、残りの配信を送信するようRunに知らせます。
+func (d *Dispatcher) deliver(ctx context.Context) {
+	for {
+		d.mu.Lock()
+		free := d.concurrency - len(d.busy)
+		busy := slices.Collect(maps.Keys(d.busy))
+		d.mu.Unlock()
+		if free <= 0 {
+			return
+		}
+		due, err := d.uc.DueDeliveries(ctx, batchSize, busy...)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "failed to find due webhook deliveries", slog.Any("error", err))
+			}
+			return
+		}
+
+		// 送信時刻の古い順を保ってWebhookごとにまとめる
+		var webhooks []uint
+		byWebhook := map[uint][]domain.WebhookDelivery{}
+		for _, delivery := range due {
+			if _, ok := byWebhook[delivery.WebhookID]; !ok {
+				webhooks = append(webhooks, delivery.WebhookID)
+			}
+			byWebhook[delivery.WebhookID] = append(byWebhook[delivery.WebhookID], delivery)
+		}
+		started := min(free, len(webhooks))
+		for _, id := range webhooks[:started] {
+			d.start(ctx, id, byWebhook[id])
+		}
+		// 取り出しきれなかった配信が他のWebhookにもあり得る場合のみ続けて取り出す
+		if len(due) < batchSize || started < len(webhooks) {
+			return
+		}
+	}
+}
+
+// startは、webhookIDの配信deliveriesを順に送信するgoroutineを開始します。
+func (d *Dispatcher) start(ctx context.Context, webhookID uint, deliveries []domain.WebhookDelivery) {
+	d.mu.Lock()
+	d.busy[webhookID] = true
+	d.mu.Unlock()
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer func() {
+			d.mu.Lock()
+			delete(d.busy, webhookID)
+			d.mu.Unlock()
+			d.Notify()
+		}()
+		for _, delivery := range deliveries {
+			if err := d.uc.Deliver(ctx, delivery); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				slog.ErrorContext(ctx, "failed to deliver webhook", slog.Uint64("webhook_id", uint64(webhookID)),
+					slog.Uint64("delivery_id", uint64(delivery.ID)), slog.Any("error", err))
+			}
+		}
+	}()
+}
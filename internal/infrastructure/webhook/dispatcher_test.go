@@ -0,0 +1,91 @@
+package webhook_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/memory"
+	"todo_backend/internal/infrastructure/webhook"
+	"todo_backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingSenderは、blockedのWebhookへの送信をctxがキャンセルされるまで止め、それ以外は成功させます。
+type blockingSender struct {
+	blocked uint
+	mu      sync.Mutex
+	sent    []uint
+}
+
+func (s *blockingSender) Send(ctx context.Context, w domain.Webhook, d domain.WebhookDelivery) (int, error) {
+	if w.ID == s.blocked {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, d.ID)
+	return 204, nil
+}
+
+func (s *blockingSender) sentCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
+
+// 応答しない通知先があっても、他の通知先への配信は待たずに送信する
+func TestDispatcher_SlowWebhookDoesNotDelayOthers(t *testing.T) {
+	// given
+	repo := memory.NewWebhookRepo()
+	sender := &blockingSender{}
+	uc := usecase.NewWebhookUsecase(repo, sender)
+	ctx := context.Background()
+	slow, err := uc.CreateWebhook(ctx, 1, "https://slow.example.com", domain.WebhookEventTypes, "")
+	require.NoError(t, err)
+	fast, err := uc.CreateWebhook(ctx, 1, "https://fast.example.com", domain.WebhookEventTypes, "")
+	require.NoError(t, err)
+	sender.blocked = slow.ID
+	for i := range 3 {
+		event := domain.TodoEvent{Type: domain.TodoCreated, UserID: 1, Todo: domain.Todo{ID: uint(i + 1), UserID: 1}, OccurredAt: time.Now()}
+		require.NoError(t, uc.Enqueue(ctx, fmt.Sprintf("job:%d", i), event))
+	}
+	d := webhook.NewDispatcher(uc, 2, time.Hour)
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(runCtx)
+	}()
+
+	// when
+	d.Notify()
+
+	// then
+	require.Eventually(t, func() bool { return sender.sentCount() == 3 }, time.Second, 5*time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	// 中断した送信は記録せず、次回に送信し直す
+	toSlow, err := repo.FindDeliveries(ctx, 1, slow.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, toSlow, 3)
+	for _, delivery := range toSlow {
+		assert.Equal(t, domain.WebhookDeliveryPending, delivery.Status)
+		assert.Zero(t, delivery.Attempts)
+	}
+	toFast, err := repo.FindDeliveries(ctx, 1, fast.ID, 10)
+	require.NoError(t, err)
+	for _, delivery := range toFast {
+		assert.Equal(t, domain.WebhookDeliverySucceeded, delivery.Status)
+	}
+}
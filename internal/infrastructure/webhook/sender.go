@@ -0,0 +1,165 @@
+// Package webhookは、Webhookの署名付きHTTPリクエストの送信と、配信ワーカーを提供します。
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/usecase"
+)
+
+// Webhookのリクエストに付けるヘッダです。
+const (
+	// HeaderEventはイベントの種類（todo.createdなど）です。
+	HeaderEvent = "X-Webhook-Event"
+	// HeaderDeliveryは配信のIDです（再試行では同じ、手動の再配信では新しいIDになります）。
+	HeaderDelivery = "X-Webhook-Delivery"
+	// HeaderTimestampは送信時刻（Unix時間の秒）です。署名の対象に含まれます。
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignatureは"sha256=<HMAC-SHA256の16進数>"形式の署名です。
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// DefaultTimeoutは1回の送信の期限のデフォルト値です。
+const DefaultTimeout = 10 * time.Second
+
+// maxResponseBytesは読み捨てる通知先のレスポンスボディの上限です。
+const maxResponseBytes = 64 << 10
+
+// ErrForbiddenAddressは、通知先のURLがループバック・プライベート・リンクローカルなど
+// 公開されていないアドレスに解決されたため、接続しなかったことを表します。
+var ErrForbiddenAddress = errors.New("webhook: destination address is not public")
+
+// reservedPrefixesは、netip.Addrのメソッドでは判定できない、公開されていないアドレスの範囲です。
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),  // キャリアグレードNAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETFプロトコル割り当て
+	netip.MustParsePrefix("198.18.0.0/15"),  // ベンチマーク
+	netip.MustParsePrefix("240.0.0.0/4"),    // 予約済み（ブロードキャストを含む）
+	netip.MustParsePrefix("64:ff9b:1::/48"), // ローカル用のNAT64
+	netip.MustParsePrefix("2001:db8::/32"),  // 文書用
+	netip.MustParsePrefix("fec0::/10"),      // サイトローカル（廃止）
+}
+
+// isPublicはaddrがインターネット上の公開されたアドレスかどうかを返します。
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		// ループバック・リンクローカル・マルチキャスト・未指定のアドレスはIsGlobalUnicastがfalseになる
+		return false
+	}
+	return !slices.ContainsFunc(reservedPrefixes, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+// dialControlは、名前解決後の接続先のアドレスが公開されたアドレスかallowedに含まれることを確認する
+// net.Dialer.Controlの関数を返します。接続の直前に確認するため、DNSリバインディングでも迂回できません。
+func dialControl(allowed []netip.Prefix) func(network, address string, _ syscall.RawConn) error {
+	return func(network, address string, _ syscall.RawConn) error {
+		ap, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+		}
+		addr := ap.Addr().Unmap()
+		if isPublic(addr) || slices.ContainsFunc(allowed, func(p netip.Prefix) bool { return p.Contains(addr) }) {
+			return nil
+		}
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+}
+
+// Signは、送信時刻timestampとボディbodyに対する署名（"sha256=..."）を返します。
+// 署名の対象は"<timestamp>.<body>"で、鍵はWebhookのsecretです。
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verifyは、受信したリクエストのヘッダの送信時刻と署名がボディとsecretに一致するかを検証します。
+// 通知先の実装（とテスト）で使う想定です。リプレイ攻撃を防ぐには、送信時刻が十分新しいことも確認してください。
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature))
+}
+
+// SenderはWebhookのリクエストを署名を付けて送信するusecase.WebhookSenderの実装です。
+type Sender struct {
+	Client *http.Client
+}
+
+// コンパイル時に Sender が usecase.WebhookSender を実装しているか確認します。
+var _ usecase.WebhookSender = (*Sender)(nil)
+
+// NewSenderは1回の送信の期限をtimeoutとするSenderを返します（0以下の場合はDefaultTimeout）。
+// リダイレクトには従わず、3xxは失敗として扱います。
+//
+// サーバの内部のネットワークへのリクエストに使われないよう（SSRF）、公開されていないアドレスには接続せず、
+// ErrForbiddenAddressを返します。ローカルで動かす通知先（開発・テスト用）に送る場合は、そのアドレスの範囲を
+// allowedに指定します。接続先を確認できなくなるため、環境変数のプロキシの設定は使いません。
+func NewSender(timeout time.Duration, allowed ...netip.Prefix) *Sender {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   dialControl(allowed),
+	}).DialContext
+	return &Sender{Client: &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Sendはdeliveryのペイロードをwebhook.URLにPOSTします。通知先が2xx以外を返した場合はエラーを返します。
+func (s *Sender) Send(ctx context.Context, w domain.Webhook, d domain.WebhookDelivery) (int, error) {
+	body := []byte(d.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todo_backend-webhook/1")
+	req.Header.Set(HeaderEvent, string(d.Event))
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(d.ID), 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(w.Secret, ts, body))
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// 接続を再利用できるようにボディを読み捨てる
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxResponseBytes))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected status %s", strings.TrimSpace(res.Status))
+	}
+	return res.StatusCode, nil
+}
@@ -0,0 +1,109 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/webhook"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loopbackはhttptestのサーバに送るために許可するループバックのアドレスです。
+var loopback = netip.MustParsePrefix("127.0.0.0/8")
+
+// 送信したリクエストには、受信側で検証できる署名とイベントのヘッダが付く
+func TestSender_SendsSignedRequest(t *testing.T) {
+	// given
+	var received *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	hook := domain.Webhook{ID: 1, URL: srv.URL, Secret: "whsec_test"}
+	delivery := domain.WebhookDelivery{ID: 42, Event: domain.WebhookTodoCreated, Payload: `{"id":"evt_1","type":"todo.created"}`}
+
+	// when
+	status, err := webhook.NewSender(0, loopback).Send(context.Background(), hook, delivery)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, status)
+	require.NotNil(t, received)
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	assert.Equal(t, "todo.created", received.Header.Get(webhook.HeaderEvent))
+	assert.Equal(t, "42", received.Header.Get(webhook.HeaderDelivery))
+	assert.Equal(t, delivery.Payload, string(body))
+	ts, sig := received.Header.Get(webhook.HeaderTimestamp), received.Header.Get(webhook.HeaderSignature)
+	assert.True(t, webhook.Verify("whsec_test", ts, body, sig))
+	assert.False(t, webhook.Verify("whsec_other", ts, body, sig))
+	assert.False(t, webhook.Verify("whsec_test", ts, append(body, ' '), sig))
+}
+
+// 2xx以外（リダイレクトを含む）は失敗として、ステータスコードとともにエラーを返す
+func TestSender_FailsOnNon2xx(t *testing.T) {
+	for _, status := range []int{http.StatusFound, http.StatusBadRequest, http.StatusInternalServerError} {
+		// given
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if status == http.StatusFound {
+				w.Header().Set("Location", "/elsewhere")
+			}
+			w.WriteHeader(status)
+		}))
+
+		// when
+		got, err := webhook.NewSender(0, loopback).Send(context.Background(),
+			domain.Webhook{URL: srv.URL, Secret: "s"}, domain.WebhookDelivery{ID: 1, Payload: "{}"})
+		srv.Close()
+
+		// then
+		assert.Error(t, err, "status=%d", status)
+		assert.Equal(t, status, got)
+	}
+}
+
+// 公開されていないアドレスには、許可していない限り接続しない
+func TestSender_RefusesNonPublicAddresses(t *testing.T) {
+	// given
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+	delivery := domain.WebhookDelivery{ID: 1, Payload: "{}"}
+
+	for _, url := range []string{
+		srv.URL,
+		"http://10.0.0.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]:1/hook",
+		"http://0.0.0.0:1/hook",
+		"http://100.64.0.1/hook",
+	} {
+		// when
+		status, err := webhook.NewSender(0).Send(context.Background(), domain.Webhook{URL: url, Secret: "s"}, delivery)
+
+		// then
+		assert.ErrorIs(t, err, webhook.ErrForbiddenAddress, url)
+		assert.Zero(t, status)
+	}
+	assert.Zero(t, requests)
+}
+
+// 署名は"<timestamp>.<body>"のHMAC-SHA256で、送信時刻が変われば署名も変わる
+func TestSign_SignsTimestampAndBody(t *testing.T) {
+	assert.Equal(t, "sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163",
+		webhook.Sign("secret", 1700000000, []byte("{}")))
+	assert.NotEqual(t, webhook.Sign("secret", 1700000000, []byte("{}")), webhook.Sign("secret", 1700000001, []byte("{}")))
+	assert.False(t, webhook.Verify("secret", "not-a-number", []byte("{}"), webhook.Sign("secret", 0, []byte("{}"))))
+}
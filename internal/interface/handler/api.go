@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"todo_backend/internal/infrastructure/realtime"
+	"todo_backend/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Mountは、APIの1バージョン分のルートの登録先です。
+// 各グループにはインフラ層で共通のミドルウェア（レート制限・認証など）が適用済みです。
+type Mount struct {
+	// Publicは認証不要のルートの登録先です。
+	Public gin.IRoutes
+	// Protectedは認証必須（JWT）のルートの登録先です。冪等キーのミドルウェアも適用済みです。
+	Protected gin.IRoutes
+	// Idempotentは、Publicのうち作成系のPOSTに個別に付与する冪等キーのミドルウェアです（無効な場合は空）。
+	Idempotent []gin.HandlerFunc
+	// RealtimeはWebSocketのルートの登録先です。認証（クエリパラメータのJWTも可）とレート制限のみ適用済みです。
+	Realtime gin.IRoutes
+	// Adminは管理者用のルート（/admin以下）の登録先です。認証に加えて管理者であることを確認済みです。
+	Admin gin.IRoutes
+}
+
+// APIは、APIの1バージョン分のハンドラです。
+// バージョンごとにリクエスト・レスポンスの形式が異なるハンドラを同じユースケースの上に用意し、
+// インフラ層で/v1・/v2などのプレフィックスにマウントします。
+type API interface {
+	// Registerはこのバージョンのエンドポイントを登録します。
+	Register(m Mount)
+}
+
+// V1はAPI v1のハンドラです。
+type V1 struct {
+	Auth   *AuthHandler
+	TodoUC *usecase.TodoUsecase
+	Hub    *realtime.Hub
+	// WebhooksはWebhookのユースケースです。nilの場合は/webhooksを登録しません。
+	Webhooks *usecase.WebhookUsecase
+	// Jobsはバックグラウンドジョブのユースケースです。nilの場合は/admin/jobsを登録しません。
+	Jobs *usecase.JobUsecase
+	// RemindersはTodoのリマインダーのユースケースです。nilの場合は/todos/:id/remindersを登録しません。
+	Reminders *usecase.ReminderUsecase
+	// Notificationsはアプリ内の通知のユースケースです。nilの場合は/notificationsを登録しません。
+	Notifications *usecase.NotificationUsecase
+}
+
+// NewV1はAPI v1を生成します。hubはWebSocket接続の間でプレゼンスを共有します。
+func NewV1(auth *AuthHandler, todoUC *usecase.TodoUsecase, hub *realtime.Hub) *V1 {
+	return &V1{Auth: auth, TodoUC: todoUC, Hub: hub}
+}
+
+// RegisterはAPI v1のエンドポイントを登録します。
+func (v *V1) Register(m Mount) {
+	// 新規ユーザー登録（再試行による重複登録を防ぐため冪等キーに対応）
+	m.Public.POST("/signup", append(append([]gin.HandlerFunc(nil), m.Idempotent...), v.Auth.Signup)...)
+	// ログイン（JWT 発行）
+	m.Public.POST("/login", v.Auth.Login)
+
+	NewTodoHandler(m.Protected, v.TodoUC)
+	// リアルタイム接続（WebSocket）
+	NewRealtimeHandler(m.Realtime, v.TodoUC, v.Hub)
+	if v.Webhooks != nil {
+		NewWebhookHandler(m.Protected, v.Webhooks)
+	}
+	if v.Jobs != nil {
+		NewJobHandler(m.Admin, v.Jobs)
+	}
+	if v.Reminders != nil {
+		NewReminderHandler(m.Protected, v.Reminders)
+	}
+	if v.Notifications != nil {
+		NewNotificationHandler(m.Protected, v.Notifications)
+	}
+}
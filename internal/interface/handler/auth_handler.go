@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
 	"todo_backend/internal/usecase"
 
 	"github.com/gin-gonic/gin"
@@ -21,25 +24,28 @@ func NewAuthHandler(auth usecase.AuthUsecase) *AuthHandler {
 }
 
 // signupReqは/signupのリクエストボディを表す構造体です。
-// Ginのbindingタグで入力チェック（必須・メール形式・パスワード長）を行います。
+// Ginのbindingタグで入力チェック（必須・メール形式・パスワード長・対応言語）を行います。
 type signupReq struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=8"`
+	// Localeは表示言語の設定です（省略可）。ログイン後のエラーメッセージはこの言語で返します。
+	Locale string `json:"locale" binding:"omitempty,oneof=ja en"`
 }
 
 // Signupは新規ユーザー登録APIです。
 // - リクエストJSONをsignupReqにバインド
-// - バリデーションエラー時は400を返す
+// - バリデーションエラー時は400とフィールドごとの詳細を返す
 // - ユーザー作成失敗（例:重複メール）の場合は409を返す
+// - 処理期限切れの場合は504を返す
 // - 成功時は201を返す
 func (h *AuthHandler) Signup(c *gin.Context) {
 	var req signupReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, bindingError(err, &req))
 		return
 	}
-	if err := h.auth.Signup(req.Email, req.Password); err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	if err := h.auth.Signup(c.Request.Context(), req.Email, req.Password, req.Locale); err != nil {
+		respondError(c, http.StatusConflict, err)
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"message": "ok"})
@@ -54,18 +60,26 @@ type loginReq struct {
 
 // LoginはログインAPIです。
 // - リクエストJSONをloginReqにバインド
-// - バリデーションエラー時は400を返す
-// - 認証失敗時は401を返す
+// - バリデーションエラー時は400とフィールドごとの詳細を返す
+// - 認証失敗時は401を返す（処理期限切れの場合は504）
 // - 認証成功時はJWTを発行して200を返す
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req loginReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, bindingError(err, &req))
+		return
+	}
+	token, err := h.auth.Login(c.Request.Context(), req.Email, req.Password)
+	if isContextErr(err) {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	if errors.Is(err, domain.ErrInvalidCredentials) {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeInvalidCredentials, nil))
 		return
 	}
-	token, err := h.auth.Login(req.Email, req.Password)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"token": token})
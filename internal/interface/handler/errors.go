@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatusClientClosedRequestは、レスポンスを返す前にクライアントが接続を切断したことを表す
+// ステータスコードです（nginxの慣習に合わせた非標準コード）。
+const StatusClientClosedRequest = 499
+
+// respondErrorはエラー内容に応じたステータスコードでエラーレスポンスを返します。
+// レスポンスには機械可読なエラーコード（"code"）と、リクエストの言語に翻訳した文言（"error"）を含めます。
+// - 処理期限切れ（context.DeadlineExceeded）: 504
+// - クライアント切断（context.Canceled）: 499
+// - Todo・Webhook・Webhookの配信・ジョブ・リマインダー・通知が存在しない: 404
+// - バージョン不一致（If-Match / versionが古い）: 412
+// - メールアドレスの重複・デッドレターでないジョブの再実行・期限のないTodoへの期限からの相対のリマインダー: 409
+// - If-Match / versionの指定なし: 428
+// - If-Matchの形式が不正: 400
+// - リクエストボディの検証エラー・JSONの形式の誤り: 400（検証エラーはフィールドごとの詳細を"details"に含める）
+// - リクエストボディが大きすぎる: 413
+// - それ以外: fallbackで指定したステータス（5xxの場合、エラーの内容はログにのみ出力する）
+func respondError(c *gin.Context, fallback int, err error) {
+	status, code := errorStatus(err, fallback)
+	if status >= http.StatusInternalServerError && !isContextErr(err) {
+		slog.ErrorContext(c.Request.Context(), "request failed", slog.Any("error", err))
+	}
+	l := i18n.FromContext(c)
+	body := i18n.Error(c, code, nil)
+	body["error"] = errorMessage(l, code, err)
+	var invalid *validationError
+	if errors.As(err, &invalid) {
+		body["details"] = invalid.localize(l)
+	}
+	c.JSON(status, body)
+}
+
+// errorStatusはrespondErrorの規則でエラーに対応するステータスコードとエラーコード（i18n.Code*）を返します。
+func errorStatus(err error, fallback int) (int, string) {
+	switch {
+	case errors.Is(err, domain.ErrTodoNotFound):
+		return http.StatusNotFound, i18n.CodeTodoNotFound
+	case errors.Is(err, domain.ErrWebhookNotFound):
+		return http.StatusNotFound, i18n.CodeWebhookNotFound
+	case errors.Is(err, domain.ErrWebhookDeliveryNotFound):
+		return http.StatusNotFound, i18n.CodeWebhookDeliveryNotFound
+	case errors.Is(err, domain.ErrJobNotFound):
+		return http.StatusNotFound, i18n.CodeJobNotFound
+	case errors.Is(err, domain.ErrReminderNotFound):
+		return http.StatusNotFound, i18n.CodeReminderNotFound
+	case errors.Is(err, domain.ErrNotificationNotFound):
+		return http.StatusNotFound, i18n.CodeNotificationNotFound
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return http.StatusPreconditionFailed, i18n.CodeVersionMismatch
+	case errors.Is(err, domain.ErrEmailAlreadyExists):
+		return http.StatusConflict, i18n.CodeEmailAlreadyExists
+	case errors.Is(err, domain.ErrJobNotDead):
+		return http.StatusConflict, i18n.CodeJobNotDead
+	case errors.Is(err, domain.ErrTodoHasNoDueDate):
+		return http.StatusConflict, i18n.CodeTodoHasNoDueDate
+	case errors.Is(err, errPreconditionRequired):
+		return http.StatusPreconditionRequired, i18n.CodePreconditionRequired
+	case errors.Is(err, errInvalidETag):
+		return http.StatusBadRequest, i18n.CodeInvalidIfMatch
+	case errors.As(err, new(*validationError)):
+		return http.StatusBadRequest, i18n.CodeInvalidRequest
+	case errors.Is(err, errInvalidJSON):
+		return http.StatusBadRequest, i18n.CodeInvalidJSON
+	case errors.As(err, new(*http.MaxBytesError)):
+		return http.StatusRequestEntityTooLarge, i18n.CodePayloadTooLarge
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, i18n.CodeRequestTimeout
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest, i18n.CodeRequestCanceled
+	default:
+		return fallback, i18n.StatusCode(fallback)
+	}
+}
+
+// errorMessageは、errorStatusで得たエラーコードに対応するlの言語の文言を返します。
+// 検証エラーの場合はフィールドごとの誤りを含めます。
+func errorMessage(l i18n.Locale, code string, err error) string {
+	var invalid *validationError
+	if errors.As(err, &invalid) {
+		return invalid.message(l)
+	}
+	return i18n.Message(l, code, nil)
+}
+
+// isContextErrはエラーが期限切れまたはキャンセルによるものかを判定します。
+func isContextErr(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+}
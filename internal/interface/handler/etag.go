@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+
+	"todo_backend/internal/domain"
+)
+
+// errPreconditionRequiredは更新・削除リクエストにIf-Matchヘッダもversionも含まれていないことを表します。
+var errPreconditionRequired = errors.New("If-Match header or version is required")
+
+// errInvalidETagはIf-Matchヘッダの値がこのAPIの発行したETagとして解釈できないことを表します。
+var errInvalidETag = errors.New("invalid If-Match header")
+
+// todoETagはTodo 1件のETag（強いETag、例: "v3"）を返します。
+// バージョンは更新のたびに進むため、表現が変われば必ずETagも変わります。
+func todoETag(t domain.Todo) string {
+	return `"v` + strconv.FormatUint(uint64(t.Version), 10) + `"`
+}
+
+// listETagはTodo一覧のETag（弱いETag）を返します。
+// 各TodoのIDとバージョンからハッシュを計算するため、追加・更新・削除のいずれでも値が変わります。
+func listETag(todos []domain.Todo) string {
+	h := sha256.New()
+	var buf [16]byte
+	for _, t := range todos {
+		binary.BigEndian.PutUint64(buf[:8], uint64(t.ID))
+		binary.BigEndian.PutUint64(buf[8:], uint64(t.Version))
+		h.Write(buf[:])
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// unreadCountETagは未読の通知の件数のETag（強いETag、例: "u3"）を返します。
+// レスポンスは件数だけなので、件数が同じであれば表現も同じです。
+func unreadCountETag(n int) string {
+	return `"u` + strconv.Itoa(n) + `"`
+}
+
+// parseIfMatchはIf-Matchヘッダから期待するバージョンを取り出します。
+// "*"の場合はwildcardがtrueになります（存在すればバージョンを問わない）。
+// 弱いETagは強い比較に使えないため不正とみなします。
+func parseIfMatch(header string) (version uint, wildcard bool, err error) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return 0, true, nil
+	}
+	if strings.Contains(header, ",") || !strings.HasPrefix(header, `"v`) || !strings.HasSuffix(header, `"`) || len(header) < 4 {
+		return 0, false, errInvalidETag
+	}
+	v, err := strconv.ParseUint(header[2:len(header)-1], 10, 0)
+	if err != nil || v == 0 {
+		return 0, false, errInvalidETag
+	}
+	return uint(v), false, nil
+}
+
+// etagMatchesAnyはIf-None-Matchヘッダの値のいずれかがetagと一致するかを判定します。
+// If-None-Matchでは弱い比較を行うため、"W/"接頭辞は無視して比較します。
+func etagMatchesAny(header, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+	target := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == target {
+			return true
+		}
+	}
+	return false
+}
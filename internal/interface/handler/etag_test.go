@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"testing"
+
+	"todo_backend/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	v, wildcard, err := parseIfMatch(`"v3"`)
+	assert.NoError(t, err)
+	assert.False(t, wildcard)
+	assert.Equal(t, uint(3), v)
+
+	_, wildcard, err = parseIfMatch("*")
+	assert.NoError(t, err)
+	assert.True(t, wildcard)
+
+	for _, bad := range []string{`W/"v3"`, `"3"`, `"v"`, `"v0"`, `"v1", "v2"`, `v3`} {
+		_, _, err := parseIfMatch(bad)
+		assert.ErrorIs(t, err, errInvalidETag, bad)
+	}
+}
+
+func TestListETag_ChangesWithContent(t *testing.T) {
+	a := []domain.Todo{{ID: 1, Version: 1}, {ID: 2, Version: 1}}
+	b := []domain.Todo{{ID: 1, Version: 2}, {ID: 2, Version: 1}}
+	c := []domain.Todo{{ID: 1, Version: 1}}
+
+	assert.Equal(t, listETag(a), listETag(a))
+	assert.NotEqual(t, listETag(a), listETag(b))
+	assert.NotEqual(t, listETag(a), listETag(c))
+}
+
+func TestETagMatchesAny(t *testing.T) {
+	etag := `W/"abc"`
+	assert.True(t, etagMatchesAny(`W/"abc"`, etag))
+	assert.True(t, etagMatchesAny(`"xyz", "abc"`, etag))
+	assert.True(t, etagMatchesAny("*", etag))
+	assert.False(t, etagMatchesAny(`"xyz"`, etag))
+	assert.False(t, etagMatchesAny("", etag))
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
+	"todo_backend/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultJobLimit・maxJobLimitはジョブの一覧の1回の取得件数の既定値と上限です。
+	defaultJobLimit = 50
+	maxJobLimit     = 100
+)
+
+// jobStatusesは一覧の絞り込みに指定できるジョブの状態です。
+var jobStatuses = []string{
+	string(domain.JobPending), string(domain.JobRunning), string(domain.JobSucceeded), string(domain.JobDead),
+}
+
+// JobHandlerは、管理者向けのバックグラウンドジョブの確認・再実行に関するHTTPリクエストを処理するハンドラです。
+type JobHandler struct {
+	Usecase *usecase.JobUsecase
+}
+
+// NewJobHandlerは、JobHandlerを生成し、Ginのルーター（管理者用のグループ）にエンドポイントを登録します。
+func NewJobHandler(r gin.IRoutes, uc *usecase.JobUsecase) {
+	h := &JobHandler{Usecase: uc}
+	r.GET("/jobs", h.GetJobs)
+	r.GET("/jobs/:id", h.GetJob)
+	r.POST("/jobs/:id/retry", h.RetryJob)
+}
+
+// jobResponseはジョブのレスポンスです。
+type jobResponse struct {
+	ID          uint             `json:"id"`
+	Type        string           `json:"type"`
+	Status      domain.JobStatus `json:"status"`
+	Attempts    int              `json:"attempts"`
+	RunAt       time.Time        `json:"run_at"`
+	LockedUntil *time.Time       `json:"locked_until,omitempty"`
+	LastError   string           `json:"last_error,omitempty"`
+	Payload     json.RawMessage  `json:"payload"`
+	CreatedAt   time.Time        `json:"created_at"`
+	FinishedAt  *time.Time       `json:"finished_at,omitempty"`
+}
+
+func newJobResponse(j domain.Job) jobResponse {
+	return jobResponse{
+		ID:          j.ID,
+		Type:        j.Type,
+		Status:      j.Status,
+		Attempts:    j.Attempts,
+		RunAt:       j.RunAt,
+		LockedUntil: j.LockedUntil,
+		LastError:   j.LastError,
+		Payload:     json.RawMessage(j.Payload),
+		CreatedAt:   j.CreatedAt,
+		FinishedAt:  j.FinishedAt,
+	}
+}
+
+// GetJobsは、ジョブを新しい順に返します（status で絞り込み、limit、既定50・最大100）。
+// デッドレターのジョブはstatus=deadで確認できます。
+// HTTP:GET/admin/jobs
+func (h *JobHandler) GetJobs(c *gin.Context) {
+	var errs fieldErrors
+	status := c.Query("status")
+	if status != "" && !slices.Contains(jobStatuses, status) {
+		errs.add("status", i18n.FieldOneOf, i18n.Params{"values": strings.Join(jobStatuses, ", ")})
+	}
+	limit := defaultJobLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			errs.add("limit", i18n.FieldPositiveInteger, nil)
+		}
+		limit = min(n, maxJobLimit)
+	}
+	if err := errs.err(); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	jobs, err := h.Usecase.GetJobs(c.Request.Context(), domain.JobStatus(status), limit)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	out := make([]jobResponse, len(jobs))
+	for i, j := range jobs {
+		out[i] = newJobResponse(j)
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// GetJobはIDのジョブを返します。
+// HTTP:GET/admin/jobs/:id
+func (h *JobHandler) GetJob(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	j, err := h.Usecase.GetJob(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, newJobResponse(j))
+}
+
+// RetryJobは、デッドレターのジョブを実行回数を0に戻して実行し直します。
+// 実行は非同期に行うため202を返します。デッドレター以外のジョブの場合は409を返します。
+// HTTP:POST/admin/jobs/:id/retry
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	j, err := h.Usecase.RetryJob(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, newJobResponse(j))
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
+)
+
+// decodeMergePatchは、JSON Merge Patch（RFC 7396）形式のボディをdomain.TodoPatchに変換します。
+// 変更可能なフィールドはtitle・completed・due_atで、versionは前提条件として扱います。
+// nullによる削除は省略可能なdue_at（期限の削除）のみ受け付けます。
+// titleは前後の空白を除去して検証します。フィールドの誤りはvalidationErrorとしてまとめて返します。
+func decodeMergePatch(body io.Reader) (patch domain.TodoPatch, version uint, err error) {
+	var fields map[string]json.RawMessage
+	dec := json.NewDecoder(body)
+	if err := dec.Decode(&fields); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return patch, 0, err
+		}
+		return patch, 0, fmt.Errorf("%w: invalid merge patch: %v", errInvalidJSON, err)
+	}
+	if fields == nil {
+		// "null"など、オブジェクト以外のパッチはドキュメント全体の置き換えになるため受け付けない
+		return patch, 0, fmt.Errorf("%w: merge patch must be a JSON object", errInvalidJSON)
+	}
+
+	// エラーの順序が毎回同じになるようキー順に検証する
+	var errs fieldErrors
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		raw := fields[key]
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			if key == "due_at" {
+				patch.ClearDueAt = true
+			} else {
+				errs.add(key, i18n.FieldCannotBeNull, nil)
+			}
+			continue
+		}
+		switch key {
+		case "title":
+			var title string
+			if err := json.Unmarshal(raw, &title); err != nil {
+				errs.add(key, i18n.FieldInvalidType, i18n.Params{"type": "string"})
+				continue
+			}
+			patch.Title = &title
+			errs.title(key, patch.Title, false)
+		case "completed":
+			var completed bool
+			if err := json.Unmarshal(raw, &completed); err != nil {
+				errs.add(key, i18n.FieldInvalidType, i18n.Params{"type": "boolean"})
+				continue
+			}
+			patch.Completed = &completed
+		case "due_at":
+			var due time.Time
+			if err := json.Unmarshal(raw, &due); err != nil {
+				errs.add(key, i18n.FieldInvalidType, i18n.Params{"type": "date-time"})
+				continue
+			}
+			patch.DueAt = utc(&due)
+		case "version":
+			if err := json.Unmarshal(raw, &version); err != nil || version == 0 {
+				errs.add(key, i18n.FieldPositiveInteger, nil)
+			}
+		case "id", "user_id":
+			errs.add(key, i18n.FieldImmutable, nil)
+		default:
+			errs.add(key, i18n.FieldNotAllowed, nil)
+		}
+	}
+	if err := errs.err(); err != nil {
+		return domain.TodoPatch{}, 0, err
+	}
+	return patch, version, nil
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeMergePatch_OnlyProvidedFields(t *testing.T) {
+	patch, version, err := decodeMergePatch(strings.NewReader(`{"completed":true,"version":3}`))
+
+	require.NoError(t, err)
+	assert.Nil(t, patch.Title)
+	require.NotNil(t, patch.Completed)
+	assert.True(t, *patch.Completed)
+	assert.Equal(t, uint(3), version)
+}
+
+func TestDecodeMergePatch_RejectsInvalidPatches(t *testing.T) {
+	cases := map[string]string{
+		"not json":       `{`,
+		"not an object":  `null`,
+		"array":          `[]`,
+		"null title":     `{"title":null}`,
+		"wrong type":     `{"completed":"yes"}`,
+		"invalid due_at": `{"due_at":"tomorrow"}`,
+		"blank title":    `{"title":"  "}`,
+		"zero version":   `{"version":0}`,
+		"immutable id":   `{"id":2}`,
+		"immutable user": `{"user_id":2}`,
+		"unknown field":  `{"priority":1}`,
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := decodeMergePatch(strings.NewReader(body))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDecodeMergePatch_TrimsTitleAndReportsAllFields(t *testing.T) {
+	patch, _, err := decodeMergePatch(strings.NewReader(`{"title":"  牛乳を買う  "}`))
+	require.NoError(t, err)
+	assert.Equal(t, "牛乳を買う", *patch.Title)
+
+	_, _, err = decodeMergePatch(strings.NewReader(`{"user_id":2,"title":"","completed":null}`))
+	var invalid *validationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []fieldError{
+		{Field: "completed", Code: "cannot_be_null", Message: "cannot be null"},
+		{Field: "title", Code: "empty", Message: "must not be empty"},
+		{Field: "user_id", Code: "immutable", Message: "cannot be modified"},
+	}, invalid.Fields)
+}
+
+// due_atは日時で設定し、nullで削除する
+func TestDecodeMergePatch_SetsAndClearsDueAt(t *testing.T) {
+	patch, _, err := decodeMergePatch(strings.NewReader(`{"due_at":"2026-10-20T09:00:00+09:00"}`))
+	require.NoError(t, err)
+	require.NotNil(t, patch.DueAt)
+	assert.Equal(t, time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), *patch.DueAt)
+	assert.False(t, patch.ClearDueAt)
+
+	patch, _, err = decodeMergePatch(strings.NewReader(`{"due_at":null}`))
+	require.NoError(t, err)
+	assert.Nil(t, patch.DueAt)
+	assert.True(t, patch.ClearDueAt)
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
+	"todo_backend/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultNotificationLimit・maxNotificationLimitは通知の一覧の1回の取得件数の既定値と上限です。
+	defaultNotificationLimit = 50
+	maxNotificationLimit     = 100
+)
+
+// NotificationHandlerはアプリ内の通知一覧に関するHTTPリクエストを処理するハンドラです。
+type NotificationHandler struct {
+	Usecase *usecase.NotificationUsecase
+}
+
+// NewNotificationHandlerは、NotificationHandlerを生成し、Ginのルーターにエンドポイントを登録します。
+func NewNotificationHandler(r gin.IRoutes, uc *usecase.NotificationUsecase) {
+	h := &NotificationHandler{Usecase: uc}
+	r.GET("/notifications", h.GetNotifications)
+	r.GET("/notifications/unread_count", h.CountUnread)
+	r.POST("/notifications/read_all", h.MarkAllRead)
+	r.POST("/notifications/:id/read", h.MarkRead)
+	r.DELETE("/notifications/:id", h.DeleteNotification)
+}
+
+// notificationResponseは通知のレスポンスです。
+type notificationResponse struct {
+	ID         uint                    `json:"id"`
+	Type       domain.NotificationType `json:"type"`
+	TodoID     uint                    `json:"todo_id"`
+	ReminderID uint                    `json:"reminder_id,omitempty"`
+	Title      string                  `json:"title"`
+	DueAt      *time.Time              `json:"due_at,omitempty"`
+	Read       bool                    `json:"read"`
+	ReadAt     *time.Time              `json:"read_at,omitempty"`
+	CreatedAt  time.Time               `json:"created_at"`
+}
+
+func newNotificationResponse(n domain.Notification) notificationResponse {
+	return notificationResponse{
+		ID:         n.ID,
+		Type:       n.Type,
+		TodoID:     n.TodoID,
+		ReminderID: n.ReminderID,
+		Title:      n.Title,
+		DueAt:      n.DueAt,
+		Read:       n.ReadAt != nil,
+		ReadAt:     n.ReadAt,
+		CreatedAt:  n.CreatedAt,
+	}
+}
+
+// GetNotificationsはログインユーザー宛ての通知を新しい順に返します（unread=trueで未読のみ、limit、既定50・最大100）。
+// HTTP:GET/notifications
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	var errs fieldErrors
+	var unread bool
+	if v := c.Query("unread"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs.add("unread", i18n.FieldInvalidType, i18n.Params{"type": "boolean"})
+		}
+		unread = b
+	}
+	limit := defaultNotificationLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			errs.add("limit", i18n.FieldPositiveInteger, nil)
+		}
+		limit = min(n, maxNotificationLimit)
+	}
+	if err := errs.err(); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	notifications, err := h.Usecase.GetNotifications(c.Request.Context(), userID, unread, limit)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	out := make([]notificationResponse, len(notifications))
+	for i, n := range notifications {
+		out[i] = newNotificationResponse(n)
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// MarkReadはログインユーザー宛ての通知を既読にします。既読の通知を指定しても既読日時は変わりません。
+// HTTP:POST/notifications/:id/read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	n, err := h.Usecase.MarkRead(c.Request.Context(), userID, id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, newNotificationResponse(n))
+}
+
+// MarkAllReadはログインユーザー宛ての未読の通知をすべて既読にし、既読にした件数を返します。
+// HTTP:POST/notifications/read_all
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	read, err := h.Usecase.MarkAllRead(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"read": read})
+}
+
+// DeleteNotificationはログインユーザー宛ての通知を削除します。
+// HTTP:DELETE/notifications/:id
+func (h *NotificationHandler) DeleteNotification(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.Usecase.DeleteNotification(c.Request.Context(), userID, id); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// CountUnreadはログインユーザー宛ての未読の通知の件数を返します。
+// ポーリング向けに件数のETagを返し、If-None-Matchが一致する場合は304を返します。
+// HTTP:GET/notifications/unread_count
+func (h *NotificationHandler) CountUnread(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	unread, err := h.Usecase.CountUnread(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	etag := unreadCountETag(unread)
+	c.Header("ETag", etag)
+	if etagMatchesAny(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread": unread})
+}
@@ -0,0 +1,430 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
+	"todo_backend/internal/infrastructure/realtime"
+	"todo_backend/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// realtimeSendBufferは接続ごとの送信待ちメッセージの上限です。
+	// 超えた場合は受信が追いつかないクライアントとみなして接続を閉じます（1013 Try Again Later）。
+	realtimeSendBuffer = 64
+	// realtimePingPeriodはpingを送る間隔です。realtimePongWaitより短くします。
+	realtimePingPeriod = 30 * time.Second
+	// realtimePongWaitは、クライアントからpongなどのメッセージが届かない場合に切断するまでの時間です。
+	realtimePongWait = 60 * time.Second
+	// realtimeWriteWaitは1件のメッセージの送信にかける時間の上限です。
+	realtimeWriteWait = 10 * time.Second
+	// realtimeMaxMessageSizeはクライアントから受け付けるメッセージの大きさの上限です。
+	realtimeMaxMessageSize = 64 << 10
+	// realtimeMutationTimeoutは1件の変更操作（mutate）の処理期限です。
+	realtimeMutationTimeout = 10 * time.Second
+)
+
+// RealtimeHandlerは、WebSocketでTodoの変更の受信・プレゼンスの共有・変更操作を行うハンドラです。
+type RealtimeHandler struct {
+	Usecase *usecase.TodoUsecase
+	Hub     *realtime.Hub
+}
+
+// NewRealtimeHandlerは、RealtimeHandlerを生成し、Ginのルーターにエンドポイントを登録します。
+func NewRealtimeHandler(r gin.IRoutes, uc *usecase.TodoUsecase, hub *realtime.Hub) {
+	h := &RealtimeHandler{Usecase: uc, Hub: hub}
+	r.GET("/realtime", h.Connect)
+}
+
+// realtimeRequestはクライアントから届くメッセージです。
+// typeはsubscribe / unsubscribe / presence / mutate / pingのいずれかで、
+// idを指定すると対応するack・errorに同じidが付きます。
+type realtimeRequest struct {
+	Type      string                 `json:"type"`
+	ID        string                 `json:"id"`
+	Channel   string                 `json:"channel"`
+	State     string                 `json:"state"`
+	Operation *batchOperationRequest `json:"operation"`
+}
+
+// realtimeWelcomeは接続直後に送るメッセージです。
+type realtimeWelcome struct {
+	Type       string `json:"type"`
+	Connection string `json:"connection"`
+}
+
+// realtimeAckは操作の成功（pingの場合はpong）を表すメッセージです。
+// subscribeではチャネルで状態を設定している他の接続を、mutateでは操作の結果を含めます。
+type realtimeAck struct {
+	Type     string                `json:"type"`
+	ID       string                `json:"id,omitempty"`
+	Presence []realtime.Presence   `json:"presence,omitempty"`
+	Result   *batchOperationResult `json:"result,omitempty"`
+}
+
+// realtimeErrorは操作の失敗を表すメッセージです。codeと文言はHTTPのエラーレスポンスと同じです。
+type realtimeError struct {
+	Type    string       `json:"type"`
+	ID      string       `json:"id,omitempty"`
+	Code    string       `json:"code"`
+	Error   string       `json:"error"`
+	Details []fieldError `json:"details,omitempty"`
+}
+
+// realtimeEventは購読中のチャネルのTodoの変更を表すメッセージです。
+type realtimeEvent struct {
+	Type    string               `json:"type"`
+	Channel string               `json:"channel"`
+	Event   domain.TodoEventType `json:"event"`
+	EventID uint64               `json:"event_id"`
+	Data    todoEventResponse    `json:"data"`
+}
+
+// realtimeNotificationは、"notifications"チャネルに届くログインユーザー宛ての通知です。
+// dataはGET /notificationsの一覧の要素と同じ形式です。
+type realtimeNotification struct {
+	Type    string               `json:"type"`
+	Channel string               `json:"channel"`
+	Data    notificationResponse `json:"data"`
+}
+
+// realtimePresenceは購読中のチャネルで他の接続の状態が変わったことを表すメッセージです。
+type realtimePresence struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	realtime.Presence
+}
+
+// Connectは、WebSocketに切り替えてログインユーザーのリアルタイム接続を開始します。
+// ブラウザのWebSocketはヘッダを指定できないため、JWTはクエリパラメータaccess_tokenでも受け付けます。
+// チャネルは、すべてのTodoの"todos"と、1件のTodoの"todo:<id>"と、アプリ内の通知の"notifications"です。
+// サーバは30秒ごとにpingを送り、60秒間クライアントから応答がない場合は切断します。
+// HTTP:GET/realtime
+func (h *RealtimeHandler) Connect(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+
+	upgrader := websocket.Upgrader{
+		// 認証はCookieではなくJWTで行うため、他のオリジンのページから接続されても
+		// ユーザーの権限を使われることはない（CORSで許可したフロントエンドと同様に受け付ける）
+		CheckOrigin: func(*http.Request) bool { return true },
+		Error: func(_ http.ResponseWriter, _ *http.Request, status int, _ error) {
+			c.JSON(status, i18n.Error(c, i18n.StatusCode(status), nil))
+		},
+	}
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	conn := &realtimeConn{
+		h:      h,
+		ws:     ws,
+		id:     newConnectionID(),
+		userID: userID,
+		locale: i18n.FromContext(c),
+		send:   make(chan any, realtimeSendBuffer),
+		done:   make(chan struct{}),
+	}
+	if !h.Hub.Join(conn) {
+		ws.Close()
+		return
+	}
+	stream := h.Usecase.SubscribeTodoEvents(ctx, userID, 0)
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		conn.writePump()
+	}()
+	go conn.eventPump(stream.Events)
+
+	conn.enqueue(realtimeWelcome{Type: "welcome", Connection: conn.id})
+	conn.readPump(ctx)
+
+	conn.closeWith(websocket.CloseNormalClosure, "")
+	h.Hub.Leave(conn)
+	stream.Close()
+	<-written
+}
+
+// realtimeConnは1つのWebSocket接続です。realtime.Peerを実装します。
+// 送信は書き込み用のgoroutineにまとめ、他のgoroutineはsendに積むだけにします。
+type realtimeConn struct {
+	h      *RealtimeHandler
+	ws     *websocket.Conn
+	id     string
+	userID uint
+	locale i18n.Locale
+	send   chan any
+
+	done      chan struct{}
+	closeOnce sync.Once
+	// closeCode・closeTextはdoneを閉じる前に設定し、閉じた後に読み出します。
+	closeCode int
+	closeText string
+}
+
+func (c *realtimeConn) ID() string   { return c.id }
+func (c *realtimeConn) UserID() uint { return c.userID }
+
+// PresenceChangedは他の接続の状態の変化を送信します。
+func (c *realtimeConn) PresenceChanged(channel string, p realtime.Presence) {
+	c.enqueue(realtimePresence{Type: "presence", Channel: channel, Presence: p})
+}
+
+// NotificationCreatedはユーザー宛ての通知を送信します。
+func (c *realtimeConn) NotificationCreated(n domain.Notification) {
+	c.enqueue(realtimeNotification{Type: "notification", Channel: realtime.NotificationsChannel, Data: newNotificationResponse(n)})
+}
+
+// Closeはサーバの停止に伴って接続を閉じます。
+func (c *realtimeConn) Close() {
+	c.closeWith(websocket.CloseGoingAway, "server shutting down")
+}
+
+// closeWithは、codeとreasonのCloseフレームを送って接続を閉じるよう書き込み用のgoroutineに指示します。
+// 2回目以降の呼び出しは何もしません。
+func (c *realtimeConn) closeWith(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeCode, c.closeText = code, reason
+		close(c.done)
+	})
+}
+
+// enqueueはメッセージを送信待ちに積みます。送信待ちがあふれた場合は接続を閉じます（ブロックしません）。
+func (c *realtimeConn) enqueue(msg any) {
+	select {
+	case <-c.done:
+	case c.send <- msg:
+	default:
+		c.closeWith(websocket.CloseTryAgainLater, "slow consumer")
+	}
+}
+
+// writePumpは送信待ちのメッセージと定期的なpingを書き込みます。接続を閉じるときはCloseフレームを送ります。
+func (c *realtimeConn) writePump() {
+	ping := time.NewTicker(realtimePingPeriod)
+	defer ping.Stop()
+	defer c.ws.Close()
+
+	for {
+		select {
+		case msg := <-c.send:
+			c.ws.SetWriteDeadline(time.Now().Add(realtimeWriteWait))
+			if err := c.ws.WriteJSON(msg); err != nil {
+				c.closeWith(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ping.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(realtimeWriteWait)); err != nil {
+				c.closeWith(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-c.done:
+			if c.closeCode != websocket.CloseAbnormalClosure {
+				msg := websocket.FormatCloseMessage(c.closeCode, c.closeText)
+				c.ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(realtimeWriteWait))
+			}
+			return
+		}
+	}
+}
+
+// eventPumpは、ユーザーのTodoの変更のうち購読中のチャネルのものを送信します。
+// 購読が打ち切られた場合（受信が追いつかない・サーバの停止）は接続を閉じます。
+func (c *realtimeConn) eventPump(events <-chan domain.TodoEvent) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case event, ok := <-events:
+			if !ok {
+				c.closeWith(websocket.CloseTryAgainLater, "event stream closed")
+				return
+			}
+			if channel, ok := c.channelFor(event); ok {
+				c.enqueue(newRealtimeEvent(channel, event))
+			}
+		}
+	}
+}
+
+// channelForはイベントを届けるチャネルを返します。"todos"と"todo:<id>"の両方を購読している場合は"todos"で1回だけ届けます。
+func (c *realtimeConn) channelFor(event domain.TodoEvent) (string, bool) {
+	for _, channel := range []string{"todos", "todo:" + strconv.FormatUint(uint64(event.Todo.ID), 10)} {
+		if c.h.Hub.Subscribed(c, channel) {
+			return channel, true
+		}
+	}
+	return "", false
+}
+
+// readPumpは、接続が閉じられるまでクライアントのメッセージを順に処理します。
+func (c *realtimeConn) readPump(ctx context.Context) {
+	c.ws.SetReadLimit(realtimeMaxMessageSize)
+	alive := func(string) error { return c.ws.SetReadDeadline(time.Now().Add(realtimePongWait)) }
+	alive("")
+	c.ws.SetPongHandler(alive)
+
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		alive("")
+		c.handle(ctx, data)
+	}
+}
+
+// handleは1件のメッセージを処理して結果（ack / error）を送信します。
+func (c *realtimeConn) handle(ctx context.Context, data []byte) {
+	var req realtimeRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		c.replyCode("", i18n.CodeInvalidJSON, nil)
+		return
+	}
+	switch req.Type {
+	case "subscribe":
+		c.subscribe(ctx, req)
+	case "unsubscribe":
+		c.h.Hub.Unsubscribe(c, req.Channel)
+		c.enqueue(realtimeAck{Type: "ack", ID: req.ID})
+	case "presence":
+		c.presence(ctx, req)
+	case "mutate":
+		c.mutate(ctx, req)
+	case "ping":
+		c.enqueue(realtimeAck{Type: "pong", ID: req.ID})
+	default:
+		c.replyCode(req.ID, i18n.CodeUnknownMessageType, i18n.Params{"type": req.Type})
+	}
+}
+
+// subscribeはチャネルを購読します。"todo:<id>"の場合はログインユーザーのTodoであることを確認します。
+func (c *realtimeConn) subscribe(ctx context.Context, req realtimeRequest) {
+	todoID, ok := parseRealtimeChannel(req.Channel)
+	if !ok {
+		c.replyCode(req.ID, i18n.CodeUnknownChannel, i18n.Params{"channel": req.Channel})
+		return
+	}
+	if todoID != 0 {
+		if _, err := c.h.Usecase.GetTodo(ctx, c.userID, todoID); err != nil {
+			c.replyError(ctx, req.ID, err)
+			return
+		}
+	}
+	presence := c.h.Hub.Subscribe(c, req.Channel)
+	c.enqueue(realtimeAck{Type: "ack", ID: req.ID, Presence: presence})
+}
+
+// presenceは購読中のチャネルでの状態（viewing / editing、解除はleft）を設定します。
+func (c *realtimeConn) presence(ctx context.Context, req realtimeRequest) {
+	switch req.State {
+	case realtime.Viewing, realtime.Editing, realtime.Left:
+	default:
+		var errs fieldErrors
+		errs.add("state", i18n.FieldOneOf, i18n.Params{"values": strings.Join([]string{realtime.Viewing, realtime.Editing, realtime.Left}, ", ")})
+		c.replyError(ctx, req.ID, errs.err())
+		return
+	}
+	if !c.h.Hub.SetPresence(c, req.Channel, req.State) {
+		c.replyCode(req.ID, i18n.CodeNotSubscribed, i18n.Params{"channel": req.Channel})
+		return
+	}
+	c.enqueue(realtimeAck{Type: "ack", ID: req.ID})
+}
+
+// mutateは、一括操作（POST /todos/batch）の1件分と同じ形式の操作を実行します。
+// 変更はackの結果に加えて、購読中のすべての接続（自分を含む）にeventとして届きます。
+func (c *realtimeConn) mutate(ctx context.Context, req realtimeRequest) {
+	if req.Operation == nil {
+		var errs fieldErrors
+		errs.add("operation", i18n.FieldRequired, nil)
+		c.replyError(ctx, req.ID, errs.err())
+		return
+	}
+	if err := req.Operation.validate("operation").err(); err != nil {
+		c.replyError(ctx, req.ID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, realtimeMutationTimeout)
+	defer cancel()
+	results, err := c.h.Usecase.ExecuteBatch(ctx, c.userID, []usecase.BatchOperation{req.Operation.toOperation()})
+	if err != nil {
+		var batchErr *usecase.BatchError
+		if errors.As(err, &batchErr) {
+			err = batchErr.Err
+		}
+		c.replyError(ctx, req.ID, err)
+		return
+	}
+	result := newBatchOperationResult(0, results[0])
+	c.enqueue(realtimeAck{Type: "ack", ID: req.ID, Result: &result})
+}
+
+// replyCodeはcodeのerrorメッセージを送信します。
+func (c *realtimeConn) replyCode(id, code string, params i18n.Params) {
+	c.enqueue(realtimeError{Type: "error", ID: id, Code: code, Error: i18n.Message(c.locale, code, params)})
+}
+
+// replyErrorは、respondErrorと同じ規則でerrに対応するerrorメッセージを送信します。
+func (c *realtimeConn) replyError(ctx context.Context, id string, err error) {
+	status, code := errorStatus(err, http.StatusInternalServerError)
+	if status >= http.StatusInternalServerError && !isContextErr(err) {
+		slog.ErrorContext(ctx, "realtime request failed", slog.Any("error", err))
+	}
+	msg := realtimeError{Type: "error", ID: id, Code: code, Error: errorMessage(c.locale, code, err)}
+	var invalid *validationError
+	if errors.As(err, &invalid) {
+		msg.Details = invalid.localize(c.locale)
+	}
+	c.enqueue(msg)
+}
+
+// newRealtimeEventはイベントをchannelのメッセージに変換します。
+func newRealtimeEvent(channel string, event domain.TodoEvent) realtimeEvent {
+	return realtimeEvent{Type: "event", Channel: channel, Event: event.Type, EventID: event.ID, Data: newTodoEventResponse(event)}
+}
+
+// parseRealtimeChannelはチャネル名を解釈し、"todo:<id>"の場合はTodoのIDを返します（"todos"・"notifications"の場合は0）。
+func parseRealtimeChannel(channel string) (uint, bool) {
+	if channel == "todos" || channel == realtime.NotificationsChannel {
+		return 0, true
+	}
+	v, ok := strings.CutPrefix(channel, "todo:")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(v, 10, 0)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// newConnectionIDは接続を識別するランダムな文字列を返します。
+func newConnectionID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
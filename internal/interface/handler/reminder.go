@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
+	"todo_backend/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxMinutesBeforeは期限の何分前に通知するかに指定できる上限（1年）です。
+const maxMinutesBefore = 365 * 24 * 60
+
+// ReminderHandlerはTodoのリマインダーに関するHTTPリクエストを処理するハンドラです。
+type ReminderHandler struct {
+	Usecase *usecase.ReminderUsecase
+}
+
+// NewReminderHandlerは、ReminderHandlerを生成し、Ginのルーターにエンドポイントを登録します。
+func NewReminderHandler(r gin.IRoutes, uc *usecase.ReminderUsecase) {
+	h := &ReminderHandler{Usecase: uc}
+	r.GET("/todos/:id/reminders", h.GetReminders)
+	r.POST("/todos/:id/reminders", h.CreateReminder)
+	r.DELETE("/todos/:id/reminders/:reminder_id", h.DeleteReminder)
+}
+
+// reminderRequestはリマインダーの設定のリクエストボディです。
+// remind_at（日時）とminutes_before（期限の何分前か）のどちらか一方を指定します。
+type reminderRequest struct {
+	RemindAt      *time.Time                   `json:"remind_at"`
+	MinutesBefore *int                         `json:"minutes_before"`
+	Channels      []domain.NotificationChannel `json:"channels"`
+}
+
+// validateはリクエストを検証します。
+func (r *reminderRequest) validate() error {
+	var errs fieldErrors
+	switch {
+	case r.RemindAt == nil && r.MinutesBefore == nil:
+		errs.add("remind_at", i18n.FieldRequired, nil)
+	case r.RemindAt != nil && r.MinutesBefore != nil:
+		errs.add("minutes_before", i18n.FieldNotAllowed, nil)
+	case r.MinutesBefore != nil && (*r.MinutesBefore < 0 || *r.MinutesBefore > maxMinutesBefore):
+		errs.add("minutes_before", i18n.FieldInvalid, nil)
+	}
+
+	channels := make([]string, len(domain.NotificationChannels))
+	for i, ch := range domain.NotificationChannels {
+		channels[i] = string(ch)
+	}
+	if len(r.Channels) > len(channels) {
+		errs.add("channels", i18n.FieldItemCount, i18n.Params{"min": 1, "max": len(channels)})
+	}
+	for i, ch := range r.Channels {
+		if !slices.Contains(channels, string(ch)) {
+			errs.add(fmt.Sprintf("channels[%d]", i), i18n.FieldOneOf, i18n.Params{"values": strings.Join(channels, ", ")})
+		}
+	}
+	return errs.err()
+}
+
+// reminderResponseはリマインダーのレスポンスです。
+type reminderResponse struct {
+	ID            uint                         `json:"id"`
+	TodoID        uint                         `json:"todo_id"`
+	RemindAt      *time.Time                   `json:"remind_at,omitempty"`
+	MinutesBefore *int                         `json:"minutes_before,omitempty"`
+	Channels      []domain.NotificationChannel `json:"channels"`
+	FireAt        *time.Time                   `json:"fire_at,omitempty"`
+	FiredAt       *time.Time                   `json:"fired_at,omitempty"`
+	CreatedAt     time.Time                    `json:"created_at"`
+}
+
+func newReminderResponse(r domain.Reminder) reminderResponse {
+	return reminderResponse{
+		ID:            r.ID,
+		TodoID:        r.TodoID,
+		RemindAt:      r.RemindAt,
+		MinutesBefore: r.MinutesBefore,
+		Channels:      r.Channels,
+		FireAt:        r.FireAt,
+		FiredAt:       r.FiredAt,
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+// GetRemindersはログインユーザーのTodoのリマインダーを作成順に返します。
+// HTTP:GET/todos/:id/reminders
+func (h *ReminderHandler) GetReminders(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	todoID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	reminders, err := h.Usecase.GetReminders(c.Request.Context(), userID, todoID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	out := make([]reminderResponse, len(reminders))
+	for i, r := range reminders {
+		out[i] = newReminderResponse(r)
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// CreateReminderはログインユーザーのTodoにリマインダーを設定します。
+// channelsを省略した場合はアプリ内の通知一覧（GET /notifications）に通知します。
+// minutes_beforeで指定する場合、Todoに期限（due_at）がなければ409を返します。
+// HTTP:POST/todos/:id/reminders
+func (h *ReminderHandler) CreateReminder(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	todoID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req reminderRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	r, err := h.Usecase.CreateReminder(c.Request.Context(), userID, todoID, domain.Reminder{
+		RemindAt:      utc(req.RemindAt),
+		MinutesBefore: req.MinutesBefore,
+		Channels:      req.Channels,
+	})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+strconv.FormatUint(uint64(r.ID), 10))
+	c.JSON(http.StatusCreated, newReminderResponse(r))
+}
+
+// DeleteReminderはログインユーザーのTodoのリマインダーを削除します。
+// HTTP:DELETE/todos/:id/reminders/:reminder_id
+func (h *ReminderHandler) DeleteReminder(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	todoID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "reminder_id")
+	if !ok {
+		return
+	}
+	if err := h.Usecase.DeleteReminder(c.Request.Context(), userID, todoID, id); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
+	"todo_backend/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBatchOperationsは1回の一括操作に含められる操作数の上限です。
+const maxBatchOperations = 100
+
+// batchRequestは一括操作のリクエストボディです。
+type batchRequest struct {
+	Operations []batchOperationRequest `json:"operations"`
+}
+
+// batchOperationRequestは一括操作に含まれる1件の操作です。
+type batchOperationRequest struct {
+	Op        string       `json:"op"`
+	ID        uint         `json:"id"`
+	Version   uint         `json:"version"`
+	Title     *string      `json:"title"`
+	Completed *bool        `json:"completed"`
+	DueAt     nullableTime `json:"due_at"`
+}
+
+// batchOperationResultは1件の操作の結果です。
+// statusは同じ操作を個別のエンドポイントで行った場合のステータスコードに相当します。
+type batchOperationResult struct {
+	Index  int           `json:"index"`
+	Op     string        `json:"op"`
+	Status int           `json:"status"`
+	ID     uint          `json:"id,omitempty"`
+	Todo   *todoResponse `json:"todo,omitempty"`
+	Error  string        `json:"error,omitempty"`
+	Code   string        `json:"code,omitempty"`
+}
+
+// validateは操作を検証し、titleの前後の空白を除去します。
+// fieldはエラーの位置として使う操作の位置（"operations[0]"など）です。
+func (r *batchOperationRequest) validate(field string) fieldErrors {
+	var errs fieldErrors
+	switch usecase.BatchOpType(r.Op) {
+	case usecase.BatchCreate:
+		if r.ID != 0 {
+			errs.add(field+".id", i18n.FieldNotAllowedForOp, i18n.Params{"op": r.Op})
+		}
+		if r.Version != 0 {
+			errs.add(field+".version", i18n.FieldNotAllowedForOp, i18n.Params{"op": r.Op})
+		}
+		errs.title(field+".title", r.Title, true)
+	case usecase.BatchUpdate, usecase.BatchDelete, usecase.BatchComplete:
+		if r.ID == 0 {
+			errs.add(field+".id", i18n.FieldRequired, nil)
+		}
+		if r.Version == 0 && r.Op != string(usecase.BatchComplete) {
+			errs.add(field+".version", i18n.FieldRequired, nil)
+		}
+		if r.Op == string(usecase.BatchUpdate) {
+			errs.title(field+".title", r.Title, false)
+			break
+		}
+		if r.Title != nil {
+			errs.add(field+".title", i18n.FieldNotAllowedForOp, i18n.Params{"op": r.Op})
+		}
+		if r.Completed != nil {
+			errs.add(field+".completed", i18n.FieldNotAllowedForOp, i18n.Params{"op": r.Op})
+		}
+		if r.DueAt.Set {
+			errs.add(field+".due_at", i18n.FieldNotAllowedForOp, i18n.Params{"op": r.Op})
+		}
+	default:
+		errs.add(field+".op", i18n.FieldUnknownOp, i18n.Params{"op": r.Op})
+	}
+	return errs
+}
+
+// toOperationは検証済みの操作をユースケースの操作に変換します。
+func (r batchOperationRequest) toOperation() usecase.BatchOperation {
+	op := usecase.BatchOperation{
+		Type:    usecase.BatchOpType(r.Op),
+		ID:      r.ID,
+		Version: r.Version,
+		Patch:   domain.TodoPatch{Title: r.Title, Completed: r.Completed},
+	}
+	r.DueAt.applyDueAt(&op.Patch)
+	return op
+}
+
+// BatchTodosは、複数のTodo操作（create / update / delete / complete）を1つのトランザクションで実行します。
+// 成功時は200と操作ごとの結果を返します。
+// いずれかの操作が失敗した場合は全体をロールバックし、失敗した操作に応じたステータスコードを返します。
+// そのときresultsには失敗した操作のエラーと、それ以外の操作が取り消された（424）ことを含めます。
+// HTTP:POST/todos/batch
+func (h *TodoHandler) BatchTodos(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+
+	var req batchRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	var errs fieldErrors
+	if len(req.Operations) == 0 || len(req.Operations) > maxBatchOperations {
+		errs.add("operations", i18n.FieldItemCount, i18n.Params{"min": 1, "max": maxBatchOperations})
+	}
+	for i := range req.Operations {
+		errs = append(errs, req.Operations[i].validate(fmt.Sprintf("operations[%d]", i))...)
+	}
+	if err := errs.err(); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	ops := make([]usecase.BatchOperation, len(req.Operations))
+	for i, r := range req.Operations {
+		ops[i] = r.toOperation()
+	}
+
+	results, err := h.Usecase.ExecuteBatch(c.Request.Context(), userID, ops)
+	var batchErr *usecase.BatchError
+	if errors.As(err, &batchErr) && !isContextErr(err) {
+		status, code := errorStatus(batchErr.Err, http.StatusInternalServerError)
+		l := i18n.FromContext(c)
+		body := i18n.Error(c, i18n.CodeBatchFailed, i18n.Params{"index": batchErr.Index, "reason": errorMessage(l, code, batchErr.Err)})
+		body["results"] = failedBatchResults(l, req.Operations, batchErr.Index, status, code)
+		c.JSON(status, body)
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	out := make([]batchOperationResult, len(results))
+	for i, res := range results {
+		out[i] = newBatchOperationResult(i, res)
+	}
+	c.JSON(http.StatusOK, gin.H{"results": out})
+}
+
+// newBatchOperationResultはi番目の操作の成功時の結果を返します。削除した場合はtodoを含めません。
+func newBatchOperationResult(i int, res usecase.BatchResult) batchOperationResult {
+	out := batchOperationResult{Index: i, Op: string(res.Type), Status: http.StatusOK, ID: res.Todo.ID}
+	switch res.Type {
+	case usecase.BatchCreate:
+		out.Status = http.StatusCreated
+	case usecase.BatchDelete:
+		return out
+	}
+	todo := newTodoResponse(res.Todo)
+	out.Todo = &todo
+	return out
+}
+
+// failedBatchResultsは、failed番目の操作がエラーコードcodeで失敗してロールバックされた場合の
+// 操作ごとの結果を返します。エラーの文言はlの言語で返します。
+func failedBatchResults(l i18n.Locale, ops []batchOperationRequest, failed int, status int, code string) []batchOperationResult {
+	out := make([]batchOperationResult, len(ops))
+	for i, op := range ops {
+		out[i] = batchOperationResult{Index: i, Op: op.Op, ID: op.ID, Status: http.StatusFailedDependency}
+		switch {
+		case i < failed:
+			out[i].Code = i18n.CodeRolledBack
+		case i == failed:
+			out[i].Status, out[i].Code = status, code
+		default:
+			out[i].Code = i18n.CodeNotExecuted
+		}
+		out[i].Error = i18n.Message(l, out[i].Code, nil)
+	}
+	return out
+}
+
+// CompleteTodosは、絞り込み条件（クエリパラメータq: タイトルの部分一致）に一致する
+// 未完了のTodoをすべて完了にし、件数と更新後のTodoを返します。
+// HTTP:POST/todos/complete
+func (h *TodoHandler) CompleteTodos(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+
+	completed, err := h.Usecase.CompleteAll(c.Request.Context(), userID, domain.TodoFilter{Query: c.Query("q")})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"completed": len(completed), "todos": newTodoResponses(completed)})
+}
+
+// DeleteCompletedTodosは、完了済みのTodoをすべて削除し、削除件数を返します。
+// HTTP:DELETE/todos/completed
+func (h *TodoHandler) DeleteCompletedTodos(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+
+	deleted, err := h.Usecase.DeleteCompleted(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchOperationRequest_Validation(t *testing.T) {
+	title := "t"
+	valid := []batchOperationRequest{
+		{Op: "create", Title: &title},
+		{Op: "update", ID: 1, Version: 2, Title: &title},
+		{Op: "delete", ID: 1, Version: 2},
+		{Op: "complete", ID: 1},
+	}
+	for _, r := range valid {
+		assert.Empty(t, r.validate("operations[0]"), r.Op)
+	}
+
+	blank, long := "  ", strings.Repeat("あ", maxTitleLength+1)
+	invalid := map[string]struct {
+		req   batchOperationRequest
+		field string
+	}{
+		"unknown op":           {batchOperationRequest{Op: "archive", ID: 1}, "operations[0].op"},
+		"create with id":       {batchOperationRequest{Op: "create", ID: 1, Title: &title}, "operations[0].id"},
+		"create without title": {batchOperationRequest{Op: "create"}, "operations[0].title"},
+		"blank title":          {batchOperationRequest{Op: "update", ID: 1, Version: 1, Title: &blank}, "operations[0].title"},
+		"too long title":       {batchOperationRequest{Op: "create", Title: &long}, "operations[0].title"},
+		"update without id":    {batchOperationRequest{Op: "update", Version: 1}, "operations[0].id"},
+		"delete without ver":   {batchOperationRequest{Op: "delete", ID: 1}, "operations[0].version"},
+		"complete with title":  {batchOperationRequest{Op: "complete", ID: 1, Title: &title}, "operations[0].title"},
+		"delete with due_at":   {batchOperationRequest{Op: "delete", ID: 1, Version: 1, DueAt: nullableTime{Set: true}}, "operations[0].due_at"},
+	}
+	for name, tc := range invalid {
+		errs := tc.req.validate("operations[0]")
+		require.Len(t, errs, 1, name)
+		assert.Equal(t, tc.field, errs[0].Field, name)
+	}
+}
+
+func TestBatchOperationRequest_TrimsTitle(t *testing.T) {
+	title := "  牛乳を買う\n"
+	r := batchOperationRequest{Op: "create", Title: &title}
+
+	errs := r.validate("operations[0]")
+
+	assert.Empty(t, errs)
+	assert.Equal(t, "牛乳を買う", *r.toOperation().Patch.Title)
+}
+
+func TestBatchOperationRequest_MapsDueAt(t *testing.T) {
+	due := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+	cases := map[string]struct {
+		body string
+		want domain.TodoPatch
+	}{
+		"omitted": {`{"operations":[{"op":"update","id":1,"version":1}]}`, domain.TodoPatch{}},
+		"null":    {`{"operations":[{"op":"update","id":1,"version":1,"due_at":null}]}`, domain.TodoPatch{ClearDueAt: true}},
+		"set":     {`{"operations":[{"op":"update","id":1,"version":1,"due_at":"2026-10-20T18:00:00+09:00"}]}`, domain.TodoPatch{DueAt: &due}},
+	}
+	for name, tc := range cases {
+		var req batchRequest
+		require.NoError(t, bindJSON(newJSONContext(tc.body), &req), name)
+		require.Empty(t, req.Operations[0].validate("operations[0]"), name)
+
+		assert.Equal(t, tc.want, req.Operations[0].toOperation().Patch, name)
+	}
+}
+
+func TestFailedBatchResults_MarksRolledBackAndSkipped(t *testing.T) {
+	ops := []batchOperationRequest{{Op: "create"}, {Op: "delete", ID: 2}, {Op: "complete", ID: 3}}
+
+	got := failedBatchResults(i18n.English, ops, 1, http.StatusPreconditionFailed, i18n.CodeVersionMismatch)
+
+	require.Len(t, got, 3)
+	assert.Equal(t, http.StatusFailedDependency, got[0].Status)
+	assert.Equal(t, "rolled_back", got[0].Code)
+	assert.Equal(t, "rolled back", got[0].Error)
+	assert.Equal(t, http.StatusPreconditionFailed, got[1].Status)
+	assert.Equal(t, "version_mismatch", got[1].Code)
+	assert.Equal(t, "todo version mismatch", got[1].Error)
+	assert.Equal(t, "not_executed", got[2].Code)
+	assert.Equal(t, "not executed", got[2].Error)
+}
+
+func TestFailedBatchResults_LocalizesErrors(t *testing.T) {
+	ops := []batchOperationRequest{{Op: "create"}, {Op: "delete", ID: 2}}
+
+	got := failedBatchResults(i18n.Japanese, ops, 1, http.StatusNotFound, i18n.CodeTodoNotFound)
+
+	assert.Equal(t, "取り消されました", got[0].Error)
+	assert.Equal(t, "todo_not_found", got[1].Code)
+	assert.Equal(t, "Todo が見つかりません", got[1].Error)
+}
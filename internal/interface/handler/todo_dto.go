@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
+)
+
+// todoResponseはTodoのレスポンスです。
+// domain.Todoの内部表現の変更がAPIの形式に影響しないよう、レスポンスはこの型に変換して返します。
+type todoResponse struct {
+	ID        uint       `json:"id"`
+	UserID    uint       `json:"user_id"`
+	Title     string     `json:"title"`
+	Completed bool       `json:"completed"`
+	DueAt     *time.Time `json:"due_at,omitempty"`
+	Version   uint       `json:"version"`
+}
+
+// newTodoResponseはdomain.Todoをレスポンスに変換します。
+func newTodoResponse(t domain.Todo) todoResponse {
+	return todoResponse{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Title:     t.Title,
+		Completed: t.Completed,
+		DueAt:     t.DueAt,
+		Version:   t.Version,
+	}
+}
+
+// newTodoResponsesはTodoの一覧をレスポンスに変換します。0件の場合も空の配列を返します。
+func newTodoResponses(todos []domain.Todo) []todoResponse {
+	out := make([]todoResponse, len(todos))
+	for i, t := range todos {
+		out[i] = newTodoResponse(t)
+	}
+	return out
+}
+
+// createTodoRequestはTodoの作成（POST /todos）のリクエストです。
+// id・user_id・versionはサーバが決めるため指定できません。
+type createTodoRequest struct {
+	Title     *string    `json:"title"`
+	Completed *bool      `json:"completed"`
+	DueAt     *time.Time `json:"due_at"`
+}
+
+// validateはリクエストを検証し、titleの前後の空白を除去します。
+func (r *createTodoRequest) validate() error {
+	var errs fieldErrors
+	errs.title("title", r.Title, true)
+	return errs.err()
+}
+
+// toDomainは検証済みのリクエストをuserIDのTodoに変換します。
+func (r createTodoRequest) toDomain(userID uint) domain.Todo {
+	todo := domain.Todo{UserID: userID, Title: *r.Title, DueAt: utc(r.DueAt)}
+	if r.Completed != nil {
+		todo.Completed = *r.Completed
+	}
+	return todo
+}
+
+// replaceTodoRequestはTodoの全体更新（PUT /todos/:id）のリクエストです。
+// idは省略可能で、指定する場合はパスのidと一致している必要があります。
+// due_atを省略した場合は期限なしになります（全体の置き換えのため）。
+// versionはIf-Matchヘッダの代わりに前提条件として指定できます。
+type replaceTodoRequest struct {
+	ID        *uint      `json:"id"`
+	Title     *string    `json:"title"`
+	Completed *bool      `json:"completed"`
+	DueAt     *time.Time `json:"due_at"`
+	Version   *uint      `json:"version"`
+}
+
+// validateはパスのidに対してリクエストを検証し、titleの前後の空白を除去します。
+func (r *replaceTodoRequest) validate(id uint) error {
+	var errs fieldErrors
+	if r.ID != nil && *r.ID != id {
+		errs.add("id", i18n.FieldIDMismatch, nil)
+	}
+	errs.title("title", r.Title, true)
+	if r.Version != nil && *r.Version == 0 {
+		errs.add("version", i18n.FieldPositiveInteger, nil)
+	}
+	return errs.err()
+}
+
+// toDomainは検証済みのリクエストをuserIDのid番のTodoに変換します。
+// versionが指定されていない場合、Versionは0（未指定）になります。
+func (r replaceTodoRequest) toDomain(userID, id uint) domain.Todo {
+	todo := domain.Todo{ID: id, UserID: userID, Title: *r.Title, DueAt: utc(r.DueAt)}
+	if r.Completed != nil {
+		todo.Completed = *r.Completed
+	}
+	if r.Version != nil {
+		todo.Version = *r.Version
+	}
+	return todo
+}
+
+// utcは日時をUTCに揃えます（nilの場合はnil）。
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// nullableTimeは、省略・null・日時を区別して受け取る日時のフィールドです。
+// nullは値の削除を意味します。
+type nullableTime struct {
+	// Setはフィールドが指定された（nullを含む）かどうかです。
+	Set bool
+	// Valueは指定された日時（UTC）です。nullの場合はnilです。
+	Value *time.Time
+}
+
+// UnmarshalJSONはフィールドが指定されたことを記録して日時を読み取ります。
+func (t *nullableTime) UnmarshalJSON(data []byte) error {
+	t.Set = true
+	t.Value = nil
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+	var v time.Time
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	t.Value = utc(&v)
+	return nil
+}
+
+// applyDueAtは期限の指定をTodoPatchに反映します。省略された場合は何もしません。
+func (t nullableTime) applyDueAt(patch *domain.TodoPatch) {
+	switch {
+	case !t.Set:
+	case t.Value == nil:
+		patch.ClearDueAt = true
+	default:
+		patch.DueAt = t.Value
+	}
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newJSONContextはbodyをリクエストボディに持つテスト用のgin.Contextを返します。
+func newJSONContext(body string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/todos", strings.NewReader(body))
+	return c
+}
+
+func TestCreateTodoRequest_MapsToDomain(t *testing.T) {
+	var req createTodoRequest
+	require.NoError(t, bindJSON(newJSONContext(`{"title":"  牛乳を買う ","completed":true}`), &req))
+
+	require.NoError(t, req.validate())
+
+	assert.Equal(t, domain.Todo{UserID: 7, Title: "牛乳を買う", Completed: true}, req.toDomain(7))
+}
+
+func TestCreateTodoRequest_RejectsInvalidBodies(t *testing.T) {
+	cases := map[string]struct {
+		body  string
+		field string
+	}{
+		"missing title":  {`{"completed":true}`, "title"},
+		"null title":     {`{"title":null}`, "title"},
+		"blank title":    {`{"title":" \t "}`, "title"},
+		"too long title": {`{"title":"` + strings.Repeat("a", maxTitleLength+1) + `"}`, "title"},
+		"wrong type":     {`{"title":"t","completed":"yes"}`, "completed"},
+		"id is not set":  {`{"title":"t","id":1}`, "id"},
+		"user_id":        {`{"title":"t","user_id":2}`, "user_id"},
+		"unknown field":  {`{"title":"t","priority":1}`, "priority"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var req createTodoRequest
+			err := bindJSON(newJSONContext(tc.body), &req)
+			if err == nil {
+				err = req.validate()
+			}
+
+			var invalid *validationError
+			require.ErrorAs(t, err, &invalid)
+			require.Len(t, invalid.Fields, 1)
+			assert.Equal(t, tc.field, invalid.Fields[0].Field)
+			status, _ := errorStatus(err, http.StatusInternalServerError)
+			assert.Equal(t, http.StatusBadRequest, status)
+		})
+	}
+}
+
+func TestCreateTodoRequest_AcceptsMaxLengthTitle(t *testing.T) {
+	title := strings.Repeat("あ", maxTitleLength)
+	req := createTodoRequest{Title: &title}
+
+	assert.NoError(t, req.validate())
+}
+
+func TestBindJSON_MalformedAndOversizedBodies(t *testing.T) {
+	var req createTodoRequest
+
+	err := bindJSON(newJSONContext(`{"title":`), &req)
+	assert.ErrorIs(t, err, errInvalidJSON)
+
+	err = bindJSON(newJSONContext(`{"title":"`+strings.Repeat("a", maxRequestBodyBytes)+`"}`), &req)
+	status, code := errorStatus(err, http.StatusInternalServerError)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+	assert.Equal(t, "payload_too_large", code)
+}
+
+func TestReplaceTodoRequest_Validation(t *testing.T) {
+	title, version, other := "t", uint(3), uint(9)
+
+	req := replaceTodoRequest{Title: &title, Version: &version}
+	require.NoError(t, req.validate(1))
+	assert.Equal(t, domain.Todo{ID: 1, UserID: 2, Title: "t", Version: 3}, req.toDomain(2, 1))
+
+	// 複数の誤りはまとめて返す
+	err := (&replaceTodoRequest{ID: &other}).validate(1)
+	var invalid *validationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []fieldError{
+		{Field: "id", Code: "id_mismatch", Message: "must match the id in the path"},
+		{Field: "title", Code: "required", Message: "is required"},
+	}, invalid.Fields)
+	assert.Equal(t, "invalid request: id must match the id in the path; title is required", err.Error())
+	// 文言はリクエストの言語に翻訳する（コードは変わらない）
+	assert.Equal(t, "入力内容に誤りがあります: id: パスの ID と一致させてください; title: 必須です", invalid.message(i18n.Japanese))
+	assert.Equal(t, "required", invalid.localize(i18n.Japanese)[1].Code)
+}
+
+func TestNewTodoResponses_EmptyListIsNotNull(t *testing.T) {
+	assert.NotNil(t, newTodoResponses(nil))
+	assert.Equal(t, []todoResponse{{ID: 1, UserID: 2, Title: "t", Completed: true, Version: 3}},
+		newTodoResponses([]domain.Todo{{ID: 1, UserID: 2, Title: "t", Completed: true, Version: 3}}))
+}
@@ -3,8 +3,9 @@ package handler
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
-	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
 	jwtmw "todo_backend/internal/infrastructure/jwt"
 	"todo_backend/internal/usecase"
 
@@ -23,9 +24,26 @@ type TodoHandler struct {
 func NewTodoHandler(r gin.IRoutes, uc *usecase.TodoUsecase) {
 	h := &TodoHandler{Usecase: uc}
 	r.GET("/todos", h.GetTodos)
+	// 変更の通知（Server-Sent Events）
+	r.GET("/todos/stream", h.StreamTodos)
+	r.GET("/todos/:id", h.GetTodo)
 	r.POST("/todos", h.CreateTodo)
 	r.PUT("/todos/:id", h.UpdateTodo)
+	r.PATCH("/todos/:id", h.PatchTodo)
 	r.DELETE("/todos/:id", h.DeleteTodo)
+	// 一括操作
+	r.POST("/todos/batch", h.BatchTodos)
+	r.POST("/todos/complete", h.CompleteTodos)
+	r.DELETE("/todos/completed", h.DeleteCompletedTodos)
+	// 差分同期（オフライン対応のクライアント向け）
+	r.GET("/sync", h.PullChanges)
+	r.POST("/sync", h.PushChanges)
+}
+
+// parseTodoIDは、URLパラメータ:idを正の整数として取り出します。
+// 不正な値の場合は400を返してfalseを返します。
+func parseTodoID(c *gin.Context) (uint, bool) {
+	return parseIDParam(c, "id")
 }
 
 func getUserID(c *gin.Context) (uint, bool) {
@@ -38,91 +56,238 @@ func getUserID(c *gin.Context) (uint, bool) {
 }
 
 // GetTodosは、全てのTodoを取得してJSON形式で返します。
+// 一覧のETagを返し、If-None-Matchが一致する場合は本文なしの304を返します。
 // HTTP:GET/todos
 func (h *TodoHandler) GetTodos(c *gin.Context) {
 	userID, ok := getUserID(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "unauthorized"})
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.CodeUnauthorized, nil))
 		return
 	}
 
-	todos, err := h.Usecase.GetTodos(userID)
+	todos, err := h.Usecase.GetTodos(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	etag := listETag(todos)
+	c.Header("ETag", etag)
+	if etagMatchesAny(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
 		return
 	}
-	c.JSON(http.StatusOK, todos)
+	c.JSON(http.StatusOK, newTodoResponses(todos))
+}
+
+// GetTodoは、指定されたIDのTodoを1件取得してJSON形式で返します。
+// ETagを返し、If-None-Matchが一致する場合は本文なしの304を返します。
+// HTTP:GET/todos/:id
+func (h *TodoHandler) GetTodo(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+
+	id, ok := parseTodoID(c)
+	if !ok {
+		return
+	}
+
+	todo, err := h.Usecase.GetTodo(c.Request.Context(), userID, id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	etag := todoETag(todo)
+	c.Header("ETag", etag)
+	if etagMatchesAny(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, newTodoResponse(todo))
 }
 
 // CreateTodoは、新しいTodoを作成します。
-// リクエストボディはJSON形式で、Todo構造体にバインドされます。
+// リクエストボディはcreateTodoRequestとして読み込み、検証します（titleは必須、id / user_idは指定不可）。
+// 成功時は201と作成されたTodoを返し、LocationヘッダにそのURLを設定します。
 // HTTP:POST/todos
 func (h *TodoHandler) CreateTodo(c *gin.Context) {
 	userID, ok := getUserID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
 		return
 	}
 
-	var todo domain.Todo
-	if err := c.ShouldBindJSON(&todo); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req createTodoRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
-	todo.UserID = userID
 
-	if err := h.Usecase.AddTodo(todo); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	created, err := h.Usecase.AddTodo(c.Request.Context(), req.toDomain(userID))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"message": "created"})
+	// リクエストパス（/todos）を基準にするため、プレフィックス付きでマウントされても正しいURLになる
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+strconv.FormatUint(uint64(created.ID), 10))
+	c.Header("ETag", todoETag(created))
+	c.JSON(http.StatusCreated, newTodoResponse(created))
 }
 
 // UpdateTodoは、既存のTodoを更新します。
-// リクエストボディはJSON形式で、Todo構造体にバインドされます。
+// リクエストボディはreplaceTodoRequestとして読み込み、検証します（titleは必須）。
+// 更新対象はURLパラメータ:idで決まり、ボディのidを指定する場合はパスと一致している必要があります。
+// If-Matchヘッダ（またはボディのversion）で更新前のバージョンを指定する必要があり、
+// 一致しない場合は412、指定がない場合は428を返します。
+// 成功時は更新後のTodoと新しいETagを返します。
 // HTTP:PUT/todos/:id
 func (h *TodoHandler) UpdateTodo(c *gin.Context) {
 	userID, ok := getUserID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+
+	id, ok := parseTodoID(c)
+	if !ok {
+		return
+	}
+
+	var req replaceTodoRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := req.validate(id); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	todo := req.toDomain(userID, id)
+
+	version, err := h.expectedVersion(c, userID, todo.ID, todo.Version)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	todo.Version = version
+
+	updated, err := h.Usecase.UpdateTodo(c.Request.Context(), todo)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Header("ETag", todoETag(updated))
+	c.JSON(http.StatusOK, newTodoResponse(updated))
+}
+
+// PatchTodoは、JSON Merge Patch（RFC 7396）の形式でTodoを部分更新します。
+// ボディに含まれるフィールド（title / completed）のみを更新し、省略されたフィールドは変更しません。
+// id / user_idなど変更できないフィールドや、必須フィールドへのnull指定は400を返します。
+// 前提条件（If-Match / ボディのversion）の扱いはPUTと同じです。
+// HTTP:PATCH/todos/:id
+func (h *TodoHandler) PatchTodo(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
 		return
 	}
 
-	var todo domain.Todo
-	if err := c.ShouldBindJSON(&todo); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	id, ok := parseTodoID(c)
+	if !ok {
 		return
 	}
-	todo.UserID = userID
 
-	if err := h.Usecase.UpdateTodo(todo); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	limitBody(c)
+	patch, bodyVersion, err := decodeMergePatch(c.Request.Body)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "updated"})
+
+	version, err := h.expectedVersion(c, userID, id, bodyVersion)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	updated, err := h.Usecase.PatchTodo(c.Request.Context(), userID, id, version, patch)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Header("ETag", todoETag(updated))
+	c.JSON(http.StatusOK, newTodoResponse(updated))
 }
 
 // DeleteTodo は、指定されたIDのTodoを削除します。
-// URLパラメータ:idを整数に変換して処理します。
+// If-Matchヘッダ（またはクエリパラメータversion）で削除対象のバージョンを指定する必要があります。
 // HTTP: DELETE /todos/:id
 func (h *TodoHandler) DeleteTodo(c *gin.Context) {
 	userID, ok := getUserID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+
+	id, ok := parseTodoID(c)
+	if !ok {
 		return
 	}
 
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
+	var queryVersion uint
+	if v := c.Query("version"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 0)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.CodeInvalidVersion, nil))
+			return
+		}
+		queryVersion = uint(n)
+	}
+	version, err := h.expectedVersion(c, userID, id, queryVersion)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	if err := h.Usecase.DeleteTodo(userID, id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.Usecase.DeleteTodo(c.Request.Context(), userID, id, version); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
 }
+
+// expectedVersionは、更新・削除の前提条件となるバージョンを決定します。
+// 1. If-Matchヘッダがあればそれを優先（"*"の場合は現在のバージョン）
+// 2. なければリクエストで指定されたversion（0は未指定）
+// 3. どちらもなければerrPreconditionRequired
+func (h *TodoHandler) expectedVersion(c *gin.Context, userID uint, id uint, fallback uint) (uint, error) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		if fallback == 0 {
+			return 0, errPreconditionRequired
+		}
+		return fallback, nil
+	}
+
+	version, wildcard, err := parseIfMatch(header)
+	if err != nil {
+		return 0, err
+	}
+	if wildcard {
+		current, err := h.Usecase.GetTodo(c.Request.Context(), userID, id)
+		if err != nil {
+			return 0, err
+		}
+		return current.Version, nil
+	}
+	return version, nil
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeatは、接続を維持するためにイベントがなくてもコメント行を送る間隔です。
+// プロキシのアイドルタイムアウトより短くします。
+const streamHeartbeat = 15 * time.Second
+
+// streamRetryは、切断時にクライアント（EventSource）が再接続するまでの待ち時間の指定です。
+const streamRetry = 3 * time.Second
+
+// todoEventResponseはTodoの変更イベントのデータ（SSEのdata）です。
+// 削除イベントにはtodoを含めません。
+type todoEventResponse struct {
+	ID   uint          `json:"id"`
+	Todo *todoResponse `json:"todo,omitempty"`
+}
+
+// StreamTodosは、ログインユーザーのTodoの変更（created / updated / deleted）を
+// Server-Sent Events（text/event-stream）で配信します。
+// 各イベントのidを再接続時のLast-Event-IDヘッダ（またはクエリパラメータlast_event_id）に指定すると、
+// 切断中のイベントから再開します。保持していない範囲からの再開の場合は、先にresetイベントを送ります
+// （クライアントは一覧を取得し直してください）。
+// HTTP:GET/todos/stream
+func (h *TodoHandler) StreamTodos(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	lastEventID, ok := parseLastEventID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.CodeInvalidLastEventID, nil))
+		return
+	}
+
+	ctx := c.Request.Context()
+	stream := h.Usecase.SubscribeTodoEvents(ctx, userID, lastEventID)
+	defer stream.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	// nginxなどのプロキシにバッファリングさせない
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry.Milliseconds())
+	if stream.Reset {
+		fmt.Fprint(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range stream.Replay {
+		writeTodoEvent(c.Writer, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-stream.Events:
+			if !ok {
+				// 受信が追いつかない・サーバの停止などで購読が打ち切られた（クライアントは再接続して再開する）
+				return
+			}
+			writeTodoEvent(c.Writer, event)
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+// parseLastEventIDは再開位置のイベントIDを取り出します。指定がない場合は0を返します。
+func parseLastEventID(c *gin.Context) (uint64, bool) {
+	v := c.GetHeader("Last-Event-ID")
+	if v == "" {
+		v = c.Query("last_event_id")
+	}
+	if v == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseUint(v, 10, 64)
+	return id, err == nil
+}
+
+// newTodoEventResponseはイベントのデータを返します。削除イベントにはtodoを含めません。
+func newTodoEventResponse(event domain.TodoEvent) todoEventResponse {
+	data := todoEventResponse{ID: event.Todo.ID}
+	if event.Type != domain.TodoDeleted {
+		todo := newTodoResponse(event.Todo)
+		data.Todo = &todo
+	}
+	return data
+}
+
+// writeTodoEventはイベントをSSEの形式で書き込みます。
+func writeTodoEvent(w io.Writer, event domain.TodoEvent) {
+	encoded, _ := json.Marshal(newTodoEventResponse(event))
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, encoded)
+}
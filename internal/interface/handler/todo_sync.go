@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
+	"todo_backend/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultSyncLimitは差分同期で1回に返す変更数の既定値です。
+	defaultSyncLimit = 500
+	// maxSyncLimitは差分同期で1回に返す変更数の上限です。
+	maxSyncLimit = 1000
+	// maxSyncPushChangesは1回の送信に含められる変更数の上限です。
+	maxSyncPushChanges = 100
+)
+
+// syncChangeResponseは差分同期で返す1件の変更です。削除された場合はtodoを含めません。
+type syncChangeResponse struct {
+	ID      uint          `json:"id"`
+	Deleted bool          `json:"deleted"`
+	Todo    *todoResponse `json:"todo,omitempty"`
+}
+
+// syncPushRequestは差分同期の送信のリクエストボディです。
+type syncPushRequest struct {
+	Changes []syncPushChange `json:"changes"`
+}
+
+// syncPushChangeは、オフライン中にクライアントで行われた1件の変更です。
+// idが0の場合は作成、deletedがtrueの場合は削除、それ以外は指定したフィールドの更新です。
+// client_idは結果に含めて返すだけで、クライアントで作成したTodoとサーバのIDの対応付けに使えます。
+type syncPushChange struct {
+	ClientID  string       `json:"client_id"`
+	ID        uint         `json:"id"`
+	Version   uint         `json:"version"`
+	Deleted   bool         `json:"deleted"`
+	Title     *string      `json:"title"`
+	Completed *bool        `json:"completed"`
+	DueAt     nullableTime `json:"due_at"`
+}
+
+// syncPushResultは1件の変更の結果です。
+// 競合した場合は、サーバ上の現在のTodo（削除されていた場合は省略）と競合の理由のコードを返します。
+type syncPushResult struct {
+	Index    int           `json:"index"`
+	ClientID string        `json:"client_id,omitempty"`
+	ID       uint          `json:"id"`
+	Status   string        `json:"status"`
+	Todo     *todoResponse `json:"todo,omitempty"`
+	Server   *todoResponse `json:"server,omitempty"`
+	Code     string        `json:"code,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// validateは変更を検証し、titleの前後の空白を除去します。
+func (r *syncPushChange) validate(field string) fieldErrors {
+	var errs fieldErrors
+	if r.ID == 0 {
+		if r.Version != 0 {
+			errs.add(field+".version", i18n.FieldNotAllowed, nil)
+		}
+		if r.Deleted {
+			errs.add(field+".deleted", i18n.FieldNotAllowed, nil)
+		}
+		errs.title(field+".title", r.Title, true)
+		return errs
+	}
+	if r.Version == 0 {
+		errs.add(field+".version", i18n.FieldRequired, nil)
+	}
+	if r.Deleted {
+		if r.Title != nil {
+			errs.add(field+".title", i18n.FieldNotAllowed, nil)
+		}
+		if r.Completed != nil {
+			errs.add(field+".completed", i18n.FieldNotAllowed, nil)
+		}
+		if r.DueAt.Set {
+			errs.add(field+".due_at", i18n.FieldNotAllowed, nil)
+		}
+		return errs
+	}
+	errs.title(field+".title", r.Title, false)
+	return errs
+}
+
+// PullChangesは、ログインユーザーのTodoの前回の同期以降の変更（削除を含む）を返します（差分同期）。
+// クエリパラメータsinceには前回のレスポンスのtokenを指定します。省略した場合（初回）は、
+// 現在のすべてのTodoをfull: trueで返します。tokenが不明な位置を指す場合もfull: trueで返すため、
+// クライアントはその場合、手元のTodoのうちchangesに含まれないものを破棄してください。
+// has_moreがtrueの場合は、返されたtokenで続けて取得してください（1回の件数はlimit、既定500・最大1000）。
+// HTTP:GET/sync
+func (h *TodoHandler) PullChanges(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	since, ok := parseSyncToken(c.Query("since"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.CodeInvalidSyncToken, nil))
+		return
+	}
+	limit := defaultSyncLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			var errs fieldErrors
+			errs.add("limit", i18n.FieldPositiveInteger, nil)
+			respondError(c, http.StatusBadRequest, errs.err())
+			return
+		}
+		limit = min(n, maxSyncLimit)
+	}
+
+	page, err := h.Usecase.PullChanges(c.Request.Context(), userID, since, limit)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	changes := make([]syncChangeResponse, len(page.Changes))
+	for i, change := range page.Changes {
+		changes[i] = syncChangeResponse{ID: change.TodoID, Deleted: change.Deleted}
+		if !change.Deleted {
+			todo := newTodoResponse(change.Todo)
+			changes[i].Todo = &todo
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"changes":  changes,
+		"token":    formatSyncToken(page.Seq),
+		"full":     page.Full,
+		"has_more": page.HasMore,
+	})
+}
+
+// PushChangesは、オフライン中にクライアントで行われた変更をまとめて適用し、変更ごとの結果を返します（差分同期）。
+// 更新・削除はversion（クライアントが元にしたバージョン）を確認し、サーバで変更・削除されていた場合は
+// 適用せずにstatus: conflictとサーバの現在のTodoを返します。他の変更の適用は続けます。
+// 既に削除されているTodoの削除は適用済み（applied）として扱います。
+// HTTP:POST/sync
+func (h *TodoHandler) PushChanges(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+
+	var req syncPushRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	var errs fieldErrors
+	if len(req.Changes) == 0 || len(req.Changes) > maxSyncPushChanges {
+		errs.add("changes", i18n.FieldItemCount, i18n.Params{"min": 1, "max": maxSyncPushChanges})
+	}
+	for i := range req.Changes {
+		errs = append(errs, req.Changes[i].validate(fmt.Sprintf("changes[%d]", i))...)
+	}
+	if err := errs.err(); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	pushes := make([]usecase.SyncPush, len(req.Changes))
+	for i, r := range req.Changes {
+		pushes[i] = usecase.SyncPush{
+			ID:      r.ID,
+			Version: r.Version,
+			Deleted: r.Deleted,
+			Patch:   domain.TodoPatch{Title: r.Title, Completed: r.Completed},
+		}
+		r.DueAt.applyDueAt(&pushes[i].Patch)
+	}
+	results, err := h.Usecase.PushChanges(c.Request.Context(), userID, pushes)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	l := i18n.FromContext(c)
+	out := make([]syncPushResult, len(results))
+	for i, res := range results {
+		out[i] = syncPushResult{Index: i, ClientID: req.Changes[i].ClientID, ID: res.Todo.ID, Status: string(res.Status)}
+		if res.Status == usecase.SyncConflict {
+			_, out[i].Code = errorStatus(res.Err, http.StatusConflict)
+			out[i].Error = i18n.Message(l, out[i].Code, nil)
+			if res.Server != nil {
+				server := newTodoResponse(*res.Server)
+				out[i].Server = &server
+			}
+			continue
+		}
+		if !req.Changes[i].Deleted {
+			todo := newTodoResponse(res.Todo)
+			out[i].Todo = &todo
+		}
+	}
+	c.Header("Content-Language", string(l))
+	c.JSON(http.StatusOK, gin.H{"results": out})
+}
+
+// parseSyncTokenは差分同期の位置を取り出します。空文字は0（初回）です。
+func parseSyncToken(token string) (uint64, bool) {
+	if token == "" {
+		return 0, true
+	}
+	seq, err := strconv.ParseUint(token, 10, 64)
+	return seq, err == nil
+}
+
+// formatSyncTokenは差分同期の位置をクライアントに返すトークンにします。
+func formatSyncToken(seq uint64) string {
+	return strconv.FormatUint(seq, 10)
+}
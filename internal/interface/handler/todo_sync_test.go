@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncPushChange_DueAt(t *testing.T) {
+	// given
+	var req syncPushRequest
+	body := `{"changes":[{"title":"牛乳を買う","due_at":"2026-10-20T09:00:00Z"},{"id":1,"version":1,"due_at":null},{"id":2,"version":1,"deleted":true,"due_at":null}]}`
+	require.NoError(t, bindJSON(newJSONContext(body), &req))
+
+	// when
+	created, cleared, deleted := req.Changes[0].validate("changes[0]"), req.Changes[1].validate("changes[1]"), req.Changes[2].validate("changes[2]")
+
+	// then
+	assert.Empty(t, created)
+	assert.Empty(t, cleared)
+	require.Len(t, deleted, 1)
+	assert.Equal(t, "changes[2].due_at", deleted[0].Field)
+	assert.Equal(t, time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC), *req.Changes[0].DueAt.Value)
+	assert.True(t, req.Changes[1].DueAt.Set)
+	assert.Nil(t, req.Changes[1].DueAt.Value)
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"todo_backend/internal/infrastructure/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// maxRequestBodyBytesはJSONのリクエストボディの最大サイズです。超えた場合は413を返します。
+const maxRequestBodyBytes = 1 << 20
+
+// maxTitleLengthはTodoのタイトルの最大文字数（前後の空白を除いたUnicodeの文字数）です。
+const maxTitleLength = 200
+
+// errInvalidJSONはリクエストボディがJSONとして解釈できないことを表します。
+var errInvalidJSON = errors.New("invalid JSON")
+
+// fieldErrorはリクエストの1つのフィールドの検証エラーです。
+// Fieldはボディ内の位置（"title"、"operations[0].title"など）、Codeは機械可読なエラーの種類
+// （i18n.Field*）、Paramsは文言に埋め込む値（最大文字数など）です。
+// Messageは英語の文言で、レスポンスではリクエストの言語に翻訳して返します。
+type fieldError struct {
+	Field   string      `json:"field"`
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Params  i18n.Params `json:"params,omitempty"`
+}
+
+// validationErrorはリクエストボディの検証エラーです。
+// レスポンスではフィールドごとの詳細を"details"として返します。
+type validationError struct {
+	Fields []fieldError
+}
+
+func (e *validationError) Error() string {
+	return e.message(i18n.English)
+}
+
+// messageはlの言語で検証エラー全体の文言を返します。
+func (e *validationError) message(l i18n.Locale) string {
+	fields := e.localize(l)
+	msgs := make([]string, len(fields))
+	for i, f := range fields {
+		msgs[i] = i18n.FieldMessage(l, "detail", i18n.Params{"field": f.Field, "message": f.Message})
+	}
+	return i18n.Message(l, i18n.CodeInvalidRequest, i18n.Params{"details": strings.Join(msgs, "; ")})
+}
+
+// localizeはフィールドごとの詳細の文言をlの言語にしたものを返します。
+func (e *validationError) localize(l i18n.Locale) []fieldError {
+	out := make([]fieldError, len(e.Fields))
+	for i, f := range e.Fields {
+		f.Message = i18n.FieldMessage(l, f.Code, f.Params)
+		out[i] = f
+	}
+	return out
+}
+
+// fieldErrorsはフィールドごとの検証エラーを集めます。
+type fieldErrors []fieldError
+
+// addはフィールドの検証エラーを追加します。codeはi18n.Field*のいずれかです。
+func (e *fieldErrors) add(field, code string, params i18n.Params) {
+	*e = append(*e, newFieldError(field, code, params))
+}
+
+// newFieldErrorは英語の文言を設定したfieldErrorを返します。
+func newFieldError(field, code string, params i18n.Params) fieldError {
+	return fieldError{Field: field, Code: code, Message: i18n.FieldMessage(i18n.English, code, params), Params: params}
+}
+
+// errは検証エラーがあればvalidationErrorを、なければnilを返します。
+func (e fieldErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return &validationError{Fields: e}
+}
+
+// titleは、*titleの前後の空白を除去したうえで空でないこと・最大文字数以下であることを検証します。
+// requiredの場合は未指定（nil）もエラーにします。
+func (e *fieldErrors) title(field string, title *string, required bool) {
+	if title == nil {
+		if required {
+			e.add(field, i18n.FieldRequired, nil)
+		}
+		return
+	}
+	*title = strings.TrimSpace(*title)
+	switch n := utf8.RuneCountInString(*title); {
+	case n == 0:
+		e.add(field, i18n.FieldEmpty, nil)
+	case n > maxTitleLength:
+		e.add(field, i18n.FieldTooLong, i18n.Params{"max": maxTitleLength})
+	}
+}
+
+// limitBodyはリクエストボディの読み込みをmaxRequestBodyBytesまでに制限します。
+func limitBody(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestBodyBytes)
+}
+
+// bindJSONはリクエストボディのJSONオブジェクトをdstに読み込みます。
+// dstにないフィールドや型の合わないフィールドはvalidationErrorとして返します。
+func bindJSON(c *gin.Context, dst any) error {
+	limitBody(c)
+	dec := json.NewDecoder(c.Request.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	return nil
+}
+
+// decodeErrorはJSONのデコードエラーを、フィールドを特定できる場合はvalidationErrorに変換します。
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return err
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return &validationError{Fields: []fieldError{
+			newFieldError(typeErr.Field, i18n.FieldInvalidType, i18n.Params{"type": jsonTypeName(typeErr.Type)}),
+		}}
+	}
+	// DisallowUnknownFieldsのエラーは専用の型がないためメッセージから取り出す
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		if unquoted, uerr := strconv.Unquote(name); uerr == nil {
+			name = unquoted
+		}
+		return &validationError{Fields: []fieldError{newFieldError(name, i18n.FieldNotAllowed, nil)}}
+	}
+	return fmt.Errorf("%w: %v", errInvalidJSON, err)
+}
+
+// jsonTypeNameはGoの型に対応するJSON Schemaの型名を返します。
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	default:
+		return "object"
+	}
+}
+
+// bindingErrorは、Ginのbindingタグによる検証（ShouldBindJSON）のエラーをvalidationErrorに変換します。
+// フィールド名にはdstの型のjsonタグの名前を使います。JSONのデコードエラーはdecodeErrorで変換します。
+func bindingError(err error, dst any) error {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return decodeError(err)
+	}
+	t := reflect.TypeOf(dst)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var errs fieldErrors
+	for _, fe := range invalid {
+		field := fe.Field()
+		if sf, ok := t.FieldByName(fe.StructField()); ok {
+			if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" {
+				field = name
+			}
+		}
+		switch fe.Tag() {
+		case "required":
+			errs.add(field, i18n.FieldRequired, nil)
+		case "email":
+			errs.add(field, i18n.FieldEmail, nil)
+		case "min":
+			n, _ := strconv.Atoi(fe.Param())
+			errs.add(field, i18n.FieldMinLength, i18n.Params{"min": n})
+		case "oneof":
+			errs.add(field, i18n.FieldOneOf, i18n.Params{"values": strings.Join(strings.Fields(fe.Param()), ", ")})
+		default:
+			errs.add(field, i18n.FieldInvalid, nil)
+		}
+	}
+	return errs.err()
+}
@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/i18n"
+	"todo_backend/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxWebhookURLLengthはWebhookのURLの最大文字数です。
+	maxWebhookURLLength = 2048
+	// minWebhookSecretLength・maxWebhookSecretLengthは指定できる署名の鍵の文字数の範囲です。
+	minWebhookSecretLength = 16
+	maxWebhookSecretLength = 256
+	// defaultDeliveryLimit・maxDeliveryLimitは配信ログの1回の取得件数の既定値と上限です。
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 100
+)
+
+// WebhookHandlerはWebhookの購読と配信ログに関するHTTPリクエストを処理するハンドラです。
+type WebhookHandler struct {
+	Usecase *usecase.WebhookUsecase
+}
+
+// NewWebhookHandlerは、WebhookHandlerを生成し、Ginのルーターにエンドポイントを登録します。
+func NewWebhookHandler(r gin.IRoutes, uc *usecase.WebhookUsecase) {
+	h := &WebhookHandler{Usecase: uc}
+	r.GET("/webhooks", h.GetWebhooks)
+	r.POST("/webhooks", h.CreateWebhook)
+	r.GET("/webhooks/:id", h.GetWebhook)
+	r.DELETE("/webhooks/:id", h.DeleteWebhook)
+	// 配信ログと手動の再配信
+	r.GET("/webhooks/:id/deliveries", h.GetDeliveries)
+	r.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", h.Redeliver)
+}
+
+// webhookRequestはWebhookの登録のリクエストボディです。
+type webhookRequest struct {
+	URL    *string                   `json:"url"`
+	Events []domain.WebhookEventType `json:"events"`
+	Secret *string                   `json:"secret"`
+}
+
+// validateはリクエストを検証します。
+func (r *webhookRequest) validate() error {
+	var errs fieldErrors
+	switch {
+	case r.URL == nil:
+		errs.add("url", i18n.FieldRequired, nil)
+	case utf8.RuneCountInString(*r.URL) > maxWebhookURLLength:
+		errs.add("url", i18n.FieldTooLong, i18n.Params{"max": maxWebhookURLLength})
+	default:
+		if u, err := url.Parse(*r.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.add("url", i18n.FieldURL, nil)
+		}
+	}
+
+	types := make([]string, len(domain.WebhookEventTypes))
+	for i, t := range domain.WebhookEventTypes {
+		types[i] = string(t)
+	}
+	if len(r.Events) == 0 || len(r.Events) > len(types) {
+		errs.add("events", i18n.FieldItemCount, i18n.Params{"min": 1, "max": len(types)})
+	}
+	for i, e := range r.Events {
+		if !slices.Contains(types, string(e)) {
+			errs.add(fmt.Sprintf("events[%d]", i), i18n.FieldOneOf, i18n.Params{"values": strings.Join(types, ", ")})
+		}
+	}
+
+	if r.Secret != nil {
+		switch n := utf8.RuneCountInString(*r.Secret); {
+		case n < minWebhookSecretLength:
+			errs.add("secret", i18n.FieldMinLength, i18n.Params{"min": minWebhookSecretLength})
+		case n > maxWebhookSecretLength:
+			errs.add("secret", i18n.FieldTooLong, i18n.Params{"max": maxWebhookSecretLength})
+		}
+	}
+	return errs.err()
+}
+
+// webhookResponseはWebhookのレスポンスです。署名の鍵は登録時のみsecretとして返します。
+type webhookResponse struct {
+	ID        uint                      `json:"id"`
+	URL       string                    `json:"url"`
+	Events    []domain.WebhookEventType `json:"events"`
+	Secret    string                    `json:"secret,omitempty"`
+	CreatedAt time.Time                 `json:"created_at"`
+}
+
+func newWebhookResponse(w domain.Webhook) webhookResponse {
+	return webhookResponse{ID: w.ID, URL: w.URL, Events: w.Events, CreatedAt: w.CreatedAt}
+}
+
+// deliveryResponseはWebhookの配信（配信ログ）のレスポンスです。
+type deliveryResponse struct {
+	ID             uint                         `json:"id"`
+	WebhookID      uint                         `json:"webhook_id"`
+	Event          domain.WebhookEventType      `json:"event"`
+	Status         domain.WebhookDeliveryStatus `json:"status"`
+	Attempts       int                          `json:"attempts"`
+	NextAttemptAt  *time.Time                   `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time                   `json:"last_attempt_at,omitempty"`
+	ResponseStatus int                          `json:"response_status,omitempty"`
+	Error          string                       `json:"error,omitempty"`
+	RedeliveryOf   *uint                        `json:"redelivery_of,omitempty"`
+	Payload        json.RawMessage              `json:"payload"`
+	CreatedAt      time.Time                    `json:"created_at"`
+}
+
+func newDeliveryResponse(d domain.WebhookDelivery) deliveryResponse {
+	res := deliveryResponse{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		Event:          d.Event,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastAttemptAt:  d.LastAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		Error:          d.Error,
+		RedeliveryOf:   d.RedeliveryOf,
+		Payload:        json.RawMessage(d.Payload),
+		CreatedAt:      d.CreatedAt,
+	}
+	// 次の送信時刻は配信待ちの場合のみ意味を持つ
+	if d.Status == domain.WebhookDeliveryPending {
+		next := d.NextAttemptAt
+		res.NextAttemptAt = &next
+	}
+	return res
+}
+
+// GetWebhooksはログインユーザーが登録したWebhookの一覧を返します。
+// HTTP:GET/webhooks
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	webhooks, err := h.Usecase.GetWebhooks(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	out := make([]webhookResponse, len(webhooks))
+	for i, w := range webhooks {
+		out[i] = newWebhookResponse(w)
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// CreateWebhookは、ログインユーザーのTodoのイベントを通知するWebhookを登録します。
+// secretを省略した場合は署名の鍵を生成します。鍵はこのレスポンスでのみ返します。
+// HTTP:POST/webhooks
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	var req webhookRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	var secret string
+	if req.Secret != nil {
+		secret = *req.Secret
+	}
+
+	w, err := h.Usecase.CreateWebhook(c.Request.Context(), userID, *req.URL, req.Events, secret)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	res := newWebhookResponse(w)
+	res.Secret = w.Secret
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+strconv.FormatUint(uint64(w.ID), 10))
+	c.JSON(http.StatusCreated, res)
+}
+
+// GetWebhookはログインユーザーが登録したIDのWebhookを返します。
+// HTTP:GET/webhooks/:id
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	w, err := h.Usecase.GetWebhook(c.Request.Context(), userID, id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, newWebhookResponse(w))
+}
+
+// DeleteWebhookはログインユーザーが登録したIDのWebhookを配信ログとともに削除します。
+// HTTP:DELETE/webhooks/:id
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.Usecase.DeleteWebhook(c.Request.Context(), userID, id); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// GetDeliveriesはWebhookの配信ログを新しい順に返します（limit、既定50・最大100）。
+// HTTP:GET/webhooks/:id/deliveries
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	limit := defaultDeliveryLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			var errs fieldErrors
+			errs.add("limit", i18n.FieldPositiveInteger, nil)
+			respondError(c, http.StatusBadRequest, errs.err())
+			return
+		}
+		limit = min(n, maxDeliveryLimit)
+	}
+
+	deliveries, err := h.Usecase.GetDeliveries(c.Request.Context(), userID, id, limit)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	out := make([]deliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		out[i] = newDeliveryResponse(d)
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// Redeliverは、配信と同じイベント（同じペイロード）を新しい配信として改めて送信します。
+// 送信は非同期に行うため202を返します。結果は配信ログで確認できます。
+// HTTP:POST/webhooks/:id/deliveries/:delivery_id/redeliver
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.CodeUnauthorized, nil))
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseIDParam(c, "delivery_id")
+	if !ok {
+		return
+	}
+	d, err := h.Usecase.Redeliver(c.Request.Context(), userID, id, deliveryID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, newDeliveryResponse(d))
+}
+
+// parseIDParamは、URLパラメータnameを正の整数として取り出します。
+// 不正な値の場合は400を返してfalseを返します。
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 0)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.CodeInvalidID, nil))
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"todo_backend/internal/domain"
+)
+
+// JobRepositoryはバックグラウンドジョブ（アウトボックス）の永続化を抽象化したインターフェースです。
+// Repositories.Jobsとしてドメインの変更と同じトランザクションでジョブを保存できます。
+// 全メソッドは第1引数にcontext.Contextを受け取ります。
+type JobRepository interface {
+	// Enqueueは新しいジョブを永続化します。採番されたIDと作成日時はjobに設定されます。
+	Enqueue(ctx context.Context, job *domain.Job) error
+
+	// Claimは、実行日時がnow以前の実行待ちのジョブと、実行期限がnow以前の実行中のジョブを、
+	// 実行日時の古い順に最大limit件取り出して返します。
+	// 取り出したジョブは実行中（実行期限はnow+lease）になり、実行回数が1増えます。
+	// 実行期限を過ぎた実行中のジョブのうち実行回数がmaxAttempts以上のものは、取り出さずにデッドレターにします
+	// （最後のエラーはdomain.ErrJobLeaseExpired）。
+	// 同じジョブを複数のワーカーが同時に取り出すことはありません。
+	Claim(ctx context.Context, now time.Time, lease time.Duration, maxAttempts, limit int) ([]domain.Job, error)
+
+	// Updateは取り出したジョブの実行結果（状態・次の実行日時・エラーなど）を保存します。
+	// ジョブが実行中で実行回数がjob.Attemptsと同じ場合のみ保存し、実行期限を過ぎて他のワーカーが
+	// 取り出し直していた場合などはdomain.ErrJobLeaseLost、存在しない場合はdomain.ErrJobNotFoundを返します。
+	Update(ctx context.Context, job domain.Job) error
+
+	// Requeueは、実行を諦めた（dead）ジョブを実行回数を0に戻して実行日時runAtの実行待ちにし、更新後のジョブを返します。
+	// 存在しない場合はdomain.ErrJobNotFound、デッドレター以外の場合はdomain.ErrJobNotDeadを返します。
+	Requeue(ctx context.Context, id uint, runAt time.Time) (domain.Job, error)
+
+	// FindByIDはIDのジョブを返します。存在しない場合はdomain.ErrJobNotFoundを返します。
+	FindByID(ctx context.Context, id uint) (domain.Job, error)
+
+	// Findは状態がstatusのジョブ（空の場合はすべて）を新しい順に最大limit件返します。
+	Find(ctx context.Context, status domain.JobStatus, limit int) ([]domain.Job, error)
+
+	// DeleteSucceededは、完了日時がbeforeより前の完了したジョブを削除し、削除した件数を返します。
+	DeleteSucceeded(ctx context.Context, before time.Time) (int64, error)
+}
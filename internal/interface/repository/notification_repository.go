@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"todo_backend/internal/domain"
+)
+
+// NotificationRepositoryはアプリ内の通知の永続化を抽象化したインターフェースです。
+// 全メソッドは第1引数にcontext.Contextを受け取ります。
+type NotificationRepository interface {
+	// Createは新しい通知を永続化します。採番されたIDと作成日時はnotificationに設定されます。
+	Create(ctx context.Context, notification *domain.Notification) error
+
+	// FindByUserは、userID宛ての通知（unreadOnlyの場合は未読のみ）を新しい順に最大limit件返します。
+	FindByUser(ctx context.Context, userID uint, unreadOnly bool, limit int) ([]domain.Notification, error)
+
+	// MarkReadは、userID宛てのIDの通知を既読（既読日時はat）にし、更新後の通知を返します。
+	// 既読の通知は既読日時を変更しません。存在しない場合はdomain.ErrNotificationNotFoundを返します。
+	MarkRead(ctx context.Context, userID, id uint, at time.Time) (domain.Notification, error)
+
+	// MarkAllReadは、userID宛ての未読の通知をすべて既読（既読日時はat）にし、既読にした件数を返します。
+	MarkAllRead(ctx context.Context, userID uint, at time.Time) (int, error)
+
+	// Deleteは、userID宛てのIDの通知を削除します。存在しない場合はdomain.ErrNotificationNotFoundを返します。
+	Delete(ctx context.Context, userID, id uint) error
+
+	// CountUnreadは、userID宛ての未読の通知の件数を返します。
+	CountUnread(ctx context.Context, userID uint) (int, error)
+}
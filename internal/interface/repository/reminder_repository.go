@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"todo_backend/internal/domain"
+)
+
+// ReminderRepositoryはTodoのリマインダーの永続化を抽象化したインターフェースです。
+// 全メソッドは第1引数にcontext.Contextを受け取ります。
+type ReminderRepository interface {
+	// Createは新しいリマインダーを永続化します。採番されたIDと作成日時はreminderに設定されます。
+	Create(ctx context.Context, reminder *domain.Reminder) error
+
+	// FindByTodoは、userIDが所有するTodoのリマインダーを作成順に返します。
+	FindByTodo(ctx context.Context, userID, todoID uint) ([]domain.Reminder, error)
+
+	// Deleteは、userIDが所有するTodoのIDのリマインダーを削除します。
+	// 存在しない場合はdomain.ErrReminderNotFoundを返します。
+	Delete(ctx context.Context, userID, todoID, id uint) error
+
+	// DeleteByTodoは、userIDが所有するTodoのリマインダーをすべて削除します。
+	DeleteByTodo(ctx context.Context, userID, todoID uint) error
+
+	// Rescheduleは、未通知のリマインダーの通知する日時をfireAt（nilの場合は通知しない）に変更します。
+	// rearmがtrueの場合は通知済みのリマインダーも未通知に戻します。
+	// 存在しない場合はdomain.ErrReminderNotFoundを返します。
+	Reschedule(ctx context.Context, id uint, fireAt *time.Time, rearm bool) error
+
+	// Dueは、通知する日時がnow以前の未通知のリマインダーを、通知する日時の古い順に最大limit件返します。
+	Due(ctx context.Context, now time.Time, limit int) ([]domain.Reminder, error)
+
+	// MarkFiredは、通知する日時がnow以前の未通知のリマインダーを通知済み（通知日時はnow）にし、
+	// 通知済みにしたかどうかを返します。既に通知済みの場合や、通知する日時が変更されていた場合はfalseを返します。
+	// 同じリマインダーを複数回通知しないよう、通知と同じトランザクションで呼び出してください。
+	MarkFired(ctx context.Context, id uint, now time.Time) (bool, error)
+}
@@ -0,0 +1,931 @@
+// Package repositorytestは、repositoryパッケージのインターフェースの実装が
+// 満たすべき振る舞いを確認する共通のテストスイート（コンフォーマンステスト）を提供します。
+// 各実装のテストからRunを呼び出して使います。
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factoryは、空のデータストアに対するリポジトリ一式とTransactorを返す関数です。
+// サブテストごとに呼び出されます。
+type Factory func(t *testing.T) (repository.Repositories, repository.Transactor)
+
+// Runは、newReposが返す実装に対してすべてのコンフォーマンステストを実行します。
+func Run(t *testing.T, newRepos Factory) {
+	t.Run("Todo/CreateAssignsIDAndVersion", func(t *testing.T) { testTodoCreate(t, newRepos) })
+	t.Run("Todo/FindIsScopedToOwner", func(t *testing.T) { testTodoFind(t, newRepos) })
+	t.Run("Todo/UpdateChecksVersion", func(t *testing.T) { testTodoUpdate(t, newRepos) })
+	t.Run("Todo/DeleteChecksVersion", func(t *testing.T) { testTodoDelete(t, newRepos) })
+	t.Run("Todo/HonorsCanceledContext", func(t *testing.T) { testTodoCanceled(t, newRepos) })
+	t.Run("Todo/RecordsLatestChangePerTodo", func(t *testing.T) { testTodoChanges(t, newRepos) })
+	t.Run("Todo/ChangesRollBackWithTransaction", func(t *testing.T) { testTodoChangesRollback(t, newRepos) })
+	t.Run("Todo/PruneTombstonesRecordsPrunedSeq", func(t *testing.T) { testTodoPruneTombstones(t, newRepos) })
+	t.Run("User/CreateAndFind", func(t *testing.T) { testUserCreateAndFind(t, newRepos) })
+	t.Run("User/RejectsDuplicateEmail", func(t *testing.T) { testUserDuplicate(t, newRepos) })
+	t.Run("Webhook/FindIsScopedToOwner", func(t *testing.T) { testWebhookFind(t, newRepos) })
+	t.Run("Webhook/DeliveriesAreQueuedUntilDone", func(t *testing.T) { testWebhookDeliveries(t, newRepos) })
+	t.Run("Webhook/DeliveryIsCreatedOncePerEvent", func(t *testing.T) { testWebhookDeliveryDedup(t, newRepos) })
+	t.Run("Webhook/DeleteRemovesDeliveries", func(t *testing.T) { testWebhookDelete(t, newRepos) })
+	t.Run("Job/ClaimTakesDueJobsOnce", func(t *testing.T) { testJobClaim(t, newRepos) })
+	t.Run("Job/ClaimRecoversExpiredLease", func(t *testing.T) { testJobLease(t, newRepos) })
+	t.Run("Job/UpdateRejectsLostLease", func(t *testing.T) { testJobLostLease(t, newRepos) })
+	t.Run("Job/ClaimDeadLettersExpiredLeaseAtMaxAttempts", func(t *testing.T) { testJobLeaseExhausted(t, newRepos) })
+	t.Run("Job/DeleteSucceededKeepsRecentAndDeadJobs", func(t *testing.T) { testJobDeleteSucceeded(t, newRepos) })
+	t.Run("Job/RequeueOnlyDeadJobs", func(t *testing.T) { testJobRequeue(t, newRepos) })
+	t.Run("Job/FindFiltersByStatus", func(t *testing.T) { testJobFind(t, newRepos) })
+	t.Run("Job/EnqueueRollsBackWithTransaction", func(t *testing.T) { testJobRollback(t, newRepos) })
+	t.Run("Reminder/FindAndDeleteAreScopedToTodo", func(t *testing.T) { testReminderFind(t, newRepos) })
+	t.Run("Reminder/MarkFiredOnlyOnce", func(t *testing.T) { testReminderFire(t, newRepos) })
+	t.Run("Reminder/RescheduleRearmsFiredReminders", func(t *testing.T) { testReminderReschedule(t, newRepos) })
+	t.Run("Notification/FindAndMarkReadAreScopedToUser", func(t *testing.T) { testNotification(t, newRepos) })
+	t.Run("Notification/MarkAllReadCountAndDelete", func(t *testing.T) { testNotificationBulk(t, newRepos) })
+	t.Run("Transactor/CommitsOnSuccess", func(t *testing.T) { testTxCommit(t, newRepos) })
+	t.Run("Transactor/RollsBackOnError", func(t *testing.T) { testTxRollback(t, newRepos) })
+	t.Run("Transactor/NestedRollsBackInnerOnly", func(t *testing.T) { testTxNested(t, newRepos) })
+	t.Run("Transactor/RollbackKeepsWritesOutsideTransaction", func(t *testing.T) { testTxRollbackKeepsOutsideWrites(t, newRepos) })
+}
+
+func testTodoCreate(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+
+	first, err := repos.Todos.Create(ctx, domain.Todo{ID: 99, UserID: 1, Title: "a", Version: 7})
+	require.NoError(t, err)
+	second, err := repos.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "b", Completed: true})
+	require.NoError(t, err)
+
+	// 指定されたIDとバージョンは無視され、採番される
+	assert.NotZero(t, first.ID)
+	assert.NotEqual(t, uint(99), first.ID)
+	assert.Greater(t, second.ID, first.ID)
+	assert.Equal(t, uint(1), first.Version)
+	assert.Equal(t, domain.Todo{ID: second.ID, UserID: 1, Title: "b", Completed: true, Version: 1}, second)
+}
+
+func testTodoFind(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	a, err := repos.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "a"})
+	require.NoError(t, err)
+	_, err = repos.Todos.Create(ctx, domain.Todo{UserID: 2, Title: "other"})
+	require.NoError(t, err)
+	b, err := repos.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "b"})
+	require.NoError(t, err)
+
+	// 一覧は所有者のTodoのみをID順に返す
+	todos, err := repos.Todos.FindByUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Todo{a, b}, todos)
+
+	none, err := repos.Todos.FindByUser(ctx, 3)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	got, err := repos.Todos.FindByID(ctx, 1, a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, a, got)
+
+	// 他ユーザーのTodoと存在しないTodoは区別しない
+	_, err = repos.Todos.FindByID(ctx, 2, a.ID)
+	assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+	_, err = repos.Todos.FindByID(ctx, 1, 9999)
+	assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+}
+
+func testTodoUpdate(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	todo, err := repos.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "a"})
+	require.NoError(t, err)
+
+	// 最新バージョンでの更新は成功し、バージョンが進む
+	todo.Title, todo.Completed = "b", true
+	require.NoError(t, repos.Todos.Update(ctx, todo))
+	got, err := repos.Todos.FindByID(ctx, 1, todo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.Todo{ID: todo.ID, UserID: 1, Title: "b", Completed: true, Version: 2}, got)
+
+	// 古いバージョン・他ユーザー・存在しないTodoの更新は拒否される
+	todo.Title = "stale"
+	assert.ErrorIs(t, repos.Todos.Update(ctx, todo), domain.ErrVersionMismatch)
+	assert.ErrorIs(t, repos.Todos.Update(ctx, domain.Todo{ID: todo.ID, UserID: 2, Version: 2}), domain.ErrTodoNotFound)
+	assert.ErrorIs(t, repos.Todos.Update(ctx, domain.Todo{ID: 9999, UserID: 1, Version: 1}), domain.ErrTodoNotFound)
+
+	got, err = repos.Todos.FindByID(ctx, 1, todo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "b", got.Title)
+}
+
+func testTodoDelete(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	todo, err := repos.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "a"})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, repos.Todos.Delete(ctx, 1, todo.ID, 2), domain.ErrVersionMismatch)
+	assert.ErrorIs(t, repos.Todos.Delete(ctx, 2, todo.ID, 1), domain.ErrTodoNotFound)
+
+	require.NoError(t, repos.Todos.Delete(ctx, 1, todo.ID, 1))
+	assert.ErrorIs(t, repos.Todos.Delete(ctx, 1, todo.ID, 1), domain.ErrTodoNotFound)
+	_, err = repos.Todos.FindByID(ctx, 1, todo.ID)
+	assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+}
+
+func testTodoCanceled(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repos.Todos.FindByUser(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repos.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "a"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func testTodoChanges(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	a, err := repos.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "a"})
+	require.NoError(t, err)
+	b, err := repos.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "b"})
+	require.NoError(t, err)
+	_, err = repos.Todos.Create(ctx, domain.Todo{UserID: 2, Title: "other"})
+	require.NoError(t, err)
+	first, err := repos.Todos.LastChangeSeq(ctx, 1)
+	require.NoError(t, err)
+
+	// 同じTodoへの書き込みは最新の変更だけが残り、削除は墓標になる
+	a.Title = "a2"
+	require.NoError(t, repos.Todos.Update(ctx, a))
+	updated, err := repos.Todos.LastChangeSeq(ctx, 1)
+	require.NoError(t, err)
+	a.Title, a.Version = "a3", 2
+	require.NoError(t, repos.Todos.Update(ctx, a))
+	// 番号は再利用されない（最新の変更を記録し直しても増え続ける）
+	again, err := repos.Todos.LastChangeSeq(ctx, 1)
+	require.NoError(t, err)
+	assert.Greater(t, again, updated)
+	require.NoError(t, repos.Todos.Delete(ctx, 1, b.ID, 1))
+	// 失敗した書き込みは記録されない
+	assert.ErrorIs(t, repos.Todos.Update(ctx, domain.Todo{ID: a.ID, UserID: 1, Version: 1}), domain.ErrVersionMismatch)
+
+	changes, err := repos.Todos.Changes(ctx, 1, first, 100)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Greater(t, changes[0].Seq, first)
+	assert.Greater(t, changes[1].Seq, changes[0].Seq)
+	assert.Equal(t, domain.Todo{ID: a.ID, UserID: 1, Title: "a3", Version: 3}, changes[0].Todo)
+	assert.False(t, changes[0].Deleted)
+	assert.Equal(t, b.ID, changes[1].TodoID)
+	assert.True(t, changes[1].Deleted)
+	assert.Equal(t, domain.Todo{ID: b.ID, UserID: 1}, changes[1].Todo)
+
+	last, err := repos.Todos.LastChangeSeq(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, changes[1].Seq, last)
+
+	// sinceより後の変更をlimit件まで返し、他ユーザーの変更は含まない
+	all, err := repos.Todos.Changes(ctx, 1, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, changes[0].Seq, all[0].Seq)
+	none, err := repos.Todos.Changes(ctx, 1, last, 100)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+	unknown, err := repos.Todos.LastChangeSeq(ctx, 3)
+	require.NoError(t, err)
+	assert.Zero(t, unknown)
+}
+
+func testTodoChangesRollback(t *testing.T, newRepos Factory) {
+	repos, tx := newRepos(t)
+	ctx := context.Background()
+	todo, err := repos.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "a"})
+	require.NoError(t, err)
+	before, err := repos.Todos.LastChangeSeq(ctx, 1)
+	require.NoError(t, err)
+
+	err = tx.WithinTransaction(ctx, func(ctx context.Context, r repository.Repositories) error {
+		require.NoError(t, r.Todos.Delete(ctx, 1, todo.ID, 1))
+		return errors.New("boom")
+	})
+
+	// ロールバックされた書き込みの変更履歴は残らない
+	// （コミット前の変更を読める実装では、元の変更を新しいseqで記録し直してもよい）
+	require.Error(t, err)
+	changes, err := repos.Todos.Changes(ctx, 1, 0, 100)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.GreaterOrEqual(t, changes[0].Seq, before)
+	assert.False(t, changes[0].Deleted)
+	assert.Equal(t, todo, changes[0].Todo)
+}
+
+func testTodoPruneTombstones(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	kept, err := repos.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "kept"})
+	require.NoError(t, err)
+	deleted, err := repos.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "deleted"})
+	require.NoError(t, err)
+	require.NoError(t, repos.Todos.Delete(ctx, 1, deleted.ID, 1))
+	tombstone, err := repos.Todos.LastChangeSeq(ctx, 1)
+	require.NoError(t, err)
+
+	// 保持期間内の墓標は削除しない
+	n, err := repos.Todos.PruneTombstones(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	pruned, err := repos.Todos.PrunedChangeSeq(ctx, 1)
+	require.NoError(t, err)
+	assert.Zero(t, pruned)
+
+	// 保持期間を過ぎた墓標だけを削除し、そのseqを記録する
+	n, err = repos.Todos.PruneTombstones(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	pruned, err = repos.Todos.PrunedChangeSeq(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, tombstone, pruned)
+	changes, err := repos.Todos.Changes(ctx, 1, 0, 100)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, kept.ID, changes[0].TodoID)
+	other, err := repos.Todos.PrunedChangeSeq(ctx, 2)
+	require.NoError(t, err)
+	assert.Zero(t, other)
+}
+
+func testUserCreateAndFind(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	u := &domain.User{Email: "a@example.com", Password: "hash"}
+
+	require.NoError(t, repos.Users.Create(ctx, u))
+	assert.NotZero(t, u.ID)
+
+	byEmail, err := repos.Users.FindByEmail(ctx, "a@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, byEmail.ID)
+	assert.Equal(t, "hash", byEmail.Password)
+
+	byID, err := repos.Users.FindByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "a@example.com", byID.Email)
+
+	_, err = repos.Users.FindByEmail(ctx, "missing@example.com")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	_, err = repos.Users.FindByID(ctx, 9999)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+}
+
+func testUserDuplicate(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	require.NoError(t, repos.Users.Create(ctx, &domain.User{Email: "a@example.com", Password: "x"}))
+
+	err := repos.Users.Create(ctx, &domain.User{Email: "a@example.com", Password: "y"})
+
+	assert.ErrorIs(t, err, domain.ErrEmailAlreadyExists)
+}
+
+func testWebhookFind(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	a := &domain.Webhook{UserID: 1, URL: "https://a.example.com", Events: []domain.WebhookEventType{domain.WebhookTodoCreated}, Secret: "s"}
+	other := &domain.Webhook{UserID: 2, URL: "https://other.example.com", Events: domain.WebhookEventTypes, Secret: "s"}
+	b := &domain.Webhook{UserID: 1, URL: "https://b.example.com", Events: domain.WebhookEventTypes, Secret: "s"}
+	for _, w := range []*domain.Webhook{a, other, b} {
+		require.NoError(t, repos.Webhooks.Create(ctx, w))
+	}
+
+	// 一覧は所有者のWebhookのみをID順に返す
+	webhooks, err := repos.Webhooks.FindByUser(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 2)
+	assert.Equal(t, a.ID, webhooks[0].ID)
+	assert.Equal(t, []domain.WebhookEventType{domain.WebhookTodoCreated}, webhooks[0].Events)
+	assert.Equal(t, b.ID, webhooks[1].ID)
+	assert.False(t, webhooks[1].CreatedAt.IsZero())
+
+	got, err := repos.Webhooks.FindByID(ctx, 1, b.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://b.example.com", got.URL)
+	assert.Equal(t, domain.WebhookEventTypes, got.Events)
+
+	_, err = repos.Webhooks.FindByID(ctx, 1, other.ID)
+	assert.ErrorIs(t, err, domain.ErrWebhookNotFound)
+	assert.ErrorIs(t, repos.Webhooks.Delete(ctx, 1, other.ID), domain.ErrWebhookNotFound)
+}
+
+func testWebhookDeliveries(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	w := &domain.Webhook{UserID: 1, URL: "https://a.example.com", Events: domain.WebhookEventTypes, Secret: "s"}
+	require.NoError(t, repos.Webhooks.Create(ctx, w))
+	now := time.Now()
+	later := &domain.WebhookDelivery{WebhookID: w.ID, UserID: 1, Event: domain.WebhookTodoUpdated, Payload: "{}",
+		Status: domain.WebhookDeliveryPending, NextAttemptAt: now.Add(time.Minute)}
+	due := &domain.WebhookDelivery{WebhookID: w.ID, UserID: 1, Event: domain.WebhookTodoCreated, Payload: `{"a":1}`,
+		Status: domain.WebhookDeliveryPending, NextAttemptAt: now.Add(-time.Second)}
+	for _, d := range []*domain.WebhookDelivery{later, due} {
+		require.NoError(t, repos.Webhooks.CreateDelivery(ctx, d))
+	}
+
+	// 送信時刻を迎えた配信待ちのみが返る
+	got, err := repos.Webhooks.DueDeliveries(ctx, now, 10, nil)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, due.ID, got[0].ID)
+	assert.Equal(t, `{"a":1}`, got[0].Payload)
+
+	// 完了した配信は返らなくなり、結果が配信ログに残る
+	attempted := now
+	got[0].Status, got[0].Attempts, got[0].LastAttemptAt, got[0].ResponseStatus = domain.WebhookDeliverySucceeded, 1, &attempted, 204
+	require.NoError(t, repos.Webhooks.UpdateDelivery(ctx, got[0]))
+	got, err = repos.Webhooks.DueDeliveries(ctx, now.Add(2*time.Minute), 10, nil)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, later.ID, got[0].ID)
+
+	log, err := repos.Webhooks.FindDeliveries(ctx, 1, w.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, log, 2)
+	assert.Equal(t, due.ID, log[0].ID)
+	assert.Equal(t, domain.WebhookDeliverySucceeded, log[0].Status)
+	assert.Equal(t, 204, log[0].ResponseStatus)
+	require.NotNil(t, log[0].LastAttemptAt)
+	one, err := repos.Webhooks.FindDeliveries(ctx, 1, w.ID, 1)
+	require.NoError(t, err)
+	assert.Len(t, one, 1)
+
+	_, err = repos.Webhooks.FindDelivery(ctx, 2, w.ID, due.ID)
+	assert.ErrorIs(t, err, domain.ErrWebhookDeliveryNotFound)
+}
+
+func testWebhookDeliveryDedup(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	a := &domain.Webhook{UserID: 1, URL: "https://a.example.com", Events: domain.WebhookEventTypes, Secret: "s"}
+	b := &domain.Webhook{UserID: 1, URL: "https://b.example.com", Events: domain.WebhookEventTypes, Secret: "s"}
+	require.NoError(t, repos.Webhooks.Create(ctx, a))
+	require.NoError(t, repos.Webhooks.Create(ctx, b))
+	newDelivery := func(webhookID uint, eventID *string) *domain.WebhookDelivery {
+		return &domain.WebhookDelivery{WebhookID: webhookID, UserID: 1, Event: domain.WebhookTodoCreated, Payload: "{}",
+			EventID: eventID, Status: domain.WebhookDeliveryPending, NextAttemptAt: time.Now()}
+	}
+	first, other := "evt_1", "evt_2"
+
+	// 同じWebhookへの同じイベントの配信は1つだけ作成する。イベントのない配信（手動の再配信）は制限しない
+	for _, d := range []*domain.WebhookDelivery{
+		newDelivery(a.ID, &first), newDelivery(a.ID, &first), newDelivery(a.ID, &other),
+		newDelivery(b.ID, &first), newDelivery(a.ID, nil), newDelivery(a.ID, nil),
+	} {
+		require.NoError(t, repos.Webhooks.CreateDelivery(ctx, d))
+	}
+
+	toA, err := repos.Webhooks.FindDeliveries(ctx, 1, a.ID, 10)
+	require.NoError(t, err)
+	assert.Len(t, toA, 4)
+	toB, err := repos.Webhooks.FindDeliveries(ctx, 1, b.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, toB, 1)
+	require.NotNil(t, toB[0].EventID)
+	assert.Equal(t, first, *toB[0].EventID)
+
+	// 除外したWebhookの配信は送信待ちとして返らない
+	due, err := repos.Webhooks.DueDeliveries(ctx, time.Now(), 10, []uint{a.ID})
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, b.ID, due[0].WebhookID)
+}
+
+func testWebhookDelete(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	w := &domain.Webhook{UserID: 1, URL: "https://a.example.com", Events: domain.WebhookEventTypes, Secret: "s"}
+	require.NoError(t, repos.Webhooks.Create(ctx, w))
+	d := &domain.WebhookDelivery{WebhookID: w.ID, UserID: 1, Event: domain.WebhookTodoCreated, Payload: "{}",
+		Status: domain.WebhookDeliveryPending, NextAttemptAt: time.Now()}
+	require.NoError(t, repos.Webhooks.CreateDelivery(ctx, d))
+
+	require.NoError(t, repos.Webhooks.Delete(ctx, 1, w.ID))
+
+	// 削除したWebhookの配信は送信されず、送信中だった配信の結果も保存されない
+	_, err := repos.Webhooks.FindByID(ctx, 1, w.ID)
+	assert.ErrorIs(t, err, domain.ErrWebhookNotFound)
+	due, err := repos.Webhooks.DueDeliveries(ctx, time.Now(), 10, nil)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+	assert.ErrorIs(t, repos.Webhooks.UpdateDelivery(ctx, *d), domain.ErrWebhookDeliveryNotFound)
+}
+
+func testJobClaim(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	now := time.Now()
+	later := &domain.Job{Type: "a", Payload: "{}", Status: domain.JobPending, RunAt: now.Add(-time.Second)}
+	first := &domain.Job{Type: "a", Payload: "{}", Status: domain.JobPending, RunAt: now.Add(-time.Minute)}
+	scheduled := &domain.Job{Type: "a", Payload: "{}", Status: domain.JobPending, RunAt: now.Add(time.Hour)}
+	for _, job := range []*domain.Job{later, first, scheduled} {
+		require.NoError(t, repos.Jobs.Enqueue(ctx, job))
+	}
+
+	claimed, err := repos.Jobs.Claim(ctx, now, time.Minute, 3, 10)
+	require.NoError(t, err)
+	again, err := repos.Jobs.Claim(ctx, now, time.Minute, 3, 10)
+	require.NoError(t, err)
+
+	// 実行日時を迎えたジョブのみを古い順に1回だけ取り出し、実行中にする
+	require.Len(t, claimed, 2)
+	assert.Equal(t, []uint{first.ID, later.ID}, []uint{claimed[0].ID, claimed[1].ID})
+	assert.Equal(t, domain.JobRunning, claimed[0].Status)
+	assert.Equal(t, 1, claimed[0].Attempts)
+	require.NotNil(t, claimed[0].LockedUntil)
+	assert.WithinDuration(t, now.Add(time.Minute), *claimed[0].LockedUntil, time.Second)
+	assert.Empty(t, again)
+	stored, err := repos.Jobs.FindByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobRunning, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+}
+
+func testJobLease(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	now := time.Now()
+	job := &domain.Job{Type: "a", Payload: "{}", Status: domain.JobPending, RunAt: now}
+	require.NoError(t, repos.Jobs.Enqueue(ctx, job))
+	_, err := repos.Jobs.Claim(ctx, now, time.Minute, 3, 10)
+	require.NoError(t, err)
+
+	// 実行期限を過ぎても完了しないジョブは中断されたとみなし、再び取り出す
+	before, err := repos.Jobs.Claim(ctx, now.Add(30*time.Second), time.Minute, 3, 10)
+	require.NoError(t, err)
+	after, err := repos.Jobs.Claim(ctx, now.Add(2*time.Minute), time.Minute, 3, 10)
+	require.NoError(t, err)
+
+	assert.Empty(t, before)
+	require.Len(t, after, 1)
+	assert.Equal(t, job.ID, after[0].ID)
+	assert.Equal(t, 2, after[0].Attempts)
+
+	// 完了したジョブは取り出さない
+	done := after[0]
+	done.Status, done.LockedUntil = domain.JobSucceeded, nil
+	require.NoError(t, repos.Jobs.Update(ctx, done))
+	none, err := repos.Jobs.Claim(ctx, now.Add(time.Hour), time.Minute, 3, 10)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+	assert.ErrorIs(t, repos.Jobs.Update(ctx, domain.Job{ID: job.ID + 100}), domain.ErrJobNotFound)
+}
+
+func testJobLostLease(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	now := time.Now()
+	job := &domain.Job{Type: "a", Payload: "{}", Status: domain.JobPending, RunAt: now}
+	require.NoError(t, repos.Jobs.Enqueue(ctx, job))
+	first, err := repos.Jobs.Claim(ctx, now, time.Minute, 3, 10)
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	second, err := repos.Jobs.Claim(ctx, now.Add(2*time.Minute), time.Minute, 3, 10)
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+
+	// 実行期限を過ぎた後の最初のワーカーの結果は保存しない
+	late := first[0]
+	late.Status, late.LockedUntil = domain.JobSucceeded, nil
+	assert.ErrorIs(t, repos.Jobs.Update(ctx, late), domain.ErrJobLeaseLost)
+	stored, err := repos.Jobs.FindByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobRunning, stored.Status)
+	assert.Equal(t, 2, stored.Attempts)
+
+	// 取り出し直したワーカーの結果は保存し、その後の保存は受け付けない
+	done := second[0]
+	done.Status, done.LockedUntil = domain.JobSucceeded, nil
+	require.NoError(t, repos.Jobs.Update(ctx, done))
+	assert.ErrorIs(t, repos.Jobs.Update(ctx, done), domain.ErrJobLeaseLost)
+}
+
+func testJobLeaseExhausted(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	now := time.Now()
+	job := &domain.Job{Type: "a", Payload: "{}", Status: domain.JobPending, RunAt: now}
+	require.NoError(t, repos.Jobs.Enqueue(ctx, job))
+	_, err := repos.Jobs.Claim(ctx, now, time.Minute, 2, 10)
+	require.NoError(t, err)
+	second, err := repos.Jobs.Claim(ctx, now.Add(2*time.Minute), time.Minute, 2, 10)
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+
+	// 上限の回数目の実行も期限を過ぎた場合は、取り出し直さずにデッドレターにする
+	third, err := repos.Jobs.Claim(ctx, now.Add(4*time.Minute), time.Minute, 2, 10)
+	require.NoError(t, err)
+	assert.Empty(t, third)
+	stored, err := repos.Jobs.FindByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobDead, stored.Status)
+	assert.Equal(t, 2, stored.Attempts)
+	assert.Equal(t, domain.ErrJobLeaseExpired.Error(), stored.LastError)
+	assert.Nil(t, stored.LockedUntil)
+	assert.NotNil(t, stored.FinishedAt)
+	// 期限を過ぎた後の結果は保存しない
+	assert.ErrorIs(t, repos.Jobs.Update(ctx, second[0]), domain.ErrJobLeaseLost)
+}
+
+func testJobDeleteSucceeded(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	now := time.Now()
+	old, recent := now.Add(-2*time.Hour), now.Add(-time.Minute)
+	jobs := []*domain.Job{
+		{Type: "a", Payload: "{}", Status: domain.JobSucceeded, RunAt: old, FinishedAt: &old},
+		{Type: "a", Payload: "{}", Status: domain.JobSucceeded, RunAt: recent, FinishedAt: &recent},
+		{Type: "a", Payload: "{}", Status: domain.JobDead, RunAt: old, FinishedAt: &old},
+		{Type: "a", Payload: "{}", Status: domain.JobPending, RunAt: old},
+	}
+	for _, job := range jobs {
+		require.NoError(t, repos.Jobs.Enqueue(ctx, job))
+	}
+
+	// 完了日時が保持期間より前の完了したジョブのみを削除する
+	n, err := repos.Jobs.DeleteSucceeded(ctx, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	_, err = repos.Jobs.FindByID(ctx, jobs[0].ID)
+	assert.ErrorIs(t, err, domain.ErrJobNotFound)
+	for _, job := range jobs[1:] {
+		_, err := repos.Jobs.FindByID(ctx, job.ID)
+		assert.NoError(t, err)
+	}
+}
+
+func testJobRequeue(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	now := time.Now()
+	finished := now.Add(-time.Minute)
+	dead := &domain.Job{Type: "a", Payload: "{}", Status: domain.JobDead, Attempts: 3, RunAt: now, LastError: "boom", FinishedAt: &finished}
+	pending := &domain.Job{Type: "a", Payload: "{}", Status: domain.JobPending, RunAt: now}
+	require.NoError(t, repos.Jobs.Enqueue(ctx, dead))
+	require.NoError(t, repos.Jobs.Enqueue(ctx, pending))
+
+	requeued, err := repos.Jobs.Requeue(ctx, dead.ID, now)
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobPending, requeued.Status)
+	assert.Zero(t, requeued.Attempts)
+	assert.Nil(t, requeued.FinishedAt)
+	assert.Equal(t, "boom", requeued.LastError)
+	_, err = repos.Jobs.Requeue(ctx, dead.ID, now)
+	assert.ErrorIs(t, err, domain.ErrJobNotDead)
+	_, err = repos.Jobs.Requeue(ctx, pending.ID, now)
+	assert.ErrorIs(t, err, domain.ErrJobNotDead)
+	_, err = repos.Jobs.Requeue(ctx, pending.ID+100, now)
+	assert.ErrorIs(t, err, domain.ErrJobNotFound)
+}
+
+func testJobFind(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	var ids []uint
+	for _, status := range []domain.JobStatus{domain.JobDead, domain.JobSucceeded, domain.JobDead} {
+		job := &domain.Job{Type: "a", Payload: "{}", Status: status, RunAt: time.Now()}
+		require.NoError(t, repos.Jobs.Enqueue(ctx, job))
+		ids = append(ids, job.ID)
+	}
+
+	dead, err := repos.Jobs.Find(ctx, domain.JobDead, 10)
+	require.NoError(t, err)
+	all, err := repos.Jobs.Find(ctx, "", 2)
+	require.NoError(t, err)
+
+	// 新しい順に返す
+	require.Len(t, dead, 2)
+	assert.Equal(t, []uint{ids[2], ids[0]}, []uint{dead[0].ID, dead[1].ID})
+	require.Len(t, all, 2)
+	assert.Equal(t, []uint{ids[2], ids[1]}, []uint{all[0].ID, all[1].ID})
+	_, err = repos.Jobs.FindByID(ctx, ids[2]+100)
+	assert.ErrorIs(t, err, domain.ErrJobNotFound)
+}
+
+func testJobRollback(t *testing.T, newRepos Factory) {
+	repos, tx := newRepos(t)
+	ctx := context.Background()
+
+	err := tx.WithinTransaction(ctx, func(ctx context.Context, r repository.Repositories) error {
+		_, err := r.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "a"})
+		require.NoError(t, err)
+		require.NoError(t, r.Jobs.Enqueue(ctx, &domain.Job{Type: "a", Payload: "{}", Status: domain.JobPending, RunAt: time.Now()}))
+		return errors.New("boom")
+	})
+
+	// ドメインの変更を取り消したトランザクションのジョブは実行されない
+	require.Error(t, err)
+	jobs, err := repos.Jobs.Find(ctx, "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+}
+
+// newReminderは通知する日時がfireAtの日時指定のリマインダーを返します。
+func newReminder(userID, todoID uint, fireAt time.Time) *domain.Reminder {
+	return &domain.Reminder{
+		UserID: userID, TodoID: todoID, RemindAt: &fireAt, FireAt: &fireAt,
+		Channels: []domain.NotificationChannel{domain.NotificationInApp},
+	}
+}
+
+func testReminderFind(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	now := time.Now()
+	first, second := newReminder(1, 10, now), newReminder(1, 10, now)
+	other := newReminder(1, 11, now)
+	for _, r := range []*domain.Reminder{first, second, other} {
+		require.NoError(t, repos.Reminders.Create(ctx, r))
+	}
+
+	got, err := repos.Reminders.FindByTodo(ctx, 1, 10)
+	require.NoError(t, err)
+	none, err := repos.Reminders.FindByTodo(ctx, 2, 10)
+	require.NoError(t, err)
+
+	// Todoのリマインダーを作成順に返し、チャネルも保存する
+	require.Len(t, got, 2)
+	assert.Equal(t, []uint{first.ID, second.ID}, []uint{got[0].ID, got[1].ID})
+	assert.Equal(t, []domain.NotificationChannel{domain.NotificationInApp}, got[0].Channels)
+	assert.Empty(t, none)
+	assert.ErrorIs(t, repos.Reminders.Delete(ctx, 2, 10, first.ID), domain.ErrReminderNotFound)
+	assert.ErrorIs(t, repos.Reminders.Delete(ctx, 1, 11, first.ID), domain.ErrReminderNotFound)
+	require.NoError(t, repos.Reminders.Delete(ctx, 1, 10, first.ID))
+	require.NoError(t, repos.Reminders.DeleteByTodo(ctx, 1, 10))
+	got, err = repos.Reminders.FindByTodo(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	got, err = repos.Reminders.FindByTodo(ctx, 1, 11)
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+}
+
+func testReminderFire(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	now := time.Now()
+	later := newReminder(1, 10, now.Add(-time.Second))
+	first := newReminder(1, 10, now.Add(-time.Minute))
+	scheduled := newReminder(1, 10, now.Add(time.Hour))
+	unscheduled := &domain.Reminder{UserID: 1, TodoID: 10, Channels: []domain.NotificationChannel{domain.NotificationInApp}}
+	for _, r := range []*domain.Reminder{later, first, scheduled, unscheduled} {
+		require.NoError(t, repos.Reminders.Create(ctx, r))
+	}
+
+	due, err := repos.Reminders.Due(ctx, now, 10)
+	require.NoError(t, err)
+
+	// 通知する日時を迎えたリマインダーのみを古い順に返す
+	require.Len(t, due, 2)
+	assert.Equal(t, []uint{first.ID, later.ID}, []uint{due[0].ID, due[1].ID})
+
+	// 通知済みにできるのは1回だけ
+	fired, err := repos.Reminders.MarkFired(ctx, first.ID, now)
+	require.NoError(t, err)
+	assert.True(t, fired)
+	fired, err = repos.Reminders.MarkFired(ctx, first.ID, now)
+	require.NoError(t, err)
+	assert.False(t, fired)
+	fired, err = repos.Reminders.MarkFired(ctx, scheduled.ID, now)
+	require.NoError(t, err)
+	assert.False(t, fired, "not due yet")
+	due, err = repos.Reminders.Due(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, later.ID, due[0].ID)
+}
+
+func testReminderReschedule(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	now := time.Now()
+	r := newReminder(1, 10, now.Add(-time.Minute))
+	require.NoError(t, repos.Reminders.Create(ctx, r))
+	fired, err := repos.Reminders.MarkFired(ctx, r.ID, now)
+	require.NoError(t, err)
+	require.True(t, fired)
+
+	// rearmしない場合、通知済みのリマインダーは変更しない
+	require.NoError(t, repos.Reminders.Reschedule(ctx, r.ID, nil, false))
+	got, err := repos.Reminders.FindByTodo(ctx, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.NotNil(t, got[0].FiredAt)
+	assert.NotNil(t, got[0].FireAt)
+
+	// rearmした場合は未通知に戻り、新しい日時に再び通知する
+	next := now.Add(time.Minute)
+	require.NoError(t, repos.Reminders.Reschedule(ctx, r.ID, &next, true))
+	got, err = repos.Reminders.FindByTodo(ctx, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Nil(t, got[0].FiredAt)
+	require.NotNil(t, got[0].FireAt)
+	assert.WithinDuration(t, next, *got[0].FireAt, time.Second)
+	due, err := repos.Reminders.Due(ctx, next, 10)
+	require.NoError(t, err)
+	assert.Len(t, due, 1)
+	assert.ErrorIs(t, repos.Reminders.Reschedule(ctx, r.ID+100, nil, true), domain.ErrReminderNotFound)
+}
+
+func testNotification(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	var ids []uint
+	for _, userID := range []uint{1, 2, 1, 1} {
+		n := &domain.Notification{UserID: userID, Type: domain.NotificationReminder, TodoID: 10, Title: "a"}
+		require.NoError(t, repos.Notifications.Create(ctx, n))
+		ids = append(ids, n.ID)
+	}
+	now := time.Now()
+
+	read, err := repos.Notifications.MarkRead(ctx, 1, ids[2], now)
+	require.NoError(t, err)
+	again, err := repos.Notifications.MarkRead(ctx, 1, ids[2], now.Add(time.Hour))
+	require.NoError(t, err)
+
+	// 既読日時は最初に既読にした日時のまま
+	require.NotNil(t, read.ReadAt)
+	require.NotNil(t, again.ReadAt)
+	assert.WithinDuration(t, now, *again.ReadAt, time.Second)
+	_, err = repos.Notifications.MarkRead(ctx, 2, ids[0], now)
+	assert.ErrorIs(t, err, domain.ErrNotificationNotFound)
+
+	// ユーザー宛ての通知を新しい順に返す
+	all, err := repos.Notifications.FindByUser(ctx, 1, false, 10)
+	require.NoError(t, err)
+	unread, err := repos.Notifications.FindByUser(ctx, 1, true, 1)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, []uint{ids[3], ids[2], ids[0]}, []uint{all[0].ID, all[1].ID, all[2].ID})
+	require.Len(t, unread, 1)
+	assert.Equal(t, ids[3], unread[0].ID)
+}
+
+func testNotificationBulk(t *testing.T, newRepos Factory) {
+	repos, _ := newRepos(t)
+	ctx := context.Background()
+	var ids []uint
+	for _, userID := range []uint{1, 1, 2, 1} {
+		n := &domain.Notification{UserID: userID, Type: domain.NotificationReminder, TodoID: 10, Title: "a"}
+		require.NoError(t, repos.Notifications.Create(ctx, n))
+		ids = append(ids, n.ID)
+	}
+	earlier := time.Now().Add(-time.Hour)
+	_, err := repos.Notifications.MarkRead(ctx, 1, ids[0], earlier)
+	require.NoError(t, err)
+
+	unread, err := repos.Notifications.CountUnread(ctx, 1)
+	require.NoError(t, err)
+	marked, err := repos.Notifications.MarkAllRead(ctx, 1, time.Now())
+	require.NoError(t, err)
+	after, err := repos.Notifications.CountUnread(ctx, 1)
+	require.NoError(t, err)
+	other, err := repos.Notifications.CountUnread(ctx, 2)
+	require.NoError(t, err)
+
+	// 既読の通知と他のユーザーの通知は変更しない
+	assert.Equal(t, 2, unread)
+	assert.Equal(t, 2, marked)
+	assert.Zero(t, after)
+	assert.Equal(t, 1, other)
+	all, err := repos.Notifications.FindByUser(ctx, 1, false, 10)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	require.NotNil(t, all[2].ReadAt)
+	assert.WithinDuration(t, earlier, *all[2].ReadAt, time.Second)
+
+	// 削除は宛先のユーザーに限る
+	assert.ErrorIs(t, repos.Notifications.Delete(ctx, 2, ids[0]), domain.ErrNotificationNotFound)
+	require.NoError(t, repos.Notifications.Delete(ctx, 1, ids[0]))
+	assert.ErrorIs(t, repos.Notifications.Delete(ctx, 1, ids[0]), domain.ErrNotificationNotFound)
+	all, err = repos.Notifications.FindByUser(ctx, 1, false, 10)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+}
+
+func testTxCommit(t *testing.T, newRepos Factory) {
+	repos, tx := newRepos(t)
+	ctx := context.Background()
+
+	err := tx.WithinTransaction(ctx, func(ctx context.Context, r repository.Repositories) error {
+		if err := r.Users.Create(ctx, &domain.User{Email: "a@example.com", Password: "x"}); err != nil {
+			return err
+		}
+		_, err := r.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "a"})
+		return err
+	})
+
+	require.NoError(t, err)
+	_, err = repos.Users.FindByEmail(ctx, "a@example.com")
+	assert.NoError(t, err)
+	todos, err := repos.Todos.FindByUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, todos, 1)
+}
+
+func testTxRollback(t *testing.T, newRepos Factory) {
+	repos, tx := newRepos(t)
+	ctx := context.Background()
+	existing, err := repos.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "keep"})
+	require.NoError(t, err)
+	failure := errors.New("boom")
+
+	err = tx.WithinTransaction(ctx, func(ctx context.Context, r repository.Repositories) error {
+		require.NoError(t, r.Users.Create(ctx, &domain.User{Email: "a@example.com", Password: "x"}))
+		_, err := r.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "rolled back"})
+		require.NoError(t, err)
+		existing.Title = "changed"
+		require.NoError(t, r.Todos.Update(ctx, existing))
+		return failure
+	})
+
+	// どのリポジトリの変更も残らない
+	assert.ErrorIs(t, err, failure)
+	_, err = repos.Users.FindByEmail(ctx, "a@example.com")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	todos, err := repos.Todos.FindByUser(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, todos, 1)
+	assert.Equal(t, "keep", todos[0].Title)
+	assert.Equal(t, uint(1), todos[0].Version)
+}
+
+func testTxNested(t *testing.T, newRepos Factory) {
+	repos, tx := newRepos(t)
+	ctx := context.Background()
+
+	err := tx.WithinTransaction(ctx, func(ctx context.Context, r repository.Repositories) error {
+		if _, err := r.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "outer"}); err != nil {
+			return err
+		}
+		inner := tx.WithinTransaction(ctx, func(ctx context.Context, r repository.Repositories) error {
+			if _, err := r.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "inner"}); err != nil {
+				return err
+			}
+			return errors.New("inner failed")
+		})
+		assert.Error(t, inner)
+		return nil
+	})
+
+	// 外側の変更のみコミットされる
+	require.NoError(t, err)
+	todos, err := repos.Todos.FindByUser(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, todos, 1)
+	assert.Equal(t, "outer", todos[0].Title)
+}
+
+func testTxRollbackKeepsOutsideWrites(t *testing.T, newRepos Factory) {
+	repos, tx := newRepos(t)
+	ctx := context.Background()
+	existing, err := repos.Todos.Create(ctx, domain.Todo{UserID: 1, Title: "keep"})
+	require.NoError(t, err)
+	failure := errors.New("boom")
+	outside := make(chan error, 1)
+
+	err = tx.WithinTransaction(ctx, func(txCtx context.Context, r repository.Repositories) error {
+		existing.Title = "changed"
+		require.NoError(t, r.Todos.Update(txCtx, existing))
+		// トランザクションの外の書き込み（DBの実装ではトランザクションの終了まで待たされる）
+		go func() {
+			outside <- repos.Users.Create(ctx, &domain.User{Email: "outside@example.com", Password: "x"})
+		}()
+		select {
+		case err := <-outside:
+			outside <- err
+		case <-time.After(100 * time.Millisecond):
+		}
+		return failure
+	})
+
+	// トランザクションの変更だけが戻り、外の書き込みは残る
+	assert.ErrorIs(t, err, failure)
+	require.NoError(t, <-outside)
+	_, err = repos.Users.FindByEmail(ctx, "outside@example.com")
+	assert.NoError(t, err)
+	got, err := repos.Todos.FindByID(ctx, 1, existing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "keep", got.Title)
+}
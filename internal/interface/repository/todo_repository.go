@@ -1,23 +1,53 @@
 package repository
 
-import "todo_backend/internal/domain"
+import (
+	"context"
+	"time"
+
+	"todo_backend/internal/domain"
+)
 
 // TodoRepository は Todo エンティティの永続化操作を定義するインターフェースです。
 // Clean Architecture における Repository 層の契約を表し、
 // 実際のデータストア（MySQL、PostgreSQL、メモリなど）の実装はこのインターフェースを満たす必要があります。
+// 全メソッドは第1引数にcontext.Contextを受け取り、キャンセルやトレースの伝播に利用します。
 type TodoRepository interface {
 	// FindAll は、指定ユーザーの全ての Todo を取得します。
 	// 戻り値は Todo のスライスと、エラー情報です。
-	FindByUser(userId uint) ([]domain.Todo, error)
+	FindByUser(ctx context.Context, userId uint) ([]domain.Todo, error)
+
+	// FindByID は、指定ユーザーが所有する ID の Todo を取得します。
+	// 存在しない場合は domain.ErrTodoNotFound を返します。
+	FindByID(ctx context.Context, userID uint, id uint) (domain.Todo, error)
 
-	// Create は、新しい Todo を永続化します。
+	// Create は、新しい Todo を永続化し、採番された ID とバージョンを含む Todo を返します。
 	// 引数には作成する Todo エンティティを渡します。
-	Create(todo domain.Todo) error
+	Create(ctx context.Context, todo domain.Todo) (domain.Todo, error)
 
-	// Update は、既存の Todo を更新します。
-	// 引数には更新内容を含む Todo エンティティを渡します。
-	Update(todo domain.Todo) error
+	// Update は、既存の Todo を更新し、バージョンを1つ進めます。
+	// 引数には更新内容を含む Todo エンティティを渡します。todo.Version には更新前の（期待する）バージョンを指定します。
+	// Todo が存在しない場合は domain.ErrTodoNotFound、
+	// バージョンが一致しない場合は domain.ErrVersionMismatch を返します。
+	Update(ctx context.Context, todo domain.Todo) error
 
 	// Delete は、指定された ID の Todo を削除します。
-	Delete(userID uint, id int) error
+	// version が現在のバージョンと一致しない場合は domain.ErrVersionMismatch、
+	// Todo が存在しない場合は domain.ErrTodoNotFound を返します。
+	Delete(ctx context.Context, userID, id, version uint) error
+
+	// Changes は、指定ユーザーの Todo の変更履歴のうち Seq が since より大きいものを、Seq の昇順に最大 limit 件返します。
+	// Create / Update / Delete は書き込みと同時に変更履歴を記録し、Todo ごとに最新の変更のみを残します。
+	// 削除された Todo は Deleted の墓標として返します。
+	Changes(ctx context.Context, userID uint, since uint64, limit int) ([]domain.TodoChange, error)
+
+	// LastChangeSeq は、指定ユーザーの Todo の最新の変更の Seq を返します。変更がない場合は0を返します。
+	LastChangeSeq(ctx context.Context, userID uint) (uint64, error)
+
+	// PrunedChangeSeq は、指定ユーザーの削除済みの墓標の Seq の最大値（domain.TodoChangeLog.PrunedSeq）を返します。
+	// 墓標を削除していない場合は0を返します。
+	PrunedChangeSeq(ctx context.Context, userID uint) (uint64, error)
+
+	// PruneTombstones は、before より前に記録された削除の墓標を削除し、削除した件数を返します。
+	// 削除した墓標の Seq はユーザーごとに PrunedChangeSeq として記録します。
+	PruneTombstones(ctx context.Context, before time.Time) (int64, error)
 }
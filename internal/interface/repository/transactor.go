@@ -0,0 +1,24 @@
+package repository
+
+import "context"
+
+// Repositories はトランザクションに束縛されたリポジトリの組です。
+// Transactor.WithinTransaction の fn に渡され、fn 内での読み書きはすべて同じトランザクションで行われます。
+type Repositories struct {
+	Todos         TodoRepository
+	Users         UserRepository
+	Webhooks      WebhookRepository
+	Jobs          JobRepository
+	Reminders     ReminderRepository
+	Notifications NotificationRepository
+}
+
+// Transactor は複数のリポジトリにまたがる書き込みを1つのトランザクション（Unit of Work）として実行します。
+// ユースケース層はこのインターフェースを通じてトランザクションを扱い、DB の実装には依存しません。
+type Transactor interface {
+	// WithinTransaction は fn をトランザクション内で実行します。
+	// fn がエラーを返した場合はすべての変更を取り消し、そのエラーを返します。
+	// fn には トランザクションの情報を持つ context が渡されます。その context で WithinTransaction を
+	// 呼び出すと入れ子のトランザクション（セーブポイント）になり、内側の失敗は内側の変更だけを取り消します。
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error
+}
@@ -1,21 +1,25 @@
 package repository
 
 import (
+	"context"
+
 	"todo_backend/internal/domain"
 )
 
 // UserRepositoryはユーザエンティティの永続化をを抽象化したインターフェースです。
 // DBの種類や実装に依存せず、ユースケース側から利用されます。
+// 全メソッドは第1引数にcontext.Contextを受け取ります。
 type UserRepository interface {
 	// Createは新しいユーザーを永続化します。
-	// すでに同じEmailが存在する場合はエラーを返します。
-	Create(user *domain.User) error
+	// 採番されたIDはuser.IDに設定されます。
+	// すでに同じEmailが存在する場合はdomain.ErrEmailAlreadyExistsを返します。
+	Create(ctx context.Context, user *domain.User) error
 
 	// FindByEmailは指定したEmailに一致するユーザーを取得します。
-	// ユーザーが存在しない場合はエラーを返します。
-	FindByEmail(email string) (*domain.User, error)
+	// ユーザーが存在しない場合はdomain.ErrUserNotFoundを返します。
+	FindByEmail(ctx context.Context, email string) (*domain.User, error)
 
 	// FindByIDは指定したIDに一致するユーザーを取得します。
-	// ユーザーが存在しない場合はエラーを返します。
-	FindByID(id uint) (*domain.User, error)
+	// ユーザーが存在しない場合はdomain.ErrUserNotFoundを返します。
+	FindByID(ctx context.Context, id uint) (*domain.User, error)
 }
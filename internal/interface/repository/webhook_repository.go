@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"todo_backend/internal/domain"
+)
+
+// WebhookRepositoryはWebhookの購読と配信ログの永続化を抽象化したインターフェースです。
+// 全メソッドは第1引数にcontext.Contextを受け取ります。
+type WebhookRepository interface {
+	// Createは新しいWebhookを永続化します。採番されたIDと登録日時はwebhookに設定されます。
+	Create(ctx context.Context, webhook *domain.Webhook) error
+
+	// FindByUserは指定ユーザーのWebhookをID順に返します。
+	FindByUser(ctx context.Context, userID uint) ([]domain.Webhook, error)
+
+	// FindByIDは指定ユーザーが所有するIDのWebhookを返します。
+	// 存在しない場合はdomain.ErrWebhookNotFoundを返します。
+	FindByID(ctx context.Context, userID, id uint) (domain.Webhook, error)
+
+	// Deleteは指定ユーザーが所有するIDのWebhookを、その配信ログとともに削除します。
+	// 存在しない場合はdomain.ErrWebhookNotFoundを返します。
+	Delete(ctx context.Context, userID, id uint) error
+
+	// CreateDeliveryは新しい配信を永続化します。採番されたIDと作成日時はdeliveryに設定されます。
+	// 同じWebhookにdelivery.EventIDが同じ配信が既にある場合は何もしません。
+	CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+
+	// FindDeliveriesは指定ユーザーのWebhookの配信を新しい順に最大limit件返します。
+	FindDeliveries(ctx context.Context, userID, webhookID uint, limit int) ([]domain.WebhookDelivery, error)
+
+	// FindDeliveryは指定ユーザーのWebhookのIDの配信を返します。
+	// 存在しない場合はdomain.ErrWebhookDeliveryNotFoundを返します。
+	FindDelivery(ctx context.Context, userID, webhookID, id uint) (domain.WebhookDelivery, error)
+
+	// DueDeliveriesは、配信待ちでNextAttemptAtがnow以前の配信を、NextAttemptAtの古い順に最大limit件返します。
+	// excludeのWebhookの配信は含めません。
+	DueDeliveries(ctx context.Context, now time.Time, limit int, exclude []uint) ([]domain.WebhookDelivery, error)
+
+	// UpdateDeliveryは配信の送信結果（状態・試行回数・次の試行日時など）を保存します。
+	// 配信が存在しない場合（Webhookとともに削除された場合）はdomain.ErrWebhookDeliveryNotFoundを返します。
+	UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error
+}
@@ -1,9 +1,12 @@
 package usecase
 
 import (
+	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"todo_backend/internal/domain"
@@ -17,32 +20,55 @@ import (
 // 具体的な実装はインフラ層のDBや外部ライブラリに依存せず、
 // ユースケース層からはこの抽象を通して利用されます。
 type AuthUsecase interface {
-	Signup(email, password string) error
-	Login(email, password string) (string, error) // returns JWT
+	// localeは表示言語の設定（"ja" / "en"）です。空の場合は設定しません。
+	Signup(ctx context.Context, email, password, locale string) error
+	Login(ctx context.Context, email, password string) (string, error) // returns JWT
 }
 
 // authUsecaseは認証関連のユースケースを表す構造体です。
 // UserRepositoryに依存しており、ユーザの作成や取得を行う際に利用する。
 type authUsecase struct {
-	users repository.UserRepository
+	users   repository.UserRepository
+	metrics AuthMetrics
+	admins  []uint
+}
+
+// AuthOptionはNewAuthUsecaseの任意設定です。
+type AuthOption func(*authUsecase)
+
+// WithAdminUserIDsは管理者として扱うユーザーのIDを設定します。
+// 管理者のアクセストークンには"admin"クレームが付きます。メールアドレスは登録時に確認しておらず、
+// 誰でも任意のアドレスで登録できるため、管理者はサーバ側で割り当てたIDで指定します。
+func WithAdminUserIDs(ids ...uint) AuthOption {
+	return func(u *authUsecase) { u.admins = append(u.admins, ids...) }
 }
 
 // NewAuthUsecaseはauthUsecaseの新しいインスタンスを作成する。
 // 引数usersには、ユーザの永続化を行うためにUserRepositoryの実装を渡す。
-func NewAuthUsecase(users repository.UserRepository) AuthUsecase {
-	return &authUsecase{users: users}
+// optsでメトリクスなどの任意の依存を注入できる。
+func NewAuthUsecase(users repository.UserRepository, opts ...AuthOption) AuthUsecase {
+	u := &authUsecase{users: users, metrics: noopMetrics{}}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
 }
 
 // SignUpは新規ユーザ登録を行います。
-// 受け取ったパスワードはbcryptでハッシュ化し、UserRepository経由で保存します。
+// 受け取ったパスワードはbcryptでハッシュ化し、表示言語の設定とともにUserRepository経由で保存します。
+// メールアドレスは前後の空白を除いて小文字にそろえて保存する（大文字・小文字違いで別のユーザーを登録できないようにする）。
 // 同じメールアドレスがすでに存在する場合やDBエラーが発生した場合はエラーを返す。
-func (u *authUsecase) Signup(email, password string) error {
+func (u *authUsecase) Signup(ctx context.Context, email, password, locale string) (err error) {
+	ctx, span := startSpan(ctx, "AuthUsecase.Signup")
+	defer func() { endSpan(span, err) }()
+
+	email = normalizeEmail(email)
 	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
-	user := &domain.User{Email: email, Password: string(hashed)}
-	return u.users.Create(user)
+	user := &domain.User{Email: email, Password: string(hashed), Locale: locale}
+	return u.users.Create(ctx, user)
 }
 
 // Loginはユーザ認証を行い、成功した場合はJWTのアクセストークンを返す。
@@ -50,20 +76,40 @@ func (u *authUsecase) Signup(email, password string) error {
 // 2. bcryptでパスワード検証
 // 3. JWT_SECRETを使用し、署名つきJWTを生成
 // 4. 成功時にログを出力し、トークンを返す
-func (u *authUsecase) Login(email, password string) (string, error) {
-	// 1. Emailでユーザ検索
-	user, err := u.users.FindByEmail(email)
+//
+// ユーザーが存在しない場合とパスワードが一致しない場合のみ、ログインの失敗として記録してdomain.ErrInvalidCredentialsを返す。
+// DBの障害などその他のエラーはログインの失敗として数えず、そのまま返す。
+func (u *authUsecase) Login(ctx context.Context, email, password string) (_ string, err error) {
+	ctx, span := startSpan(ctx, "AuthUsecase.Login")
+	defer func() { endSpan(span, err) }()
+
+	// 1. Emailでユーザ検索（登録時と同じく小文字にそろえる）
+	user, err := u.users.FindByEmail(ctx, normalizeEmail(email))
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		// 期限切れ・キャンセルは認証失敗ではないため、そのまま返す
+		return "", ctxErr
+	}
+	if errors.Is(err, domain.ErrUserNotFound) {
+		slog.WarnContext(ctx, "login failed", slog.String("reason", "user not found"))
+		u.metrics.LoginFailed()
+		return "", domain.ErrInvalidCredentials
+	}
 	if err != nil {
-		return "", errors.New("invalid credentials")
+		return "", err
 	}
 
 	// 2. bcryptでパスワード検証
 	// 第1引数が「ハッシュ」、第2引数が「平文」
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		log.Printf("[LOGIN] bcrypt NG: %v", err)
-		return "", errors.New("invalid email or password")
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		slog.WarnContext(ctx, "login failed", slog.Uint64("user_id", uint64(user.ID)), slog.String("reason", "password mismatch"))
+		u.metrics.LoginFailed()
+		return "", domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		// 保存されたハッシュが壊れているなど、パスワードの誤りではない
+		return "", err
 	}
-	log.Printf("[LOGIN] bcrypt OK for id=%d", user.ID)
 
 	// 3. JWT_SECRETを使用し、署名つきJWTを生成
 	secret := os.Getenv("JWT_SECRET")
@@ -78,6 +124,12 @@ func (u *authUsecase) Login(email, password string) (string, error) {
 		"iat":   time.Now().Unix(),                     // 発行時刻（標準: iat）
 		"email": user.Email,                            // アプリ独自の公開クレーム
 	}
+	if user.Locale != "" {
+		claims["locale"] = user.Locale // 表示言語の設定（認証済みリクエストのエラーメッセージに使う）
+	}
+	if slices.Contains(u.admins, user.ID) {
+		claims["admin"] = true // 管理者（/admin以下のAPIを利用できる）
+	}
 
 	// 署名付きJWTの生成
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -86,6 +138,12 @@ func (u *authUsecase) Login(email, password string) (string, error) {
 		return "", err
 	}
 
-	log.Printf("[LOGIN] success id=%d", user.ID)
+	u.metrics.LoginSucceeded()
+	slog.InfoContext(ctx, "login succeeded", slog.Uint64("user_id", uint64(user.ID)))
 	return signed, nil
 }
+
+// normalizeEmailは、メールアドレスを保存・検索に使う形（前後の空白を除いた小文字）にします。
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
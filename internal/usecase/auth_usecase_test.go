@@ -0,0 +1,102 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/memory"
+	"todo_backend/internal/interface/repository"
+	"todo_backend/internal/usecase"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingAuthMetricsはログインの成功・失敗の回数を数えるusecase.AuthMetricsです。
+type countingAuthMetrics struct {
+	succeeded, failed int
+}
+
+func (m *countingAuthMetrics) LoginSucceeded() { m.succeeded++ }
+func (m *countingAuthMetrics) LoginFailed()    { m.failed++ }
+
+// brokenUserRepoはユーザーの検索が常にerrで失敗するUserRepositoryです。
+type brokenUserRepo struct {
+	repository.UserRepository
+	err error
+}
+
+func (r brokenUserRepo) FindByEmail(context.Context, string) (*domain.User, error) {
+	return nil, r.err
+}
+
+// 存在しないユーザーと誤ったパスワードは、区別せずにログインの失敗として数える
+func TestLogin_InvalidCredentialsAreCountedAsFailures(t *testing.T) {
+	// given
+	t.Setenv("JWT_SECRET", "test-secret")
+	m := &countingAuthMetrics{}
+	uc := usecase.NewAuthUsecase(memory.NewUserRepo(), usecase.WithAuthMetrics(m))
+	require.NoError(t, uc.Signup(context.Background(), "a@example.com", "password", ""))
+
+	// when
+	_, errMissing := uc.Login(context.Background(), "b@example.com", "password")
+	_, errWrong := uc.Login(context.Background(), "a@example.com", "wrong")
+	token, err := uc.Login(context.Background(), "a@example.com", "password")
+
+	// then
+	assert.ErrorIs(t, errMissing, domain.ErrInvalidCredentials)
+	assert.ErrorIs(t, errWrong, domain.ErrInvalidCredentials)
+	require.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.Equal(t, 2, m.failed)
+	assert.Equal(t, 1, m.succeeded)
+}
+
+// DBの障害などはログインの失敗として数えず、認証情報の誤りとも扱わない
+func TestLogin_StorageErrorIsNotAFailedLogin(t *testing.T) {
+	// given
+	outage := errors.New("connection refused")
+	m := &countingAuthMetrics{}
+	uc := usecase.NewAuthUsecase(brokenUserRepo{err: outage}, usecase.WithAuthMetrics(m))
+
+	// when
+	_, err := uc.Login(context.Background(), "a@example.com", "password")
+
+	// then
+	assert.ErrorIs(t, err, outage)
+	assert.NotErrorIs(t, err, domain.ErrInvalidCredentials)
+	assert.Zero(t, m.failed)
+}
+
+// loginClaimsはLoginが返したトークンのクレームを返します。
+func loginClaims(t *testing.T, uc usecase.AuthUsecase, email string) jwt.MapClaims {
+	t.Helper()
+	token, err := uc.Login(context.Background(), email, "password")
+	require.NoError(t, err)
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) { return []byte("test-secret"), nil })
+	require.NoError(t, err)
+	return claims
+}
+
+// メールアドレスは大文字・小文字を区別せずに1人のユーザーとして扱い、管理者はIDでのみ決まる
+func TestLogin_AdminIsGrantedByUserIDOnly(t *testing.T) {
+	// given: ID 1のユーザーのみが管理者
+	t.Setenv("JWT_SECRET", "test-secret")
+	uc := usecase.NewAuthUsecase(memory.NewUserRepo(), usecase.WithAdminUserIDs(1))
+	require.NoError(t, uc.Signup(context.Background(), "admin@example.com", "password", ""))
+
+	// when: 大文字・小文字違いのアドレスでは別のユーザーとして登録できず、別のアドレスのユーザーは管理者にならない
+	errDup := uc.Signup(context.Background(), " Admin@Example.com", "password", "")
+	require.NoError(t, uc.Signup(context.Background(), "Other@Example.com", "password", ""))
+
+	// then
+	assert.ErrorIs(t, errDup, domain.ErrEmailAlreadyExists)
+	assert.Equal(t, true, loginClaims(t, uc, "ADMIN@example.com")["admin"])
+	other := loginClaims(t, uc, "other@example.com")
+	assert.Equal(t, "other@example.com", other["email"])
+	assert.NotContains(t, other, "admin")
+}
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+)
+
+// TodoEventBusはTodoの変更イベントの配信を抽象化したインターフェースです。
+// 実装（プロセス内のイベントバスなど）はインフラ層に置きます。
+type TodoEventBus interface {
+	// Publishはイベントを採番して購読者に配信します。配信を待たずに戻ります。
+	Publish(event domain.TodoEvent)
+	// SubscribeはuserIDのイベントの購読を開始します。
+	// lastEventIDが0以外の場合は、そのIDより後のイベントから再開します。
+	Subscribe(userID uint, lastEventID uint64) TodoEventStream
+}
+
+// TodoEventStreamはTodoの変更イベントの購読です。
+type TodoEventStream struct {
+	// Replayは再開位置より後に発生した、保持されているイベントです（古い順）。
+	Replay []domain.TodoEvent
+	// Resetは再開位置のイベントが既に保持されておらず、取りこぼした変更があり得ることを表します。
+	// クライアントは一覧を取得し直す必要があります。
+	Reset bool
+	// Eventsは購読開始後のイベントです。
+	// 購読者の受信が追いつかない場合やサーバの停止時には閉じられます（クライアントは再接続して再開します）。
+	Events <-chan domain.TodoEvent
+	// Closeは購読を終了します。
+	Close func()
+}
+
+// noopEventsはイベントバスが注入されなかった場合に使う実装です。
+// イベントは破棄し、購読はすぐに閉じられます。
+type noopEvents struct{}
+
+func (noopEvents) Publish(domain.TodoEvent) {}
+
+func (noopEvents) Subscribe(uint, uint64) TodoEventStream {
+	events := make(chan domain.TodoEvent)
+	close(events)
+	return TodoEventStream{Events: events, Close: func() {}}
+}
+
+// TodoEventHandlerは、コミット後のTodoの変更イベントを受け取る処理（Webhookの配信など）です。
+// HandleTodoEventはリクエストの処理中に呼び出されるため、時間のかかる処理は非同期に行ってください。
+// コミット後にプロセスが異常終了した場合は呼び出されないため、確実に処理する必要がある場合はWithEventJobを使ってください。
+type TodoEventHandler interface {
+	HandleTodoEvent(event domain.TodoEvent)
+}
+
+// WithEventBusはTodoの変更イベントを配信するイベントバスを設定します。
+func WithEventBus(bus TodoEventBus) TodoOption {
+	return func(uc *TodoUsecase) { uc.Events = bus }
+}
+
+// WithEventHandlerは、Todoの変更イベントをイベントバスへの発行後に受け取る処理を追加します。
+func WithEventHandler(h TodoEventHandler) TodoOption {
+	return func(uc *TodoUsecase) { uc.EventHandlers = append(uc.EventHandlers, h) }
+}
+
+// EventJobは、変更イベントを保存するジョブの種類と、ジョブにする変更の種類です。
+type EventJob struct {
+	// Typeはジョブの種類です。
+	Type string
+	// Eventsはジョブにする変更の種類です。空の場合はすべての変更をジョブにします。
+	Events []domain.TodoEventType
+}
+
+// WithEventJobは、Todoの変更イベントを、変更と同じトランザクションでjobTypeのジョブとして保存します
+// （トランザクショナルアウトボックス）。ジョブのペイロードはdomain.TodoEvent（JSON）です。
+// eventsを指定した場合は、その種類の変更のみをジョブにします（ハンドラが使わないジョブを登録しないため）。
+// 変更が確定した場合にのみジョブが保存されるため、プロセスが異常終了してもイベントは失われません。
+// ジョブを保存できるTransactor（WithTransactor）の指定が必要です。
+func WithEventJob(jobType string, events ...domain.TodoEventType) TodoOption {
+	return func(uc *TodoUsecase) { uc.EventJobs = append(uc.EventJobs, EventJob{Type: jobType, Events: events}) }
+}
+
+// SubscribeTodoEventsは、userIDのTodoの変更イベントの購読を開始します。
+// lastEventIDが0以外の場合は、そのIDより後のイベントから再開します。
+// 購読が不要になったらTodoEventStream.Closeを呼び出してください。
+func (uc *TodoUsecase) SubscribeTodoEvents(ctx context.Context, userID uint, lastEventID uint64) TodoEventStream {
+	_, span := startSpan(ctx, "TodoUsecase.SubscribeTodoEvents")
+	defer endSpan(span, nil)
+
+	return uc.Events.Subscribe(userID, lastEventID)
+}
+
+// newTodoEventsは、todosの変更の変更イベントを返します。
+// completedは、更新で未完了のTodoが完了になったこと（完了メトリクスを記録する更新と同じ条件）を表します。
+func newTodoEvents(typ domain.TodoEventType, completed bool, todos ...domain.Todo) []domain.TodoEvent {
+	now := time.Now()
+	events := make([]domain.TodoEvent, len(todos))
+	for i, todo := range todos {
+		events[i] = domain.TodoEvent{Type: typ, UserID: todo.UserID, Todo: todo, Completed: completed, OccurredAt: now}
+	}
+	return events
+}
+
+// saveEventsは、変更イベントをWithEventJobで指定した種類のジョブとしてjobsに保存します。
+// 変更と同じトランザクションで呼び出してください。
+func (uc *TodoUsecase) saveEvents(ctx context.Context, jobs repository.JobRepository, events []domain.TodoEvent) error {
+	if len(uc.EventJobs) == 0 || len(events) == 0 {
+		return nil
+	}
+	if jobs == nil {
+		return errors.New("usecase: event jobs require a transactor with a job repository")
+	}
+	for _, event := range events {
+		for _, ej := range uc.EventJobs {
+			if len(ej.Events) > 0 && !slices.Contains(ej.Events, event.Type) {
+				continue
+			}
+			job, err := NewJob(ej.Type, event, time.Time{})
+			if err != nil {
+				return err
+			}
+			if err := jobs.Enqueue(ctx, &job); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// publishEventsは、コミット済みの変更イベントをイベントバスと変更イベントを受け取る処理に渡します。
+func (uc *TodoUsecase) publishEvents(events []domain.TodoEvent) {
+	for _, event := range events {
+		uc.Events.Publish(event)
+		for _, h := range uc.EventHandlers {
+			h.HandleTodoEvent(event)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEventBusは発行されたイベントを記録するusecase.TodoEventBusです。
+type fakeEventBus struct{ published []domain.TodoEvent }
+
+func (f *fakeEventBus) Publish(event domain.TodoEvent) { f.published = append(f.published, event) }
+
+func (f *fakeEventBus) Subscribe(uint, uint64) usecase.TodoEventStream {
+	return usecase.TodoEventStream{}
+}
+
+func (f *fakeEventBus) types() []domain.TodoEventType {
+	out := make([]domain.TodoEventType, len(f.published))
+	for i, ev := range f.published {
+		out[i] = ev.Type
+	}
+	return out
+}
+
+func TestTodoUsecase_PublishesEventsAfterChanges(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	bus := &fakeEventBus{}
+	uc := usecase.NewTodoUsecase(repo, usecase.WithEventBus(bus))
+
+	repo.On("Create", mock.Anything, mock.Anything).Return(domain.Todo{ID: 1, UserID: 7, Title: "t", Version: 1}, nil).Once()
+	repo.On("FindByID", mock.Anything, uint(7), uint(1)).Return(domain.Todo{ID: 1, UserID: 7, Title: "t", Version: 1}, nil).Once()
+	repo.On("Update", mock.Anything, mock.Anything).Return(nil).Once()
+	repo.On("Delete", mock.Anything, uint(7), uint(1), uint(2)).Return(nil).Once()
+
+	// when
+	_, err := uc.AddTodo(context.Background(), domain.Todo{UserID: 7, Title: "t"})
+	require.NoError(t, err)
+	_, err = uc.UpdateTodo(context.Background(), domain.Todo{ID: 1, UserID: 7, Title: "u", Version: 1})
+	require.NoError(t, err)
+	require.NoError(t, uc.DeleteTodo(context.Background(), 7, 1, 2))
+
+	// then
+	assert.Equal(t, []domain.TodoEventType{domain.TodoCreated, domain.TodoUpdated, domain.TodoDeleted}, bus.types())
+	assert.Equal(t, uint(2), bus.published[1].Todo.Version)
+	assert.Equal(t, domain.Todo{ID: 1, UserID: 7}, bus.published[2].Todo)
+	for _, ev := range bus.published {
+		assert.Equal(t, uint(7), ev.UserID)
+		assert.False(t, ev.OccurredAt.IsZero())
+	}
+}
+
+// 全体更新のイベントは、未完了のTodoが完了になった場合にのみCompletedになる（完了済みのTodoの名前の変更では通知しない）
+func TestTodoUsecase_UpdateMarksCompletedOnlyOnTransition(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	bus := &fakeEventBus{}
+	uc := usecase.NewTodoUsecase(repo, usecase.WithEventBus(bus))
+
+	repo.On("FindByID", mock.Anything, uint(7), uint(1)).Return(domain.Todo{ID: 1, UserID: 7, Title: "t", Version: 1}, nil).Once()
+	repo.On("FindByID", mock.Anything, uint(7), uint(1)).Return(domain.Todo{ID: 1, UserID: 7, Title: "t", Completed: true, Version: 2}, nil).Once()
+	repo.On("Update", mock.Anything, mock.Anything).Return(nil).Twice()
+
+	// when
+	_, err := uc.UpdateTodo(context.Background(), domain.Todo{ID: 1, UserID: 7, Title: "t", Completed: true, Version: 1})
+	require.NoError(t, err)
+	_, err = uc.UpdateTodo(context.Background(), domain.Todo{ID: 1, UserID: 7, Title: "renamed", Completed: true, Version: 2})
+	require.NoError(t, err)
+
+	// then
+	require.Len(t, bus.published, 2)
+	assert.True(t, bus.published[0].Completed)
+	assert.False(t, bus.published[1].Completed)
+	repo.AssertExpectations(t)
+}
+
+// 失敗した（ロールバックされた）変更のイベントは発行しない
+func TestTodoUsecase_DoesNotPublishFailedChanges(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	bus := &fakeEventBus{}
+	uc := usecase.NewTodoUsecase(repo, usecase.WithEventBus(bus))
+
+	repo.On("Create", mock.Anything, mock.Anything).Return(domain.Todo{ID: 3, UserID: 1, Version: 1}, nil).Once()
+	repo.On("Delete", mock.Anything, uint(1), uint(6), uint(1)).Return(domain.ErrVersionMismatch).Twice()
+
+	// when
+	_, batchErr := uc.ExecuteBatch(context.Background(), 1, []usecase.BatchOperation{
+		{Type: usecase.BatchCreate},
+		{Type: usecase.BatchDelete, ID: 6, Version: 1},
+	})
+	deleteErr := uc.DeleteTodo(context.Background(), 1, 6, 1)
+
+	// then
+	require.Error(t, batchErr)
+	require.ErrorIs(t, deleteErr, domain.ErrVersionMismatch)
+	assert.Empty(t, bus.published)
+}
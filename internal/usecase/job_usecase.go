@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+)
+
+// JobHandlerはバックグラウンドジョブの処理です。エラーを返した場合は再試行の方針に従って実行し直します。
+// 同じジョブが2回以上実行されることがある（少なくとも1回の実行）ため、処理は冪等にしてください。
+type JobHandler func(ctx context.Context, job domain.Job) error
+
+// permanentJobErrorは再試行しても成功しないジョブのエラーです。
+type permanentJobError struct{ err error }
+
+func (e *permanentJobError) Error() string { return e.err.Error() }
+func (e *permanentJobError) Unwrap() error { return e.err }
+
+// PermanentJobErrorは、再試行しても成功しない（ペイロードが不正など）ことを表すエラーでerrを包みます。
+// JobHandlerがこのエラーを返した場合、再試行せずにジョブをデッドレターにします。
+func PermanentJobError(err error) error {
+	return &permanentJobError{err: err}
+}
+
+// DefaultJobRetryPolicyはジョブの再試行の方針のデフォルト値です（10秒から最大1時間の間隔で10回まで）。
+var DefaultJobRetryPolicy = RetryPolicy{MaxAttempts: 10, Backoff: 10 * time.Second, MaxBackoff: time.Hour}
+
+// DefaultJobTimeoutは1回のジョブの実行期限のデフォルト値です。
+const DefaultJobTimeout = 5 * time.Minute
+
+// JobUsecaseは、バックグラウンドジョブ（トランザクショナルアウトボックス）の登録・実行の管理と、
+// 管理者向けの確認・再試行を行うユースケースです。ジョブの実行自体はインフラ層のワーカーが行います。
+type JobUsecase struct {
+	Repo  repository.JobRepository
+	Retry RetryPolicy
+	// Timeoutは1回の実行期限です。期限を過ぎても完了しないジョブは中断されたとみなし、再び実行します。
+	Timeout time.Duration
+}
+
+// JobOptionはNewJobUsecaseの任意設定です。
+type JobOption func(*JobUsecase)
+
+// WithJobRetryは失敗したジョブの再試行の方針を設定します。
+func WithJobRetry(p RetryPolicy) JobOption {
+	return func(uc *JobUsecase) { uc.Retry = p }
+}
+
+// WithJobTimeoutは1回のジョブの実行期限を設定します。
+func WithJobTimeout(d time.Duration) JobOption {
+	return func(uc *JobUsecase) { uc.Timeout = d }
+}
+
+// NewJobUsecaseは、指定されたリポジトリにジョブを保存するJobUsecaseを返します。
+func NewJobUsecase(r repository.JobRepository, opts ...JobOption) *JobUsecase {
+	uc := &JobUsecase{Repo: r, Retry: DefaultJobRetryPolicy, Timeout: DefaultJobTimeout}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+// NewJobは、payloadをJSONにしたtypのジョブを返します。runAtがゼロ値の場合はすぐに実行します。
+// ドメインの変更と同じトランザクションで登録する場合は、Repositories.Jobs.Enqueueで保存します。
+func NewJob(typ string, payload any, runAt time.Time) (domain.Job, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return domain.Job{}, fmt.Errorf("marshal %s job payload: %w", typ, err)
+	}
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+	return domain.Job{Type: typ, Payload: string(b), Status: domain.JobPending, RunAt: runAt}, nil
+}
+
+// Enqueueは、runAt（ゼロ値の場合はすぐ）に実行するtypのジョブを登録します。
+func (uc *JobUsecase) Enqueue(ctx context.Context, typ string, payload any, runAt time.Time) (_ domain.Job, err error) {
+	ctx, span := startSpan(ctx, "JobUsecase.Enqueue")
+	defer func() { endSpan(span, err) }()
+
+	job, err := NewJob(typ, payload, runAt)
+	if err != nil {
+		return domain.Job{}, err
+	}
+	if err := uc.Repo.Enqueue(ctx, &job); err != nil {
+		return domain.Job{}, err
+	}
+	return job, nil
+}
+
+// Claimは実行日時を迎えたジョブを最大limit件取り出し、実行中にして返します。
+// 取り出したジョブはTimeoutの間に完了（Complete）または失敗（Fail）を記録してください。
+// 再試行の上限の回数まで実行しても実行期限内に完了しなかったジョブは、取り出し直さずにデッドレターにします。
+func (uc *JobUsecase) Claim(ctx context.Context, limit int) (_ []domain.Job, err error) {
+	ctx, span := startSpan(ctx, "JobUsecase.Claim")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.Claim(ctx, time.Now(), uc.Timeout, uc.Retry.MaxAttempts, limit)
+}
+
+// Completeはジョブの完了を記録します。
+// 実行期限を過ぎて他のワーカーが取り出し直していた場合は記録せず、domain.ErrJobLeaseLostを返します。
+func (uc *JobUsecase) Complete(ctx context.Context, job domain.Job) (err error) {
+	ctx, span := startSpan(ctx, "JobUsecase.Complete")
+	defer func() { endSpan(span, err) }()
+
+	now := time.Now()
+	job.Status, job.LockedUntil, job.LastError, job.FinishedAt = domain.JobSucceeded, nil, "", &now
+	return uc.Repo.Update(ctx, job)
+}
+
+// Failはジョブの失敗を記録し、記録後のジョブを返します。
+// 再試行の上限に達した場合やjobErrがPermanentJobErrorの場合はデッドレターにし、
+// それ以外は再試行の方針に従って次の実行日時を設定します。
+// 実行期限を過ぎて他のワーカーが取り出し直していた場合は記録せず、domain.ErrJobLeaseLostを返します。
+func (uc *JobUsecase) Fail(ctx context.Context, job domain.Job, jobErr error) (_ domain.Job, err error) {
+	ctx, span := startSpan(ctx, "JobUsecase.Fail")
+	defer func() { endSpan(span, err) }()
+
+	now := time.Now()
+	job.LockedUntil, job.LastError = nil, jobErr.Error()
+	var permanent *permanentJobError
+	if errors.As(jobErr, &permanent) || job.Attempts >= uc.Retry.MaxAttempts {
+		job.Status, job.FinishedAt = domain.JobDead, &now
+	} else {
+		job.Status, job.RunAt = domain.JobPending, now.Add(uc.Retry.Delay(job.Attempts))
+	}
+	if err := uc.Repo.Update(ctx, job); err != nil {
+		return domain.Job{}, err
+	}
+	return job, nil
+}
+
+// PurgeSucceededは、完了してから保持期間retentionを過ぎたジョブを削除し、削除した件数を返します。
+func (uc *JobUsecase) PurgeSucceeded(ctx context.Context, retention time.Duration) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "JobUsecase.PurgeSucceeded")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.DeleteSucceeded(ctx, time.Now().Add(-retention))
+}
+
+// GetJobsは状態がstatus（空の場合はすべて）のジョブを新しい順に最大limit件返します。
+func (uc *JobUsecase) GetJobs(ctx context.Context, status domain.JobStatus, limit int) (_ []domain.Job, err error) {
+	ctx, span := startSpan(ctx, "JobUsecase.GetJobs")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.Find(ctx, status, limit)
+}
+
+// GetJobはIDのジョブを返します。存在しない場合はdomain.ErrJobNotFoundを返します。
+func (uc *JobUsecase) GetJob(ctx context.Context, id uint) (_ domain.Job, err error) {
+	ctx, span := startSpan(ctx, "JobUsecase.GetJob")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.FindByID(ctx, id)
+}
+
+// RetryJobは、デッドレターのジョブを実行回数を0に戻してすぐに実行し直します。
+// 存在しない場合はdomain.ErrJobNotFound、デッドレター以外の場合はdomain.ErrJobNotDeadを返します。
+func (uc *JobUsecase) RetryJob(ctx context.Context, id uint) (_ domain.Job, err error) {
+	ctx, span := startSpan(ctx, "JobUsecase.RetryJob")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.Requeue(ctx, id, time.Now())
+}
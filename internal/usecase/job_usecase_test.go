@@ -0,0 +1,153 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/memory"
+	"todo_backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// claimOneはジョブを1件取り出して返します。
+func claimOne(t *testing.T, uc *usecase.JobUsecase) domain.Job {
+	t.Helper()
+	return claimAt(t, uc, time.Now())
+}
+
+// claimAtは、時刻がnowであるとしてジョブを1件取り出して返します。
+func claimAt(t *testing.T, uc *usecase.JobUsecase, now time.Time) domain.Job {
+	t.Helper()
+	claimed, err := uc.Repo.Claim(context.Background(), now, uc.Timeout, uc.Retry.MaxAttempts, 1)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	return claimed[0]
+}
+
+func TestJobUsecase_FailRetriesWithBackoffThenDeadLetters(t *testing.T) {
+	// given
+	uc := usecase.NewJobUsecase(memory.NewJobRepo(),
+		usecase.WithJobRetry(usecase.RetryPolicy{MaxAttempts: 2, Backoff: time.Minute, MaxBackoff: time.Hour}))
+	_, err := uc.Enqueue(context.Background(), "test", map[string]int{"n": 1}, time.Time{})
+	require.NoError(t, err)
+
+	// when: 1回目の失敗
+	failed, err := uc.Fail(context.Background(), claimOne(t, uc), errors.New("boom"))
+
+	// then: 待ち時間の後に再試行する
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobPending, failed.Status)
+	assert.Equal(t, "boom", failed.LastError)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), failed.RunAt, 5*time.Second)
+	none, err := uc.Claim(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Empty(t, none, "not due until backoff elapses")
+
+	// when: 待ち時間の後に取り出し、上限の回数で失敗
+	dead, err := uc.Fail(context.Background(), claimAt(t, uc, time.Now().Add(2*time.Minute)), errors.New("boom again"))
+
+	// then: デッドレターになる
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobDead, dead.Status)
+	assert.Equal(t, 2, dead.Attempts)
+	assert.NotNil(t, dead.FinishedAt)
+}
+
+// PermanentJobErrorは回数によらずすぐにデッドレターにする
+func TestJobUsecase_FailDeadLettersPermanentErrors(t *testing.T) {
+	// given
+	uc := usecase.NewJobUsecase(memory.NewJobRepo())
+	_, err := uc.Enqueue(context.Background(), "test", nil, time.Time{})
+	require.NoError(t, err)
+
+	// when
+	failed, err := uc.Fail(context.Background(), claimOne(t, uc), usecase.PermanentJobError(errors.New("bad payload")))
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobDead, failed.Status)
+	assert.Equal(t, "bad payload", failed.LastError)
+}
+
+func TestJobUsecase_RetryJobRequeuesOnlyDeadJobs(t *testing.T) {
+	// given
+	uc := usecase.NewJobUsecase(memory.NewJobRepo())
+	job, err := uc.Enqueue(context.Background(), "test", nil, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	// when / then: デッドレター以外は再実行できない
+	_, err = uc.RetryJob(context.Background(), job.ID)
+	assert.ErrorIs(t, err, domain.ErrJobNotDead)
+	_, err = uc.RetryJob(context.Background(), job.ID+1)
+	assert.ErrorIs(t, err, domain.ErrJobNotFound)
+
+	// given
+	_, err = uc.Fail(context.Background(), claimAt(t, uc, time.Now().Add(2*time.Hour)), usecase.PermanentJobError(errors.New("bad")))
+	require.NoError(t, err)
+
+	// when
+	retried, err := uc.RetryJob(context.Background(), job.ID)
+
+	// then: 実行回数を0に戻してすぐに実行する
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobPending, retried.Status)
+	assert.Zero(t, retried.Attempts)
+	assert.Nil(t, retried.FinishedAt)
+	assert.Equal(t, job.ID, claimOne(t, uc).ID)
+}
+
+// WithEventJobを指定すると、Todoの変更と同じトランザクションで変更イベントのジョブを保存する
+func TestTodoUsecase_SavesEventJobsWithinTransaction(t *testing.T) {
+	// given
+	repos, tx := memory.NewRepositories()
+	uc := usecase.NewTodoUsecase(repos.Todos, usecase.WithTransactor(tx), usecase.WithEventJob("todo.event"))
+
+	// when
+	todo, err := uc.AddTodo(context.Background(), domain.Todo{UserID: 7, Title: "t"})
+	require.NoError(t, err)
+	title := "a"
+	_, err = uc.ExecuteBatch(context.Background(), 7, []usecase.BatchOperation{
+		{Type: usecase.BatchCreate, Patch: domain.TodoPatch{Title: &title}},
+		{Type: usecase.BatchDelete, ID: todo.ID + 100, Version: 1},
+	})
+
+	// then: 失敗した一括操作のジョブはロールバックされる
+	require.Error(t, err)
+	jobs, err := repos.Jobs.Find(context.Background(), "", 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "todo.event", jobs[0].Type)
+	var event domain.TodoEvent
+	require.NoError(t, json.Unmarshal([]byte(jobs[0].Payload), &event))
+	assert.Equal(t, domain.TodoCreated, event.Type)
+	assert.Equal(t, todo.ID, event.Todo.ID)
+	assert.Equal(t, uint(7), event.UserID)
+}
+
+// WithEventJobで変更の種類を指定すると、その種類の変更のみをジョブにする
+func TestTodoUsecase_SavesEventJobsForSelectedEvents(t *testing.T) {
+	// given
+	repos, tx := memory.NewRepositories()
+	uc := usecase.NewTodoUsecase(repos.Todos, usecase.WithTransactor(tx),
+		usecase.WithEventJob("all"), usecase.WithEventJob("deleted", domain.TodoDeleted))
+
+	// when
+	todo, err := uc.AddTodo(context.Background(), domain.Todo{UserID: 7, Title: "t"})
+	require.NoError(t, err)
+	require.NoError(t, uc.DeleteTodo(context.Background(), 7, todo.ID, todo.Version))
+
+	// then
+	jobs, err := repos.Jobs.Find(context.Background(), "", 10)
+	require.NoError(t, err)
+	var types []string
+	for _, job := range jobs {
+		types = append(types, job.Type)
+	}
+	assert.ElementsMatch(t, []string{"all", "all", "deleted"}, types)
+}
@@ -0,0 +1,32 @@
+package usecase
+
+// AuthMetricsは認証ユースケースが記録するメトリクスを抽象化したインターフェースです。
+// 実装（Prometheusなど）はインフラ層に置き、ユースケース層は計測基盤に依存しません。
+type AuthMetrics interface {
+	LoginSucceeded()
+	LoginFailed()
+}
+
+// TodoMetricsはTodoユースケースが記録するメトリクスを抽象化したインターフェースです。
+type TodoMetrics interface {
+	TodoCreated()
+	TodoCompleted()
+}
+
+// noopMetricsはメトリクスが注入されなかった場合に使う何もしない実装です。
+type noopMetrics struct{}
+
+func (noopMetrics) LoginSucceeded() {}
+func (noopMetrics) LoginFailed()    {}
+func (noopMetrics) TodoCreated()    {}
+func (noopMetrics) TodoCompleted()  {}
+
+// WithAuthMetricsはログイン成功・失敗を記録するメトリクスを設定します。
+func WithAuthMetrics(m AuthMetrics) AuthOption {
+	return func(u *authUsecase) { u.metrics = m }
+}
+
+// WithTodoMetricsはTodoの作成・完了を記録するメトリクスを設定します。
+func WithTodoMetrics(m TodoMetrics) TodoOption {
+	return func(uc *TodoUsecase) { uc.Metrics = m }
+}
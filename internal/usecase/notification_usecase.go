@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+)
+
+// NotificationSenderは、アプリ内以外のチャネル（メール・Webhook）への通知の送信を抽象化したインターフェースです。
+// 実装はインフラ層に置きます。
+type NotificationSender interface {
+	// SendNotificationは通知nを送信します。失敗した場合は、ジョブの再試行の方針に従って送信し直します。
+	SendNotification(ctx context.Context, n domain.Notification) error
+}
+
+// NotificationPublisherは、アプリ内の通知一覧に追加した通知を接続中のクライアントに届ける処理（リアルタイム接続など）を
+// 抽象化したインターフェースです。実装はインフラ層に置きます。
+type NotificationPublisher interface {
+	// PublishNotificationはコミット済みの通知nを届けます。配信を待たずに戻ります。
+	PublishNotification(n domain.Notification)
+}
+
+// noopNotificationsはNotificationPublisherが注入されなかった場合に使う実装です。通知は届けません。
+type noopNotifications struct{}
+
+func (noopNotifications) PublishNotification(domain.Notification) {}
+
+// NotificationUsecaseは、アプリ内の通知一覧の参照と、他のチャネルへの通知の送信を行うユースケースです。
+type NotificationUsecase struct {
+	Repo repository.NotificationRepository
+	// Sendersはチャネルごとの送信の実装です。
+	Senders map[domain.NotificationChannel]NotificationSender
+}
+
+// NotificationOptionはNewNotificationUsecaseの任意設定です。
+type NotificationOption func(*NotificationUsecase)
+
+// WithNotificationSenderは、チャネルchへの通知をsで送信するよう設定します。
+func WithNotificationSender(ch domain.NotificationChannel, s NotificationSender) NotificationOption {
+	return func(uc *NotificationUsecase) { uc.Senders[ch] = s }
+}
+
+// NewNotificationUsecaseは、指定されたリポジトリの通知を扱うNotificationUsecaseを返します。
+func NewNotificationUsecase(r repository.NotificationRepository, opts ...NotificationOption) *NotificationUsecase {
+	uc := &NotificationUsecase{Repo: r, Senders: map[domain.NotificationChannel]NotificationSender{}}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+// GetNotificationsは、userID宛ての通知（unreadOnlyの場合は未読のみ）を新しい順に最大limit件返します。
+func (uc *NotificationUsecase) GetNotifications(ctx context.Context, userID uint, unreadOnly bool, limit int) (_ []domain.Notification, err error) {
+	ctx, span := startSpan(ctx, "NotificationUsecase.GetNotifications")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.FindByUser(ctx, userID, unreadOnly, limit)
+}
+
+// MarkReadは、userID宛てのIDの通知を既読にして返します。
+// 存在しない場合はdomain.ErrNotificationNotFoundを返します。
+func (uc *NotificationUsecase) MarkRead(ctx context.Context, userID, id uint) (_ domain.Notification, err error) {
+	ctx, span := startSpan(ctx, "NotificationUsecase.MarkRead")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.MarkRead(ctx, userID, id, time.Now())
+}
+
+// MarkAllReadは、userID宛ての未読の通知をすべて既読にし、既読にした件数を返します。
+func (uc *NotificationUsecase) MarkAllRead(ctx context.Context, userID uint) (_ int, err error) {
+	ctx, span := startSpan(ctx, "NotificationUsecase.MarkAllRead")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.MarkAllRead(ctx, userID, time.Now())
+}
+
+// DeleteNotificationは、userID宛てのIDの通知を削除します。
+// 存在しない場合はdomain.ErrNotificationNotFoundを返します。
+func (uc *NotificationUsecase) DeleteNotification(ctx context.Context, userID, id uint) (err error) {
+	ctx, span := startSpan(ctx, "NotificationUsecase.DeleteNotification")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.Delete(ctx, userID, id)
+}
+
+// CountUnreadは、userID宛ての未読の通知の件数を返します。
+func (uc *NotificationUsecase) CountUnread(ctx context.Context, userID uint) (_ int, err error) {
+	ctx, span := startSpan(ctx, "NotificationUsecase.CountUnread")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.CountUnread(ctx, userID)
+}
+
+// HandleDeliveryJobは、JobTypeNotificationDeliveryのジョブの通知をチャネルの実装で送信するJobHandlerです。
+// 送信の実装がないチャネルの場合は再試行しても成功しないため、PermanentJobErrorを返します。
+func (uc *NotificationUsecase) HandleDeliveryJob(ctx context.Context, job domain.Job) (err error) {
+	ctx, span := startSpan(ctx, "NotificationUsecase.HandleDeliveryJob")
+	defer func() { endSpan(span, err) }()
+
+	var d notificationDelivery
+	if err := json.Unmarshal([]byte(job.Payload), &d); err != nil {
+		return PermanentJobError(fmt.Errorf("decode notification delivery: %w", err))
+	}
+	sender, ok := uc.Senders[d.Channel]
+	if !ok {
+		return PermanentJobError(fmt.Errorf("no sender for notification channel %q", d.Channel))
+	}
+	return sender.SendNotification(ctx, d.Notification)
+}
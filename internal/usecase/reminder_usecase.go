@@ -0,0 +1,277 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+)
+
+const (
+	// JobTypeReminderTodoEventは、Todoの変更イベントに合わせてリマインダーの通知する日時を変更するジョブの種類です。
+	// ペイロードはdomain.TodoEventです。TodoUsecaseにWithEventJobで登録すると、Todoの変更と同じトランザクションで登録されます。
+	JobTypeReminderTodoEvent = "reminder.todo_event"
+	// JobTypeNotificationDeliveryは、通知をアプリ内以外のチャネル（メール・Webhook）に送るジョブの種類です。
+	// リマインダーを通知済みにするのと同じトランザクションで登録されます。
+	JobTypeNotificationDelivery = "notification.deliver"
+)
+
+// ReminderTodoEventsは、JobTypeReminderTodoEventのジョブにするTodoの変更の種類です（作成直後のTodoにはリマインダーがない）。
+// WithEventJob(JobTypeReminderTodoEvent, ReminderTodoEvents...)のように指定します。
+var ReminderTodoEvents = []domain.TodoEventType{domain.TodoUpdated, domain.TodoDeleted}
+
+// ReminderUsecaseは、Todoのリマインダーの管理と、通知する日時を迎えたリマインダーの通知を行うユースケースです。
+type ReminderUsecase struct {
+	Repos repository.Repositories
+	// Txは、リマインダーを通知済みにする変更と通知の保存を1つのトランザクションで行うためのTransactorです。
+	Tx repository.Transactor
+	// Publisherは、アプリ内の通知一覧に追加した通知をコミット後に届ける先です。
+	Publisher NotificationPublisher
+}
+
+// ReminderOptionはNewReminderUsecaseの任意設定です。
+type ReminderOption func(*ReminderUsecase)
+
+// WithNotificationPublisherは、アプリ内の通知一覧に追加した通知をpで接続中のクライアントに届けるよう設定します。
+func WithNotificationPublisher(p NotificationPublisher) ReminderOption {
+	return func(uc *ReminderUsecase) { uc.Publisher = p }
+}
+
+// NewReminderUsecaseは、reposにリマインダーと通知を保存するReminderUsecaseを返します。
+// txがnilの場合はトランザクションを張りません（同じリマインダーを2回以上通知しないことは保証されません）。
+func NewReminderUsecase(repos repository.Repositories, tx repository.Transactor, opts ...ReminderOption) *ReminderUsecase {
+	if tx == nil {
+		tx = nonTransactional{repos: repos}
+	}
+	uc := &ReminderUsecase{Repos: repos, Tx: tx, Publisher: noopNotifications{}}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+// CreateReminderは、userIDのTodo（todoID）にreminderの日時（RemindAtまたはMinutesBefore）で通知するリマインダーを設定します。
+// チャネルを指定しない場合はアプリ内の通知一覧に通知します。
+// Todoが存在しない場合はdomain.ErrTodoNotFoundを、期限からの相対で指定してTodoに期限がない場合は
+// domain.ErrTodoHasNoDueDateを返します。
+func (uc *ReminderUsecase) CreateReminder(ctx context.Context, userID, todoID uint, reminder domain.Reminder) (_ domain.Reminder, err error) {
+	ctx, span := startSpan(ctx, "ReminderUsecase.CreateReminder")
+	defer func() { endSpan(span, err) }()
+
+	todo, err := uc.Repos.Todos.FindByID(ctx, userID, todoID)
+	if err != nil {
+		return domain.Reminder{}, err
+	}
+	if reminder.Relative() && todo.DueAt == nil {
+		return domain.Reminder{}, domain.ErrTodoHasNoDueDate
+	}
+	// 重複を除き、一覧の順に並べる
+	var channels []domain.NotificationChannel
+	for _, ch := range domain.NotificationChannels {
+		if slices.Contains(reminder.Channels, ch) {
+			channels = append(channels, ch)
+		}
+	}
+	if len(channels) == 0 {
+		channels = []domain.NotificationChannel{domain.NotificationInApp}
+	}
+	r := domain.Reminder{
+		UserID:        userID,
+		TodoID:        todoID,
+		RemindAt:      reminder.RemindAt,
+		MinutesBefore: reminder.MinutesBefore,
+		Channels:      channels,
+	}
+	r.FireAt = r.NextFireAt(todo.DueAt)
+	if err := uc.Repos.Reminders.Create(ctx, &r); err != nil {
+		return domain.Reminder{}, err
+	}
+	return r, nil
+}
+
+// GetRemindersは、userIDのTodo（todoID）のリマインダーを作成順に返します。
+// Todoが存在しない場合はdomain.ErrTodoNotFoundを返します。
+func (uc *ReminderUsecase) GetReminders(ctx context.Context, userID, todoID uint) (_ []domain.Reminder, err error) {
+	ctx, span := startSpan(ctx, "ReminderUsecase.GetReminders")
+	defer func() { endSpan(span, err) }()
+
+	if _, err := uc.Repos.Todos.FindByID(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+	return uc.Repos.Reminders.FindByTodo(ctx, userID, todoID)
+}
+
+// DeleteReminderは、userIDのTodo（todoID）のIDのリマインダーを削除します。
+// 存在しない場合はdomain.ErrReminderNotFoundを返します。
+func (uc *ReminderUsecase) DeleteReminder(ctx context.Context, userID, todoID, id uint) (err error) {
+	ctx, span := startSpan(ctx, "ReminderUsecase.DeleteReminder")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repos.Reminders.Delete(ctx, userID, todoID, id)
+}
+
+// FireDueは、通知する日時を迎えたリマインダーを最大limit件、古い順に通知し、通知済みにした件数を返します。
+//
+// リマインダーごとに、通知済みにする変更と、アプリ内の通知の保存・他のチャネルへの配信のジョブの登録を
+// 1つのトランザクションで行います。通知済みにできるのは1回だけのため、複数のサーバで同時に実行しても、
+// 途中で再起動しても、同じリマインダーを2回以上通知しません。
+// 通知する時点でTodoが削除または完了されていた場合は、通知せずに通知済みにします。
+// アプリ内の通知一覧に追加した通知は、コミット後にPublisherで届けます。
+// 通知に失敗したリマインダーはログに記録して残りのリマインダーの通知を続け、次回に通知し直します。
+func (uc *ReminderUsecase) FireDue(ctx context.Context, limit int) (fired int, err error) {
+	ctx, span := startSpan(ctx, "ReminderUsecase.FireDue")
+	defer func() { endSpan(span, err) }()
+
+	now := time.Now()
+	reminders, err := uc.Repos.Reminders.Due(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range reminders {
+		marked, err := uc.fire(ctx, r, now)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fired, ctx.Err()
+			}
+			// 1件の失敗で他のリマインダーの通知を止めない。失敗したリマインダーは次回に通知し直す
+			slog.ErrorContext(ctx, "failed to fire reminder", slog.Uint64("reminder_id", uint64(r.ID)), slog.Any("error", err))
+			continue
+		}
+		if marked {
+			fired++
+		}
+	}
+	return fired, nil
+}
+
+// fireは、リマインダーrを通知済みにして通知を保存し、コミット後にアプリ内の通知を届けます。
+// 通知済みにした（他のサーバが先に通知していなかった）かどうかを返します。
+func (uc *ReminderUsecase) fire(ctx context.Context, r domain.Reminder, now time.Time) (marked bool, err error) {
+	ctx, span := startSpan(ctx, "ReminderUsecase.fire")
+	defer func() { endSpan(span, err) }()
+
+	var stored []domain.Notification
+	err = uc.Tx.WithinTransaction(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		ok, err := repos.Reminders.MarkFired(ctx, r.ID, now)
+		if err != nil || !ok {
+			// 他のサーバが先に通知した、または通知する日時が変更された
+			return err
+		}
+		marked = true
+		todo, err := repos.Todos.FindByID(ctx, r.UserID, r.TodoID)
+		if errors.Is(err, domain.ErrTodoNotFound) || (err == nil && todo.Completed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		stored, err = notify(ctx, repos, r, newReminderNotification(r, todo, now))
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, n := range stored {
+		uc.Publisher.PublishNotification(n)
+	}
+	return marked, nil
+}
+
+// newReminderNotificationはリマインダーの通知を返します。
+func newReminderNotification(r domain.Reminder, todo domain.Todo, now time.Time) domain.Notification {
+	return domain.Notification{
+		UserID:     r.UserID,
+		Type:       domain.NotificationReminder,
+		TodoID:     todo.ID,
+		ReminderID: r.ID,
+		Title:      todo.Title,
+		DueAt:      todo.DueAt,
+		CreatedAt:  now,
+	}
+}
+
+// notificationDeliveryはJobTypeNotificationDeliveryのジョブのペイロードです。
+type notificationDelivery struct {
+	Channel      domain.NotificationChannel
+	Notification domain.Notification
+}
+
+// notifyは、通知をreminderのチャネルに送ります。アプリ内の通知はreposに保存し、
+// 他のチャネルへの配信はJobTypeNotificationDeliveryのジョブとして登録します。保存したアプリ内の通知を返します。
+func notify(ctx context.Context, repos repository.Repositories, reminder domain.Reminder, n domain.Notification) ([]domain.Notification, error) {
+	var stored []domain.Notification
+	for _, ch := range reminder.Channels {
+		if ch == domain.NotificationInApp {
+			inApp := n
+			if err := repos.Notifications.Create(ctx, &inApp); err != nil {
+				return nil, err
+			}
+			stored = append(stored, inApp)
+			continue
+		}
+		job, err := NewJob(JobTypeNotificationDelivery, notificationDelivery{Channel: ch, Notification: n}, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		if err := repos.Jobs.Enqueue(ctx, &job); err != nil {
+			return nil, err
+		}
+	}
+	return stored, nil
+}
+
+// HandleTodoEventJobは、ジョブのペイロードのTodoの変更イベントに合わせて、期限からの相対で指定したリマインダーの
+// 通知する日時を変更するJobHandlerです。Todoが削除された場合はリマインダーを削除します。
+//
+// イベントの順序によらず同じ結果になるよう、イベントのTodoではなく現在のTodoを読み直して反映します。
+// 期限が未来に変更された場合は、通知済みのリマインダーも改めて通知します。
+func (uc *ReminderUsecase) HandleTodoEventJob(ctx context.Context, job domain.Job) (err error) {
+	ctx, span := startSpan(ctx, "ReminderUsecase.HandleTodoEventJob")
+	defer func() { endSpan(span, err) }()
+
+	var event domain.TodoEvent
+	if err := json.Unmarshal([]byte(job.Payload), &event); err != nil {
+		return PermanentJobError(fmt.Errorf("decode todo event: %w", err))
+	}
+	if event.Type == domain.TodoCreated {
+		// 作成直後のTodoにはリマインダーがない
+		return nil
+	}
+	todo, err := uc.Repos.Todos.FindByID(ctx, event.UserID, event.Todo.ID)
+	if errors.Is(err, domain.ErrTodoNotFound) {
+		return uc.Repos.Reminders.DeleteByTodo(ctx, event.UserID, event.Todo.ID)
+	}
+	if err != nil {
+		return err
+	}
+	reminders, err := uc.Repos.Reminders.FindByTodo(ctx, todo.UserID, todo.ID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, r := range reminders {
+		next := r.NextFireAt(todo.DueAt)
+		if !r.Relative() || equalTime(r.FireAt, next) {
+			continue
+		}
+		rearm := next != nil && next.After(now)
+		if err := uc.Repos.Reminders.Reschedule(ctx, r.ID, next, rearm); err != nil && !errors.Is(err, domain.ErrReminderNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// equalTimeは2つの日時が同じ（どちらもnilの場合を含む）かどうかを返します。
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
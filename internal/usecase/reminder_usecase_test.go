@@ -0,0 +1,250 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/memory"
+	"todo_backend/internal/interface/repository"
+	"todo_backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addTodoDueAtは期限がdueAtのTodoを作成して返します。
+func addTodoDueAt(t *testing.T, repos repository.Repositories, userID uint, dueAt *time.Time) domain.Todo {
+	t.Helper()
+	todo, err := repos.Todos.Create(context.Background(), domain.Todo{UserID: userID, Title: "牛乳を買う", DueAt: dueAt})
+	require.NoError(t, err)
+	return todo
+}
+
+// 複数のスケジューラが同時に実行しても、リマインダーは1回だけ通知する
+func TestReminderUsecase_FireDueNotifiesExactlyOnce(t *testing.T) {
+	// given
+	repos, tx := memory.NewRepositories()
+	uc := usecase.NewReminderUsecase(repos, tx)
+	dueAt := time.Now().Add(10 * time.Minute)
+	todo := addTodoDueAt(t, repos, 7, &dueAt)
+	minutes := 30
+	r, err := uc.CreateReminder(context.Background(), 7, todo.ID, domain.Reminder{
+		MinutesBefore: &minutes,
+		Channels:      []domain.NotificationChannel{domain.NotificationEmail, domain.NotificationInApp, domain.NotificationEmail},
+	})
+	require.NoError(t, err)
+
+	// when
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := uc.FireDue(context.Background(), 10)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// then: チャネルは重複を除いて一覧の順に並べる
+	assert.Equal(t, []domain.NotificationChannel{domain.NotificationInApp, domain.NotificationEmail}, r.Channels)
+	notifications, err := repos.Notifications.FindByUser(context.Background(), 7, false, 10)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, domain.NotificationReminder, notifications[0].Type)
+	assert.Equal(t, r.ID, notifications[0].ReminderID)
+	assert.Equal(t, "牛乳を買う", notifications[0].Title)
+	jobs, err := repos.Jobs.Find(context.Background(), "", 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, usecase.JobTypeNotificationDelivery, jobs[0].Type)
+	var payload struct{ Channel domain.NotificationChannel }
+	require.NoError(t, json.Unmarshal([]byte(jobs[0].Payload), &payload))
+	assert.Equal(t, domain.NotificationEmail, payload.Channel)
+	fired, err := uc.FireDue(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Zero(t, fired)
+}
+
+// 通知する時点でTodoが完了していた場合は通知しない
+func TestReminderUsecase_FireDueSkipsCompletedTodos(t *testing.T) {
+	// given
+	repos, tx := memory.NewRepositories()
+	uc := usecase.NewReminderUsecase(repos, tx)
+	todo := addTodoDueAt(t, repos, 7, nil)
+	at := time.Now().Add(-time.Second)
+	_, err := uc.CreateReminder(context.Background(), 7, todo.ID, domain.Reminder{RemindAt: &at})
+	require.NoError(t, err)
+	todo.Completed = true
+	require.NoError(t, repos.Todos.Update(context.Background(), todo))
+
+	// when
+	fired, err := uc.FireDue(context.Background(), 10)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, 1, fired)
+	notifications, err := repos.Notifications.FindByUser(context.Background(), 7, false, 10)
+	require.NoError(t, err)
+	assert.Empty(t, notifications)
+}
+
+// failingTodoRepoは、failIDのTodoの読み取りに失敗するTodoRepositoryです。
+type failingTodoRepo struct {
+	repository.TodoRepository
+	failID uint
+}
+
+func (r *failingTodoRepo) FindByID(ctx context.Context, userID, id uint) (domain.Todo, error) {
+	if id == r.failID {
+		return domain.Todo{}, errors.New("boom")
+	}
+	return r.TodoRepository.FindByID(ctx, userID, id)
+}
+
+// 1件のリマインダーの通知に失敗しても、残りのリマインダーは通知し、失敗したものは次回に通知し直す
+func TestReminderUsecase_FireDueContinuesAfterFailure(t *testing.T) {
+	// given
+	repos, _ := memory.NewRepositories()
+	todos := &failingTodoRepo{TodoRepository: repos.Todos}
+	repos.Todos = todos
+	uc := usecase.NewReminderUsecase(repos, memory.NewTransactor(repos))
+	broken := addTodoDueAt(t, repos, 7, nil)
+	ok := addTodoDueAt(t, repos, 7, nil)
+	earlier, later := time.Now().Add(-time.Minute), time.Now().Add(-time.Second)
+	failed, err := uc.CreateReminder(context.Background(), 7, broken.ID, domain.Reminder{RemindAt: &earlier})
+	require.NoError(t, err)
+	_, err = uc.CreateReminder(context.Background(), 7, ok.ID, domain.Reminder{RemindAt: &later})
+	require.NoError(t, err)
+	todos.failID = broken.ID
+
+	// when
+	fired, err := uc.FireDue(context.Background(), 10)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, 1, fired)
+	notifications, err := repos.Notifications.FindByUser(context.Background(), 7, false, 10)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, ok.ID, notifications[0].TodoID)
+	due, err := repos.Reminders.Due(context.Background(), time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, failed.ID, due[0].ID)
+}
+
+func TestReminderUsecase_CreateReminderRequiresDueDateForRelativeReminders(t *testing.T) {
+	// given
+	repos, tx := memory.NewRepositories()
+	uc := usecase.NewReminderUsecase(repos, tx)
+	todo := addTodoDueAt(t, repos, 7, nil)
+	minutes := 10
+
+	// when
+	_, err := uc.CreateReminder(context.Background(), 7, todo.ID, domain.Reminder{MinutesBefore: &minutes})
+	_, errOther := uc.CreateReminder(context.Background(), 8, todo.ID, domain.Reminder{MinutesBefore: &minutes})
+
+	// then
+	assert.ErrorIs(t, err, domain.ErrTodoHasNoDueDate)
+	assert.ErrorIs(t, errOther, domain.ErrTodoNotFound)
+}
+
+// 期限の変更に合わせて期限からの相対のリマインダーの通知する日時を変更し、未来に変更された場合は改めて通知する
+func TestReminderUsecase_HandleTodoEventJobReschedulesRelativeReminders(t *testing.T) {
+	// given
+	repos, tx := memory.NewRepositories()
+	uc := usecase.NewReminderUsecase(repos, tx)
+	todoUC := usecase.NewTodoUsecase(repos.Todos, usecase.WithTransactor(tx), usecase.WithEventJob(usecase.JobTypeReminderTodoEvent, usecase.ReminderTodoEvents...))
+	dueAt := time.Now()
+	todo, err := todoUC.AddTodo(context.Background(), domain.Todo{UserID: 7, Title: "t", DueAt: &dueAt})
+	require.NoError(t, err)
+	assert.Empty(t, claimEventJobs(t, repos), "created events are not queued")
+	minutes := 60
+	relative, err := uc.CreateReminder(context.Background(), 7, todo.ID, domain.Reminder{MinutesBefore: &minutes})
+	require.NoError(t, err)
+	at := time.Now().Add(time.Hour)
+	absolute, err := uc.CreateReminder(context.Background(), 7, todo.ID, domain.Reminder{RemindAt: &at})
+	require.NoError(t, err)
+	fired, err := uc.FireDue(context.Background(), 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, fired)
+
+	// when
+	later := dueAt.Add(24 * time.Hour)
+	_, err = todoUC.PatchTodo(context.Background(), 7, todo.ID, todo.Version, domain.TodoPatch{DueAt: &later})
+	require.NoError(t, err)
+	for _, job := range claimEventJobs(t, repos) {
+		require.NoError(t, uc.HandleTodoEventJob(context.Background(), job))
+	}
+
+	// then
+	reminders, err := uc.GetReminders(context.Background(), 7, todo.ID)
+	require.NoError(t, err)
+	require.Len(t, reminders, 2)
+	assert.Equal(t, relative.ID, reminders[0].ID)
+	assert.Nil(t, reminders[0].FiredAt, "rearmed")
+	require.NotNil(t, reminders[0].FireAt)
+	assert.WithinDuration(t, later.Add(-time.Hour), *reminders[0].FireAt, time.Second)
+	assert.Equal(t, absolute.FireAt, reminders[1].FireAt, "absolute reminders keep their time")
+
+	// when: Todoを削除するとリマインダーも削除する
+	require.NoError(t, todoUC.DeleteTodo(context.Background(), 7, todo.ID, todo.Version+1))
+	for _, job := range claimEventJobs(t, repos) {
+		require.NoError(t, uc.HandleTodoEventJob(context.Background(), job))
+	}
+
+	// then
+	reminders, err = repos.Reminders.FindByTodo(context.Background(), 7, todo.ID)
+	require.NoError(t, err)
+	assert.Empty(t, reminders)
+}
+
+// claimEventJobsは実行待ちのジョブをすべて取り出して返します。
+func claimEventJobs(t *testing.T, repos repository.Repositories) []domain.Job {
+	t.Helper()
+	jobs, err := repos.Jobs.Claim(context.Background(), time.Now(), time.Minute, 10, 100)
+	require.NoError(t, err)
+	return jobs
+}
+
+// fakeNotificationPublisherは届けた通知を記録するusecase.NotificationPublisherです。
+type fakeNotificationPublisher struct {
+	published []domain.Notification
+}
+
+func (f *fakeNotificationPublisher) PublishNotification(n domain.Notification) {
+	f.published = append(f.published, n)
+}
+
+// アプリ内の通知一覧に追加した通知だけを、保存後にPublisherで届ける
+func TestReminderUsecase_FireDuePublishesInAppNotifications(t *testing.T) {
+	// given
+	repos, tx := memory.NewRepositories()
+	publisher := &fakeNotificationPublisher{}
+	uc := usecase.NewReminderUsecase(repos, tx, usecase.WithNotificationPublisher(publisher))
+	todo := addTodoDueAt(t, repos, 7, nil)
+	at := time.Now().Add(-time.Second)
+	_, err := uc.CreateReminder(context.Background(), 7, todo.ID, domain.Reminder{RemindAt: &at})
+	require.NoError(t, err)
+	_, err = uc.CreateReminder(context.Background(), 7, todo.ID, domain.Reminder{
+		RemindAt: &at,
+		Channels: []domain.NotificationChannel{domain.NotificationEmail},
+	})
+	require.NoError(t, err)
+
+	// when
+	fired, err := uc.FireDue(context.Background(), 10)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, 2, fired)
+	require.Len(t, publisher.published, 1)
+	assert.NotZero(t, publisher.published[0].ID)
+	assert.Equal(t, uint(7), publisher.published[0].UserID)
+}
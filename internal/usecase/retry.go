@@ -0,0 +1,23 @@
+package usecase
+
+import "time"
+
+// RetryPolicyは失敗した処理（Webhookの配信・バックグラウンドジョブ）の再試行の方針です。
+// 失敗するたびに待ち時間を2倍にし（指数バックオフ）、MaxAttempts回失敗したら諦めます。
+type RetryPolicy struct {
+	// MaxAttemptsは試行する最大回数（初回を含む）です。
+	MaxAttempts int
+	// Backoffは1回目の失敗から再試行までの待ち時間です。
+	Backoff time.Duration
+	// MaxBackoffは再試行までの待ち時間の上限です。
+	MaxBackoff time.Duration
+}
+
+// Delayは、attempts回目の試行に失敗した後、次に試行するまでの待ち時間を返します。
+func (p RetryPolicy) Delay(attempts int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempts && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxBackoff)
+}
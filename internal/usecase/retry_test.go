@@ -0,0 +1,25 @@
+package usecase_test
+
+import (
+	"testing"
+	"time"
+
+	"todo_backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := usecase.RetryPolicy{MaxAttempts: 8, Backoff: 30 * time.Second, MaxBackoff: 5 * time.Minute}
+	cases := map[int]time.Duration{
+		1: 30 * time.Second,
+		2: time.Minute,
+		3: 2 * time.Minute,
+		4: 4 * time.Minute,
+		5: 5 * time.Minute,
+		7: 5 * time.Minute,
+	}
+	for attempts, want := range cases {
+		assert.Equal(t, want, p.Delay(attempts), "attempts=%d", attempts)
+	}
+}
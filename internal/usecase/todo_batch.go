@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+)
+
+// BatchOpTypeは一括操作の種類です。
+type BatchOpType string
+
+const (
+	// BatchCreateはTodoを作成します。
+	BatchCreate BatchOpType = "create"
+	// BatchUpdateはTodoの指定されたフィールドを更新します。
+	BatchUpdate BatchOpType = "update"
+	// BatchDeleteはTodoを削除します。
+	BatchDelete BatchOpType = "delete"
+	// BatchCompleteはTodoを完了にします（完了済みの場合は何もしません）。
+	BatchComplete BatchOpType = "complete"
+)
+
+// BatchOperationは一括操作に含まれる1件の操作です。
+type BatchOperation struct {
+	Type BatchOpType
+	// IDは操作対象のTodoのIDです（create以外で必須）。
+	ID uint
+	// Versionは操作対象の期待するバージョンです。
+	// updateとdeleteでは必須、completeでは0の場合バージョンを確認しません。
+	Version uint
+	// Patchはcreate / updateで設定するフィールドです。
+	Patch domain.TodoPatch
+}
+
+// BatchResultは1件の操作の結果です。
+type BatchResult struct {
+	Type BatchOpType
+	// Todoは作成・更新後のTodoです（deleteでは削除前のIDのみ）。
+	Todo domain.Todo
+}
+
+// BatchErrorは一括操作のうちIndex番目の操作が失敗したことを表します。
+// 一括操作は全体がロールバックされます。
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d failed: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error { return e.Err }
+
+// ExecuteBatchは、複数の操作を1つのトランザクション内で順に実行し、操作ごとの結果を返します。
+// いずれかの操作が失敗した場合は全体をロールバックし、*BatchErrorを返します。
+// メトリクスの記録と変更イベントの発行はコミット後に行います。
+func (uc *TodoUsecase) ExecuteBatch(ctx context.Context, userID uint, ops []BatchOperation) (_ []BatchResult, err error) {
+	ctx, span := startSpan(ctx, "TodoUsecase.ExecuteBatch")
+	defer func() { endSpan(span, err) }()
+
+	var (
+		results []BatchResult
+		// completionsは操作ごとに未完了のTodoが完了になったかを表します。
+		completions []bool
+		created     int
+	)
+	err = uc.write(ctx, func(ctx context.Context, repos repository.Repositories) ([]domain.TodoEvent, error) {
+		repo := repos.Todos
+		results, completions, created = make([]BatchResult, 0, len(ops)), make([]bool, 0, len(ops)), 0
+		var events []domain.TodoEvent
+		for i, op := range ops {
+			res, wasCompleted, err := executeOperation(ctx, repo, userID, op)
+			if err != nil {
+				return nil, &BatchError{Index: i, Err: err}
+			}
+			if op.Type == BatchCreate {
+				created++
+			}
+			results = append(results, res)
+			completions = append(completions, wasCompleted)
+			typ := res.Type.eventType()
+			events = append(events, newTodoEvents(typ, wasCompleted && typ == domain.TodoUpdated, res.Todo)...)
+		}
+		return events, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for range created {
+		uc.Metrics.TodoCreated()
+	}
+	for _, wasCompleted := range completions {
+		if wasCompleted {
+			uc.Metrics.TodoCompleted()
+		}
+	}
+	return results, nil
+}
+
+// eventTypeは操作に対応するTodoの変更イベントの種類を返します。
+func (t BatchOpType) eventType() domain.TodoEventType {
+	switch t {
+	case BatchCreate:
+		return domain.TodoCreated
+	case BatchDelete:
+		return domain.TodoDeleted
+	default:
+		return domain.TodoUpdated
+	}
+}
+
+// executeOperationは1件の操作を実行します。
+// 未完了のTodoが完了になった場合はcompletedにtrueを返します。AddTodoと同じく、完了の状態で作成したTodoは含めません。
+func executeOperation(ctx context.Context, repo repository.TodoRepository, userID uint, op BatchOperation) (_ BatchResult, completed bool, err error) {
+	switch op.Type {
+	case BatchCreate:
+		todo := domain.Todo{UserID: userID}
+		op.Patch.Apply(&todo)
+		created, err := repo.Create(ctx, todo)
+		if err != nil {
+			return BatchResult{}, false, err
+		}
+		return BatchResult{Type: op.Type, Todo: created}, false, nil
+
+	case BatchUpdate, BatchComplete:
+		patch := op.Patch
+		if op.Type == BatchComplete {
+			done := true
+			patch = domain.TodoPatch{Completed: &done}
+		}
+		current, updated, err := patchTodo(ctx, repo, userID, op.ID, op.Version, patch)
+		if err != nil {
+			return BatchResult{}, false, err
+		}
+		return BatchResult{Type: op.Type, Todo: updated}, !current.Completed && updated.Completed, nil
+
+	case BatchDelete:
+		if err := repo.Delete(ctx, userID, op.ID, op.Version); err != nil {
+			return BatchResult{}, false, err
+		}
+		return BatchResult{Type: op.Type, Todo: domain.Todo{ID: op.ID, UserID: userID}}, false, nil
+
+	default:
+		return BatchResult{}, false, fmt.Errorf("unknown operation %q", op.Type)
+	}
+}
+
+// CompleteAllは、filterに一致する未完了のTodoをすべて1つのトランザクション内で完了にし、
+// 完了にしたTodoを返します。コミット後にTodoごとの更新イベントを発行します。
+func (uc *TodoUsecase) CompleteAll(ctx context.Context, userID uint, filter domain.TodoFilter) (_ []domain.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoUsecase.CompleteAll")
+	defer func() { endSpan(span, err) }()
+
+	var completed []domain.Todo
+	err = uc.write(ctx, func(ctx context.Context, repos repository.Repositories) ([]domain.TodoEvent, error) {
+		repo := repos.Todos
+		completed = nil
+		todos, err := repo.FindByUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, todo := range todos {
+			if todo.Completed || !filter.Match(todo) {
+				continue
+			}
+			todo.Completed = true
+			if err := repo.Update(ctx, todo); err != nil {
+				return nil, err
+			}
+			todo.Version++
+			completed = append(completed, todo)
+		}
+		return newTodoEvents(domain.TodoUpdated, true, completed...), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for range completed {
+		uc.Metrics.TodoCompleted()
+	}
+	return completed, nil
+}
+
+// DeleteCompletedは、完了済みのTodoをすべて1つのトランザクション内で削除し、削除件数を返します。
+// コミット後にTodoごとの削除イベントを発行します。
+func (uc *TodoUsecase) DeleteCompleted(ctx context.Context, userID uint) (_ int, err error) {
+	ctx, span := startSpan(ctx, "TodoUsecase.DeleteCompleted")
+	defer func() { endSpan(span, err) }()
+
+	var deleted []domain.Todo
+	err = uc.write(ctx, func(ctx context.Context, repos repository.Repositories) ([]domain.TodoEvent, error) {
+		repo := repos.Todos
+		deleted = nil
+		todos, err := repo.FindByUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, todo := range todos {
+			if !todo.Completed {
+				continue
+			}
+			if err := repo.Delete(ctx, userID, todo.ID, todo.Version); err != nil {
+				return nil, err
+			}
+			deleted = append(deleted, domain.Todo{ID: todo.ID, UserID: userID})
+		}
+		return newTodoEvents(domain.TodoDeleted, false, deleted...), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(deleted), nil
+}
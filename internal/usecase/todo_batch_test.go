@@ -0,0 +1,133 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteBatch_RunsOperationsInOrder(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	m := &fakeTodoMetrics{}
+	uc := usecase.NewTodoUsecase(repo, usecase.WithTodoMetrics(m))
+
+	title := "new"
+	repo.On("Create", mock.Anything, domain.Todo{UserID: 1, Title: "new"}).
+		Return(domain.Todo{ID: 3, UserID: 1, Title: "new", Version: 1}, nil).Once()
+	current := domain.Todo{ID: 5, UserID: 1, Title: "t", Version: 2}
+	repo.On("FindByID", mock.Anything, uint(1), uint(5)).Return(current, nil).Once()
+	repo.On("Update", mock.Anything, domain.Todo{ID: 5, UserID: 1, Title: "t", Completed: true, Version: 2}).Return(nil).Once()
+	repo.On("Delete", mock.Anything, uint(1), uint(6), uint(1)).Return(nil).Once()
+
+	// when
+	results, err := uc.ExecuteBatch(context.Background(), 1, []usecase.BatchOperation{
+		{Type: usecase.BatchCreate, Patch: domain.TodoPatch{Title: &title}},
+		{Type: usecase.BatchComplete, ID: 5},
+		{Type: usecase.BatchDelete, ID: 6, Version: 1},
+	})
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, uint(3), results[0].Todo.ID)
+	assert.True(t, results[1].Todo.Completed)
+	assert.Equal(t, uint(3), results[1].Todo.Version)
+	assert.Equal(t, uint(6), results[2].Todo.ID)
+	assert.Equal(t, 1, m.created)
+	assert.Equal(t, 1, m.completed)
+	repo.AssertExpectations(t)
+}
+
+func TestExecuteBatch_ReportsFailedOperation(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	m := &fakeTodoMetrics{}
+	uc := usecase.NewTodoUsecase(repo, usecase.WithTodoMetrics(m))
+
+	repo.On("Create", mock.Anything, mock.Anything).Return(domain.Todo{ID: 3, UserID: 1, Version: 1}, nil).Once()
+	repo.On("Delete", mock.Anything, uint(1), uint(6), uint(1)).Return(domain.ErrVersionMismatch).Once()
+
+	// when
+	_, err := uc.ExecuteBatch(context.Background(), 1, []usecase.BatchOperation{
+		{Type: usecase.BatchCreate},
+		{Type: usecase.BatchDelete, ID: 6, Version: 1},
+	})
+
+	// then: 失敗した操作の位置と原因が分かり、メトリクスは記録されない
+	var batchErr *usecase.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 1, batchErr.Index)
+	assert.ErrorIs(t, err, domain.ErrVersionMismatch)
+	assert.Equal(t, 0, m.created)
+}
+
+func TestCompleteAll_CompletesMatchingOpenTodos(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	uc := usecase.NewTodoUsecase(repo)
+	repo.On("FindByUser", mock.Anything, uint(1)).Return([]domain.Todo{
+		{ID: 1, UserID: 1, Title: "Buy milk", Version: 1},
+		{ID: 2, UserID: 1, Title: "buy eggs", Completed: true, Version: 1},
+		{ID: 3, UserID: 1, Title: "Write report", Version: 1},
+	}, nil).Once()
+	repo.On("Update", mock.Anything, domain.Todo{ID: 1, UserID: 1, Title: "Buy milk", Completed: true, Version: 1}).Return(nil).Once()
+
+	// when
+	got, err := uc.CompleteAll(context.Background(), 1, domain.TodoFilter{Query: "BUY"})
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, uint(2), got[0].Version)
+	repo.AssertExpectations(t)
+}
+
+func TestDeleteCompleted_DeletesOnlyCompletedTodos(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	uc := usecase.NewTodoUsecase(repo)
+	repo.On("FindByUser", mock.Anything, uint(1)).Return([]domain.Todo{
+		{ID: 1, UserID: 1, Completed: true, Version: 2},
+		{ID: 2, UserID: 1, Version: 1},
+	}, nil).Once()
+	repo.On("Delete", mock.Anything, uint(1), uint(1), uint(2)).Return(nil).Once()
+
+	// when
+	n, err := uc.DeleteCompleted(context.Background(), 1)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	repo.AssertExpectations(t)
+}
+
+// 完了の状態で作成したTodoは、AddTodoと同じく完了の件数に含めない
+func TestExecuteBatch_CompletedCreateIsNotCountedAsCompletion(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	m := &fakeTodoMetrics{}
+	uc := usecase.NewTodoUsecase(repo, usecase.WithTodoMetrics(m))
+
+	title := "new"
+	completed := true
+	repo.On("Create", mock.Anything, domain.Todo{UserID: 1, Title: "new", Completed: true}).
+		Return(domain.Todo{ID: 3, UserID: 1, Title: "new", Completed: true, Version: 1}, nil).Once()
+
+	// when
+	_, err := uc.ExecuteBatch(context.Background(), 1, []usecase.BatchOperation{
+		{Type: usecase.BatchCreate, Patch: domain.TodoPatch{Title: &title, Completed: &completed}},
+	})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, 1, m.created)
+	assert.Equal(t, 0, m.completed)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+)
+
+// SyncPageは差分同期（pull）で返す変更の一覧です。
+type SyncPage struct {
+	// Changesは変更履歴をSeqの昇順に並べたものです。Fullの場合は現在のすべてのTodoです。
+	Changes []domain.TodoChange
+	// Seqは次回の同期の開始位置です。
+	Seq uint64
+	// Fullは、Changesが差分ではなく現在のすべてのTodoであることを表します。
+	// クライアントはChangesに含まれないTodoを破棄してください。
+	Full bool
+	// HasMoreは、Seqより後にまだ変更があることを表します（続けて同期してください）。
+	HasMore bool
+}
+
+// PullChangesは、位置sinceより後のuserIDのTodoの変更（削除の墓標を含む）を最大limit件返します。
+// sinceが0の場合（初回の同期）、記録されている変更より先の位置の場合（別環境の位置など）、
+// 保持期間を過ぎて削除した墓標より前の位置の場合は、差分の代わりに現在のすべてのTodoを返します。
+// 同じユーザーの変更はseqの順にコミットされる（TodoRepositoryの実装が保証する）ため、
+// 返した位置より前のseqの変更が後からコミットされて取りこぼされることはありません。
+func (uc *TodoUsecase) PullChanges(ctx context.Context, userID uint, since uint64, limit int) (_ SyncPage, err error) {
+	ctx, span := startSpan(ctx, "TodoUsecase.PullChanges")
+	defer func() { endSpan(span, err) }()
+
+	// 一覧より先に位置を読む（一覧の取得中の変更は次回の同期で重複して届くが、取りこぼしはない）
+	last, err := uc.Repo.LastChangeSeq(ctx, userID)
+	if err != nil {
+		return SyncPage{}, err
+	}
+	if since == 0 || since > last {
+		return uc.pullAll(ctx, userID, last)
+	}
+
+	changes, err := uc.Repo.Changes(ctx, userID, since, limit+1)
+	if err != nil {
+		return SyncPage{}, err
+	}
+	// 変更履歴の後に読む（読んでいる間に削除された墓標も検出する）
+	pruned, err := uc.Repo.PrunedChangeSeq(ctx, userID)
+	if err != nil {
+		return SyncPage{}, err
+	}
+	if since < pruned {
+		return uc.pullAll(ctx, userID, last)
+	}
+	page := SyncPage{Changes: changes, Seq: since}
+	if len(changes) > limit {
+		page.Changes, page.HasMore = changes[:limit], true
+	}
+	if n := len(page.Changes); n > 0 {
+		page.Seq = page.Changes[n-1].Seq
+	}
+	return page, nil
+}
+
+// pullAllは、現在のすべてのTodoを位置lastまでの同期の結果として返します。
+func (uc *TodoUsecase) pullAll(ctx context.Context, userID uint, last uint64) (SyncPage, error) {
+	todos, err := uc.Repo.FindByUser(ctx, userID)
+	if err != nil {
+		return SyncPage{}, err
+	}
+	changes := make([]domain.TodoChange, len(todos))
+	for i, t := range todos {
+		changes[i] = domain.TodoChange{TodoID: t.ID, UserID: t.UserID, Todo: t}
+	}
+	return SyncPage{Changes: changes, Seq: last, Full: true}, nil
+}
+
+// PruneTombstonesは、保持期間retentionを過ぎた削除の墓標を削除し、削除した件数を返します。
+// 削除した墓標より前の位置から同期するクライアントには、PullChangesが差分の代わりに全件を返します。
+func (uc *TodoUsecase) PruneTombstones(ctx context.Context, retention time.Duration) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "TodoUsecase.PruneTombstones")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.PruneTombstones(ctx, time.Now().Add(-retention))
+}
+
+// SyncPushは、オフライン中にクライアントで行われた1件の変更です。
+type SyncPush struct {
+	// IDは変更したTodoのIDです。0の場合はクライアントで作成されたTodoとして作成します。
+	ID uint
+	// Versionは、クライアントが変更の元にしたTodoのバージョンです（ID指定時に必須）。
+	Version uint
+	// Deletedは削除を表します。
+	Deleted bool
+	// Patchは作成・更新で設定するフィールドです。
+	Patch domain.TodoPatch
+}
+
+// SyncStatusは差分同期（push）の1件の変更の結果です。
+type SyncStatus string
+
+const (
+	// SyncAppliedは変更を適用したことを表します（既に削除済みのTodoの削除を含む）。
+	SyncApplied SyncStatus = "applied"
+	// SyncConflictは、クライアントが元にしたバージョンの後にサーバで変更されていたため適用しなかったことを表します。
+	SyncConflict SyncStatus = "conflict"
+)
+
+// SyncPushResultは1件の変更の結果です。
+type SyncPushResult struct {
+	Status SyncStatus
+	// Todoは適用後のTodoです（削除ではIDのみ）。
+	Todo domain.Todo
+	// Errは競合の原因です。サーバで更新されていた場合はdomain.ErrVersionMismatch、
+	// 削除されていた場合はdomain.ErrTodoNotFoundです。
+	Err error
+	// Serverは競合時のサーバ上の現在のTodoです（削除されていた場合はnil）。
+	Server *domain.Todo
+}
+
+// PushChangesは、クライアントの変更を順に適用し、変更ごとの結果を返します。
+// 競合した変更は適用せずにサーバの状態を返し、他の変更の適用は続けます。
+// 変更は1つのトランザクションで適用し、競合以外のエラーの場合はすべて取り消します。
+// メトリクスの記録と変更イベントの発行はコミット後に行います。
+func (uc *TodoUsecase) PushChanges(ctx context.Context, userID uint, pushes []SyncPush) (_ []SyncPushResult, err error) {
+	ctx, span := startSpan(ctx, "TodoUsecase.PushChanges")
+	defer func() { endSpan(span, err) }()
+
+	var (
+		results   []SyncPushResult
+		created   int
+		completed int
+	)
+	err = uc.write(ctx, func(ctx context.Context, repos repository.Repositories) ([]domain.TodoEvent, error) {
+		repo := repos.Todos
+		results, created, completed = make([]SyncPushResult, len(pushes)), 0, 0
+		var events []domain.TodoEvent
+		for i, p := range pushes {
+			res, event, wasCompleted, err := pushChange(ctx, repo, userID, p)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = res
+			if event.Type != "" {
+				events = append(events, newTodoEvents(event.Type, event.Completed, event.Todo)...)
+			}
+			if event.Type == domain.TodoCreated {
+				created++
+			}
+			if wasCompleted {
+				completed++
+			}
+		}
+		return events, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for range created {
+		uc.Metrics.TodoCreated()
+	}
+	for range completed {
+		uc.Metrics.TodoCompleted()
+	}
+	return results, nil
+}
+
+// pushChangeは1件の変更を適用します。
+// 発行する変更イベント（適用しなかった場合はTypeが空）と、未完了のTodoが完了になったかを返します。
+// AddTodoと同じく、完了の状態で作成したTodoは完了になったものとして数えません。
+func pushChange(ctx context.Context, repo repository.TodoRepository, userID uint, p SyncPush) (_ SyncPushResult, _ domain.TodoEvent, completed bool, err error) {
+	switch {
+	case p.ID == 0:
+		todo := domain.Todo{UserID: userID}
+		p.Patch.Apply(&todo)
+		created, err := repo.Create(ctx, todo)
+		if err != nil {
+			return SyncPushResult{}, domain.TodoEvent{}, false, err
+		}
+		return SyncPushResult{Status: SyncApplied, Todo: created}, domain.TodoEvent{Type: domain.TodoCreated, Todo: created}, false, nil
+
+	case p.Deleted:
+		deleted := domain.Todo{ID: p.ID, UserID: userID}
+		err := repo.Delete(ctx, userID, p.ID, p.Version)
+		switch {
+		case err == nil:
+			return SyncPushResult{Status: SyncApplied, Todo: deleted}, domain.TodoEvent{Type: domain.TodoDeleted, Todo: deleted}, false, nil
+		case errors.Is(err, domain.ErrTodoNotFound):
+			// サーバでも削除済み（結果は同じなので競合としない）
+			return SyncPushResult{Status: SyncApplied, Todo: deleted}, domain.TodoEvent{}, false, nil
+		}
+		return conflict(ctx, repo, userID, p.ID, err)
+
+	default:
+		current, updated, err := patchTodo(ctx, repo, userID, p.ID, p.Version, p.Patch)
+		if err != nil {
+			return conflict(ctx, repo, userID, p.ID, err)
+		}
+		completed := !current.Completed && updated.Completed
+		return SyncPushResult{Status: SyncApplied, Todo: updated}, domain.TodoEvent{Type: domain.TodoUpdated, Todo: updated, Completed: completed}, completed, nil
+	}
+}
+
+// conflictは、書き込みのエラーerrが競合（バージョン不一致・サーバで削除済み）の場合に、
+// サーバの現在の状態を含む結果を返します。それ以外のエラーはそのまま返します。
+func conflict(ctx context.Context, repo repository.TodoRepository, userID, id uint, err error) (SyncPushResult, domain.TodoEvent, bool, error) {
+	switch {
+	case errors.Is(err, domain.ErrTodoNotFound):
+		return SyncPushResult{Status: SyncConflict, Todo: domain.Todo{ID: id, UserID: userID}, Err: err}, domain.TodoEvent{}, false, nil
+	case errors.Is(err, domain.ErrVersionMismatch):
+		server, findErr := repo.FindByID(ctx, userID, id)
+		if findErr != nil {
+			return SyncPushResult{}, domain.TodoEvent{}, false, findErr
+		}
+		return SyncPushResult{Status: SyncConflict, Todo: domain.Todo{ID: id, UserID: userID}, Err: err, Server: &server}, domain.TodoEvent{}, false, nil
+	default:
+		return SyncPushResult{}, domain.TodoEvent{}, false, err
+	}
+}
@@ -0,0 +1,184 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullChanges_ReturnsAllTodosOnFirstSync(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	uc := usecase.NewTodoUsecase(repo)
+	todos := []domain.Todo{{ID: 1, UserID: 1, Title: "a", Version: 1}, {ID: 2, UserID: 1, Title: "b", Version: 3}}
+	repo.On("LastChangeSeq", mock.Anything, uint(1)).Return(uint64(7), nil).Once()
+	repo.On("FindByUser", mock.Anything, uint(1)).Return(todos, nil).Once()
+
+	// when
+	page, err := uc.PullChanges(context.Background(), 1, 0, 500)
+
+	// then
+	require.NoError(t, err)
+	assert.True(t, page.Full)
+	assert.Equal(t, uint64(7), page.Seq)
+	require.Len(t, page.Changes, 2)
+	assert.Equal(t, uint(2), page.Changes[1].TodoID)
+	assert.Equal(t, todos[1], page.Changes[1].Todo)
+	repo.AssertNotCalled(t, "Changes", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPullChanges_ReturnsAllTodosForUnknownToken(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	uc := usecase.NewTodoUsecase(repo)
+	repo.On("LastChangeSeq", mock.Anything, uint(1)).Return(uint64(7), nil).Once()
+	repo.On("FindByUser", mock.Anything, uint(1)).Return([]domain.Todo{}, nil).Once()
+
+	// when
+	page, err := uc.PullChanges(context.Background(), 1, 100, 500)
+
+	// then
+	require.NoError(t, err)
+	assert.True(t, page.Full)
+	assert.Equal(t, uint64(7), page.Seq)
+}
+
+func TestPullChanges_ReturnsAllTodosForPrunedToken(t *testing.T) {
+	// given: 位置3より後の削除の墓標は保持期間を過ぎて削除されている
+	repo := new(MockTodoRepo)
+	uc := usecase.NewTodoUsecase(repo)
+	todos := []domain.Todo{{ID: 1, UserID: 1, Title: "a", Version: 2}}
+	repo.On("LastChangeSeq", mock.Anything, uint(1)).Return(uint64(9), nil).Once()
+	repo.On("Changes", mock.Anything, uint(1), uint64(3), 501).Return([]domain.TodoChange{{Seq: 9, TodoID: 1, UserID: 1}}, nil).Once()
+	repo.On("PrunedChangeSeq", mock.Anything, uint(1)).Return(uint64(5), nil).Once()
+	repo.On("FindByUser", mock.Anything, uint(1)).Return(todos, nil).Once()
+
+	// when
+	page, err := uc.PullChanges(context.Background(), 1, 3, 500)
+
+	// then: 削除を伝えられないため全件を返す
+	require.NoError(t, err)
+	assert.True(t, page.Full)
+	assert.Equal(t, uint64(9), page.Seq)
+	require.Len(t, page.Changes, 1)
+	assert.Equal(t, todos[0], page.Changes[0].Todo)
+	repo.AssertExpectations(t)
+}
+
+func TestPullChanges_PagesChangesSinceToken(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	uc := usecase.NewTodoUsecase(repo)
+	changes := []domain.TodoChange{
+		{Seq: 4, TodoID: 1, UserID: 1, Todo: domain.Todo{ID: 1}},
+		{Seq: 5, TodoID: 2, UserID: 1, Deleted: true},
+		{Seq: 6, TodoID: 3, UserID: 1, Todo: domain.Todo{ID: 3}},
+	}
+	repo.On("LastChangeSeq", mock.Anything, uint(1)).Return(uint64(6), nil)
+	repo.On("PrunedChangeSeq", mock.Anything, uint(1)).Return(uint64(2), nil)
+	repo.On("Changes", mock.Anything, uint(1), uint64(3), 3).Return(changes, nil).Once()
+	repo.On("Changes", mock.Anything, uint(1), uint64(5), 3).Return(changes[2:], nil).Once()
+	repo.On("Changes", mock.Anything, uint(1), uint64(6), 3).Return([]domain.TodoChange{}, nil).Once()
+
+	// when
+	first, err1 := uc.PullChanges(context.Background(), 1, 3, 2)
+	second, err2 := uc.PullChanges(context.Background(), 1, first.Seq, 2)
+	third, err3 := uc.PullChanges(context.Background(), 1, second.Seq, 2)
+
+	// then: 続きがある間はhas_moreになり、変更がなければ位置は変わらない
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	require.NoError(t, err3)
+	assert.Equal(t, changes[:2], first.Changes)
+	assert.True(t, first.HasMore)
+	assert.Equal(t, uint64(5), first.Seq)
+	assert.Equal(t, changes[2:], second.Changes)
+	assert.False(t, second.HasMore)
+	assert.Equal(t, uint64(6), second.Seq)
+	assert.Empty(t, third.Changes)
+	assert.Equal(t, uint64(6), third.Seq)
+	assert.False(t, first.Full || second.Full || third.Full)
+}
+
+func TestPushChanges_AppliesChangesAndReportsConflicts(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	m := &fakeTodoMetrics{}
+	bus := &fakeEventBus{}
+	uc := usecase.NewTodoUsecase(repo, usecase.WithTodoMetrics(m), usecase.WithEventBus(bus))
+
+	title := "new"
+	completed := true
+	repo.On("Create", mock.Anything, domain.Todo{UserID: 1, Title: "new"}).
+		Return(domain.Todo{ID: 3, UserID: 1, Title: "new", Version: 1}, nil).Once()
+	repo.On("FindByID", mock.Anything, uint(1), uint(4)).Return(domain.Todo{ID: 4, UserID: 1, Title: "t", Version: 2}, nil).Once()
+	repo.On("Update", mock.Anything, domain.Todo{ID: 4, UserID: 1, Title: "t", Completed: true, Version: 2}).Return(nil).Once()
+	server := domain.Todo{ID: 5, UserID: 1, Title: "server", Version: 4}
+	repo.On("FindByID", mock.Anything, uint(1), uint(5)).Return(server, nil)
+	repo.On("Delete", mock.Anything, uint(1), uint(6), uint(1)).Return(domain.ErrTodoNotFound).Once()
+	repo.On("FindByID", mock.Anything, uint(1), uint(7)).Return(domain.Todo{}, domain.ErrTodoNotFound).Once()
+
+	// when
+	results, err := uc.PushChanges(context.Background(), 1, []usecase.SyncPush{
+		{Patch: domain.TodoPatch{Title: &title}},
+		{ID: 4, Version: 2, Patch: domain.TodoPatch{Completed: &completed}},
+		{ID: 5, Version: 3, Patch: domain.TodoPatch{Completed: &completed}},
+		{ID: 6, Version: 1, Deleted: true},
+		{ID: 7, Version: 1, Patch: domain.TodoPatch{Title: &title}},
+	})
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	assert.Equal(t, usecase.SyncApplied, results[0].Status)
+	assert.Equal(t, uint(3), results[0].Todo.ID)
+	assert.Equal(t, usecase.SyncApplied, results[1].Status)
+	assert.Equal(t, uint(3), results[1].Todo.Version)
+	// サーバで更新されていた変更は適用せず、サーバの状態を返す
+	assert.Equal(t, usecase.SyncConflict, results[2].Status)
+	assert.ErrorIs(t, results[2].Err, domain.ErrVersionMismatch)
+	assert.Equal(t, &server, results[2].Server)
+	// 削除済みのTodoの削除は適用済み
+	assert.Equal(t, usecase.SyncApplied, results[3].Status)
+	// 削除済みのTodoの更新は競合
+	assert.Equal(t, usecase.SyncConflict, results[4].Status)
+	assert.ErrorIs(t, results[4].Err, domain.ErrTodoNotFound)
+	assert.Nil(t, results[4].Server)
+
+	assert.Equal(t, 1, m.created)
+	assert.Equal(t, 1, m.completed)
+	assert.Equal(t, []domain.TodoEventType{domain.TodoCreated, domain.TodoUpdated}, bus.types())
+	repo.AssertExpectations(t)
+}
+
+// 完了の状態で作成したTodoは、AddTodoと同じく完了の件数に含めない
+func TestPushChanges_CompletedCreateIsNotCountedAsCompletion(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	m := &fakeTodoMetrics{}
+	uc := usecase.NewTodoUsecase(repo, usecase.WithTodoMetrics(m))
+
+	title := "new"
+	completed := true
+	repo.On("Create", mock.Anything, domain.Todo{UserID: 1, Title: "new", Completed: true}).
+		Return(domain.Todo{ID: 3, UserID: 1, Title: "new", Completed: true, Version: 1}, nil).Once()
+
+	// when
+	results, err := uc.PushChanges(context.Background(), 1, []usecase.SyncPush{
+		{Patch: domain.TodoPatch{Title: &title, Completed: &completed}},
+	})
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, usecase.SyncApplied, results[0].Status)
+	assert.Equal(t, 1, m.created)
+	assert.Equal(t, 0, m.completed)
+	repo.AssertExpectations(t)
+}
@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"context"
+
 	"todo_backend/internal/domain"
 	"todo_backend/internal/interface/repository"
 )
@@ -10,31 +12,155 @@ import (
 // Clean ArchitectureにおけるUsecase層であり、
 // Repositoryインターフェースを通じて永続化層へアクセスします。
 type TodoUsecase struct {
-	Repo repository.TodoRepository
+	Repo    repository.TodoRepository
+	Metrics TodoMetrics
+	// Txは複数の書き込みを1つのトランザクションで行うためのTransactorです。
+	Tx repository.Transactor
+	// Eventsは変更を確定した後にTodoの変更イベントを発行するイベントバスです。
+	Events TodoEventBus
+	// EventHandlersはイベントバスへの発行後に変更イベントを受け取る処理です。
+	EventHandlers []TodoEventHandler
+	// EventJobsは変更イベントを変更と同じトランザクションで保存するジョブの種類です。
+	EventJobs []EventJob
 }
 
+// TodoOptionはNewTodoUsecaseの任意設定です。
+type TodoOption func(*TodoUsecase)
+
 // NewTodoUsecaseは、指定されたTodoRepositoryを使用する
 // TodoUsecaseの新しいインスタンスを返します。
-func NewTodoUsecase(r repository.TodoRepository) *TodoUsecase {
-	return &TodoUsecase{Repo: r}
+// optsでメトリクスなどの任意の依存を注入できます。
+func NewTodoUsecase(r repository.TodoRepository, opts ...TodoOption) *TodoUsecase {
+	uc := &TodoUsecase{Repo: r, Metrics: noopMetrics{}, Events: noopEvents{}}
+	uc.Tx = nonTransactional{repos: repository.Repositories{Todos: r}}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
 }
 
 // GetTodosは、登録されている全てのTodoを取得します。
-func (uc *TodoUsecase) GetTodos(userID uint) ([]domain.Todo, error) {
-	return uc.Repo.FindByUser(userID)
+func (uc *TodoUsecase) GetTodos(ctx context.Context, userID uint) (_ []domain.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoUsecase.GetTodos")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.FindByUser(ctx, userID)
+}
+
+// GetTodoは、指定ユーザーが所有するIDのTodoを1件取得します。
+// 存在しない場合はdomain.ErrTodoNotFoundを返します。
+func (uc *TodoUsecase) GetTodo(ctx context.Context, userID uint, id uint) (_ domain.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoUsecase.GetTodo")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.FindByID(ctx, userID, id)
 }
 
-// AddTodoは、新しいTodoを作成して保存します。
-func (uc *TodoUsecase) AddTodo(todo domain.Todo) error {
-	return uc.Repo.Create(todo)
+// AddTodoは、新しいTodoを作成して保存し、採番されたIDを含むTodoを返します。
+// 保存後に作成イベントを発行します。
+func (uc *TodoUsecase) AddTodo(ctx context.Context, todo domain.Todo) (_ domain.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoUsecase.AddTodo")
+	defer func() { endSpan(span, err) }()
+
+	var created domain.Todo
+	err = uc.write(ctx, func(ctx context.Context, repos repository.Repositories) (_ []domain.TodoEvent, err error) {
+		if created, err = repos.Todos.Create(ctx, todo); err != nil {
+			return nil, err
+		}
+		return newTodoEvents(domain.TodoCreated, false, created), nil
+	})
+	if err != nil {
+		return domain.Todo{}, err
+	}
+	uc.Metrics.TodoCreated()
+	return created, nil
 }
 
-// UpdateTodoは、既存のTodoを更新します。
-func (uc *TodoUsecase) UpdateTodo(todo domain.Todo) error {
-	return uc.Repo.Update(todo)
+// UpdateTodoは、既存のTodoを更新し、更新後のTodo（新しいバージョン）を返します。
+// todo.Versionにはクライアントが最後に取得したバージョンを指定します。
+// 他の更新によりバージョンが進んでいる場合はdomain.ErrVersionMismatchを返し、上書きしません。
+// 未完了から完了に変わった場合は完了メトリクスを記録します（完了済みのTodoを保存し直しても記録しません）。
+func (uc *TodoUsecase) UpdateTodo(ctx context.Context, todo domain.Todo) (_ domain.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoUsecase.UpdateTodo")
+	defer func() { endSpan(span, err) }()
+
+	var current domain.Todo
+	err = uc.write(ctx, func(ctx context.Context, repos repository.Repositories) (_ []domain.TodoEvent, err error) {
+		// 完了になったかどうかを判定するため、更新前の状態を同じトランザクションで読む
+		if current, err = repos.Todos.FindByID(ctx, todo.UserID, todo.ID); err != nil {
+			return nil, err
+		}
+		if err := repos.Todos.Update(ctx, todo); err != nil {
+			return nil, err
+		}
+		updated := todo
+		updated.Version++
+		return newTodoEvents(domain.TodoUpdated, !current.Completed && updated.Completed, updated), nil
+	})
+	if err != nil {
+		return domain.Todo{}, err
+	}
+	todo.Version++
+	if !current.Completed && todo.Completed {
+		uc.Metrics.TodoCompleted()
+	}
+	return todo, nil
+}
+
+// PatchTodoは、Todoの指定されたフィールドのみを更新し、更新後のTodoを返します。
+// versionにはクライアントが最後に取得したバージョンを指定します。
+// 未完了から完了に変わった場合は完了メトリクスを記録します。
+func (uc *TodoUsecase) PatchTodo(ctx context.Context, userID uint, id uint, version uint, patch domain.TodoPatch) (_ domain.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoUsecase.PatchTodo")
+	defer func() { endSpan(span, err) }()
+
+	var current, updated domain.Todo
+	err = uc.write(ctx, func(ctx context.Context, repos repository.Repositories) (_ []domain.TodoEvent, err error) {
+		if current, updated, err = patchTodo(ctx, repos.Todos, userID, id, version, patch); err != nil {
+			return nil, err
+		}
+		return newTodoEvents(domain.TodoUpdated, !current.Completed && updated.Completed, updated), nil
+	})
+	if err != nil {
+		return domain.Todo{}, err
+	}
+	if !current.Completed && updated.Completed {
+		uc.Metrics.TodoCompleted()
+	}
+	return updated, nil
+}
+
+// patchTodoは、repoを使ってTodoに部分更新を適用し、更新前と更新後のTodoを返します。
+// versionが0の場合はバージョンを確認せず、取得時点のバージョンを期待値とします。
+func patchTodo(ctx context.Context, repo repository.TodoRepository, userID, id, version uint, patch domain.TodoPatch) (current, updated domain.Todo, err error) {
+	current, err = repo.FindByID(ctx, userID, id)
+	if err != nil {
+		return domain.Todo{}, domain.Todo{}, err
+	}
+	if version != 0 && current.Version != version {
+		return domain.Todo{}, domain.Todo{}, domain.ErrVersionMismatch
+	}
+
+	updated = current
+	patch.Apply(&updated)
+	// 読み取り後に他の更新が入った場合もRepository側のバージョン条件で検出される
+	if err := repo.Update(ctx, updated); err != nil {
+		return domain.Todo{}, domain.Todo{}, err
+	}
+	updated.Version++
+	return current, updated, nil
 }
 
 // DeleteTodoは、指定されたIDのTodoを削除します。
-func (uc *TodoUsecase) DeleteTodo(userID uint, id int) error {
-	return uc.Repo.Delete(userID, id)
+// versionが現在のバージョンと一致しない場合はdomain.ErrVersionMismatchを返します。
+func (uc *TodoUsecase) DeleteTodo(ctx context.Context, userID, id, version uint) (err error) {
+	ctx, span := startSpan(ctx, "TodoUsecase.DeleteTodo")
+	defer func() { endSpan(span, err) }()
+
+	return uc.write(ctx, func(ctx context.Context, repos repository.Repositories) ([]domain.TodoEvent, error) {
+		if err := repos.Todos.Delete(ctx, userID, id, version); err != nil {
+			return nil, err
+		}
+		return newTodoEvents(domain.TodoDeleted, false, domain.Todo{ID: id, UserID: userID}), nil
+	})
 }
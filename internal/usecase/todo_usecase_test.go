@@ -1,7 +1,9 @@
 package usecase_test
 
 import (
+	"context"
 	"testing"
+	"time"
 	"todo_backend/internal/domain"
 	"todo_backend/internal/interface/repository"
 	"todo_backend/internal/usecase"
@@ -12,21 +14,47 @@ import (
 
 type MockTodoRepo struct{ mock.Mock }
 
-func (m *MockTodoRepo) FindByUser(userID uint) ([]domain.Todo, error) {
-	args := m.Called(userID)
+func (m *MockTodoRepo) FindByUser(ctx context.Context, userID uint) ([]domain.Todo, error) {
+	args := m.Called(ctx, userID)
 	return args.Get(0).([]domain.Todo), args.Error(1)
 }
 
-func (m *MockTodoRepo) Create(todo domain.Todo) error {
-	return m.Called(todo).Error(0)
+func (m *MockTodoRepo) FindByID(ctx context.Context, userID uint, id uint) (domain.Todo, error) {
+	args := m.Called(ctx, userID, id)
+	return args.Get(0).(domain.Todo), args.Error(1)
 }
 
-func (m *MockTodoRepo) Update(todo domain.Todo) error {
-	return m.Called(todo).Error(0)
+func (m *MockTodoRepo) Create(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+	args := m.Called(ctx, todo)
+	return args.Get(0).(domain.Todo), args.Error(1)
 }
 
-func (m *MockTodoRepo) Delete(userID uint, id int) error {
-	return m.Called(userID, id).Error(0)
+func (m *MockTodoRepo) Update(ctx context.Context, todo domain.Todo) error {
+	return m.Called(ctx, todo).Error(0)
+}
+
+func (m *MockTodoRepo) Delete(ctx context.Context, userID, id, version uint) error {
+	return m.Called(ctx, userID, id, version).Error(0)
+}
+
+func (m *MockTodoRepo) Changes(ctx context.Context, userID uint, since uint64, limit int) ([]domain.TodoChange, error) {
+	args := m.Called(ctx, userID, since, limit)
+	return args.Get(0).([]domain.TodoChange), args.Error(1)
+}
+
+func (m *MockTodoRepo) LastChangeSeq(ctx context.Context, userID uint) (uint64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(uint64), args.Error(1)
+}
+
+func (m *MockTodoRepo) PrunedChangeSeq(ctx context.Context, userID uint) (uint64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(uint64), args.Error(1)
+}
+
+func (m *MockTodoRepo) PruneTombstones(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 var _ repository.TodoRepository = (*MockTodoRepo)(nil)
@@ -38,9 +66,9 @@ func TestGetTodos_CallsRepoWithUserID_AndReturnsList(t *testing.T) {
 	expected := []domain.Todo{
 		{ID: 1, UserID: 42, Title: "Test Todo", Completed: false},
 	}
-	mockRepo.On("FindByUser", uint(42)).Return(expected, nil)
+	mockRepo.On("FindByUser", mock.Anything, uint(42)).Return(expected, nil)
 
-	todos, err := uc.GetTodos(42)
+	todos, err := uc.GetTodos(context.Background(), 42)
 	assert.NoError(t, err)
 	assert.Equal(t, expected, todos)
 
@@ -56,9 +84,9 @@ func TestGetTodo_PropagatesErrorFromRepo(t *testing.T) {
 		{ID: 1, Title: "A", Completed: false, UserID: userID},
 		{ID: 2, Title: "B", Completed: true, UserID: userID},
 	}
-	repo.On("FindByUser", userID).Return(expected, nil).Once()
+	repo.On("FindByUser", mock.Anything, userID).Return(expected, nil).Once()
 
-	got, err := uc.GetTodos(userID)
+	got, err := uc.GetTodos(context.Background(), userID)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expected, got)
@@ -71,13 +99,15 @@ func TestAddTodo_CallsCreate(t *testing.T) {
 	uc := usecase.NewTodoUsecase(repo)
 
 	in := domain.Todo{ID: 0, Title: "new", Completed: false, UserID: 1}
-	repo.On("Create", in).Return(nil).Once()
+	persisted := domain.Todo{ID: 7, Title: "new", Completed: false, UserID: 1, Version: 1}
+	repo.On("Create", mock.Anything, in).Return(persisted, nil).Once()
 
 	// when
-	err := uc.AddTodo(in)
+	got, err := uc.AddTodo(context.Background(), in)
 
 	// then
 	assert.NoError(t, err)
+	assert.Equal(t, persisted, got)
 	repo.AssertExpectations(t)
 }
 
@@ -86,14 +116,16 @@ func TestUpdateTodo_CallsUpdate(t *testing.T) {
 	repo := new(MockTodoRepo)
 	uc := usecase.NewTodoUsecase(repo)
 
-	in := domain.Todo{ID: 10, Title: "edited", Completed: true, UserID: 1}
-	repo.On("Update", in).Return(nil).Once()
+	in := domain.Todo{ID: 10, Title: "edited", Completed: true, UserID: 1, Version: 3}
+	repo.On("FindByID", mock.Anything, uint(1), uint(10)).Return(domain.Todo{ID: 10, Title: "old", UserID: 1, Version: 3}, nil).Once()
+	repo.On("Update", mock.Anything, in).Return(nil).Once()
 
 	// when
-	err := uc.UpdateTodo(in)
+	got, err := uc.UpdateTodo(context.Background(), in)
 
 	// then
 	assert.NoError(t, err)
+	assert.Equal(t, uint(4), got.Version)
 	repo.AssertExpectations(t)
 }
 
@@ -103,13 +135,121 @@ func TestDeleteTodo_CallsDeleteWithUserAndID(t *testing.T) {
 	uc := usecase.NewTodoUsecase(repo)
 
 	userID := uint(1)
-	id := 10
-	repo.On("Delete", userID, id).Return(nil).Once()
+	id := uint(10)
+	repo.On("Delete", mock.Anything, userID, id, uint(2)).Return(nil).Once()
 
 	// when
-	err := uc.DeleteTodo(userID, id)
+	err := uc.DeleteTodo(context.Background(), userID, id, 2)
 
 	// then
 	assert.NoError(t, err)
 	repo.AssertExpectations(t)
 }
+
+type fakeTodoMetrics struct{ created, completed int }
+
+func (f *fakeTodoMetrics) TodoCreated()   { f.created++ }
+func (f *fakeTodoMetrics) TodoCompleted() { f.completed++ }
+
+func TestAddAndUpdateTodo_RecordMetrics(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	m := &fakeTodoMetrics{}
+	uc := usecase.NewTodoUsecase(repo, usecase.WithTodoMetrics(m))
+
+	created := domain.Todo{Title: "new", UserID: 1}
+	done := domain.Todo{ID: 1, Title: "new", Completed: true, UserID: 1}
+	repo.On("Create", mock.Anything, created).Return(created, nil).Once()
+	repo.On("FindByID", mock.Anything, uint(1), uint(1)).Return(domain.Todo{ID: 1, Title: "new", UserID: 1}, nil).Once()
+	repo.On("Update", mock.Anything, done).Return(nil).Once()
+
+	// when
+	_, err := uc.AddTodo(context.Background(), created)
+	assert.NoError(t, err)
+	_, err = uc.UpdateTodo(context.Background(), done)
+	assert.NoError(t, err)
+
+	// then
+	assert.Equal(t, 1, m.created)
+	assert.Equal(t, 1, m.completed)
+	repo.AssertExpectations(t)
+}
+
+// 完了済みのTodoを完了のまま保存し直しても、完了メトリクスは記録しない
+func TestUpdateTodo_DoesNotRecordCompletionOfCompletedTodo(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	m := &fakeTodoMetrics{}
+	uc := usecase.NewTodoUsecase(repo, usecase.WithTodoMetrics(m))
+
+	renamed := domain.Todo{ID: 1, Title: "renamed", Completed: true, UserID: 1, Version: 2}
+	repo.On("FindByID", mock.Anything, uint(1), uint(1)).Return(domain.Todo{ID: 1, Title: "done", Completed: true, UserID: 1, Version: 2}, nil).Once()
+	repo.On("Update", mock.Anything, renamed).Return(nil).Once()
+
+	// when
+	_, err := uc.UpdateTodo(context.Background(), renamed)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 0, m.completed)
+	repo.AssertExpectations(t)
+}
+
+func TestUpdateTodo_PropagatesVersionMismatch(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	m := &fakeTodoMetrics{}
+	uc := usecase.NewTodoUsecase(repo, usecase.WithTodoMetrics(m))
+
+	in := domain.Todo{ID: 10, Title: "stale", Completed: true, UserID: 1, Version: 1}
+	repo.On("FindByID", mock.Anything, uint(1), uint(10)).Return(domain.Todo{ID: 10, UserID: 1, Version: 2}, nil).Once()
+	repo.On("Update", mock.Anything, in).Return(domain.ErrVersionMismatch).Once()
+
+	// when
+	_, err := uc.UpdateTodo(context.Background(), in)
+
+	// then
+	assert.ErrorIs(t, err, domain.ErrVersionMismatch)
+	assert.Equal(t, 0, m.completed)
+	repo.AssertExpectations(t)
+}
+
+func TestPatchTodo_UpdatesOnlyProvidedFields(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	m := &fakeTodoMetrics{}
+	uc := usecase.NewTodoUsecase(repo, usecase.WithTodoMetrics(m))
+
+	current := domain.Todo{ID: 5, UserID: 1, Title: "keep", Completed: false, Version: 2}
+	merged := domain.Todo{ID: 5, UserID: 1, Title: "keep", Completed: true, Version: 2}
+	repo.On("FindByID", mock.Anything, uint(1), uint(5)).Return(current, nil).Once()
+	repo.On("Update", mock.Anything, merged).Return(nil).Once()
+
+	// when
+	done := true
+	got, err := uc.PatchTodo(context.Background(), 1, 5, 2, domain.TodoPatch{Completed: &done})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "keep", got.Title)
+	assert.True(t, got.Completed)
+	assert.Equal(t, uint(3), got.Version)
+	assert.Equal(t, 1, m.completed)
+	repo.AssertExpectations(t)
+}
+
+func TestPatchTodo_RejectsStaleVersion(t *testing.T) {
+	// given
+	repo := new(MockTodoRepo)
+	uc := usecase.NewTodoUsecase(repo)
+	current := domain.Todo{ID: 5, UserID: 1, Title: "t", Version: 4}
+	repo.On("FindByID", mock.Anything, uint(1), uint(5)).Return(current, nil).Once()
+
+	// when
+	title := "new"
+	_, err := uc.PatchTodo(context.Background(), 1, 5, 3, domain.TodoPatch{Title: &title})
+
+	// then
+	assert.ErrorIs(t, err, domain.ErrVersionMismatch)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
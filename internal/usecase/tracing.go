@@ -0,0 +1,27 @@
+package usecase
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerはユースケース層のスパンを作成するトレーサです。
+// otelのAPIのみに依存し、エクスポータなどの実装はインフラ層で設定されます。
+var tracer = otel.Tracer("todo_backend/internal/usecase")
+
+// startSpanはユースケースメソッド用のスパンを開始します。
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name)
+}
+
+// endSpanはエラーを記録してスパンを終了します。
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"context"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+)
+
+// nonTransactionalはTransactorが注入されなかった場合に使う実装です。
+// トランザクションを張らず、ユースケースのリポジトリをそのままfnに渡します（原子性は保証されません）。
+type nonTransactional struct {
+	repos repository.Repositories
+}
+
+func (n nonTransactional) WithinTransaction(ctx context.Context, fn func(ctx context.Context, repos repository.Repositories) error) error {
+	return fn(ctx, n.repos)
+}
+
+// WithTransactorは一括操作などの複数の書き込みを原子的に行うためのTransactorを設定します。
+func WithTransactor(tx repository.Transactor) TodoOption {
+	return func(uc *TodoUsecase) { uc.Tx = tx }
+}
+
+// writeは、Todoの書き込みfnをトランザクション内で実行します。
+// fnが返した変更イベントは同じトランザクションでジョブとして保存し（WithEventJob）、コミット後に発行します。
+func (uc *TodoUsecase) write(ctx context.Context, fn func(ctx context.Context, repos repository.Repositories) ([]domain.TodoEvent, error)) error {
+	var events []domain.TodoEvent
+	err := uc.Tx.WithinTransaction(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		var err error
+		if events, err = fn(ctx, repos); err != nil {
+			return err
+		}
+		return uc.saveEvents(ctx, repos.Jobs, events)
+	})
+	if err != nil {
+		return err
+	}
+	uc.publishEvents(events)
+	return nil
+}
@@ -0,0 +1,366 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/interface/repository"
+)
+
+// WebhookSenderはWebhookの配信（HTTPリクエストの送信）を抽象化したインターフェースです。
+// 実装（署名付きのHTTPクライアント）はインフラ層に置きます。
+type WebhookSender interface {
+	// Sendはdeliveryのペイロードをwebhookに送信し、通知先が返したステータスコードを返します。
+	// 通知先が2xx以外を返した場合や接続できなかった場合はエラーを返します。
+	Send(ctx context.Context, webhook domain.Webhook, delivery domain.WebhookDelivery) (status int, err error)
+}
+
+// JobTypeWebhookTodoEventは、Todoの変更イベントをWebhookの配信待ちに加えるジョブの種類です。
+// ペイロードはdomain.TodoEventです。TodoUsecaseにWithEventJobで登録すると、Todoの変更と同じトランザクションで登録されます。
+const JobTypeWebhookTodoEvent = "webhook.todo_event"
+
+// DefaultWebhookRetryPolicyは配信の再試行の方針のデフォルト値です（30秒から最大1時間の間隔で8回まで）。
+var DefaultWebhookRetryPolicy = RetryPolicy{MaxAttempts: 8, Backoff: 30 * time.Second, MaxBackoff: time.Hour}
+
+// WebhookUsecaseは、Webhookの購読の管理と、Todoの変更イベントの配信を行うユースケースです。
+type WebhookUsecase struct {
+	Repo   repository.WebhookRepository
+	Sender WebhookSender
+	Retry  RetryPolicy
+}
+
+// WebhookOptionはNewWebhookUsecaseの任意設定です。
+type WebhookOption func(*WebhookUsecase)
+
+// WithWebhookRetryは失敗した配信の再試行の方針を設定します。
+func WithWebhookRetry(p RetryPolicy) WebhookOption {
+	return func(uc *WebhookUsecase) { uc.Retry = p }
+}
+
+// NewWebhookUsecaseは、指定されたリポジトリに購読と配信ログを保存し、senderで配信するWebhookUsecaseを返します。
+func NewWebhookUsecase(r repository.WebhookRepository, sender WebhookSender, opts ...WebhookOption) *WebhookUsecase {
+	uc := &WebhookUsecase{Repo: r, Sender: sender, Retry: DefaultWebhookRetryPolicy}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+// CreateWebhookは、userIDのTodoのイベントのうちeventsをurlに通知するWebhookを登録します。
+// secretが空の場合は署名の鍵を生成します。鍵は登録時のレスポンスでのみクライアントに返します。
+func (uc *WebhookUsecase) CreateWebhook(ctx context.Context, userID uint, url string, events []domain.WebhookEventType, secret string) (_ domain.Webhook, err error) {
+	ctx, span := startSpan(ctx, "WebhookUsecase.CreateWebhook")
+	defer func() { endSpan(span, err) }()
+
+	if secret == "" {
+		secret = newWebhookSecret()
+	}
+	// 重複を除き、一覧の順に並べる
+	var subscribed []domain.WebhookEventType
+	for _, e := range domain.WebhookEventTypes {
+		if slices.Contains(events, e) {
+			subscribed = append(subscribed, e)
+		}
+	}
+	w := domain.Webhook{UserID: userID, URL: url, Events: subscribed, Secret: secret}
+	if err := uc.Repo.Create(ctx, &w); err != nil {
+		return domain.Webhook{}, err
+	}
+	return w, nil
+}
+
+// GetWebhooksはuserIDが登録したWebhookの一覧を返します。
+func (uc *WebhookUsecase) GetWebhooks(ctx context.Context, userID uint) (_ []domain.Webhook, err error) {
+	ctx, span := startSpan(ctx, "WebhookUsecase.GetWebhooks")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.FindByUser(ctx, userID)
+}
+
+// GetWebhookはuserIDが登録したIDのWebhookを返します。
+// 存在しない場合はdomain.ErrWebhookNotFoundを返します。
+func (uc *WebhookUsecase) GetWebhook(ctx context.Context, userID, id uint) (_ domain.Webhook, err error) {
+	ctx, span := startSpan(ctx, "WebhookUsecase.GetWebhook")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.FindByID(ctx, userID, id)
+}
+
+// DeleteWebhookはuserIDが登録したIDのWebhookを削除します。配信待ちの配信は送信されなくなります。
+// 存在しない場合はdomain.ErrWebhookNotFoundを返します。
+func (uc *WebhookUsecase) DeleteWebhook(ctx context.Context, userID, id uint) (err error) {
+	ctx, span := startSpan(ctx, "WebhookUsecase.DeleteWebhook")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.Delete(ctx, userID, id)
+}
+
+// GetDeliveriesは、userIDが登録したIDのWebhookの配信ログを新しい順に最大limit件返します。
+// Webhookが存在しない場合はdomain.ErrWebhookNotFoundを返します。
+func (uc *WebhookUsecase) GetDeliveries(ctx context.Context, userID, webhookID uint, limit int) (_ []domain.WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "WebhookUsecase.GetDeliveries")
+	defer func() { endSpan(span, err) }()
+
+	if _, err := uc.Repo.FindByID(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+	return uc.Repo.FindDeliveries(ctx, userID, webhookID, limit)
+}
+
+// Redeliverは、配信deliveryIDと同じイベントを改めて配信します（手動の再配信）。
+// 元の配信の状態にかかわらず、新しい配信として配信待ちに加え、その配信を返します。
+// Webhookまたは配信が存在しない場合はdomain.ErrWebhookNotFound / domain.ErrWebhookDeliveryNotFoundを返します。
+func (uc *WebhookUsecase) Redeliver(ctx context.Context, userID, webhookID, deliveryID uint) (_ domain.WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "WebhookUsecase.Redeliver")
+	defer func() { endSpan(span, err) }()
+
+	if _, err := uc.Repo.FindByID(ctx, userID, webhookID); err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	original, err := uc.Repo.FindDelivery(ctx, userID, webhookID, deliveryID)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	d := newDelivery(original.WebhookID, original.UserID, original.Event, original.Payload)
+	d.RedeliveryOf = &original.ID
+	if err := uc.Repo.CreateDelivery(ctx, &d); err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	return d, nil
+}
+
+// Enqueueは、Todoの変更イベントを購読しているイベントの種類ごとにWebhookの配信待ちに加えます。
+// 未完了のTodoが完了になった更新は、todo.updatedとtodo.completedの両方として配信します。
+// keyはイベントを一意に表す文字列（イベントのジョブのIDなど）です。ジョブの再試行などで同じkeyで
+// 呼び出し直しても、既に配信待ちに加えたWebhookには重複して加えません。
+func (uc *WebhookUsecase) Enqueue(ctx context.Context, key string, event domain.TodoEvent) (err error) {
+	ctx, span := startSpan(ctx, "WebhookUsecase.Enqueue")
+	defer func() { endSpan(span, err) }()
+
+	return uc.enqueue(ctx, event.UserID, key, webhookEventTypes(event), func(id string, typ domain.WebhookEventType) (string, error) {
+		return newWebhookPayload(id, typ, event)
+	})
+}
+
+// EnqueueNotificationは、リマインダーの通知をreminder.firedを購読しているWebhookの配信待ちに加えます。
+// 同じ通知で呼び出し直しても、既に配信待ちに加えたWebhookには重複して加えません。
+func (uc *WebhookUsecase) EnqueueNotification(ctx context.Context, n domain.Notification) (err error) {
+	ctx, span := startSpan(ctx, "WebhookUsecase.EnqueueNotification")
+	defer func() { endSpan(span, err) }()
+
+	// Webhookへの通知は保存しないためIDがない。リマインダーと通知した日時で1回の通知を表す
+	key := fmt.Sprintf("reminder:%d:%d", n.ReminderID, n.CreatedAt.UnixNano())
+	return uc.enqueue(ctx, n.UserID, key, []domain.WebhookEventType{domain.WebhookReminderFired}, func(id string, typ domain.WebhookEventType) (string, error) {
+		return newReminderWebhookPayload(id, typ, n)
+	})
+}
+
+// enqueueは、userIDのWebhookのうちtypesを購読しているものの配信待ちに、種類ごとにpayloadが返すペイロードの配信を加えます。
+// ペイロードのidはkeyと種類から決めるため、同じkeyで呼び出し直した場合は既存の配信と重複するものを加えません。
+func (uc *WebhookUsecase) enqueue(ctx context.Context, userID uint, key string, types []domain.WebhookEventType, payload func(string, domain.WebhookEventType) (string, error)) error {
+	webhooks, err := uc.Repo.FindByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	// 同じイベントの配信は通知先によらず同じペイロード（同じid）にする
+	payloads := map[domain.WebhookEventType]string{}
+	for _, w := range webhooks {
+		for _, typ := range types {
+			if !w.Subscribes(typ) {
+				continue
+			}
+			id := webhookEventID(key, typ)
+			if _, ok := payloads[typ]; !ok {
+				if payloads[typ], err = payload(id, typ); err != nil {
+					return err
+				}
+			}
+			d := newDelivery(w.ID, w.UserID, typ, payloads[typ])
+			d.EventID = &id
+			if err := uc.Repo.CreateDelivery(ctx, &d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DueDeliveriesは、送信時刻を迎えた配信待ちの配信を古い順に最大limit件返します。
+// excludeのWebhook（送信中のものなど）の配信は含めません。
+func (uc *WebhookUsecase) DueDeliveries(ctx context.Context, limit int, exclude ...uint) (_ []domain.WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "WebhookUsecase.DueDeliveries")
+	defer func() { endSpan(span, err) }()
+
+	return uc.Repo.DueDeliveries(ctx, time.Now(), limit, exclude)
+}
+
+// Deliverは配信dを送信し、結果を記録します。送信待ちの間にWebhookが削除された場合は送信しません。
+// 失敗した配信は再試行の方針に従って次の送信時刻を設定し、上限に達したら失敗として配信を諦めます。
+// 送信の失敗はエラーとして返さず、記録できなかった場合のみエラーを返します。
+// ctxがキャンセルされた場合（サーバの停止時など）、送信の結果は記録せず、次回に送信し直します。
+func (uc *WebhookUsecase) Deliver(ctx context.Context, d domain.WebhookDelivery) (err error) {
+	ctx, span := startSpan(ctx, "WebhookUsecase.Deliver")
+	defer func() { endSpan(span, err) }()
+
+	w, err := uc.Repo.FindByID(ctx, d.UserID, d.WebhookID)
+	if errors.Is(err, domain.ErrWebhookNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	status, sendErr := uc.Sender.Send(ctx, w, d)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	uc.recordAttempt(&d, status, sendErr)
+	if err := uc.Repo.UpdateDelivery(ctx, d); err != nil && !errors.Is(err, domain.ErrWebhookDeliveryNotFound) {
+		return err
+	}
+	return nil
+}
+
+// recordAttemptは送信の結果をdに反映します。
+func (uc *WebhookUsecase) recordAttempt(d *domain.WebhookDelivery, status int, sendErr error) {
+	now := time.Now()
+	d.Attempts++
+	d.LastAttemptAt = &now
+	d.ResponseStatus = status
+	switch {
+	case sendErr == nil:
+		d.Status, d.Error = domain.WebhookDeliverySucceeded, ""
+	case d.Attempts >= uc.Retry.MaxAttempts:
+		d.Status, d.Error = domain.WebhookDeliveryFailed, sendErr.Error()
+	default:
+		d.Error = sendErr.Error()
+		d.NextAttemptAt = now.Add(uc.Retry.Delay(d.Attempts))
+	}
+}
+
+// webhookEventTypesはTodoの変更イベントに対応するWebhookのイベントの種類を返します。
+func webhookEventTypes(event domain.TodoEvent) []domain.WebhookEventType {
+	switch event.Type {
+	case domain.TodoCreated:
+		return []domain.WebhookEventType{domain.WebhookTodoCreated}
+	case domain.TodoDeleted:
+		return []domain.WebhookEventType{domain.WebhookTodoDeleted}
+	default:
+		if event.Completed {
+			return []domain.WebhookEventType{domain.WebhookTodoUpdated, domain.WebhookTodoCompleted}
+		}
+		return []domain.WebhookEventType{domain.WebhookTodoUpdated}
+	}
+}
+
+// webhookPayloadはWebhookで送信するリクエストボディです。
+type webhookPayload struct {
+	// IDはイベントの識別子です。再配信でも変わらないため、通知先は重複の検出に使えます。
+	ID         string                  `json:"id"`
+	Type       domain.WebhookEventType `json:"type"`
+	OccurredAt time.Time               `json:"occurred_at"`
+	Data       webhookPayloadData      `json:"data"`
+}
+
+// webhookPayloadDataは変更されたTodoです（SSEのイベントのdataと同じ形式）。削除の場合はidのみです。
+// reminder.firedの場合はTodoのidと通知したリマインダーです。
+type webhookPayloadData struct {
+	ID       uint                    `json:"id"`
+	Todo     *webhookPayloadTodo     `json:"todo,omitempty"`
+	Reminder *webhookPayloadReminder `json:"reminder,omitempty"`
+}
+
+// webhookPayloadTodoは通知するTodoです（APIのTodoのレスポンスと同じ形式）。
+// domain.Todoの内部表現の変更が通知先に届く形式に影響しないよう、この型に変換して送信します。
+type webhookPayloadTodo struct {
+	ID        uint       `json:"id"`
+	UserID    uint       `json:"user_id"`
+	Title     string     `json:"title"`
+	Completed bool       `json:"completed"`
+	DueAt     *time.Time `json:"due_at,omitempty"`
+	Version   uint       `json:"version"`
+}
+
+// webhookPayloadReminderはreminder.firedで通知したリマインダーと、通知の時点のTodoのタイトル・期限です。
+type webhookPayloadReminder struct {
+	ID    uint       `json:"id"`
+	Title string     `json:"title"`
+	DueAt *time.Time `json:"due_at,omitempty"`
+}
+
+// newWebhookPayloadはイベントのペイロード（JSON）を返します。
+func newWebhookPayload(id string, typ domain.WebhookEventType, event domain.TodoEvent) (string, error) {
+	p := webhookPayload{
+		ID:         id,
+		Type:       typ,
+		OccurredAt: event.OccurredAt.UTC(),
+		Data:       webhookPayloadData{ID: event.Todo.ID},
+	}
+	if event.Type != domain.TodoDeleted {
+		t := event.Todo
+		p.Data.Todo = &webhookPayloadTodo{
+			ID:        t.ID,
+			UserID:    t.UserID,
+			Title:     t.Title,
+			Completed: t.Completed,
+			DueAt:     t.DueAt,
+			Version:   t.Version,
+		}
+	}
+	b, err := json.Marshal(p)
+	return string(b), err
+}
+
+// newReminderWebhookPayloadはリマインダーの通知nのペイロード（JSON）を返します。
+func newReminderWebhookPayload(id string, typ domain.WebhookEventType, n domain.Notification) (string, error) {
+	p := webhookPayload{
+		ID:         id,
+		Type:       typ,
+		OccurredAt: n.CreatedAt.UTC(),
+		Data: webhookPayloadData{
+			ID:       n.TodoID,
+			Reminder: &webhookPayloadReminder{ID: n.ReminderID, Title: n.Title, DueAt: n.DueAt},
+		},
+	}
+	b, err := json.Marshal(p)
+	return string(b), err
+}
+
+// webhookEventIDは、keyのイベントを種類typとして配信するときのイベントの識別子を返します。
+// 同じkeyと種類からは常に同じ識別子を返します。
+func webhookEventID(key string, typ domain.WebhookEventType) string {
+	sum := sha256.Sum256([]byte(key + "\x00" + string(typ)))
+	return "evt_" + hex.EncodeToString(sum[:12])
+}
+
+// newDeliveryはすぐに送信する配信待ちの配信を返します。
+func newDelivery(webhookID, userID uint, typ domain.WebhookEventType, payload string) domain.WebhookDelivery {
+	return domain.WebhookDelivery{
+		WebhookID:     webhookID,
+		UserID:        userID,
+		Event:         typ,
+		Payload:       payload,
+		Status:        domain.WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+}
+
+// newWebhookSecretは署名の鍵を生成します。
+func newWebhookSecret() string {
+	return "whsec_" + randomHex(32)
+}
+
+// randomHexはnバイトの乱数を16進数の文字列で返します。
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b) // crypto/randのReadは失敗しない
+	return hex.EncodeToString(b)
+}
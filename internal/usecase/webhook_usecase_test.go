@@ -0,0 +1,317 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"todo_backend/internal/domain"
+	"todo_backend/internal/infrastructure/memory"
+	"todo_backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockWebhookSender struct{ mock.Mock }
+
+func (m *MockWebhookSender) Send(ctx context.Context, w domain.Webhook, d domain.WebhookDelivery) (int, error) {
+	args := m.Called(ctx, w, d)
+	return args.Int(0), args.Error(1)
+}
+
+var _ usecase.WebhookSender = (*MockWebhookSender)(nil)
+
+// createWebhookはuserIDのWebhookをeventsの購読で登録します。
+func createWebhook(t *testing.T, uc *usecase.WebhookUsecase, userID uint, events ...domain.WebhookEventType) domain.Webhook {
+	t.Helper()
+	w, err := uc.CreateWebhook(context.Background(), userID, "https://example.com/hook", events, "")
+	require.NoError(t, err)
+	return w
+}
+
+// deliverDueは送信時刻を迎えた配信を順に送信し、送信した配信の数を返します（Dispatcherの1回分の送信）。
+func deliverDue(uc *usecase.WebhookUsecase) (int, error) {
+	due, err := uc.DueDeliveries(context.Background(), 10)
+	if err != nil {
+		return 0, err
+	}
+	for _, d := range due {
+		if err := uc.Deliver(context.Background(), d); err != nil {
+			return 0, err
+		}
+	}
+	return len(due), nil
+}
+
+func TestCreateWebhook_GeneratesSecretAndNormalizesEvents(t *testing.T) {
+	// given
+	uc := usecase.NewWebhookUsecase(memory.NewWebhookRepo(), nil)
+
+	// when
+	w, err := uc.CreateWebhook(context.Background(), 1, "https://example.com/hook",
+		[]domain.WebhookEventType{domain.WebhookTodoDeleted, domain.WebhookTodoCreated, domain.WebhookTodoDeleted}, "")
+
+	// then
+	require.NoError(t, err)
+	assert.Regexp(t, `^whsec_[0-9a-f]{64}$`, w.Secret)
+	assert.Equal(t, []domain.WebhookEventType{domain.WebhookTodoCreated, domain.WebhookTodoDeleted}, w.Events)
+}
+
+func TestEnqueue_CompletedUpdateIsDeliveredAsUpdatedAndCompleted(t *testing.T) {
+	// given
+	repo := memory.NewWebhookRepo()
+	uc := usecase.NewWebhookUsecase(repo, nil)
+	all := createWebhook(t, uc, 1, domain.WebhookEventTypes...)
+	completedOnly := createWebhook(t, uc, 1, domain.WebhookTodoCompleted)
+	createWebhook(t, uc, 1, domain.WebhookTodoCreated)
+	createWebhook(t, uc, 2, domain.WebhookEventTypes...)
+	todo := domain.Todo{ID: 5, UserID: 1, Title: "a", Completed: true, Version: 2}
+	event := domain.TodoEvent{Type: domain.TodoUpdated, UserID: 1, Todo: todo, Completed: true, OccurredAt: time.Now()}
+
+	// when
+	err := uc.Enqueue(context.Background(), "job:1", event)
+
+	// then: 購読している種類のみ、他のユーザーのWebhookには配信しない
+	require.NoError(t, err)
+	toAll, err := repo.FindDeliveries(context.Background(), 1, all.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, toAll, 2)
+	assert.ElementsMatch(t, []domain.WebhookEventType{domain.WebhookTodoUpdated, domain.WebhookTodoCompleted},
+		[]domain.WebhookEventType{toAll[0].Event, toAll[1].Event})
+	toCompleted, err := repo.FindDeliveries(context.Background(), 1, completedOnly.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, toCompleted, 1)
+	due, err := repo.DueDeliveries(context.Background(), time.Now(), 10, nil)
+	require.NoError(t, err)
+	assert.Len(t, due, 3)
+
+	// 同じイベントは通知先によらず同じペイロードになる
+	var payload struct {
+		ID   string                  `json:"id"`
+		Type domain.WebhookEventType `json:"type"`
+		Data struct {
+			ID   uint           `json:"id"`
+			Todo map[string]any `json:"todo"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(toCompleted[0].Payload), &payload))
+	assert.Equal(t, domain.WebhookTodoCompleted, payload.Type)
+	assert.Equal(t, uint(5), payload.Data.ID)
+	// TodoはAPIのレスポンスと同じ形式で、domain.Todoの内部のフィールドは含まない
+	assert.Equal(t, map[string]any{"id": float64(5), "user_id": float64(1), "title": "a", "completed": true, "version": float64(2)},
+		payload.Data.Todo)
+	for _, d := range toAll {
+		if d.Event == domain.WebhookTodoCompleted {
+			assert.Equal(t, toCompleted[0].Payload, d.Payload)
+		}
+	}
+}
+
+func TestEnqueue_DeletedEventOmitsTodo(t *testing.T) {
+	// given
+	repo := memory.NewWebhookRepo()
+	uc := usecase.NewWebhookUsecase(repo, nil)
+	w := createWebhook(t, uc, 1, domain.WebhookTodoDeleted)
+	event := domain.TodoEvent{Type: domain.TodoDeleted, UserID: 1, Todo: domain.Todo{ID: 3, UserID: 1}, OccurredAt: time.Now()}
+
+	// when
+	err := uc.Enqueue(context.Background(), "job:1", event)
+
+	// then
+	require.NoError(t, err)
+	deliveries, err := repo.FindDeliveries(context.Background(), 1, w.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(deliveries[0].Payload), &payload))
+	assert.Equal(t, map[string]any{"id": float64(3)}, payload["data"])
+}
+
+// ジョブの再試行などで同じイベントを配信待ちに加え直しても、配信は重複しない
+func TestEnqueue_SameKeyIsQueuedOnce(t *testing.T) {
+	// given
+	repo := memory.NewWebhookRepo()
+	uc := usecase.NewWebhookUsecase(repo, nil)
+	w := createWebhook(t, uc, 1, domain.WebhookEventTypes...)
+	event := domain.TodoEvent{Type: domain.TodoUpdated, UserID: 1, Todo: domain.Todo{ID: 5, UserID: 1, Completed: true}, Completed: true, OccurredAt: time.Now()}
+	require.NoError(t, uc.Enqueue(context.Background(), "job:1", event))
+
+	// when
+	retried := uc.Enqueue(context.Background(), "job:1", event)
+	other := uc.Enqueue(context.Background(), "job:2", event)
+
+	// then: 別のイベント（job:2）は別のidで配信する
+	require.NoError(t, retried)
+	require.NoError(t, other)
+	deliveries, err := repo.FindDeliveries(context.Background(), 1, w.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 4)
+	ids := map[string]bool{}
+	for _, d := range deliveries {
+		var payload struct {
+			ID string `json:"id"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(d.Payload), &payload))
+		require.NotNil(t, d.EventID)
+		assert.Equal(t, payload.ID, *d.EventID)
+		ids[payload.ID] = true
+	}
+	assert.Len(t, ids, 4)
+}
+
+// 同じリマインダーの通知を配信待ちに加え直しても、配信は重複しない
+func TestEnqueueNotification_SameNotificationIsQueuedOnce(t *testing.T) {
+	// given
+	repo := memory.NewWebhookRepo()
+	uc := usecase.NewWebhookUsecase(repo, nil)
+	w := createWebhook(t, uc, 1, domain.WebhookReminderFired)
+	n := domain.Notification{UserID: 1, Type: domain.NotificationReminder, TodoID: 5, ReminderID: 3, Title: "a", CreatedAt: time.Now()}
+
+	// when
+	for range 2 {
+		require.NoError(t, uc.EnqueueNotification(context.Background(), n))
+	}
+	later := n
+	later.CreatedAt = n.CreatedAt.Add(time.Hour)
+	require.NoError(t, uc.EnqueueNotification(context.Background(), later))
+
+	// then: 改めて通知した場合は別の配信にする
+	deliveries, err := repo.FindDeliveries(context.Background(), 1, w.ID, 10)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 2)
+}
+
+func TestDeliver_RecordsSuccess(t *testing.T) {
+	// given
+	repo := memory.NewWebhookRepo()
+	sender := new(MockWebhookSender)
+	uc := usecase.NewWebhookUsecase(repo, sender)
+	w := createWebhook(t, uc, 1, domain.WebhookTodoCreated)
+	require.NoError(t, uc.Enqueue(context.Background(), "job:1", domain.TodoEvent{Type: domain.TodoCreated, UserID: 1, Todo: domain.Todo{ID: 1}}))
+	sender.On("Send", mock.Anything, mock.MatchedBy(func(got domain.Webhook) bool { return got.ID == w.ID }), mock.Anything).
+		Return(204, nil).Once()
+
+	// when
+	sent, err := deliverDue(uc)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	deliveries, err := repo.FindDeliveries(context.Background(), 1, w.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, domain.WebhookDeliverySucceeded, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, 204, deliveries[0].ResponseStatus)
+	assert.NotNil(t, deliveries[0].LastAttemptAt)
+	sender.AssertExpectations(t)
+}
+
+func TestDeliver_RetriesWithBackoffUntilMaxAttempts(t *testing.T) {
+	// given: 1回目の失敗の後は待たずに再試行し、2回で諦める
+	repo := memory.NewWebhookRepo()
+	sender := new(MockWebhookSender)
+	uc := usecase.NewWebhookUsecase(repo, sender,
+		usecase.WithWebhookRetry(usecase.RetryPolicy{MaxAttempts: 2, Backoff: 0, MaxBackoff: time.Hour}))
+	w := createWebhook(t, uc, 1, domain.WebhookTodoCreated)
+	require.NoError(t, uc.Enqueue(context.Background(), "job:1", domain.TodoEvent{Type: domain.TodoCreated, UserID: 1, Todo: domain.Todo{ID: 1}}))
+	sender.On("Send", mock.Anything, mock.Anything, mock.Anything).Return(500, errors.New("unexpected status 500")).Twice()
+
+	// when
+	_, err1 := deliverDue(uc)
+	first, _ := repo.FindDeliveries(context.Background(), 1, w.ID, 10)
+	_, err2 := deliverDue(uc)
+	second, _ := repo.FindDeliveries(context.Background(), 1, w.ID, 10)
+	sent, err3 := deliverDue(uc)
+
+	// then
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	require.NoError(t, err3)
+	require.Len(t, first, 1)
+	assert.Equal(t, domain.WebhookDeliveryPending, first[0].Status)
+	assert.Equal(t, 1, first[0].Attempts)
+	assert.Equal(t, 500, first[0].ResponseStatus)
+	assert.Equal(t, "unexpected status 500", first[0].Error)
+	require.Len(t, second, 1)
+	assert.Equal(t, domain.WebhookDeliveryFailed, second[0].Status)
+	assert.Equal(t, 2, second[0].Attempts)
+	assert.Zero(t, sent)
+	sender.AssertExpectations(t)
+}
+
+func TestDeliver_SchedulesRetryAfterBackoff(t *testing.T) {
+	// given
+	repo := memory.NewWebhookRepo()
+	sender := new(MockWebhookSender)
+	uc := usecase.NewWebhookUsecase(repo, sender)
+	w := createWebhook(t, uc, 1, domain.WebhookTodoCreated)
+	require.NoError(t, uc.Enqueue(context.Background(), "job:1", domain.TodoEvent{Type: domain.TodoCreated, UserID: 1, Todo: domain.Todo{ID: 1}}))
+	sender.On("Send", mock.Anything, mock.Anything, mock.Anything).Return(0, errors.New("connection refused")).Once()
+	before := time.Now()
+
+	// when
+	_, err1 := deliverDue(uc)
+	sent, err2 := deliverDue(uc)
+
+	// then: 次の送信時刻までは送信しない
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	assert.Zero(t, sent)
+	deliveries, err := repo.FindDeliveries(context.Background(), 1, w.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, domain.WebhookDeliveryPending, deliveries[0].Status)
+	assert.WithinDuration(t, before.Add(usecase.DefaultWebhookRetryPolicy.Backoff), deliveries[0].NextAttemptAt, time.Second)
+	sender.AssertExpectations(t)
+}
+
+func TestRedeliver_QueuesCopyOfDelivery(t *testing.T) {
+	// given
+	repo := memory.NewWebhookRepo()
+	sender := new(MockWebhookSender)
+	uc := usecase.NewWebhookUsecase(repo, sender,
+		usecase.WithWebhookRetry(usecase.RetryPolicy{MaxAttempts: 1}))
+	w := createWebhook(t, uc, 1, domain.WebhookTodoCreated)
+	require.NoError(t, uc.Enqueue(context.Background(), "job:1", domain.TodoEvent{Type: domain.TodoCreated, UserID: 1, Todo: domain.Todo{ID: 1}}))
+	sender.On("Send", mock.Anything, mock.Anything, mock.Anything).Return(410, errors.New("unexpected status 410")).Once()
+	_, err := deliverDue(uc)
+	require.NoError(t, err)
+	failed, err := repo.FindDeliveries(context.Background(), 1, w.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+
+	// when
+	d, err := uc.Redeliver(context.Background(), 1, w.ID, failed[0].ID)
+
+	// then
+	require.NoError(t, err)
+	assert.NotEqual(t, failed[0].ID, d.ID)
+	assert.Equal(t, domain.WebhookDeliveryPending, d.Status)
+	assert.Zero(t, d.Attempts)
+	assert.Equal(t, failed[0].Payload, d.Payload)
+	require.NotNil(t, d.RedeliveryOf)
+	assert.Equal(t, failed[0].ID, *d.RedeliveryOf)
+}
+
+func TestRedeliver_IsScopedToOwner(t *testing.T) {
+	// given
+	repo := memory.NewWebhookRepo()
+	uc := usecase.NewWebhookUsecase(repo, nil)
+	w := createWebhook(t, uc, 1, domain.WebhookTodoCreated)
+	require.NoError(t, uc.Enqueue(context.Background(), "job:1", domain.TodoEvent{Type: domain.TodoCreated, UserID: 1, Todo: domain.Todo{ID: 1}}))
+	deliveries, err := repo.FindDeliveries(context.Background(), 1, w.ID, 10)
+	require.NoError(t, err)
+
+	// when
+	_, errOther := uc.Redeliver(context.Background(), 2, w.ID, deliveries[0].ID)
+	_, errMissing := uc.Redeliver(context.Background(), 1, w.ID, deliveries[0].ID+100)
+
+	// then
+	assert.ErrorIs(t, errOther, domain.ErrWebhookNotFound)
+	assert.ErrorIs(t, errMissing, domain.ErrWebhookDeliveryNotFound)
+}